@@ -0,0 +1,187 @@
+package goorm
+
+import (
+	"context"
+	"time"
+)
+
+// auditHookPriority is the priority of the built-in audit hook.
+// auditHookPriority 是内置审计钩子的优先级。
+const auditHookPriority = 1000
+
+// actorKey is the context key for the acting user.
+// actorKey 是执行操作用户的上下文键。
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the acting user for audit logs.
+// WithActor 返回携带执行操作用户（用于审计日志）的 ctx 副本。
+//
+// Example / 示例:
+//
+//	ctx := goorm.WithActor(r.Context(), userID)
+//	db.ExecuteContext(ctx, jql)
+func WithActor(ctx context.Context, actor any) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the acting user stored by WithActor, or nil.
+// ActorFromContext 返回 WithActor 存储的执行操作用户，没有则返回 nil。
+func ActorFromContext(ctx context.Context) any {
+	if ctx == nil {
+		return nil
+	}
+	return ctx.Value(actorKey{})
+}
+
+// AuditEntry describes a single audited operation.
+// AuditEntry 描述单个被审计的操作。
+type AuditEntry struct {
+	// Time is when the operation was performed.
+	// Time 是执行操作的时间。
+	Time time.Time `json:"time"`
+
+	// Actor is the acting user, set via WithActor.
+	// Actor 是执行操作的用户，通过 WithActor 设置。
+	Actor any `json:"actor,omitempty"`
+
+	// Table is the table name.
+	// Table 是表名。
+	Table string `json:"table"`
+
+	// Action is the operation being performed.
+	// Action 是正在执行的操作。
+	Action Action `json:"action"`
+
+	// Data is the data being written.
+	// Data 是正在写入的数据。
+	Data map[string]any `json:"data,omitempty"`
+
+	// IDs are the primary keys of the rows written by an update or delete, when the
+	// write reported them.
+	// IDs 是更新或删除所写入行的主键（写入报告了主键时）。
+	IDs []any `json:"ids,omitempty"`
+}
+
+// AuditSink receives audit entries.
+// Implement it to store audits in a table or send them to an external system.
+//
+// AuditSink 接收审计条目。
+// 实现它可以将审计存入表中或发送到外部系统。
+type AuditSink interface {
+	WriteAudit(ctx context.Context, entry *AuditEntry) error
+}
+
+// AuditSinkFunc adapts a function to the AuditSink interface.
+// AuditSinkFunc 将函数适配为 AuditSink 接口。
+type AuditSinkFunc func(ctx context.Context, entry *AuditEntry) error
+
+// WriteAudit calls f(ctx, entry).
+// WriteAudit 调用 f(ctx, entry)。
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, entry *AuditEntry) error {
+	return f(ctx, entry)
+}
+
+// LoggerAuditSink writes audit entries to a Logger.
+// LoggerAuditSink 将审计条目写入 Logger。
+type LoggerAuditSink struct {
+	Logger Logger
+}
+
+// WriteAudit logs the entry at info level.
+// WriteAudit 以 info 级别记录条目。
+func (s *LoggerAuditSink) WriteAudit(ctx context.Context, entry *AuditEntry) error {
+	if s.Logger == nil {
+		return nil
+	}
+
+	s.Logger.Info("DB operation",
+		"table", entry.Table,
+		"action", entry.Action,
+		"actor", entry.Actor,
+		"ids", entry.IDs,
+		"data", entry.Data,
+	)
+	return nil
+}
+
+// AuditHookWithSink returns a hook that writes an AuditEntry to sink. Register it on
+// after_update/after_delete so updates and deletes are audited once they succeed;
+// their entries carry the keys of the rows written when the write reports them,
+// with ReturnIDs or Config.Security.AuditIDs.
+//
+// AuditHookWithSink 返回将 AuditEntry 写入 sink 的钩子。应将其注册在 after_update/after_delete 上，
+// 使更新和删除在成功后被审计；写入报告了所写入行的主键时（通过 ReturnIDs 或 Config.Security.AuditIDs），
+// 条目会包含这些主键。
+func AuditHookWithSink(sink AuditSink) HookFunc {
+	return func(ctx *HookContext) error {
+		if sink == nil {
+			return nil
+		}
+
+		entry := &AuditEntry{
+			Time:   time.Now(),
+			Actor:  ctx.Actor,
+			Table:  ctx.Table,
+			Action: ctx.Action,
+			Data:   ctx.Data,
+		}
+
+		if ctx.Result != nil && (ctx.Action == ActionUpdate || ctx.Action == ActionDelete) {
+			entry.IDs = ctx.Result.AffectedKeys
+		}
+
+		return sink.WriteAudit(ctx.Context, entry)
+	}
+}
+
+// auditIDsKey marks the context of a write that runs with ReturnIDs only for its
+// audit entry.
+// auditIDsKey 标记仅为审计条目而以 ReturnIDs 执行的写入的上下文。
+type auditIDsKey struct{}
+
+// auditIDs reports whether query runs with ReturnIDs only so that its audit entry
+// gets the keys of the rows written, which are then dropped from its result. Writes
+// to registered tables without a single primary key run as they are, and their
+// entries have no IDs.
+//
+// auditIDs 报告 query 是否仅为让审计条目获得所写入行的主键而以 ReturnIDs 执行，这些主键随后会从结果中移除。
+// 对没有单一主键的已注册表的写入按原样执行，其审计条目没有 ID。
+func (db *DB) auditIDs(query *Query) bool {
+	if !db.config.Security.AuditEnabled || !db.config.Security.AuditIDs || query.ReturnIDs ||
+		(query.Action != ActionUpdate && query.Action != ActionDelete) {
+		return false
+	}
+	if db.registry != nil {
+		if meta, ok := db.registry.Get(query.Table); ok && len(meta.PrimaryKeys) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// withAuditIDs marks ctx as the context of a write that runs with ReturnIDs only for
+// its audit entry, so a write with too many rows to report runs without them.
+// withAuditIDs 将 ctx 标记为仅为审计条目而以 ReturnIDs 执行的写入的上下文，因此行数过多而无法报告的写入会不带主键执行。
+func withAuditIDs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, auditIDsKey{}, true)
+}
+
+// auditOnlyIDs reports whether ctx was marked by withAuditIDs.
+// auditOnlyIDs 判断 ctx 是否由 withAuditIDs 标记。
+func auditOnlyIDs(ctx context.Context) bool {
+	marked, _ := ctx.Value(auditIDsKey{}).(bool)
+	return marked
+}
+
+// dbAuditHook writes audits to the executing DB's audit sink,
+// falling back to its logger.
+// It is registered automatically when Config.Security.AuditEnabled is set.
+//
+// dbAuditHook 将审计写入执行中 DB 的审计 sink，未设置时使用其日志记录器。
+// 设置 Config.Security.AuditEnabled 时自动注册。
+func dbAuditHook(ctx *HookContext) error {
+	if ctx.DB == nil {
+		return nil
+	}
+	return AuditHookWithSink(ctx.DB.AuditSink())(ctx)
+}
@@ -0,0 +1,195 @@
+package goorm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// unknownColumnPatterns extract the column name from the unknown column errors of
+// PostgreSQL, MySQL and SQLite.
+//
+// unknownColumnPatterns 从 PostgreSQL、MySQL 和 SQLite 的未知列错误中提取列名。
+var unknownColumnPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`column "?([\w.]+)"? (?:of relation "\w+" )?does not exist`),
+	regexp.MustCompile(`Unknown column '([\w.]+)'`),
+	regexp.MustCompile(`no such column: ([\w.]+)`),
+	regexp.MustCompile(`has no column named (\w+)`),
+}
+
+// unknownColumn returns the column an unknown column error names, or "".
+// unknownColumn 返回未知列错误中指出的列，没有时返回 ""。
+func unknownColumn(errStr string) string {
+	for _, pattern := range unknownColumnPatterns {
+		if m := pattern.FindStringSubmatch(errStr); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// closestColumn returns the column nearest to name, ignoring case: one within an
+// edit per three characters of it, or one that contains it or is contained by it
+// when the shorter of the two has at least minContained characters. It returns ""
+// if none is close.
+//
+// closestColumn 返回与 name 最接近的列（忽略大小写）：每三个字符最多相差一次编辑的列；或在两者中较短者
+// 至少有 minContained 个字符时，包含 name 或被 name 包含的列。没有接近的列时返回 ""。
+func closestColumn(name string, columns []string) string {
+	name = strings.ToLower(name)
+	best, bestDist := "", -1
+	for _, col := range columns {
+		lower := strings.ToLower(col)
+		d := editDistance(name, lower)
+		near := d <= max(1, len(name)/3) ||
+			min(len(name), len(lower)) >= minContained &&
+				(strings.Contains(lower, name) || strings.Contains(name, lower))
+		if near && (bestDist < 0 || d < bestDist) {
+			best, bestDist = col, d
+		}
+	}
+	return best
+}
+
+// minContained is the shortest name closestColumn matches by containment, so short
+// names such as "id" do not match every column that ends in them.
+// minContained 是 closestColumn 按包含关系匹配的最短名称长度，避免 "id" 等短名称匹配所有以其结尾的列。
+const minContained = 4
+
+// suggestColumn fills the valid fields of the table of query into an INVALID_COLUMN
+// error, and, when a column is close to the unknown one, a suggestion naming it and,
+// unless query is an update or delete, an AutoFix query using it.
+//
+// suggestColumn 为 INVALID_COLUMN 错误填入 query 所在表的有效字段；当有列与未知列接近时，
+// 还会填入指出该列的建议，并在 query 不是更新或删除时填入使用该列的 AutoFix 查询。
+func (db *DB) suggestColumn(resultErr *ResultError, query *Query, errStr string) {
+	if query == nil || db.registry == nil {
+		return
+	}
+	meta, ok := db.registry.Get(query.Table)
+	if !ok {
+		return
+	}
+	columns := make([]string, len(meta.Fields))
+	for i, field := range meta.Fields {
+		columns[i] = field.ColumnName
+	}
+	resultErr.ValidFields = columns
+
+	unknown := unknownColumn(errStr)
+	if _, column, ok := strings.Cut(unknown, "."); ok {
+		unknown = column
+	}
+	if unknown == "" {
+		return
+	}
+	best := closestColumn(unknown, columns)
+	if best == "" {
+		return
+	}
+	resultErr.Suggestion = fmt.Sprintf("Use %q instead of %q", best, unknown)
+
+	// A guessed column must not pick the rows an update or delete changes
+	// 猜测的列不能决定更新或删除所修改的行
+	if query.Action == ActionUpdate || query.Action == ActionDelete {
+		return
+	}
+	if fixed, renamed := renameField(query, unknown, best); renamed {
+		resultErr.AutoFix = fixed
+	}
+}
+
+// renameField returns a copy of q with every reference to the field from, bare
+// or qualified by a table, renamed to to, and whether any was found.
+//
+// renameField 返回 q 的副本，其中对字段 from 的所有引用（无论是否以表名限定）都重命名为 to，
+// 并返回是否找到了引用。
+func renameField(q *Query, from, to string) (*Query, bool) {
+	found := false
+	rename := func(name string) string {
+		table, column, qualified := strings.Cut(name, ".")
+		switch {
+		case !qualified && name == from:
+			found = true
+			return to
+		case qualified && column == from:
+			found = true
+			return table + "." + to
+		}
+		return name
+	}
+
+	fixed := *q
+	fixed.Where = renameConditions(q.Where, rename)
+
+	if q.Select != nil {
+		fixed.Select = make([]any, len(q.Select))
+		for i, sel := range q.Select {
+			switch v := sel.(type) {
+			case string:
+				fixed.Select[i] = rename(v)
+			case map[string]any:
+				m := make(map[string]any, len(v))
+				for key, value := range v {
+					m[key] = value
+				}
+				if field, ok := m["field"].(string); ok {
+					m["field"] = rename(field)
+				}
+				fixed.Select[i] = m
+			default:
+				fixed.Select[i] = sel
+			}
+		}
+	}
+	if q.Data != nil {
+		fixed.Data = make(map[string]any, len(q.Data))
+		for col, value := range q.Data {
+			fixed.Data[rename(col)] = value
+		}
+	}
+	if q.GroupBy != nil {
+		fixed.GroupBy = make([]string, len(q.GroupBy))
+		for i, col := range q.GroupBy {
+			fixed.GroupBy[i] = rename(col)
+		}
+	}
+	if q.Having != nil {
+		fixed.Having = make([]HavingCondition, len(q.Having))
+		for i, h := range q.Having {
+			h.Field = rename(h.Field)
+			fixed.Having[i] = h
+		}
+	}
+	if q.OrderBy != nil {
+		fixed.OrderBy = make([]Order, len(q.OrderBy))
+		for i, o := range q.OrderBy {
+			o.Field = rename(o.Field)
+			fixed.OrderBy[i] = o
+		}
+	}
+	if q.Returning != nil {
+		fixed.Returning = make([]string, len(q.Returning))
+		for i, col := range q.Returning {
+			fixed.Returning[i] = rename(col)
+		}
+	}
+	return &fixed, found
+}
+
+// renameConditions returns a copy of conds with their fields and refs renamed.
+// renameConditions 返回 conds 的副本，其中的字段和引用已重命名。
+func renameConditions(conds []Condition, rename func(string) string) []Condition {
+	if conds == nil {
+		return nil
+	}
+	out := make([]Condition, len(conds))
+	for i, cond := range conds {
+		cond.Field = rename(cond.Field)
+		cond.Ref = rename(cond.Ref)
+		cond.And = renameConditions(cond.And, rename)
+		cond.OrGroup = renameConditions(cond.OrGroup, rename)
+		out[i] = cond
+	}
+	return out
+}
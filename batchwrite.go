@@ -0,0 +1,115 @@
+package goorm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// executeBatchedWrite runs an update or delete in chunks of query.BatchSize rows.
+// Each chunk selects the next primary keys matching query in key order and writes
+// only the rows with those keys, as its own statement, so every chunk is committed
+// before the next starts. Walking the keys in order ends the loop even when the write
+// leaves rows matching, as soft delete and most updates do. ctx is checked between
+// chunks; the rows of finished chunks stay written when it is done or a chunk fails.
+// With ReturnIDs the keys of every chunk are reported.
+//
+// executeBatchedWrite 以每块 query.BatchSize 行分块执行更新或删除。每块按主键顺序选出下一批匹配 query 的主键，
+// 并以独立语句只写入具有这些主键的行，因此每块都在下一块开始前提交。按顺序遍历主键可确保即使写入后行仍然匹配
+// （如软删除和大多数更新）循环也会结束。各块之间会检查 ctx；ctx 结束或某块失败时，已完成块写入的行保持不变。
+// 设置 ReturnIDs 时报告每块的主键。
+func (e *Executor) executeBatchedWrite(ctx context.Context, query *Query) *Result {
+	startTime := time.Now()
+	pk, err := e.db.primaryKeyColumn(query.Table)
+	if err != nil {
+		return validationResult(err)
+	}
+	total := &Result{Success: true}
+	batches := 0
+
+	var last any
+	var written []any
+	for {
+		if err := ctx.Err(); err != nil {
+			return batchInterrupted(total, batches, err)
+		}
+
+		keys, r := e.nextBatchKeys(ctx, query, pk, last)
+		if r != nil {
+			return batchFailed(r, total, batches)
+		}
+		if len(keys) == 0 {
+			break
+		}
+
+		chunk := *query
+		chunk.BatchSize = 0
+		chunk.ReturnIDs = false
+		chunk.Where = append(slices.Clip(query.Where), Condition{Field: pk, Op: OpIn, Value: keys})
+		r = e.executeWriteQuery(ctx, &chunk)
+		if !r.Success {
+			return batchFailed(r, total, batches)
+		}
+		batches++
+		total.Affected += r.Affected
+		total.Data = append(total.Data, r.Data...)
+		total.Count += r.Count
+		if query.ReturnIDs {
+			written = append(written, keys...)
+		}
+
+		if len(keys) < query.BatchSize {
+			break
+		}
+		last = keys[len(keys)-1]
+	}
+
+	setAffectedKeys(total, written)
+	total.Meta = &ResultMeta{Batches: batches}
+	if e.db.debug(ctx, query) {
+		total.Meta.DurationMs = float64(time.Since(startTime).Microseconds()) / 1000
+	}
+	return total
+}
+
+// nextBatchKeys returns the primary keys keySelect selects for query and last.
+// nextBatchKeys 返回 keySelect 为 query 和 last 选出的主键。
+func (e *Executor) nextBatchKeys(ctx context.Context, query *Query, pk string, last any) ([]any, *Result) {
+	found := e.findKeys(ctx, keySelect(query, pk, last))
+	if !found.Success {
+		return nil, found
+	}
+	return rowKeys(found.Data, pk), nil
+}
+
+// batchFailed returns the error result of a failed chunk, reporting the rows the
+// chunks before it wrote.
+//
+// batchFailed 返回失败块的错误结果，并报告其之前各块写入的行。
+func batchFailed(r, total *Result, batches int) *Result {
+	r.Affected = total.Affected
+	if r.Error != nil {
+		if r.Error.Details == nil {
+			r.Error.Details = make(map[string]any)
+		}
+		r.Error.Details["affected"] = total.Affected
+		r.Error.Details["batches"] = batches
+	}
+	return r
+}
+
+// batchInterrupted returns the result of a batched write stopped by ctx.
+// batchInterrupted 返回被 ctx 中止的分批写入的结果。
+func batchInterrupted(total *Result, batches int, err error) *Result {
+	return &Result{
+		Success:  false,
+		Affected: total.Affected,
+		Error: &ResultError{
+			Code:       "BATCH_INTERRUPTED",
+			Message:    fmt.Sprintf("batched write stopped after %d batches: %v", batches, err),
+			Suggestion: "Rows in finished batches stay written; run the query again to continue",
+			Details:    map[string]any{"affected": total.Affected, "batches": batches},
+		},
+	}
+}
@@ -31,6 +31,33 @@ func BenchmarkCreate(b *testing.B) {
 	}
 }
 
+// BenchmarkCreatePrepared benchmarks identical-shape inserts with the statement cache on.
+// Compare with BenchmarkCreate to measure the saving of prepared-statement reuse.
+//
+// BenchmarkCreatePrepared 测试启用语句缓存时相同结构插入的性能。
+// 与 BenchmarkCreate 对比可衡量预处理语句复用带来的节省。
+func BenchmarkCreatePrepared(b *testing.B) {
+	config := goorm.DefaultConfig()
+	config.PrepareStatements = true
+	db := setupBenchDBWithConfig(b, config)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db.ExecuteQuery(ctx, &goorm.Query{
+			Table:  "bench_users",
+			Action: goorm.ActionCreate,
+			Data: map[string]any{
+				"name":  "User",
+				"email": "user@example.com",
+				"age":   25,
+			},
+		})
+	}
+}
+
 // BenchmarkFind benchmarks record querying.
 // BenchmarkFind 测试记录查询的性能。
 func BenchmarkFind(b *testing.B) {
@@ -147,7 +174,11 @@ func BenchmarkTransaction(b *testing.B) {
 }
 
 func setupBenchDB(b *testing.B) *goorm.DB {
-	db, err := goorm.Connect("sqlite://:memory:")
+	return setupBenchDBWithConfig(b, goorm.DefaultConfig())
+}
+
+func setupBenchDBWithConfig(b *testing.B, config goorm.Config) *goorm.DB {
+	db, err := goorm.ConnectWithConfig("sqlite://:memory:", config)
 	if err != nil {
 		b.Skip("Database not available")
 	}
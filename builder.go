@@ -2,7 +2,9 @@ package goorm
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 )
 
 // SQLBuilder builds SQL statements from JQL queries.
@@ -11,17 +13,33 @@ import (
 // SQLBuilder 从 JQL 查询构建 SQL 语句。
 // 它处理方言特定的差异和参数绑定。
 type SQLBuilder struct {
-	dialect Dialect
-	query   *Query
-	params  []any
-	paramN  int
+	dialect    Dialect
+	query      *Query
+	params     []any
+	paramN     int
+	primaryKey string
+	funcs      *sqlFuncRegistry
+	schema     string
+	maxIn      int
+	location   *time.Location
+	templates  *templateCache
 }
 
+// DefaultMaxInValues is the number of values per IN list above which the builder
+// splits the list into OR-joined groups.
+//
+// DefaultMaxInValues 是每个 IN 列表的值数量上限，超过时构建器会将列表拆分为以 OR 连接的多个分组。
+const DefaultMaxInValues = 1000
+
 // BuildResult contains the built SQL and parameters.
 // BuildResult 包含构建的 SQL 和参数。
 type BuildResult struct {
 	SQL    string
 	Params []any
+
+	// query is the query the statement was built from, used to suggest fixes on errors.
+	// query 是生成该语句的查询，用于在出错时给出修复建议。
+	query *Query
 }
 
 // NewSQLBuilder creates a new SQL builder.
@@ -32,12 +50,81 @@ func NewSQLBuilder(dialect Dialect, query *Query) *SQLBuilder {
 		query:   query,
 		params:  make([]any, 0),
 		paramN:  0,
+		maxIn:   DefaultMaxInValues,
 	}
 }
 
+// WithPrimaryKey sets the primary key column returned by inserts (default "id").
+// WithPrimaryKey 设置插入语句返回的主键列（默认为 "id"）。
+func (b *SQLBuilder) WithPrimaryKey(column string) *SQLBuilder {
+	b.primaryKey = column
+	return b
+}
+
+// withFuncs sets the registered SQL functions the builder can render.
+// withFuncs 设置构建器可以生成的已注册 SQL 函数。
+func (b *SQLBuilder) withFuncs(funcs *sqlFuncRegistry) *SQLBuilder {
+	b.funcs = funcs
+	return b
+}
+
+// withSchema qualifies table names with schema; "" leaves them unqualified.
+// withSchema 用 schema 限定表名；为 "" 时不限定。
+func (b *SQLBuilder) withSchema(schema string) *SQLBuilder {
+	b.schema = schema
+	return b
+}
+
+// withLocation converts time.Time params to loc before binding; nil leaves them as given.
+// withLocation 在绑定前将 time.Time 参数转换到 loc；为 nil 时保持原样。
+func (b *SQLBuilder) withLocation(loc *time.Location) *SQLBuilder {
+	b.location = loc
+	return b
+}
+
+// withTemplates builds through templates, reusing the SQL of queries of the same shape; nil builds every query.
+// withTemplates 通过 templates 构建，复用相同结构查询的 SQL；为 nil 时每个查询都重新构建。
+func (b *SQLBuilder) withTemplates(templates *templateCache) *SQLBuilder {
+	b.templates = templates
+	return b
+}
+
+// withMaxInValues sets the IN list size above which lists are split; n <= 0 keeps the default.
+// withMaxInValues 设置 IN 列表拆分的阈值；n <= 0 时保留默认值。
+func (b *SQLBuilder) withMaxInValues(n int) *SQLBuilder {
+	if n > 0 {
+		b.maxIn = n
+	}
+	return b
+}
+
+// checkParams returns ErrTooManyParams if the statement binds more parameters than the dialect allows.
+// checkParams 在语句绑定的参数超过方言允许的数量时返回 ErrTooManyParams。
+func (b *SQLBuilder) checkParams() error {
+	if limit := b.dialect.MaxParams(); len(b.params) > limit {
+		return fmt.Errorf("%w: %d parameters, %s allows %d", ErrTooManyParams, len(b.params), b.dialect.Name(), limit)
+	}
+	return nil
+}
+
+// table returns the quoted name of table, qualified with the builder's schema.
+// table 返回带引号、并以构建器的 schema 限定的表名。
+func (b *SQLBuilder) table(name string) string {
+	return b.dialect.QualifiedTable(b.schema, name)
+}
+
 // Build builds the SQL statement based on the query action.
 // Build 根据查询操作构建 SQL 语句。
 func (b *SQLBuilder) Build() (*BuildResult, error) {
+	if b.templates != nil {
+		return b.templates.build(b)
+	}
+	return b.build()
+}
+
+// build builds the SQL statement of the query without the template cache.
+// build 不经过模板缓存构建查询的 SQL 语句。
+func (b *SQLBuilder) build() (*BuildResult, error) {
 	var sql string
 	var err error
 
@@ -56,6 +143,8 @@ func (b *SQLBuilder) Build() (*BuildResult, error) {
 		sql, err = b.buildCount()
 	case ActionAggregate:
 		sql, err = b.buildAggregate()
+	case ActionExists:
+		sql, err = b.buildExists()
 	default:
 		return nil, fmt.Errorf("unsupported action for SQL building: %s", b.query.Action)
 	}
@@ -63,10 +152,14 @@ func (b *SQLBuilder) Build() (*BuildResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := b.checkParams(); err != nil {
+		return nil, err
+	}
 
 	return &BuildResult{
 		SQL:    sql,
 		Params: b.params,
+		query:  b.query,
 	}, nil
 }
 
@@ -79,7 +172,11 @@ func (b *SQLBuilder) buildSelect() (string, error) {
 	// SELECT 子句
 	sb.WriteString("SELECT ")
 	if len(b.query.Select) > 0 {
-		sb.WriteString(b.buildSelectColumns())
+		columns, err := b.buildSelectColumns()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(columns)
 	} else {
 		sb.WriteString("*")
 	}
@@ -87,7 +184,7 @@ func (b *SQLBuilder) buildSelect() (string, error) {
 	// FROM clause
 	// FROM 子句
 	sb.WriteString(" FROM ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	sb.WriteString(b.table(b.query.Table))
 
 	// JOIN clause
 	// JOIN 子句
@@ -113,8 +210,12 @@ func (b *SQLBuilder) buildSelect() (string, error) {
 	// GROUP BY clause
 	// GROUP BY 子句
 	if len(b.query.GroupBy) > 0 {
+		groupBy, err := b.buildGroupBy()
+		if err != nil {
+			return "", err
+		}
 		sb.WriteString(" GROUP BY ")
-		sb.WriteString(b.buildGroupBy())
+		sb.WriteString(groupBy)
 	}
 
 	// HAVING clause
@@ -131,25 +232,80 @@ func (b *SQLBuilder) buildSelect() (string, error) {
 	// ORDER BY clause
 	// ORDER BY 子句
 	if len(b.query.OrderBy) > 0 {
+		orderBy, err := b.buildOrderBy()
+		if err != nil {
+			return "", err
+		}
 		sb.WriteString(" ORDER BY ")
-		sb.WriteString(b.buildOrderBy())
+		sb.WriteString(orderBy)
 	}
 
-	// LIMIT clause
-	// LIMIT 子句
-	if b.query.Limit > 0 {
-		sb.WriteString(fmt.Sprintf(" LIMIT %d", b.query.Limit))
+	// LIMIT / OFFSET clause, in the dialect's pagination syntax
+	// LIMIT / OFFSET 子句，使用方言的分页语法
+	pagination, err := b.dialect.LimitOffsetClause(b.query.Limit, b.query.Offset, len(b.query.OrderBy) > 0)
+	if err != nil {
+		return "", err
+	}
+	if pagination != "" {
+		sb.WriteString(" ")
+		sb.WriteString(pagination)
 	}
 
-	// OFFSET clause
-	// OFFSET 子句
-	if b.query.Offset > 0 {
-		sb.WriteString(fmt.Sprintf(" OFFSET %d", b.query.Offset))
+	// Row locking clause
+	// 行锁子句
+	if b.query.Lock != "" {
+		if clause := b.dialect.LockClause(b.query.Lock); clause != "" {
+			sb.WriteString(" ")
+			sb.WriteString(clause)
+		}
 	}
 
 	return sb.String(), nil
 }
 
+// BuildExists builds a cheap presence check for the query's table and WHERE conditions,
+// in the form the dialect's ExistsQuery gives it.
+//
+// BuildExists 为查询的表和 WHERE 条件构建轻量的存在性检查，其形式由方言的 ExistsQuery 决定。
+func (b *SQLBuilder) BuildExists() (*BuildResult, error) {
+	sql, err := b.buildExists()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkParams(); err != nil {
+		return nil, err
+	}
+
+	return &BuildResult{
+		SQL:    sql,
+		Params: b.params,
+		query:  b.query,
+	}, nil
+}
+
+// buildExists builds the presence check of BuildExists.
+// buildExists 构建 BuildExists 的存在性检查。
+func (b *SQLBuilder) buildExists() (string, error) {
+	if b.query.Table == "" {
+		return "", fmt.Errorf("table is required for exists")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT 1 FROM ")
+	sb.WriteString(b.table(b.query.Table))
+
+	if len(b.query.Where) > 0 {
+		whereSQL, err := b.buildWhere()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(" WHERE ")
+		sb.WriteString(whereSQL)
+	}
+
+	return b.dialect.ExistsQuery(sb.String()), nil
+}
+
 // buildInsert builds an INSERT statement.
 // buildInsert 构建 INSERT 语句。
 func (b *SQLBuilder) buildInsert() (string, error) {
@@ -164,28 +320,67 @@ func (b *SQLBuilder) buildInsert() (string, error) {
 	columns := make([]string, 0, len(b.query.Data))
 	placeholders := make([]string, 0, len(b.query.Data))
 
-	for col, val := range b.query.Data {
+	for _, col := range sortedColumns(b.query.Data) {
 		columns = append(columns, b.dialect.Quote(col))
-		placeholders = append(placeholders, b.addParam(val))
+		placeholders = append(placeholders, b.addParam(b.query.Data[col]))
 	}
 
-	sb.WriteString("INSERT INTO ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	insert, conflict := "INSERT", ""
+	if b.query.OnConflict == OnConflictIgnore {
+		insert, conflict = b.dialect.InsertIgnore()
+	}
+
+	sb.WriteString(insert)
+	sb.WriteString(" INTO ")
+	sb.WriteString(b.table(b.query.Table))
 	sb.WriteString(" (")
 	sb.WriteString(strings.Join(columns, ", "))
 	sb.WriteString(") VALUES (")
 	sb.WriteString(strings.Join(placeholders, ", "))
 	sb.WriteString(")")
+	sb.WriteString(conflict)
 
-	// Add RETURNING for PostgreSQL
-	// 为 PostgreSQL 添加 RETURNING
-	if b.dialect.SupportsReturning() {
-		sb.WriteString(" RETURNING id")
+	// RETURNING the requested columns plus the primary key, or just the primary key
+	// RETURNING 请求的列及主键，或仅返回主键
+	if len(b.query.Returning) > 0 {
+		returning, err := b.buildReturning(withKeyColumn(b.query.Returning, b.primaryKeyColumn()))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(returning)
+	} else if b.dialect.SupportsReturning() {
+		sb.WriteString(" RETURNING ")
+		sb.WriteString(b.dialect.Quote(b.primaryKeyColumn()))
 	}
 
 	return sb.String(), nil
 }
 
+// sortedColumns returns the keys of data in sorted order, so the same columns
+// always produce the same SQL: logs stay comparable and a prepared statement can be shared.
+//
+// sortedColumns 按排序顺序返回 data 的键，使相同的列总是生成相同的 SQL：日志可比较，预处理语句也可共享。
+func sortedColumns(data map[string]any) []string {
+	columns := make([]string, 0, len(data))
+	for col := range data {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// batchColumns returns the sorted union of the keys of every record in batch.
+// batchColumns 返回 batch 中所有记录的键的并集（已排序）。
+func batchColumns(batch []map[string]any) []string {
+	seen := make(map[string]any)
+	for _, record := range batch {
+		for col := range record {
+			seen[col] = nil
+		}
+	}
+	return sortedColumns(seen)
+}
+
 // buildInsertBatch builds a batch INSERT statement.
 // buildInsertBatch 构建批量 INSERT 语句。
 func (b *SQLBuilder) buildInsertBatch() (string, error) {
@@ -195,40 +390,53 @@ func (b *SQLBuilder) buildInsertBatch() (string, error) {
 
 	var sb strings.Builder
 
-	// Get columns from first record
-	// 从第一条记录获取列
-	firstRecord := b.query.DataBatch[0]
-	columns := make([]string, 0, len(firstRecord))
-	columnNames := make([]string, 0, len(firstRecord))
-
-	for col := range firstRecord {
-		columns = append(columns, b.dialect.Quote(col))
-		columnNames = append(columnNames, col)
+	// Insert the union of the columns of all records
+	// 插入所有记录的列的并集
+	columnNames := batchColumns(b.query.DataBatch)
+	columns := make([]string, len(columnNames))
+	for i, col := range columnNames {
+		columns[i] = b.dialect.Quote(col)
 	}
 
 	sb.WriteString("INSERT INTO ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	sb.WriteString(b.table(b.query.Table))
 	sb.WriteString(" (")
 	sb.WriteString(strings.Join(columns, ", "))
 	sb.WriteString(") VALUES ")
 
-	// Build value rows
-	// 构建值行
+	// Build value rows; a column missing from a record takes its default. Dialects
+	// without DEFAULT in VALUES need records with the same columns, see insertChunks
+	// 构建值行；记录中缺失的列使用其默认值。VALUES 不支持 DEFAULT 的方言要求记录的列相同，参见 insertChunks
 	valueRows := make([]string, 0, len(b.query.DataBatch))
-	for _, record := range b.query.DataBatch {
+	for n, record := range b.query.DataBatch {
 		placeholders := make([]string, len(columnNames))
 		for i, col := range columnNames {
-			placeholders[i] = b.addParam(record[col])
+			val, ok := record[col]
+			switch {
+			case ok:
+				placeholders[i] = b.addParam(val)
+			case b.dialect.SupportsDefaultValue():
+				placeholders[i] = "DEFAULT"
+			default:
+				return "", fmt.Errorf("record %d of the batch has no column %q: %s batch inserts need the same columns in every record", n, col, b.dialect.Name())
+			}
 		}
 		valueRows = append(valueRows, "("+strings.Join(placeholders, ", ")+")")
 	}
 
 	sb.WriteString(strings.Join(valueRows, ", "))
 
-	// Add RETURNING for PostgreSQL
-	// 为 PostgreSQL 添加 RETURNING
-	if b.dialect.SupportsReturning() {
-		sb.WriteString(" RETURNING id")
+	// RETURNING the requested columns plus the primary key, or just the primary key
+	// RETURNING 请求的列及主键，或仅返回主键
+	if len(b.query.Returning) > 0 {
+		returning, err := b.buildReturning(withKeyColumn(b.query.Returning, b.primaryKeyColumn()))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(returning)
+	} else if b.dialect.SupportsReturning() {
+		sb.WriteString(" RETURNING ")
+		sb.WriteString(b.dialect.Quote(b.primaryKeyColumn()))
 	}
 
 	return sb.String(), nil
@@ -244,13 +452,14 @@ func (b *SQLBuilder) buildUpdate() (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString("UPDATE ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	sb.WriteString(b.table(b.query.Table))
 	sb.WriteString(" SET ")
 
 	// Build SET clause
 	// 构建 SET 子句
 	setParts := make([]string, 0, len(b.query.Data))
-	for col, val := range b.query.Data {
+	for _, col := range sortedColumns(b.query.Data) {
+		val := b.query.Data[col]
 		// Handle special operators like $incr, $decr
 		// 处理特殊运算符如 $incr、$decr
 		if m, ok := val.(map[string]any); ok {
@@ -287,6 +496,12 @@ func (b *SQLBuilder) buildUpdate() (string, error) {
 		sb.WriteString(whereSQL)
 	}
 
+	returning, err := b.buildReturning(b.query.Returning)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(returning)
+
 	return sb.String(), nil
 }
 
@@ -296,7 +511,7 @@ func (b *SQLBuilder) buildDelete() (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString("DELETE FROM ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	sb.WriteString(b.table(b.query.Table))
 
 	// WHERE clause
 	// WHERE 子句
@@ -309,16 +524,52 @@ func (b *SQLBuilder) buildDelete() (string, error) {
 		sb.WriteString(whereSQL)
 	}
 
+	returning, err := b.buildReturning(b.query.Returning)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(returning)
+
 	return sb.String(), nil
 }
 
+// primaryKeyColumn returns the primary key column for RETURNING.
+// primaryKeyColumn 返回用于 RETURNING 的主键列。
+func (b *SQLBuilder) primaryKeyColumn() string {
+	if b.primaryKey != "" {
+		return b.primaryKey
+	}
+	return "id"
+}
+
+// buildReturning builds a RETURNING clause for columns, or "" if there are none.
+// buildReturning 为 columns 构建 RETURNING 子句，没有列时返回 ""。
+func (b *SQLBuilder) buildReturning(columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "", nil
+	}
+	if !b.dialect.SupportsReturning() {
+		return "", fmt.Errorf("%w: %s", ErrReturningNotSupported, b.dialect.Name())
+	}
+
+	cols := make([]string, len(columns))
+	for i, col := range columns {
+		if col == "*" {
+			cols[i] = col
+		} else {
+			cols[i] = b.dialect.Quote(col)
+		}
+	}
+	return " RETURNING " + strings.Join(cols, ", "), nil
+}
+
 // buildCount builds a COUNT statement.
 // buildCount 构建 COUNT 语句。
 func (b *SQLBuilder) buildCount() (string, error) {
 	var sb strings.Builder
 
 	sb.WriteString("SELECT COUNT(*) FROM ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	sb.WriteString(b.table(b.query.Table))
 
 	// WHERE clause
 	// WHERE 子句
@@ -339,10 +590,14 @@ func (b *SQLBuilder) buildCount() (string, error) {
 func (b *SQLBuilder) buildAggregate() (string, error) {
 	var sb strings.Builder
 
+	columns, err := b.buildSelectColumns()
+	if err != nil {
+		return "", err
+	}
 	sb.WriteString("SELECT ")
-	sb.WriteString(b.buildSelectColumns())
+	sb.WriteString(columns)
 	sb.WriteString(" FROM ")
-	sb.WriteString(b.dialect.Quote(b.query.Table))
+	sb.WriteString(b.table(b.query.Table))
 
 	// JOIN clause
 	// JOIN 子句
@@ -368,8 +623,12 @@ func (b *SQLBuilder) buildAggregate() (string, error) {
 	// GROUP BY clause
 	// GROUP BY 子句
 	if len(b.query.GroupBy) > 0 {
+		groupBy, err := b.buildGroupBy()
+		if err != nil {
+			return "", err
+		}
 		sb.WriteString(" GROUP BY ")
-		sb.WriteString(b.buildGroupBy())
+		sb.WriteString(groupBy)
 	}
 
 	// HAVING clause
@@ -386,8 +645,12 @@ func (b *SQLBuilder) buildAggregate() (string, error) {
 	// ORDER BY clause
 	// ORDER BY 子句
 	if len(b.query.OrderBy) > 0 {
+		orderBy, err := b.buildOrderBy()
+		if err != nil {
+			return "", err
+		}
 		sb.WriteString(" ORDER BY ")
-		sb.WriteString(b.buildOrderBy())
+		sb.WriteString(orderBy)
 	}
 
 	return sb.String(), nil
@@ -395,7 +658,7 @@ func (b *SQLBuilder) buildAggregate() (string, error) {
 
 // buildSelectColumns builds the SELECT column list.
 // buildSelectColumns 构建 SELECT 列列表。
-func (b *SQLBuilder) buildSelectColumns() string {
+func (b *SQLBuilder) buildSelectColumns() (string, error) {
 	parts := make([]string, 0, len(b.query.Select))
 
 	for _, sel := range b.query.Select {
@@ -409,17 +672,16 @@ func (b *SQLBuilder) buildSelectColumns() string {
 				parts = append(parts, b.dialect.Quote(v))
 			}
 		case map[string]any:
-			// Aggregate function
-			// 聚合函数
+			// Aggregate or registered function
+			// 聚合函数或已注册的函数
 			fn, _ := v["fn"].(string)
 			field, _ := v["field"].(string)
 			as, _ := v["as"].(string)
+			args, _ := v["args"].([]any)
 
-			var expr string
-			if field == "" || field == "*" {
-				expr = fmt.Sprintf("%s(*)", strings.ToUpper(fn))
-			} else {
-				expr = fmt.Sprintf("%s(%s)", strings.ToUpper(fn), b.dialect.Quote(field))
+			expr, err := b.buildFuncCall(fn, field, args)
+			if err != nil {
+				return "", err
 			}
 
 			if as != "" {
@@ -430,9 +692,38 @@ func (b *SQLBuilder) buildSelectColumns() string {
 	}
 
 	if len(parts) == 0 {
-		return "*"
+		return "*", nil
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// buildFuncCall renders fn applied to field, or a registered function applied to args.
+// buildFuncCall 生成作用于 field 的 fn，或作用于 args 的已注册函数。
+func (b *SQLBuilder) buildFuncCall(fn, field string, args []any) (string, error) {
+	if registered, ok := b.funcs.get(fn); ok {
+		if len(args) == 0 && field != "" {
+			args = []any{field}
+		}
+		return registered.render(b, fn, args)
+	}
+	if len(args) > 0 || !isAggregateFn(fn) {
+		return "", fmt.Errorf("unknown SQL function %q: register it with RegisterSQLFunc", fn)
+	}
+
+	if field == "" || field == "*" {
+		return fmt.Sprintf("%s(*)", strings.ToUpper(fn)), nil
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(fn), b.dialect.Quote(field)), nil
+}
+
+// quoteColumn quotes a column name, quoting each part of a table-qualified name.
+// quoteColumn 为列名加引号，带表名限定的名称会为每一部分加引号。
+func (b *SQLBuilder) quoteColumn(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = b.dialect.Quote(part)
 	}
-	return strings.Join(parts, ", ")
+	return strings.Join(parts, ".")
 }
 
 // buildWhere builds the WHERE clause from conditions.
@@ -495,10 +786,16 @@ func (b *SQLBuilder) buildCondition(cond Condition) (string, error) {
 		return "(" + strings.Join(orParts, " OR ") + ")", nil
 	}
 
+	field, err := b.conditionField(cond)
+	if err != nil {
+		return "", err
+	}
+
 	// Handle subquery
 	// 处理子查询
 	if cond.Subquery != nil {
-		subBuilder := NewSQLBuilder(b.dialect, cond.Subquery)
+		subBuilder := NewSQLBuilder(b.dialect, cond.Subquery).withFuncs(b.funcs).withSchema(b.schema).withLocation(b.location)
+		subBuilder.maxIn = b.maxIn
 		// Transfer current param count
 		subBuilder.paramN = b.paramN
 		subResult, err := subBuilder.buildSelect()
@@ -509,28 +806,17 @@ func (b *SQLBuilder) buildCondition(cond Condition) (string, error) {
 		b.params = append(b.params, subBuilder.params...)
 		b.paramN = subBuilder.paramN
 
-		return fmt.Sprintf("%s %s (%s)",
-			b.dialect.Quote(cond.Field),
-			b.opToSQL(cond.Op),
-			subResult), nil
+		return b.compare(field, cond.Op, "("+subResult+")"), nil
 	}
 
 	// Handle reference to another column
 	// 处理对另一列的引用
 	if cond.Ref != "" {
-		return fmt.Sprintf("%s %s %s",
-			b.dialect.Quote(cond.Field),
-			b.opToSQL(cond.Op),
-			cond.Ref), nil
+		return b.compare(field, cond.Op, cond.Ref), nil
 	}
 
 	// Handle different operators
 	// 处理不同运算符
-	field := b.dialect.Quote(cond.Field)
-	if strings.Contains(cond.Field, ".") {
-		// Table.Column format, don't quote
-		field = cond.Field
-	}
 
 	switch cond.Op {
 	case OpNull:
@@ -538,38 +824,102 @@ func (b *SQLBuilder) buildCondition(cond Condition) (string, error) {
 	case OpNotNull:
 		return fmt.Sprintf("%s IS NOT NULL", field), nil
 	case OpIn, OpNotIn:
-		if values, ok := cond.Value.([]any); ok {
-			placeholders := make([]string, len(values))
-			for i, v := range values {
-				placeholders[i] = b.addParam(v)
-			}
-			op := "IN"
+		if values, ok := sliceValues(cond.Value); ok {
+			op, join := "IN", " OR "
 			if cond.Op == OpNotIn {
-				op = "NOT IN"
+				op, join = "NOT IN", " AND "
+			}
+
+			// Long lists are split into groups of at most maxIn values
+			// 长列表被拆分为每组最多 maxIn 个值的多个分组
+			var groups []string
+			for start := 0; start < len(values) || start == 0; start += b.maxIn {
+				chunk := values[start:min(start+b.maxIn, len(values))]
+				placeholders := make([]string, len(chunk))
+				for i, v := range chunk {
+					placeholders[i] = b.addParam(v)
+				}
+				groups = append(groups, fmt.Sprintf("%s %s (%s)", field, op, strings.Join(placeholders, ", ")))
 			}
-			return fmt.Sprintf("%s %s (%s)", field, op, strings.Join(placeholders, ", ")), nil
+			if len(groups) == 1 {
+				return groups[0], nil
+			}
+			return "(" + strings.Join(groups, join) + ")", nil
 		}
 		return "", fmt.Errorf("IN operator requires array value")
 	case OpBetween:
-		if values, ok := cond.Value.([]any); ok && len(values) == 2 {
-			return fmt.Sprintf("%s BETWEEN %s AND %s",
-				field,
-				b.addParam(values[0]),
-				b.addParam(values[1])), nil
+		values, ok := sliceValues(cond.Value)
+		if !ok || len(values) != 2 || (values[0] == nil && values[1] == nil) {
+			return "", fmt.Errorf("BETWEEN operator requires array of two values, at most one of them null")
+		}
+		// A null bound leaves that side of the range open
+		// 值为 null 的边界表示该侧不设限
+		switch {
+		case values[0] == nil:
+			return fmt.Sprintf("%s <= %s", field, b.addParam(values[1])), nil
+		case values[1] == nil:
+			return fmt.Sprintf("%s >= %s", field, b.addParam(values[0])), nil
 		}
-		return "", fmt.Errorf("BETWEEN operator requires array of two values")
+		return fmt.Sprintf("%s BETWEEN %s AND %s",
+			field,
+			b.addParam(values[0]),
+			b.addParam(values[1])), nil
 	case OpLike, OpNotLike:
 		op := "LIKE"
 		if cond.Op == OpNotLike {
 			op = "NOT LIKE"
 		}
 		return fmt.Sprintf("%s %s %s", field, op, b.addParam(cond.Value)), nil
+	case OpArrayContains:
+		values, ok := sliceValues(cond.Value)
+		if !ok {
+			return "", fmt.Errorf("array_contains operator requires array value")
+		}
+		if b.dialect.Name() != "postgres" {
+			return "", fmt.Errorf("array_contains operator is not supported by %s", b.dialect.Name())
+		}
+		return fmt.Sprintf("%s @> %s", field, b.addParam(pgArrayLiteral(values))), nil
 	case OpExists:
 		// EXISTS is handled with subquery
 		return "", fmt.Errorf("EXISTS operator requires subquery")
 	default:
-		return fmt.Sprintf("%s %s %s", field, b.opToSQL(cond.Op), b.addParam(cond.Value)), nil
+		return b.compare(field, cond.Op, b.addParam(cond.Value)), nil
+	}
+}
+
+// compare renders field op right, where right is a placeholder, column or subquery.
+// compare 生成 field op right，其中 right 为占位符、列或子查询。
+func (b *SQLBuilder) compare(field string, op Operator, right string) string {
+	switch op {
+	case OpNullSafeEqual:
+		return b.dialect.NullSafeEqual(field, right)
+	case OpIEqual:
+		return b.dialect.CaseInsensitiveEqual(field, right)
 	}
+	return fmt.Sprintf("%s %s %s", field, b.opToSQL(op), right)
+}
+
+// conditionField returns the SQL for a condition's field, applying its date part Fn
+// or its Collate.
+// conditionField 返回条件字段的 SQL，并应用其日期部分 Fn 或排序规则 Collate。
+func (b *SQLBuilder) conditionField(cond Condition) (string, error) {
+	field := b.dialect.Quote(cond.Field)
+	if strings.Contains(cond.Field, ".") {
+		// Table.Column format, don't quote
+		field = cond.Field
+	}
+
+	if cond.Collate != "" {
+		return b.collate(field, cond.Collate)
+	}
+	if cond.Fn == "" {
+		return field, nil
+	}
+	expr := b.dialect.DateFunc(strings.ToLower(cond.Fn), field)
+	if expr == "" {
+		return "", fmt.Errorf("unsupported date fn %q on field %s", cond.Fn, cond.Field)
+	}
+	return expr, nil
 }
 
 // buildJoins builds JOIN clauses.
@@ -583,7 +933,7 @@ func (b *SQLBuilder) buildJoins() (string, error) {
 			joinType = "INNER"
 		}
 
-		sb.WriteString(fmt.Sprintf(" %s JOIN %s ON ", joinType, b.dialect.Quote(join.Table)))
+		sb.WriteString(fmt.Sprintf(" %s JOIN %s ON ", joinType, b.table(join.Table)))
 
 		onParts := make([]string, 0, len(join.On))
 		for left, right := range join.On {
@@ -597,7 +947,7 @@ func (b *SQLBuilder) buildJoins() (string, error) {
 
 // buildGroupBy builds GROUP BY clause.
 // buildGroupBy 构建 GROUP BY 子句。
-func (b *SQLBuilder) buildGroupBy() string {
+func (b *SQLBuilder) buildGroupBy() (string, error) {
 	cols := make([]string, len(b.query.GroupBy))
 	for i, col := range b.query.GroupBy {
 		if strings.Contains(col, ".") {
@@ -606,7 +956,15 @@ func (b *SQLBuilder) buildGroupBy() string {
 			cols[i] = b.dialect.Quote(col)
 		}
 	}
-	return strings.Join(cols, ", ")
+
+	groupBy := strings.Join(cols, ", ")
+	if b.query.Rollup {
+		groupBy = b.dialect.RollupClause(groupBy)
+		if groupBy == "" {
+			return "", ErrRollupNotSupported
+		}
+	}
+	return groupBy, nil
 }
 
 // buildHaving builds HAVING clause.
@@ -615,11 +973,9 @@ func (b *SQLBuilder) buildHaving() (string, error) {
 	parts := make([]string, 0, len(b.query.Having))
 
 	for _, h := range b.query.Having {
-		var expr string
-		if h.Field == "" || h.Field == "*" {
-			expr = fmt.Sprintf("%s(*)", strings.ToUpper(h.Fn))
-		} else {
-			expr = fmt.Sprintf("%s(%s)", strings.ToUpper(h.Fn), b.dialect.Quote(h.Field))
+		expr, err := b.buildFuncCall(h.Fn, h.Field, h.Args)
+		if err != nil {
+			return "", err
 		}
 
 		parts = append(parts, fmt.Sprintf("%s %s %s",
@@ -633,30 +989,91 @@ func (b *SQLBuilder) buildHaving() (string, error) {
 
 // buildOrderBy builds ORDER BY clause.
 // buildOrderBy 构建 ORDER BY 子句。
-func (b *SQLBuilder) buildOrderBy() string {
+func (b *SQLBuilder) buildOrderBy() (string, error) {
 	parts := make([]string, len(b.query.OrderBy))
 	for i, o := range b.query.OrderBy {
 		field := b.dialect.Quote(o.Field)
-		if strings.Contains(o.Field, ".") {
+		if len(o.Priority) > 0 {
+			field = b.priorityCase(o)
+		} else if strings.Contains(o.Field, ".") {
 			field = o.Field
 		}
+		if o.Collate != "" {
+			var err error
+			if field, err = b.collate(field, o.Collate); err != nil {
+				return "", err
+			}
+		}
+		direction := "ASC"
 		if o.Desc {
-			parts[i] = field + " DESC"
+			direction = "DESC"
+		}
+		if o.Nulls != "" {
+			parts[i] = b.dialect.OrderNulls(field, direction, o.Nulls)
 		} else {
-			parts[i] = field + " ASC"
+			parts[i] = field + " " + direction
 		}
 	}
-	return strings.Join(parts, ", ")
+	return strings.Join(parts, ", "), nil
+}
+
+// collate returns expr under collation, or an error if the dialect cannot write the name.
+// collate 返回按 collation 排序规则处理的 expr；方言无法写入该名称时返回错误。
+func (b *SQLBuilder) collate(expr, collation string) (string, error) {
+	if !isCollation(collation) {
+		return "", fmt.Errorf("invalid collation %q: %s", collation, collationRule)
+	}
+	sql := b.dialect.Collate(expr, collation)
+	if sql == "" {
+		return "", fmt.Errorf("collation %q is not supported by %s", collation, b.dialect.Name())
+	}
+	return sql, nil
+}
+
+// priorityCase returns a CASE expression mapping each value of o.Priority to its
+// position, with the values bound as parameters.
+//
+// priorityCase 返回将 o.Priority 的每个值映射为其位置的 CASE 表达式，值以参数形式绑定。
+func (b *SQLBuilder) priorityCase(o Order) string {
+	field := b.dialect.Quote(o.Field)
+	if table, col, ok := strings.Cut(o.Field, "."); ok {
+		field = b.dialect.Quote(table) + "." + b.dialect.Quote(col)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CASE ")
+	sb.WriteString(field)
+	for i, v := range o.Priority {
+		fmt.Fprintf(&sb, " WHEN %s THEN %d", b.addParam(v), i)
+	}
+	fmt.Fprintf(&sb, " ELSE %d END", len(o.Priority))
+	return sb.String()
 }
 
 // addParam adds a parameter and returns the placeholder.
 // addParam 添加参数并返回占位符。
 func (b *SQLBuilder) addParam(value any) string {
 	b.paramN++
-	b.params = append(b.params, value)
+	b.params = append(b.params, b.paramValue(value))
 	return b.dialect.Placeholder(b.paramN)
 }
 
+// paramValue returns value as it is bound, converting times to the builder's location.
+// paramValue 返回绑定时的 value，并将时间转换到构建器的时区。
+func (b *SQLBuilder) paramValue(value any) any {
+	if b.location != nil {
+		switch t := value.(type) {
+		case time.Time:
+			return t.In(b.location)
+		case *time.Time:
+			if t != nil {
+				return t.In(b.location)
+			}
+		}
+	}
+	return value
+}
+
 // opToSQL converts an Operator to SQL string.
 // opToSQL 将 Operator 转换为 SQL 字符串。
 func (b *SQLBuilder) opToSQL(op Operator) string {
@@ -681,6 +1098,8 @@ func (b *SQLBuilder) opToSQL(op Operator) string {
 		return "LIKE"
 	case OpNotLike:
 		return "NOT LIKE"
+	case OpArrayContains:
+		return "@>"
 	default:
 		return string(op)
 	}
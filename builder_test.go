@@ -1,6 +1,12 @@
 package goorm
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
 
 // TestSQLBuilderSelect tests SELECT statement building.
 // TestSQLBuilderSelect 测试 SELECT 语句构建。
@@ -108,6 +114,19 @@ func TestSQLBuilderSelect(t *testing.T) {
 			wantSQL:    `SELECT * FROM "users" WHERE "age" BETWEEN $1 AND $2`,
 			wantParams: []any{18, 30},
 		},
+		{
+			name: "select with open-ended BETWEEN",
+			query: &Query{
+				Table:  "orders",
+				Action: ActionFind,
+				Where: []Condition{
+					{Field: "created_at", Op: OpBetween, Value: []any{"2024-01-01", nil}},
+					{Field: "total", Op: OpBetween, Value: []any{nil, 100}},
+				},
+			},
+			wantSQL:    `SELECT * FROM "orders" WHERE "created_at" >= $1 AND "total" <= $2`,
+			wantParams: []any{"2024-01-01", 100},
+		},
 		{
 			name: "select with NULL check",
 			query: &Query{
@@ -163,13 +182,64 @@ func TestSQLBuilderInsert(t *testing.T) {
 		t.Fatalf("Build() error = %v", err)
 	}
 
-	// Check SQL structure (column order may vary)
-	if !containsAll(result.SQL, []string{"INSERT INTO", `"users"`, "VALUES", "RETURNING id"}) {
-		t.Errorf("Build() SQL = %q, missing expected parts", result.SQL)
+	// Columns are sorted, so the SQL is the same on every run
+	// 列已排序，因此每次运行生成的 SQL 相同
+	wantSQL := `INSERT INTO "users" ("email", "name") VALUES ($1, $2) RETURNING "id"`
+	if result.SQL != wantSQL {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, wantSQL)
 	}
+	if !reflect.DeepEqual(result.Params, []any{"test@example.com", "张三"}) {
+		t.Errorf("Build() Params = %v", result.Params)
+	}
+}
 
-	if len(result.Params) != 2 {
-		t.Errorf("Build() Params count = %d, want 2", len(result.Params))
+// TestSQLBuilderInsertBatchMixedColumns tests batch inserts whose records have different columns.
+// TestSQLBuilderInsertBatchMixedColumns 测试记录列不同的批量插入。
+func TestSQLBuilderInsertBatchMixedColumns(t *testing.T) {
+	query := &Query{
+		Table:  "users",
+		Action: ActionCreateBatch,
+		DataBatch: []map[string]any{
+			{"name": "a"},
+			{"name": "b", "email": "b@example.com"},
+			{"email": "c@example.com", "age": 30},
+		},
+	}
+
+	tests := []struct {
+		dialect    Dialect
+		wantSQL    string
+		wantParams []any
+	}{
+		{
+			dialect:    &PostgresDialect{},
+			wantSQL:    `INSERT INTO "users" ("age", "email", "name") VALUES (DEFAULT, DEFAULT, $1), (DEFAULT, $2, $3), ($4, $5, DEFAULT) RETURNING "id"`,
+			wantParams: []any{"a", "b@example.com", "b", 30, "c@example.com"},
+		},
+		{
+			dialect:    &MySQLDialect{},
+			wantSQL:    "INSERT INTO `users` (`age`, `email`, `name`) VALUES (DEFAULT, DEFAULT, ?), (DEFAULT, ?, ?), (?, ?, DEFAULT)",
+			wantParams: []any{"a", "b@example.com", "b", 30, "c@example.com"},
+		},
+	}
+
+	// SQLite has no DEFAULT in VALUES, and NULL would override the column default
+	// SQLite 的 VALUES 不支持 DEFAULT，而 NULL 会覆盖列的默认值
+	if _, err := NewSQLBuilder(&SQLiteDialect{}, query).Build(); err == nil {
+		t.Error("sqlite: expected records with different columns to be rejected")
+	}
+
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.wantSQL {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.wantSQL)
+		}
+		if !reflect.DeepEqual(result.Params, tt.wantParams) {
+			t.Errorf("%s: Build() Params = %v, want %v", tt.dialect.Name(), result.Params, tt.wantParams)
+		}
 	}
 }
 
@@ -185,7 +255,9 @@ func TestSQLBuilderUpdate(t *testing.T) {
 			{Field: "id", Op: OpEqual, Value: 1},
 		},
 		Data: map[string]any{
-			"name": "新名字",
+			"name":  "新名字",
+			"email": "new@example.com",
+			"age":   30,
 		},
 	}
 
@@ -196,8 +268,12 @@ func TestSQLBuilderUpdate(t *testing.T) {
 		t.Fatalf("Build() error = %v", err)
 	}
 
-	if !containsAll(result.SQL, []string{"UPDATE", `"users"`, "SET", "WHERE"}) {
-		t.Errorf("Build() SQL = %q, missing expected parts", result.SQL)
+	wantSQL := `UPDATE "users" SET "age" = $1, "email" = $2, "name" = $3 WHERE "id" = $4`
+	if result.SQL != wantSQL {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, wantSQL)
+	}
+	if !reflect.DeepEqual(result.Params, []any{30, "new@example.com", "新名字", 1}) {
+		t.Errorf("Build() Params = %v", result.Params)
 	}
 }
 
@@ -367,3 +443,649 @@ func containsHelper(s, sub string) bool {
 	}
 	return false
 }
+
+// TestSQLBuilderExists tests presence check building across dialects.
+// TestSQLBuilderExists 测试跨方言的存在性检查构建。
+func TestSQLBuilderExists(t *testing.T) {
+	query := &Query{
+		Table: "users",
+		Where: []Condition{{Field: "email", Op: OpEqual, Value: "a@example.com"}},
+	}
+
+	tests := []struct {
+		dialect Dialect
+		wantSQL string
+	}{
+		{&PostgresDialect{}, `SELECT EXISTS(SELECT 1 FROM "users" WHERE "email" = $1)`},
+		{&SQLiteDialect{}, `SELECT EXISTS(SELECT 1 FROM "users" WHERE "email" = ?)`},
+		{&MySQLDialect{}, "SELECT 1 FROM `users` WHERE `email` = ? LIMIT 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			result, err := NewSQLBuilder(tt.dialect, query).BuildExists()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.SQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", result.SQL, tt.wantSQL)
+			}
+			if len(result.Params) != 1 {
+				t.Errorf("expected 1 param, got %v", result.Params)
+			}
+		})
+	}
+
+	if _, err := NewSQLBuilder(&PostgresDialect{}, &Query{}).BuildExists(); err == nil {
+		t.Error("expected error for missing table")
+	}
+}
+
+// TestSQLBuilderLock tests row locking clauses across dialects.
+// TestSQLBuilderLock 测试跨方言的行锁子句。
+func TestSQLBuilderLock(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		lock    string
+		wantSQL string
+	}{
+		{&PostgresDialect{}, LockUpdate, `SELECT * FROM "accounts" LIMIT 1 FOR UPDATE`},
+		{&PostgresDialect{}, LockShare, `SELECT * FROM "accounts" LIMIT 1 FOR SHARE`},
+		{&MySQLDialect{}, LockUpdate, "SELECT * FROM `accounts` LIMIT 1 FOR UPDATE"},
+		{&MySQLDialect{}, LockShare, "SELECT * FROM `accounts` LIMIT 1 LOCK IN SHARE MODE"},
+		{&SQLiteDialect{}, LockUpdate, `SELECT * FROM "accounts" LIMIT 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name()+"_"+tt.lock, func(t *testing.T) {
+			query := &Query{Table: "accounts", Action: ActionFind, Limit: 1, Lock: tt.lock}
+			result, err := NewSQLBuilder(tt.dialect, query).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.SQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", result.SQL, tt.wantSQL)
+			}
+		})
+	}
+}
+
+// TestSQLBuilderReturning tests RETURNING on create, update and delete.
+// TestSQLBuilderReturning 测试 create、update 和 delete 上的 RETURNING。
+func TestSQLBuilderReturning(t *testing.T) {
+	where := []Condition{{Field: "id", Op: OpEqual, Value: 1}}
+
+	update := &Query{Table: "users", Action: ActionUpdate, Where: where, Data: map[string]any{"name": "Tom"}, Returning: []string{"id", "name"}}
+	result, err := NewSQLBuilder(&PostgresDialect{}, update).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "users" SET "name" = $1 WHERE "id" = $2 RETURNING "id", "name"`
+	if result.SQL != want {
+		t.Errorf("SQL = %q, want %q", result.SQL, want)
+	}
+
+	del := &Query{Table: "users", Action: ActionDelete, Where: where, Returning: []string{"*"}}
+	result, err = NewSQLBuilder(&SQLiteDialect{}, del).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `DELETE FROM "users" WHERE "id" = ? RETURNING *`
+	if result.SQL != want {
+		t.Errorf("SQL = %q, want %q", result.SQL, want)
+	}
+
+	if _, err := NewSQLBuilder(&MySQLDialect{}, del).Build(); !errors.Is(err, ErrReturningNotSupported) {
+		t.Errorf("expected ErrReturningNotSupported, got %v", err)
+	}
+
+	create := &Query{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "Tom"}, Returning: []string{"created_at"}}
+	result, err = NewSQLBuilder(&PostgresDialect{}, create).WithPrimaryKey("uuid").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `INSERT INTO "users" ("name") VALUES ($1) RETURNING "uuid", "created_at"`
+	if result.SQL != want {
+		t.Errorf("SQL = %q, want %q", result.SQL, want)
+	}
+}
+
+// TestSQLBuilderReturnsPrimaryKey tests that inserts return the configured key column.
+// TestSQLBuilderReturnsPrimaryKey 测试插入语句返回配置的主键列。
+func TestSQLBuilderReturnsPrimaryKey(t *testing.T) {
+	query := &Query{Table: "codes", Action: ActionCreate, Data: map[string]any{"code": "A1"}}
+	result, err := NewSQLBuilder(&PostgresDialect{}, query).WithPrimaryKey("code").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `INSERT INTO "codes" ("code") VALUES ($1) RETURNING "code"`; result.SQL != want {
+		t.Errorf("SQL = %q, want %q", result.SQL, want)
+	}
+}
+
+// TestSQLBuilderRegisteredFunctions tests rendering functions registered with RegisterSQLFunc.
+// TestSQLBuilderRegisteredFunctions 测试生成通过 RegisterSQLFunc 注册的函数。
+func TestSQLBuilderRegisteredFunctions(t *testing.T) {
+	db := &DB{dialect: &PostgresDialect{}}
+	if err := db.RegisterSQLFunc("date_trunc", "date_trunc('{0}', {1})"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RegisterSQLFunc("coalesce", "COALESCE({0}, {1})"); err != nil {
+		t.Fatal(err)
+	}
+
+	query := &Query{
+		Table:  "orders",
+		Action: ActionAggregate,
+		Select: []any{
+			map[string]any{"fn": "date_trunc", "args": []any{"day", "orders.created_at"}, "as": "day"},
+			map[string]any{"fn": "coalesce", "args": []any{"discount", 0}, "as": "discount"},
+		},
+		Where:   []Condition{{Field: "status", Op: OpEqual, Value: "paid"}},
+		GroupBy: []string{"day", "discount"},
+		Having: []HavingCondition{
+			{Fn: "coalesce", Args: []any{"discount", 0}, Op: OpGreater, Value: 10},
+		},
+	}
+
+	result, err := db.newBuilder(context.Background(), query).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	wantSQL := `SELECT date_trunc($1, "orders"."created_at") AS "day", COALESCE("discount", $2) AS "discount" FROM "orders" WHERE "status" = $3 GROUP BY "day", "discount" HAVING COALESCE("discount", $4) > $5`
+	if result.SQL != wantSQL {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, wantSQL)
+	}
+	wantParams := []any{"day", 0, "paid", 0, 10}
+	if len(result.Params) != len(wantParams) {
+		t.Fatalf("Build() params = %v, want %v", result.Params, wantParams)
+	}
+	for i, p := range wantParams {
+		if result.Params[i] != p {
+			t.Errorf("param %d = %v, want %v", i, result.Params[i], p)
+		}
+	}
+
+	// Unregistered functions and missing arguments fail
+	// 未注册的函数和缺少参数都会失败
+	query.Select = []any{map[string]any{"fn": "unknown_fn", "args": []any{"x"}}}
+	if _, err := db.newBuilder(context.Background(), query).Build(); err == nil {
+		t.Error("expected error for unregistered function")
+	}
+	query.Select = []any{map[string]any{"fn": "date_trunc", "args": []any{"day"}}}
+	if _, err := db.newBuilder(context.Background(), query).Build(); err == nil {
+		t.Error("expected error for missing argument")
+	}
+}
+
+// TestSQLBuilderRollup tests GROUP BY ROLLUP generation per dialect.
+// TestSQLBuilderRollup 测试各方言的 GROUP BY ROLLUP 生成。
+func TestSQLBuilderRollup(t *testing.T) {
+	query := &Query{
+		Table:  "sales",
+		Action: ActionAggregate,
+		Select: []any{
+			"region",
+			"product",
+			map[string]any{"fn": "sum", "field": "amount", "as": "total"},
+		},
+		GroupBy: []string{"region", "product"},
+		Rollup:  true,
+	}
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `SELECT "region", "product", SUM("amount") AS "total" FROM "sales" GROUP BY ROLLUP("region", "product")`},
+		{&MySQLDialect{}, "SELECT `region`, `product`, SUM(`amount`) AS `total` FROM `sales` GROUP BY `region`, `product` WITH ROLLUP"},
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+	}
+
+	if _, err := NewSQLBuilder(&SQLiteDialect{}, query).Build(); !errors.Is(err, ErrRollupNotSupported) {
+		t.Errorf("SQLite: expected ErrRollupNotSupported, got %v", err)
+	}
+}
+
+// TestSQLBuilderDateFn tests date part extraction in conditions per dialect.
+// TestSQLBuilderDateFn 测试各方言在条件中提取日期部分。
+func TestSQLBuilderDateFn(t *testing.T) {
+	query := &Query{
+		Table:  "orders",
+		Action: ActionFind,
+		Select: []any{"id"},
+		Where: []Condition{
+			{Field: "created_at", Fn: "year", Op: OpEqual, Value: 2024},
+			{Field: "created_at", Fn: "month", Op: OpIn, Value: []any{1, 2}},
+		},
+	}
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `SELECT "id" FROM "orders" WHERE EXTRACT(YEAR FROM "created_at") = $1 AND EXTRACT(MONTH FROM "created_at") IN ($2, $3)`},
+		{&MySQLDialect{}, "SELECT `id` FROM `orders` WHERE YEAR(`created_at`) = ? AND MONTH(`created_at`) IN (?, ?)"},
+		{&SQLiteDialect{}, `SELECT "id" FROM "orders" WHERE CAST(strftime('%Y', "created_at") AS INTEGER) = ? AND CAST(strftime('%m', "created_at") AS INTEGER) IN (?, ?)`},
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+	}
+
+	bad := &Query{Table: "orders", Action: ActionFind, Where: []Condition{{Field: "created_at", Fn: "week", Op: OpEqual, Value: 1}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("expected validation error for unknown fn")
+	}
+}
+
+// TestSQLBuilderSchema tests qualifying tables with the schema of the context.
+// TestSQLBuilderSchema 测试以上下文中的 schema 限定表名。
+func TestSQLBuilderSchema(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, nil)
+	query := &Query{
+		Table:  "users",
+		Action: ActionFind,
+		Where: []Condition{{
+			Field:    "id",
+			Op:       OpIn,
+			Subquery: &Query{Table: "orders", Action: ActionFind, Select: []any{"user_id"}},
+		}},
+	}
+
+	result, err := db.newBuilder(context.Background(), query).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM "users" WHERE "id" IN (SELECT "user_id" FROM "orders")`; result.SQL != want {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, want)
+	}
+
+	ctx, err := WithSchema(context.Background(), "tenant_42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := db.ExecuteQuery(ctx, query); !r.Success {
+		t.Fatalf("find failed: %+v", r.Error)
+	}
+	want := `SELECT * FROM "tenant_42"."users" WHERE "id" IN (SELECT "user_id" FROM "tenant_42"."orders")`
+	if queries := backend.Queries(); len(queries) != 1 || queries[0] != want {
+		t.Errorf("queries = %q, want %q", queries, want)
+	}
+
+	if got := (&MySQLDialect{}).QualifiedTable("tenant_42", "users"); got != "`tenant_42`.`users`" {
+		t.Errorf("MySQL QualifiedTable = %s", got)
+	}
+
+	var identErr *IdentifierError
+	if _, err := WithSchema(context.Background(), `x"; DROP TABLE users; --`); !errors.As(err, &identErr) {
+		t.Errorf("expected an invalid schema to be rejected, got %v", err)
+	}
+}
+
+// TestSQLBuilderLongInList tests that long IN lists are split and oversized statements are rejected.
+// TestSQLBuilderLongInList 测试长 IN 列表会被拆分，超大语句会被拒绝。
+func TestSQLBuilderLongInList(t *testing.T) {
+	ids := []any{1, 2, 3, 4, 5}
+	build := func(dialect Dialect, op Operator, values []any) (*BuildResult, error) {
+		query := &Query{Table: "users", Action: ActionFind, Where: []Condition{{Field: "id", Op: op, Value: values}}}
+		return NewSQLBuilder(dialect, query).withMaxInValues(2).Build()
+	}
+
+	result, err := build(&MySQLDialect{}, OpIn, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT * FROM `users` WHERE (`id` IN (?, ?) OR `id` IN (?, ?) OR `id` IN (?))"
+	if result.SQL != want || len(result.Params) != 5 {
+		t.Errorf("Build() SQL = %q with %d params, want %q", result.SQL, len(result.Params), want)
+	}
+
+	result, err = build(&PostgresDialect{}, OpNotIn, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `SELECT * FROM "users" WHERE ("id" NOT IN ($1, $2) AND "id" NOT IN ($3, $4) AND "id" NOT IN ($5))`
+	if result.SQL != want {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, want)
+	}
+
+	result, err = build(&PostgresDialect{}, OpIn, ids[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want = `SELECT * FROM "users" WHERE "id" IN ($1, $2)`; result.SQL != want {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, want)
+	}
+
+	if _, err := build(&smallParamsDialect{}, OpIn, make([]any, 11)); !errors.Is(err, ErrTooManyParams) {
+		t.Errorf("expected ErrTooManyParams, got %v", err)
+	}
+}
+
+// TestSQLBuilderOrderNulls tests NULLS FIRST/LAST per dialect.
+// TestSQLBuilderOrderNulls 测试各方言的 NULLS FIRST/LAST。
+func TestSQLBuilderOrderNulls(t *testing.T) {
+	query := &Query{
+		Table:   "tasks",
+		Action:  ActionFind,
+		OrderBy: []Order{{Field: "due_at", Nulls: NullsLast}, {Field: "priority", Desc: true, Nulls: NullsFirst}},
+	}
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `SELECT * FROM "tasks" ORDER BY "due_at" ASC NULLS LAST, "priority" DESC NULLS FIRST`},
+		{&SQLiteDialect{}, `SELECT * FROM "tasks" ORDER BY "due_at" ASC NULLS LAST, "priority" DESC NULLS FIRST`},
+		{&MySQLDialect{}, "SELECT * FROM `tasks` ORDER BY CASE WHEN `due_at` IS NULL THEN 1 ELSE 0 END, `due_at` ASC, " +
+			"CASE WHEN `priority` IS NULL THEN 0 ELSE 1 END, `priority` DESC"},
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+	}
+
+	invalid := &Query{Table: "tasks", Action: ActionFind, OrderBy: []Order{{Field: "due_at", Nulls: "middle"}}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected an error for an unknown nulls placement")
+	}
+}
+
+// TestSQLBuilderCollate tests COLLATE in ORDER BY and WHERE per dialect.
+// TestSQLBuilderCollate 测试各方言在 ORDER BY 和 WHERE 中的 COLLATE。
+func TestSQLBuilderCollate(t *testing.T) {
+	build := func(d Dialect, collation string) (string, error) {
+		query := &Query{
+			Table:   "users",
+			Action:  ActionFind,
+			Where:   []Condition{{Field: "name", Op: OpEqual, Value: "ada", Collate: collation}},
+			OrderBy: []Order{{Field: "name", Collate: collation, Nulls: NullsLast}},
+		}
+		result, err := NewSQLBuilder(d, query).Build()
+		if err != nil {
+			return "", err
+		}
+		return result.SQL, nil
+	}
+
+	tests := []struct {
+		dialect   Dialect
+		collation string
+		want      string
+	}{
+		{&PostgresDialect{}, "de-DE-x-icu", `SELECT * FROM "users" WHERE "name" COLLATE "de-DE-x-icu" = $1 ORDER BY "name" COLLATE "de-DE-x-icu" ASC NULLS LAST`},
+		{&MySQLDialect{}, "utf8mb4_german2_ci", "SELECT * FROM `users` WHERE `name` COLLATE utf8mb4_german2_ci = ? ORDER BY " +
+			"CASE WHEN `name` COLLATE utf8mb4_german2_ci IS NULL THEN 1 ELSE 0 END, `name` COLLATE utf8mb4_german2_ci ASC"},
+		{&SQLiteDialect{}, "NOCASE", `SELECT * FROM "users" WHERE "name" COLLATE NOCASE = ? ORDER BY "name" COLLATE NOCASE ASC NULLS LAST`},
+	}
+	for _, tt := range tests {
+		sql, err := build(tt.dialect, tt.collation)
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if sql != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), sql, tt.want)
+		}
+	}
+
+	if _, err := build(&MySQLDialect{}, "de-DE-x-icu"); err == nil {
+		t.Error("MySQL: expected an error for a collation it cannot name")
+	}
+	for _, bad := range []string{`de_DE" ; DROP TABLE users; --`, "NOCASE)"} {
+		if _, err := build(&PostgresDialect{}, bad); err == nil {
+			t.Errorf("expected the builder to reject collation %q", bad)
+		}
+		query := &Query{Table: "users", Action: ActionFind, OrderBy: []Order{{Field: "name", Collate: bad}}}
+		if err := query.Validate(); err == nil {
+			t.Errorf("expected Validate to reject collation %q", bad)
+		}
+	}
+
+	withFn := &Query{Table: "users", Action: ActionFind, Where: []Condition{{Field: "created_at", Fn: DateYear, Op: OpEqual, Value: 2024, Collate: "C"}}}
+	if err := withFn.Validate(); err == nil {
+		t.Error("expected an error for collate combined with fn")
+	}
+}
+
+// fetchDialect is Postgres with SQL Server style pagination, which needs an ORDER BY.
+// fetchDialect 是使用 SQL Server 风格分页的 Postgres 方言，分页时要求有 ORDER BY。
+type fetchDialect struct {
+	PostgresDialect
+}
+
+func (d *fetchDialect) LimitOffsetClause(limit, offset int, hasOrder bool) (string, error) {
+	if limit == 0 && offset == 0 {
+		return "", nil
+	}
+	if !hasOrder {
+		return "", fmt.Errorf("pagination requires order_by")
+	}
+	clause := fmt.Sprintf("OFFSET %d ROWS", offset)
+	if limit > 0 {
+		clause += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return clause, nil
+}
+
+// TestSQLBuilderLimitOffset tests that pagination is rendered by the dialect.
+// TestSQLBuilderLimitOffset 测试分页由方言生成。
+func TestSQLBuilderLimitOffset(t *testing.T) {
+	offsetOnly := &Query{Table: "users", Action: ActionFind, Offset: 20}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `SELECT * FROM "users" OFFSET 20`},
+		{&SQLiteDialect{}, `SELECT * FROM "users" LIMIT -1 OFFSET 20`},
+		{&MySQLDialect{}, "SELECT * FROM `users` LIMIT 18446744073709551615 OFFSET 20"},
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, offsetOnly).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+	}
+
+	paged := &Query{Table: "users", Action: ActionFind, OrderBy: []Order{{Field: "id"}}, Limit: 10, Offset: 20}
+	result, err := NewSQLBuilder(&fetchDialect{}, paged).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `SELECT * FROM "users" ORDER BY "id" ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`; result.SQL != want {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, want)
+	}
+
+	if _, err := NewSQLBuilder(&fetchDialect{}, &Query{Table: "users", Action: ActionFind, Limit: 10}).Build(); err == nil {
+		t.Error("expected the dialect's error for pagination without order_by")
+	}
+}
+
+// TestSQLBuilderInsertIgnore tests that on_conflict ignore is rendered by the dialect.
+// TestSQLBuilderInsertIgnore 测试 on_conflict ignore 由方言生成。
+func TestSQLBuilderInsertIgnore(t *testing.T) {
+	query := &Query{Table: "users", Action: ActionCreate, Data: map[string]any{"email": "a@example.com"}, OnConflict: OnConflictIgnore}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `INSERT INTO "users" ("email") VALUES ($1) ON CONFLICT DO NOTHING RETURNING "id"`},
+		{&SQLiteDialect{}, `INSERT INTO "users" ("email") VALUES (?) ON CONFLICT DO NOTHING RETURNING "id"`},
+		{&MySQLDialect{}, "INSERT IGNORE INTO `users` (`email`) VALUES (?)"},
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+	}
+}
+
+// TestSQLBuilderNullSafeEqual tests that null-safe equality is rendered by the dialect.
+// TestSQLBuilderNullSafeEqual 测试 NULL 安全的等值比较由方言生成。
+func TestSQLBuilderNullSafeEqual(t *testing.T) {
+	query := &Query{
+		Table:  "contacts",
+		Action: ActionFind,
+		Where: []Condition{
+			{Field: "phone", Op: OpNullSafeEqual, Value: nil},
+			{Field: "contacts.email", Op: OpNullSafeEqual, Ref: "imports.email"},
+		},
+	}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `SELECT * FROM "contacts" WHERE "phone" IS NOT DISTINCT FROM $1 AND contacts.email IS NOT DISTINCT FROM imports.email`},
+		{&MySQLDialect{}, "SELECT * FROM `contacts` WHERE `phone` <=> ? AND contacts.email <=> imports.email"},
+		{&SQLiteDialect{}, `SELECT * FROM "contacts" WHERE "phone" IS ? AND contacts.email IS imports.email`},
+	}
+	if err := query.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+		if len(result.Params) != 1 || result.Params[0] != nil {
+			t.Errorf("%s: Params = %v, want [<nil>]", tt.dialect.Name(), result.Params)
+		}
+	}
+}
+
+// TestSQLBuilderIEqual tests case-insensitive equality per dialect.
+// TestSQLBuilderIEqual 测试各方言的不区分大小写等于。
+func TestSQLBuilderIEqual(t *testing.T) {
+	query := &Query{
+		Table:  "users",
+		Action: ActionFind,
+		Where: []Condition{
+			{Field: "email", Op: OpIEqual, Value: "Ada_L@Example.com"},
+			{Field: "users.login", Op: OpIEqual, Ref: "invites.login"},
+		},
+	}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `SELECT * FROM "users" WHERE LOWER("email") = LOWER($1) AND LOWER(users.login) = LOWER(invites.login)`},
+		{&MySQLDialect{}, "SELECT * FROM `users` WHERE LOWER(`email`) = LOWER(?) AND LOWER(users.login) = LOWER(invites.login)"},
+		{&SQLiteDialect{}, `SELECT * FROM "users" WHERE "email" = ? COLLATE NOCASE AND users.login = invites.login COLLATE NOCASE`},
+	}
+	if err := query.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for _, tt := range tests {
+		result, err := NewSQLBuilder(tt.dialect, query).Build()
+		if err != nil {
+			t.Fatalf("%s: Build() error = %v", tt.dialect.Name(), err)
+		}
+		if result.SQL != tt.want {
+			t.Errorf("%s: Build() SQL = %q, want %q", tt.dialect.Name(), result.SQL, tt.want)
+		}
+		if len(result.Params) != 1 || result.Params[0] != "Ada_L@Example.com" {
+			t.Errorf("%s: Params = %v, want the value unchanged", tt.dialect.Name(), result.Params)
+		}
+	}
+
+	missing := &Query{Table: "users", Action: ActionFind, Where: []Condition{{Field: "email", Op: OpIEqual}}}
+	if err := missing.Validate(); err == nil {
+		t.Error("expected an error for iequal without a value")
+	}
+	for _, c := range []Condition{
+		{Field: "email", Op: OpIEqual, Value: []any{"a@example.com", "b@example.com"}},
+		{Field: "email", Op: OpIEqual, Collate: "NOCASE", Value: "a@example.com"},
+		{Field: "created_at", Op: OpIEqual, Fn: DateYear, Value: 2024},
+	} {
+		q := &Query{Table: "users", Action: ActionFind, Where: []Condition{c}}
+		if err := q.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+}
+
+// TestSQLBuilderOrderPriority tests ordering by a value priority list.
+// TestSQLBuilderOrderPriority 测试按值优先级列表排序。
+func TestSQLBuilderOrderPriority(t *testing.T) {
+	query := &Query{
+		Table:   "tickets",
+		Action:  ActionFind,
+		Where:   []Condition{{Field: "open", Op: OpEqual, Value: true}},
+		OrderBy: []Order{{Field: "status", Priority: []any{"urgent", "high"}}, {Field: "id"}},
+		Limit:   10,
+	}
+
+	result, err := NewSQLBuilder(&PostgresDialect{}, query).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT * FROM "tickets" WHERE "open" = $1 ORDER BY CASE "status" WHEN $2 THEN 0 WHEN $3 THEN 1 ELSE 2 END ASC, "id" ASC LIMIT 10`
+	if result.SQL != want {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, want)
+	}
+	if !reflect.DeepEqual(result.Params, []any{true, "urgent", "high"}) {
+		t.Errorf("Build() Params = %v", result.Params)
+	}
+}
+
+// TestSQLBuilderArrayContains tests the array containment operator.
+// TestSQLBuilderArrayContains 测试数组包含运算符。
+func TestSQLBuilderArrayContains(t *testing.T) {
+	query := &Query{
+		Table:  "articles",
+		Action: ActionFind,
+		Where:  []Condition{{Field: "tags", Op: OpArrayContains, Value: []string{"go", `say "hi"`}}},
+	}
+	if err := query.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	result, err := NewSQLBuilder(&PostgresDialect{}, query).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if want := `SELECT * FROM "articles" WHERE "tags" @> $1`; result.SQL != want {
+		t.Errorf("Build() SQL = %q, want %q", result.SQL, want)
+	}
+	if len(result.Params) != 1 || result.Params[0] != `{"go","say \"hi\""}` {
+		t.Errorf("Params = %v, want the array literal", result.Params)
+	}
+
+	if _, err := NewSQLBuilder(&MySQLDialect{}, query).Build(); err == nil {
+		t.Error("expected array_contains to be rejected on MySQL")
+	}
+
+	scalar := &Query{Table: "articles", Action: ActionFind, Where: []Condition{{Field: "tags", Op: OpArrayContains, Value: "go"}}}
+	if err := scalar.Validate(); err == nil {
+		t.Error("expected a scalar value to be rejected")
+	}
+}
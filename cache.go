@@ -1,10 +1,13 @@
 package goorm
 
 import (
+	"container/list"
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,25 +35,40 @@ type Cache interface {
 	ClearAll()
 }
 
-// MemoryCache is an in-memory cache implementation.
-// MemoryCache 是内存缓存的实现。
+// MemoryCache is an in-memory cache implementation. Entries expire after their
+// TTL; with SetMaxEntries or SetMaxBytes, the least recently used entries are
+// also evicted to stay within the limits.
+//
+// MemoryCache 是内存缓存的实现。条目在 TTL 后过期；设置 SetMaxEntries 或 SetMaxBytes 后，
+// 还会淘汰最近最少使用的条目以保持在限制之内。
 type MemoryCache struct {
 	mu      sync.RWMutex
 	entries map[string]*cacheEntry
 	tables  map[string]map[string]bool // table -> keys
+	lru     *list.List                 // keys, most recently used first
+	hits    atomic.Int64
+	misses  atomic.Int64
+
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
 }
 
 type cacheEntry struct {
 	result    *Result
 	expiresAt time.Time
+	table     string
+	size      int64
+	elem      *list.Element
 }
 
-// NewMemoryCache creates a new memory cache.
-// NewMemoryCache 创建新的内存缓存。
+// NewMemoryCache creates a new memory cache without size limits.
+// NewMemoryCache 创建没有大小限制的内存缓存。
 func NewMemoryCache() *MemoryCache {
 	c := &MemoryCache{
 		entries: make(map[string]*cacheEntry),
 		tables:  make(map[string]map[string]bool),
+		lru:     list.New(),
 	}
 
 	// Start cleanup goroutine
@@ -60,21 +78,43 @@ func NewMemoryCache() *MemoryCache {
 	return c
 }
 
-// Get retrieves a cached result.
-// Get 获取缓存的结果。
+// SetMaxEntries limits the number of entries; 0 means no limit. Entries over the
+// limit are evicted least recently used first.
+//
+// SetMaxEntries 限制条目数量；0 表示不限制。超出限制的条目按最近最少使用的顺序淘汰。
+func (c *MemoryCache) SetMaxEntries(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxEntries = n
+	c.evictLocked()
+}
+
+// SetMaxBytes limits the approximate size of the cached results, measured as
+// their JSON encoding; 0 means no limit. A result larger than the limit is not
+// cached.
+//
+// SetMaxBytes 限制缓存结果的近似大小（按其 JSON 编码计算）；0 表示不限制。大于该限制的结果不会被缓存。
+func (c *MemoryCache) SetMaxBytes(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxBytes = n
+	c.evictLocked()
+}
+
+// Get retrieves a cached result and marks it as recently used.
+// Get 获取缓存的结果并将其标记为最近使用。
 func (c *MemoryCache) Get(key string) (*Result, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	entry, exists := c.entries[key]
-	if !exists {
-		return nil, false
-	}
-
-	if time.Now().After(entry.expiresAt) {
+	if !exists || time.Now().After(entry.expiresAt) {
+		c.misses.Add(1)
 		return nil, false
 	}
 
+	c.hits.Add(1)
+	c.lru.MoveToFront(entry.elem)
 	return entry.result, true
 }
 
@@ -84,10 +124,7 @@ func (c *MemoryCache) Set(key string, result *Result, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries[key] = &cacheEntry{
-		result:    result,
-		expiresAt: time.Now().Add(ttl),
-	}
+	c.setLocked(key, "", result, ttl)
 }
 
 // SetWithTable stores a result with table tracking.
@@ -96,15 +133,71 @@ func (c *MemoryCache) SetWithTable(key, table string, result *Result, ttl time.D
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.setLocked(key, table, result, ttl)
+}
+
+// setLocked stores result under key, then evicts entries over the limits.
+// setLocked 以 key 存储 result，然后淘汰超出限制的条目。
+func (c *MemoryCache) setLocked(key, table string, result *Result, ttl time.Duration) {
+	c.removeLocked(key)
+
+	var size int64
+	if c.maxBytes > 0 {
+		size = resultSize(key, result)
+		if size > c.maxBytes {
+			return
+		}
+	}
+
 	c.entries[key] = &cacheEntry{
 		result:    result,
 		expiresAt: time.Now().Add(ttl),
+		table:     table,
+		size:      size,
+		elem:      c.lru.PushFront(key),
+	}
+	c.bytes += size
+	if table != "" {
+		if c.tables[table] == nil {
+			c.tables[table] = make(map[string]bool)
+		}
+		c.tables[table][key] = true
 	}
+	c.evictLocked()
+}
+
+// evictLocked removes least recently used entries until the cache is within its limits.
+// evictLocked 移除最近最少使用的条目，直到缓存处于限制之内。
+func (c *MemoryCache) evictLocked() {
+	for c.lru.Len() > 0 &&
+		((c.maxEntries > 0 && len(c.entries) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.removeLocked(c.lru.Back().Value.(string))
+	}
+}
 
-	if c.tables[table] == nil {
-		c.tables[table] = make(map[string]bool)
+// removeLocked removes key and its table tracking.
+// removeLocked 移除 key 及其表跟踪。
+func (c *MemoryCache) removeLocked(key string) {
+	entry, exists := c.entries[key]
+	if !exists {
+		return
+	}
+	delete(c.entries, key)
+	c.lru.Remove(entry.elem)
+	c.bytes -= entry.size
+	if keys := c.tables[entry.table]; keys != nil {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.tables, entry.table)
+		}
 	}
-	c.tables[table][key] = true
+}
+
+// resultSize approximates the memory held by a cached result from its JSON encoding.
+// resultSize 根据 JSON 编码估算缓存结果占用的内存。
+func resultSize(key string, result *Result) int64 {
+	data, _ := json.Marshal(result)
+	return int64(len(key) + len(data))
 }
 
 // Delete removes a key from the cache.
@@ -113,7 +206,7 @@ func (c *MemoryCache) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.entries, key)
+	c.removeLocked(key)
 }
 
 // Clear clears all cached entries for a table.
@@ -122,15 +215,9 @@ func (c *MemoryCache) Clear(table string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	keys, exists := c.tables[table]
-	if !exists {
-		return
-	}
-
-	for key := range keys {
-		delete(c.entries, key)
+	for key := range c.tables[table] {
+		c.removeLocked(key)
 	}
-	delete(c.tables, table)
 }
 
 // ClearAll clears all cached entries.
@@ -141,6 +228,8 @@ func (c *MemoryCache) ClearAll() {
 
 	c.entries = make(map[string]*cacheEntry)
 	c.tables = make(map[string]map[string]bool)
+	c.lru.Init()
+	c.bytes = 0
 }
 
 // Stats returns cache statistics.
@@ -149,10 +238,7 @@ func (c *MemoryCache) Stats() CacheStats {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return CacheStats{
-		Entries: len(c.entries),
-		Tables:  len(c.tables),
-	}
+	return newCacheStats(len(c.entries), len(c.tables), c.hits.Load(), c.misses.Load())
 }
 
 // cleanup periodically removes expired entries.
@@ -166,7 +252,7 @@ func (c *MemoryCache) cleanup() {
 		now := time.Now()
 		for key, entry := range c.entries {
 			if now.After(entry.expiresAt) {
-				delete(c.entries, key)
+				c.removeLocked(key)
 			}
 		}
 		c.mu.Unlock()
@@ -176,8 +262,21 @@ func (c *MemoryCache) cleanup() {
 // CacheStats contains cache statistics.
 // CacheStats 包含缓存统计信息。
 type CacheStats struct {
-	Entries int
-	Tables  int
+	Entries int     `json:"entries"`
+	Tables  int     `json:"tables"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // Hits / (Hits + Misses), 0 before any lookup / 尚无查找时为 0
+}
+
+// newCacheStats builds CacheStats, computing the hit rate.
+// newCacheStats 构建 CacheStats 并计算命中率。
+func newCacheStats(entries, tables int, hits, misses int64) CacheStats {
+	stats := CacheStats{Entries: entries, Tables: tables, Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
 }
 
 // CacheManager manages query caching.
@@ -187,6 +286,18 @@ type CacheManager struct {
 	enabled bool
 	ttl     time.Duration
 	tables  map[string]bool // tables to cache
+	hits    atomic.Int64
+	misses  atomic.Int64
+	flights flightGroup
+
+	// genMu guards gens, the number of times each table was invalidated, and
+	// genAll, the number of InvalidateAll calls. A result is only cached if
+	// neither changed while it was produced.
+	// genMu 保护 gens（每张表失效的次数）和 genAll（InvalidateAll 调用的次数）。
+	// 只有在结果生成期间二者都未变化时才缓存该结果。
+	genMu  sync.Mutex
+	gens   map[string]int
+	genAll int
 }
 
 // NewCacheManager creates a new cache manager.
@@ -268,7 +379,13 @@ func (m *CacheManager) Get(query *Query) (*Result, bool) {
 	}
 
 	key := m.GenerateKey(query)
-	return m.cache.Get(key)
+	result, ok := m.cache.Get(key)
+	if ok {
+		m.hits.Add(1)
+	} else {
+		m.misses.Add(1)
+	}
+	return result, ok
 }
 
 // Set stores a result in the cache.
@@ -286,15 +403,109 @@ func (m *CacheManager) Set(query *Query, result *Result) {
 	}
 }
 
+// Do returns the cached result for query, or runs fn to produce it. Concurrent
+// calls for the same query, keyed by GenerateKey, share one run of fn and all get
+// its result; a successful result is then cached once. Queries that should not be
+// cached run fn directly.
+//
+// Do 返回 query 的缓存结果，或运行 fn 生成结果。相同查询（以 GenerateKey 为键）的并发调用
+// 共享 fn 的一次运行并都得到其结果；成功的结果随后只缓存一次。不应缓存的查询直接运行 fn。
+func (m *CacheManager) Do(query *Query, fn func() *Result) *Result {
+	if !m.ShouldCache(query) {
+		return fn()
+	}
+	if result, ok := m.Get(query); ok {
+		return result
+	}
+	return m.flights.do(m.GenerateKey(query), func() *Result {
+		gen := m.generation(query.Table)
+		result := fn()
+		if result != nil && result.Success {
+			// A write invalidating the table meanwhile may not be in result
+			// 期间使该表失效的写入可能未反映在 result 中
+			m.genMu.Lock()
+			if m.generationLocked(query.Table) == gen {
+				m.Set(query, result)
+			}
+			m.genMu.Unlock()
+		}
+		return result
+	})
+}
+
+// generation returns the number of times table was invalidated, directly or through
+// InvalidateAll.
+// generation 返回 table 直接或通过 InvalidateAll 失效的次数。
+func (m *CacheManager) generation(table string) int {
+	m.genMu.Lock()
+	defer m.genMu.Unlock()
+	return m.generationLocked(table)
+}
+
+// generationLocked is generation for callers holding genMu.
+// generationLocked 是供持有 genMu 的调用方使用的 generation。
+func (m *CacheManager) generationLocked(table string) int {
+	return m.gens[table] + m.genAll
+}
+
+// Middleware returns a Middleware that serves reads through Do and invalidates the
+// cached results of a table after a successful write to it. The cache key does not
+// include the context, so do not cache tables whose queries are scoped by it, such
+// as through WithSchema or tenant middleware.
+//
+// Middleware 返回一个中间件：读取通过 Do 提供，对表的写入成功后使该表的缓存结果失效。
+// 缓存键不包含 context，因此不要缓存查询受其限定的表（例如通过 WithSchema 或 EnableTenantScope）。
+//
+// Example / 示例:
+//
+//	cache := goorm.NewCacheManager()
+//	cache.Enable()
+//	cache.EnableTable("products")
+//	db.Use(cache.Middleware())
+func (m *CacheManager) Middleware() Middleware {
+	return func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query *Query) *Result {
+			if !query.IsWrite() {
+				return m.Do(query, func() *Result { return next(ctx, query) })
+			}
+			result := next(ctx, query)
+			if result != nil && result.Success {
+				m.invalidateWrites(query)
+			}
+			return result
+		}
+	}
+}
+
+// invalidateWrites invalidates the tables written by query and its operations.
+// invalidateWrites 使 query 及其操作写入的表的缓存失效。
+func (m *CacheManager) invalidateWrites(query *Query) {
+	for i := range query.Operations {
+		m.invalidateWrites(&query.Operations[i])
+	}
+	if query.Action != ActionTransaction && query.IsWrite() {
+		m.Invalidate(query.Table)
+	}
+}
+
 // Invalidate invalidates cache for a table.
 // Invalidate 使表的缓存失效。
 func (m *CacheManager) Invalidate(table string) {
+	m.genMu.Lock()
+	defer m.genMu.Unlock()
+	if m.gens == nil {
+		m.gens = make(map[string]int)
+	}
+	m.gens[table]++
 	m.cache.Clear(table)
 }
 
 // InvalidateAll invalidates all cache.
 // InvalidateAll 使所有缓存失效。
 func (m *CacheManager) InvalidateAll() {
+	m.genMu.Lock()
+	defer m.genMu.Unlock()
+	m.genAll++
 	m.cache.ClearAll()
 }
 
@@ -303,3 +514,16 @@ func (m *CacheManager) InvalidateAll() {
 func (m *CacheManager) SetCache(cache Cache) {
 	m.cache = cache
 }
+
+// Stats returns the hits and misses of the queries looked up through Get, and the
+// entry and table counts when the cache is a MemoryCache.
+//
+// Stats 返回通过 Get 查找的查询的命中和未命中次数；缓存为 MemoryCache 时还包括条目数和表数。
+func (m *CacheManager) Stats() CacheStats {
+	var entries, tables int
+	if mc, ok := m.cache.(*MemoryCache); ok {
+		mcStats := mc.Stats()
+		entries, tables = mcStats.Entries, mcStats.Tables
+	}
+	return newCacheStats(entries, tables, m.hits.Load(), m.misses.Load())
+}
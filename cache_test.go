@@ -1,6 +1,11 @@
 package goorm
 
 import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -217,3 +222,234 @@ func TestCacheManagerInvalidate(t *testing.T) {
 		t.Error("cache should be invalidated")
 	}
 }
+
+// TestCacheManagerInvalidateDuringRead tests that a read in flight while its table
+// is invalidated does not cache its result.
+// TestCacheManagerInvalidateDuringRead 测试表失效时正在进行的读取不会缓存其结果。
+func TestCacheManagerInvalidateDuringRead(t *testing.T) {
+	m := NewCacheManager()
+	m.Enable()
+	query := &Query{Table: "users", Action: ActionFind}
+
+	for _, invalidate := range []func(){func() { m.Invalidate("users") }, m.InvalidateAll} {
+		m.Do(query, func() *Result {
+			invalidate()
+			return &Result{Success: true}
+		})
+		if _, found := m.Get(query); found {
+			t.Error("a result read before the invalidation should not be cached")
+		}
+	}
+
+	m.Do(query, func() *Result {
+		m.Invalidate("orders")
+		return &Result{Success: true}
+	})
+	if _, found := m.Get(query); !found {
+		t.Error("invalidating another table should not keep the result from being cached")
+	}
+}
+
+// TestCacheHitRate tests the hit and miss counters of the cache and the manager.
+// TestCacheHitRate 测试缓存和管理器的命中与未命中计数。
+func TestCacheHitRate(t *testing.T) {
+	cache := NewMemoryCache()
+	cache.Set("key1", &Result{Success: true}, time.Hour)
+	cache.Set("stale", &Result{Success: true}, -time.Second)
+	cache.Get("key1")
+	cache.Get("key1")
+	cache.Get("stale")
+	cache.Get("missing")
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.HitRate != 0.5 {
+		t.Errorf("expected 2 hits, 2 misses and a rate of 0.5, got %+v", stats)
+	}
+
+	m := NewCacheManager()
+	if rate := m.Stats().HitRate; rate != 0 {
+		t.Errorf("expected a rate of 0 before any lookup, got %v", rate)
+	}
+	m.Enable()
+	query := &Query{Table: "users", Action: ActionFind}
+	m.Get(query)
+	m.Set(query, &Result{Success: true})
+	m.Get(query)
+	m.Get(query)
+	m.Get(&Query{Table: "users", Action: ActionCreate})
+
+	stats = m.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("expected 2 hits, 1 miss and 1 entry, got %+v", stats)
+	}
+
+	db, _ := newFakeDB(t, &SQLiteDialect{}, nil)
+	s := NewMCPServer(db)
+	s.SetCache(m)
+	out, err := s.handleGetStats(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := out.(map[string]any)["cache"].(CacheStats); !ok || got.Hits != 2 {
+		t.Errorf("expected get_stats to include the cache stats, got %v", out)
+	}
+
+	m.Disable()
+	out, _ = s.handleGetStats(context.Background(), nil)
+	if _, ok := out.(map[string]any)["cache"]; ok {
+		t.Error("expected no cache stats while caching is disabled")
+	}
+}
+
+// TestMemoryCacheLRU tests that the least recently used entries are evicted first.
+// TestMemoryCacheLRU 测试最近最少使用的条目最先被淘汰。
+func TestMemoryCacheLRU(t *testing.T) {
+	cache := NewMemoryCache()
+	cache.SetMaxEntries(3)
+
+	cache.SetWithTable("a", "users", &Result{Success: true}, time.Hour)
+	cache.SetWithTable("b", "users", &Result{Success: true}, time.Hour)
+	cache.Set("c", &Result{Success: true}, time.Hour)
+	cache.Get("a") // b is now the least recently used / b 成为最近最少使用的条目
+	cache.Set("d", &Result{Success: true}, time.Hour)
+
+	for _, tc := range []struct {
+		key  string
+		want bool
+	}{{"a", true}, {"b", false}, {"c", true}, {"d", true}} {
+		if _, found := cache.Get(tc.key); found != tc.want {
+			t.Errorf("Get(%q) found = %v, want %v", tc.key, found, tc.want)
+		}
+	}
+
+	cache.Set("e", &Result{Success: true}, time.Hour) // evicts a / 淘汰 a
+	if stats := cache.Stats(); stats.Entries != 3 || stats.Tables != 0 {
+		t.Errorf("expected 3 entries and no tracked tables, got %+v", stats)
+	}
+
+	cache.SetMaxEntries(1)
+	if _, found := cache.Get("e"); !found || cache.Stats().Entries != 1 {
+		t.Errorf("expected lowering the limit to keep only the most recent entry, got %+v", cache.Stats())
+	}
+}
+
+// TestMemoryCacheMaxBytes tests that the approximate size cap is respected.
+// TestMemoryCacheMaxBytes 测试近似大小上限会被遵守。
+func TestMemoryCacheMaxBytes(t *testing.T) {
+	row := func(n int) *Result {
+		return &Result{Success: true, Data: []map[string]any{{"payload": strings.Repeat("x", n)}}}
+	}
+	size := resultSize("k1", row(100))
+
+	cache := NewMemoryCache()
+	cache.SetMaxBytes(2*size + size/2)
+	cache.Set("k1", row(100), time.Hour)
+	cache.Set("k2", row(100), time.Hour)
+	cache.Set("k3", row(100), time.Hour)
+
+	if _, found := cache.Get("k1"); found {
+		t.Error("expected the oldest entry to be evicted")
+	}
+	if stats := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("expected 2 entries within the cap, got %d", stats.Entries)
+	}
+	if cache.bytes > cache.maxBytes {
+		t.Errorf("cached %d bytes, over the cap of %d", cache.bytes, cache.maxBytes)
+	}
+
+	cache.Set("huge", row(1000), time.Hour)
+	if _, found := cache.Get("huge"); found {
+		t.Error("expected a result larger than the cap not to be cached")
+	}
+	if _, found := cache.Get("k3"); !found {
+		t.Error("expected a rejected result not to evict others")
+	}
+}
+
+// TestCacheManagerSingleflight tests that concurrent identical cache misses share one database query.
+// TestCacheManagerSingleflight 测试并发的相同缓存未命中共享一次数据库查询。
+func TestCacheManagerSingleflight(t *testing.T) {
+	var selects atomic.Int32
+	release := make(chan struct{})
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if !strings.HasPrefix(query, "SELECT") {
+			return nil, nil, nil
+		}
+		if selects.Add(1) == 1 {
+			<-release
+		}
+		return []string{"id", "full_name", "email"}, [][]driver.Value{{int64(1), "Ada", "ada@example.com"}}, nil
+	})
+	if err := db.Register(&fixContact{}); err != nil {
+		t.Fatal(err)
+	}
+	m := NewCacheManager()
+	m.Enable()
+	db.Use(m.Middleware())
+
+	const n = 10
+	results := make([]*Result, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = db.ExecuteQuery(context.Background(), &Query{Table: "fix_contacts", Action: ActionFind})
+		}()
+	}
+	for selects.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := selects.Load(); got != 1 {
+		t.Errorf("expected 1 database query, got %d", got)
+	}
+	for i, r := range results {
+		if !r.Success || r.Count != 1 || r != results[0] {
+			t.Errorf("result %d: expected the shared result, got %+v", i, r)
+		}
+	}
+	if stats := m.Stats(); stats.Entries != 1 {
+		t.Errorf("expected the result cached once, got %d entries", stats.Entries)
+	}
+
+	if r := db.ExecuteQuery(context.Background(), &Query{Table: "fix_contacts", Action: ActionCreate, Data: map[string]any{"full_name": "Grace", "email": "grace@example.com"}}); !r.Success {
+		t.Fatalf("create failed: %+v", r.Error)
+	}
+	db.ExecuteQuery(context.Background(), &Query{Table: "fix_contacts", Action: ActionFind})
+	if got := selects.Load(); got != 2 {
+		t.Errorf("expected a write to invalidate the cached result, got %d database queries", got)
+	}
+}
+
+// TestFlightGroupPanic tests that waiters get an error result when the shared call panics.
+// TestFlightGroupPanic 测试共享调用发生 panic 时等待者得到错误结果。
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+	started, release := make(chan struct{}), make(chan struct{})
+	leader := make(chan any)
+	go func() {
+		defer func() { leader <- recover() }()
+		g.do("k", func() *Result {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	waiter := make(chan *Result)
+	go func() { waiter <- g.do("k", func() *Result { return &Result{Success: true} }) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if p := <-leader; p != "boom" {
+		t.Errorf("expected the panic to reach the leader, got %v", p)
+	}
+	if r := <-waiter; r == nil || r.Success || r.Error.Code != "PANIC" {
+		t.Errorf("expected a PANIC result for the waiter, got %+v", r)
+	}
+}
@@ -0,0 +1,233 @@
+package goorm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coerceTimeLayouts are the layouts accepted when converting strings to time.Time.
+// coerceTimeLayouts 是将字符串转换为 time.Time 时接受的格式。
+var coerceTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// coerceQuery converts the condition values of query, and of the operations of a
+// transaction, to the Go types of the registered columns they compare against.
+// Conditions on unregistered tables or columns are left untouched.
+//
+// coerceQuery 将查询（以及事务中各操作）的条件值转换为所比较的已注册列的 Go 类型。
+// 未注册的表或列上的条件保持不变。
+func (db *DB) coerceQuery(query *Query) *Result {
+	where, err := db.coerceConditions(query.Table, query.Where)
+	if err != nil {
+		return err
+	}
+	query.Where = where
+
+	for i := range query.Operations {
+		if r := db.coerceQuery(&query.Operations[i]); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// coerceConditions returns a copy of conds with converted values.
+// coerceConditions 返回值已转换的 conds 副本。
+func (db *DB) coerceConditions(table string, conds []Condition) ([]Condition, *Result) {
+	if len(conds) == 0 {
+		return conds, nil
+	}
+
+	out := make([]Condition, len(conds))
+	for i, c := range conds {
+		var r *Result
+		if c.And, r = db.coerceConditions(table, c.And); r != nil {
+			return nil, r
+		}
+		if c.OrGroup, r = db.coerceConditions(table, c.OrGroup); r != nil {
+			return nil, r
+		}
+		if c.Subquery != nil {
+			sub := *c.Subquery
+			if sub.Where, r = db.coerceConditions(sub.Table, sub.Where); r != nil {
+				return nil, r
+			}
+			c.Subquery = &sub
+		}
+
+		// Date part values are integers, not values of the column type
+		// 日期部分的值是整数，而非列类型的值
+		if c.Value != nil && c.Ref == "" && c.Subquery == nil && c.Fn == "" {
+			switch c.Op {
+			case OpLike, OpILike, OpNotLike, OpNull, OpNotNull, OpExists:
+				// Patterns stay strings; the rest take no value
+				// 模式保持为字符串；其余运算符不需要值
+			default:
+				if goType := db.columnGoType(table, c.Field); goType != "" {
+					if c.Op == OpIEqual && goType != "string" {
+						return nil, iequalTypeResult(c.Field, goType)
+					}
+					if c.Op == OpArrayContains {
+						// Values are elements of the array column
+						// 值是数组列的元素
+						goType = strings.TrimPrefix(goType, "[]")
+					}
+					value, err := coerceConditionValue(c.Value, goType)
+					if err != nil {
+						return nil, typeMismatchResult(c.Field, c.Value, goType, err)
+					}
+					c.Value = value
+				}
+			}
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+// columnGoType returns the Go type of a registered column, resolving "table.column" fields.
+// columnGoType 返回已注册列的 Go 类型，支持 "table.column" 形式的字段。
+func (db *DB) columnGoType(table, field string) string {
+	if db.registry == nil {
+		return ""
+	}
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		table, field = field[:i], field[i+1:]
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return ""
+	}
+	for _, f := range meta.Fields {
+		if f.ColumnName == field {
+			return strings.TrimPrefix(f.GoType, "*")
+		}
+	}
+	return ""
+}
+
+// coerceConditionValue converts a scalar or list value to goType.
+// coerceConditionValue 将标量或列表值转换为 goType。
+func coerceConditionValue(value any, goType string) (any, error) {
+	if values, ok := sliceValues(value); ok {
+		out := make([]any, len(values))
+		for i, v := range values {
+			converted, err := coerceValue(v, goType)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = converted
+		}
+		return out, nil
+	}
+	return coerceValue(value, goType)
+}
+
+// coerceValue converts a single value to goType. Unsupported target types pass through.
+// coerceValue 将单个值转换为 goType。不支持的目标类型原样返回。
+func coerceValue(value any, goType string) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		switch v := value.(type) {
+		case string:
+			if strings.HasPrefix(goType, "uint") {
+				return strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			}
+			return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("%v is not an integer", v)
+			}
+			return int64(v), nil
+		case float32:
+			if float64(v) != math.Trunc(float64(v)) {
+				return nil, fmt.Errorf("%v is not an integer", v)
+			}
+			return int64(v), nil
+		case bool:
+			return nil, fmt.Errorf("cannot use bool as %s", goType)
+		}
+	case "float32", "float64":
+		switch v := value.(type) {
+		case string:
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		case bool:
+			return nil, fmt.Errorf("cannot use bool as %s", goType)
+		}
+	case "bool":
+		switch v := value.(type) {
+		case string:
+			return strconv.ParseBool(strings.TrimSpace(v))
+		case float64:
+			if v != 0 && v != 1 {
+				return nil, fmt.Errorf("%v is not 0 or 1", v)
+			}
+			return v == 1, nil
+		}
+	case "time.Time":
+		if s, ok := value.(string); ok {
+			for _, layout := range coerceTimeLayouts {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t, nil
+				}
+			}
+			return nil, fmt.Errorf("%q is not a recognized time format", s)
+		}
+	case "string":
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	}
+	return value, nil
+}
+
+// typeMismatchResult reports a condition value that cannot be converted to its column type.
+// typeMismatchResult 报告无法转换为列类型的条件值。
+func typeMismatchResult(field string, value any, goType string, err error) *Result {
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:       "TYPE_MISMATCH",
+			Message:    fmt.Sprintf("value %v for field %q cannot be converted to %s: %v", value, field, goType, err),
+			Suggestion: fmt.Sprintf("Provide a %s value for %s", goType, field),
+			Details: map[string]any{
+				"field":    field,
+				"value":    value,
+				"expected": goType,
+			},
+		},
+	}
+}
+
+// iequalTypeResult returns the error for an iequal condition on a field that is not text.
+// iequalTypeResult 返回在非文本字段上使用 iequal 条件时的错误。
+func iequalTypeResult(field, goType string) *Result {
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:       "TYPE_MISMATCH",
+			Message:    fmt.Sprintf("iequal compares text and cannot be used on %s field %q", goType, field),
+			Suggestion: fmt.Sprintf("Use eq to match %s", field),
+			Details: map[string]any{
+				"field":    field,
+				"expected": "string",
+				"actual":   goType,
+			},
+		},
+	}
+}
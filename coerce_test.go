@@ -0,0 +1,110 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+type coercedEvent struct {
+	ID       uint64    `json:"id" goorm:"primaryKey;autoIncrement"`
+	Attendee int       `json:"attendee"`
+	Score    float64   `json:"score"`
+	Public   bool      `json:"public"`
+	StartsAt time.Time `json:"starts_at"`
+	Title    string    `json:"title"`
+}
+
+// TestCoerceValue tests conversion of single values to column types.
+// TestCoerceValue 测试单个值到列类型的转换。
+func TestCoerceValue(t *testing.T) {
+	tests := []struct {
+		value   any
+		goType  string
+		want    any
+		wantErr bool
+	}{
+		{"18", "int", int64(18), false},
+		{float64(18), "int64", int64(18), false},
+		{"7", "uint64", uint64(7), false},
+		{"1.5", "float64", 1.5, false},
+		{"true", "bool", true, false},
+		{float64(0), "bool", false, false},
+		{float64(42), "string", "42", false},
+		{"2024-05-01", "time.Time", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"abc", "int", nil, true},
+		{18.5, "int", nil, true},
+		{"yes please", "bool", nil, true},
+		{"soon", "time.Time", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := coerceValue(tt.value, tt.goType)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("coerceValue(%v, %s) error = %v, wantErr %v", tt.value, tt.goType, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("coerceValue(%v, %s) = %#v, want %#v", tt.value, tt.goType, got, tt.want)
+		}
+	}
+}
+
+// TestCoerceTypes tests that condition values are converted before binding when enabled.
+// TestCoerceTypes 测试启用后条件值在绑定前被转换。
+func TestCoerceTypes(t *testing.T) {
+	var args []driver.NamedValue
+	db, _ := newFakeDB(t, &PostgresDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		return []string{"id"}, nil, nil
+	})
+	db.config.CoerceTypes = true
+	if err := db.Register(&coercedEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	where := []Condition{
+		{Field: "attendee", Op: OpGreater, Value: "18"},
+		{Field: "coerced_events.public", Op: OpEqual, Value: "true"},
+		{Field: "id", Op: OpIn, Value: []any{"1", "2"}},
+		{Field: "title", Op: OpLike, Value: "1%"},
+		{Field: "starts_at", Op: OpBetween, Value: []any{"2024-03-01", nil}},
+	}
+	result := db.ExecuteQuery(ctx, &Query{Table: "coerced_events", Action: ActionFind, Where: where})
+	if !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+
+	want := []any{int64(18), true, int64(1), int64(2), "1%", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	if len(args) != len(want) {
+		t.Fatalf("got %d args, want %d", len(args), len(want))
+	}
+	for i, w := range want {
+		if args[i].Value != w {
+			t.Errorf("arg %d = %#v, want %#v", i, args[i].Value, w)
+		}
+	}
+	if where[0].Value != "18" {
+		t.Error("coercion should not modify the caller's conditions")
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "coerced_events",
+		Action: ActionFind,
+		Where:  []Condition{{OrGroup: []Condition{{Field: "score", Op: OpGreater, Value: "high"}}}},
+	})
+	if result.Success || result.Error.Code != "TYPE_MISMATCH" || result.Error.Details["field"] != "score" {
+		t.Errorf("expected TYPE_MISMATCH on score, got %+v", result.Error)
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "coerced_events",
+		Action: ActionFind,
+		Where:  []Condition{{Field: "attendee", Op: OpIEqual, Value: "18"}},
+	})
+	if result.Success || result.Error.Code != "TYPE_MISMATCH" || result.Error.Details["field"] != "attendee" {
+		t.Errorf("expected TYPE_MISMATCH for iequal on attendee, got %+v", result.Error)
+	}
+}
@@ -1,6 +1,7 @@
 package goorm
 
 import (
+	"regexp"
 	"time"
 )
 
@@ -17,6 +18,13 @@ type Config struct {
 	// DSN 是数据源名称（连接字符串）。
 	DSN string
 
+	// PostgresDriver is the database/sql driver used for postgres:// DSNs, e.g. "pgx"
+	// for github.com/jackc/pgx/v5/stdlib. When empty, "postgres" (lib/pq) is used if
+	// registered, otherwise "pgx".
+	// PostgresDriver 是 postgres:// DSN 使用的 database/sql 驱动，例如
+	// github.com/jackc/pgx/v5/stdlib 对应的 "pgx"。为空时若已注册 "postgres"（lib/pq）则使用它，否则使用 "pgx"。
+	PostgresDriver string
+
 	// MaxOpenConns sets the maximum number of open connections.
 	// MaxOpenConns 设置最大打开连接数。
 	MaxOpenConns int
@@ -45,6 +53,10 @@ type Config struct {
 	// WriteTimeout 是写操作的超时时间。
 	WriteTimeout time.Duration
 
+	// SlowThreshold is the duration above which a query is considered slow.
+	// SlowThreshold 是查询被视为慢查询的时长阈值。
+	SlowThreshold time.Duration
+
 	// Naming contains naming convention configuration.
 	// Naming 包含命名约定配置。
 	Naming NamingConfig
@@ -61,6 +73,67 @@ type Config struct {
 	// Debug 为所有查询启用调试模式。
 	Debug bool
 
+	// ReadOnly rejects every write operation before any SQL is built.
+	// ReadOnly 在构建任何 SQL 之前拒绝所有写操作。
+	ReadOnly bool
+
+	// CoerceTypes converts condition values to the registered column's Go type
+	// (e.g. "18" to 18) before binding; values that cannot convert fail with TYPE_MISMATCH.
+	// CoerceTypes 在绑定前将条件值转换为已注册列的 Go 类型（例如 "18" 转为 18）；
+	// 无法转换的值以 TYPE_MISMATCH 失败。
+	CoerceTypes bool
+
+	// MaxInValues is the number of values per IN/NOT IN list above which the list is
+	// split into OR-joined (AND-joined for NOT IN) groups (default DefaultMaxInValues).
+	// MaxInValues 是每个 IN/NOT IN 列表的值数量上限，超过时列表会被拆分为以 OR（NOT IN 为 AND）
+	// 连接的多个分组（默认 DefaultMaxInValues）。
+	MaxInValues int
+
+	// PrepareStatements prepares each generated SQL once and reuses the statement
+	// for later executions with the same SQL, skipping server-side parsing.
+	// PrepareStatements 对每条生成的 SQL 只预处理一次，之后相同 SQL 的执行复用该语句，从而跳过服务端解析。
+	PrepareStatements bool
+
+	// StatementCacheSize is the number of prepared statements kept per DB, least
+	// recently used first out (default DefaultStatementCacheSize).
+	// StatementCacheSize 是每个 DB 保留的预处理语句数量，最近最少使用的先被淘汰（默认 DefaultStatementCacheSize）。
+	StatementCacheSize int
+
+	// CacheSQLTemplates reuses the SQL built for a query for later queries of the same
+	// shape, those differing only in the values they bind, which are bound afresh.
+	// CacheSQLTemplates 对结构相同（仅绑定的值不同）的后续查询复用已为查询构建的 SQL，只重新绑定这些值。
+	CacheSQLTemplates bool
+
+	// SQLTemplateCacheSize is the number of query shapes whose SQL is kept per DB, least
+	// recently used first out (default DefaultSQLTemplateCacheSize).
+	// SQLTemplateCacheSize 是每个 DB 保留其 SQL 的查询结构数量，最近最少使用的先被淘汰（默认 DefaultSQLTemplateCacheSize）。
+	SQLTemplateCacheSize int
+
+	// IdentifierPattern is the pattern every table, column and alias name in a query
+	// must match before SQL is built (default DefaultIdentifierPattern), e.g. to allow
+	// non-ASCII column names. Names failing it are rejected with INVALID_IDENTIFIER.
+	// IdentifierPattern 是构建 SQL 之前查询中每个表名、列名和别名必须匹配的模式（默认
+	// DefaultIdentifierPattern），例如用于允许非 ASCII 列名。不匹配的名称以 INVALID_IDENTIFIER 拒绝。
+	IdentifierPattern *regexp.Regexp
+
+	// ServerTimeouts makes the database itself abort statements that outlive the
+	// query timeout, instead of only cancelling the Go call: PostgreSQL statements run
+	// after SET LOCAL statement_timeout, in a transaction of their own outside
+	// transactions, and MySQL SELECTs carry a MAX_EXECUTION_TIME hint. Outside
+	// transactions each PostgreSQL statement with a deadline costs extra round trips
+	// and bypasses the statement cache.
+	// ServerTimeouts 让数据库本身中止超过查询超时的语句，而不仅仅是取消 Go 调用：PostgreSQL 语句在
+	// SET LOCAL statement_timeout 之后执行（事务之外时在其自身的事务中），MySQL 的 SELECT 带有
+	// MAX_EXECUTION_TIME 提示。在事务之外，每条带截止时间的 PostgreSQL 语句会多几次往返，并且不使用语句缓存。
+	ServerTimeouts bool
+
+	// Location is the time zone time.Time values are bound in, timestamps read back
+	// as strings (SQLite) are parsed in, and TimestampHook and create defaults use
+	// (default UTC).
+	// Location 是绑定 time.Time 值、解析以字符串读回的时间戳（SQLite）以及 TimestampHook
+	// 和创建默认值所使用的时区（默认 UTC）。
+	Location *time.Location
+
 	// Logger is the logger for GoORM.
 	// Logger 是 GoORM 的日志记录器。
 	Logger Logger
@@ -96,6 +169,12 @@ type NamingConfig struct {
 	// DeletedAtField is the name of the deleted_at field.
 	// DeletedAtField 是 deleted_at 字段的名称。
 	DeletedAtField string
+
+	// DeletedByField is the column soft delete sets to the request actor (see
+	// WithActor), e.g. "deleted_by". Empty leaves the deleting user unrecorded.
+	// DeletedByField 是软删除时设置为请求执行者（见 WithActor）的列，例如 "deleted_by"。
+	// 为空时不记录执行删除的用户。
+	DeletedByField string
 }
 
 // TableNamerFunc is a function type for converting struct name to table name.
@@ -145,6 +224,15 @@ type SecurityConfig struct {
 	// AuditEnabled 启用审计日志。
 	AuditEnabled bool
 
+	// AuditIDs adds the primary keys of the rows each update and delete wrote to its
+	// audit entry. The writes run with ReturnIDs to get them, so they take its cost.
+	// Entries have no IDs for tables without a single primary key, and for writes of
+	// more rows than ReturnIDs can report without BatchSize.
+	// AuditIDs 将每次更新和删除所写入行的主键加入其审计条目。写入会以 ReturnIDs 执行来获取主键，
+	// 因此承担其开销。对没有单一主键的表，以及行数超过 ReturnIDs 在未设置 BatchSize 时所能报告的写入，
+	// 审计条目没有 ID。
+	AuditIDs bool
+
 	// MaskSensitive enables automatic masking of sensitive fields.
 	// MaskSensitive 启用敏感字段的自动脱敏。
 	MaskSensitive bool
@@ -181,6 +269,8 @@ func DefaultConfig() Config {
 		DefaultTimeout:  30 * time.Second,
 		QueryTimeout:    10 * time.Second,
 		WriteTimeout:    30 * time.Second,
+		SlowThreshold:   DefaultSlowThreshold,
+		Location:        time.UTC,
 		Naming: NamingConfig{
 			TableNamer:     SnakeCasePlural,
 			ColumnNamer:    SnakeCase,
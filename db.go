@@ -3,8 +3,13 @@ package goorm
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -24,6 +29,10 @@ type DB struct {
 	// sqlDB 是底层数据库连接。
 	sqlDB *sql.DB
 
+	// replicas are the read replicas used by ConnectWithReplicas, or nil.
+	// replicas 是 ConnectWithReplicas 使用的只读副本，可为 nil。
+	replicas *replicaPool
+
 	// dialect is the database-specific dialect.
 	// dialect 是数据库特定的方言。
 	dialect Dialect
@@ -47,6 +56,42 @@ type DB struct {
 	// cancelFunc is the cancel function for the default context.
 	// cancelFunc 是默认上下文的取消函数。
 	cancelFunc context.CancelFunc
+
+	// metrics collects query metrics when enabled via EnableMetrics.
+	// metrics 在通过 EnableMetrics 启用后收集查询指标。
+	metrics *MetricsCollector
+
+	// logger is used for connection errors, migrations, slow queries and audit logs.
+	// logger 用于记录连接错误、迁移、慢查询和审计日志。
+	logger Logger
+
+	// queryLogger logs slow and failed queries through logger.
+	// queryLogger 通过 logger 记录慢查询和失败的查询。
+	queryLogger *QueryLogger
+
+	// auditSink receives audit entries; nil means audits go to logger.
+	// auditSink 接收审计条目；为 nil 时审计写入 logger。
+	auditSink AuditSink
+
+	// sqlFuncs holds SQL functions registered with RegisterSQLFunc.
+	// sqlFuncs 保存通过 RegisterSQLFunc 注册的 SQL 函数。
+	sqlFuncs *sqlFuncRegistry
+
+	// stmts caches prepared statements when Config.PrepareStatements is on; nil otherwise.
+	// stmts 在启用 Config.PrepareStatements 时缓存预处理语句，否则为 nil。
+	stmts *stmtCache
+
+	// templates caches SQL templates when Config.CacheSQLTemplates is on; nil otherwise.
+	// templates 在启用 Config.CacheSQLTemplates 时缓存 SQL 模板，否则为 nil。
+	templates *templateCache
+
+	// middleware wraps ExecuteQuery with the middleware added by Use.
+	// middleware 用通过 Use 添加的中间件包装 ExecuteQuery。
+	middleware *middlewareChain
+
+	// inflight tracks running queries for Shutdown.
+	// inflight 为 Shutdown 跟踪正在运行的查询。
+	inflight *inflight
 }
 
 // Connect creates a new database connection with the given DSN.
@@ -67,9 +112,69 @@ func Connect(dsn string) (*DB, error) {
 // ConnectWithConfig creates a new database connection with custom configuration.
 // ConnectWithConfig 使用自定义配置创建新的数据库连接。
 func ConnectWithConfig(dsn string, config Config) (*DB, error) {
+	logger := config.Logger
+	if logger == nil {
+		logger = newDefaultLogger(config)
+	}
+
+	sqlDB, dialect, err := openPool(dsn, &config, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newDB(sqlDB, dialect, config, logger), nil
+}
+
+// ConnectWithReplicas connects to a primary and its read replicas. Finds, counts
+// and aggregates are spread round-robin over the replicas; writes, transactions,
+// locking reads and queries with Primary set use the primary. Every DSN must use
+// the same database type.
+//
+// ConnectWithReplicas 连接主库及其只读副本。查找、计数和聚合以轮询方式分发到各副本；
+// 写操作、事务、加锁读取以及设置了 Primary 的查询使用主库。所有 DSN 必须使用相同的数据库类型。
+//
+// Example / 示例:
+//
+//	db, err := goorm.ConnectWithReplicas(
+//	    "postgres://app@primary/app",
+//	    []string{"postgres://app@replica-1/app", "postgres://app@replica-2/app"},
+//	    goorm.DefaultConfig(),
+//	)
+func ConnectWithReplicas(primaryDSN string, replicaDSNs []string, config Config) (*DB, error) {
+	db, err := ConnectWithConfig(primaryDSN, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(replicaDSNs) == 0 {
+		return db, nil
+	}
+
+	replicas := &replicaPool{}
+	for _, dsn := range replicaDSNs {
+		replicaConfig := config
+		sqlDB, dialect, err := openPool(dsn, &replicaConfig, db.Logger())
+		if err == nil && dialect.Name() != db.dialect.Name() {
+			sqlDB.Close()
+			err = fmt.Errorf("replica dialect %s does not match primary dialect %s", dialect.Name(), db.dialect.Name())
+		}
+		if err != nil {
+			replicas.close()
+			db.Close()
+			return nil, fmt.Errorf("replica %d: %w", len(replicas.dbs)+1, err)
+		}
+		replicas.dbs = append(replicas.dbs, sqlDB)
+	}
+	db.replicas = replicas
+	return db, nil
+}
+
+// openPool opens and pings the connection pool for dsn, recording the driver
+// and cleaned DSN in config.
+//
+// openPool 打开并 ping dsn 对应的连接池，并将驱动和清理后的 DSN 记录到 config 中。
+func openPool(dsn string, config *Config, logger Logger) (*sql.DB, Dialect, error) {
 	driver, cleanDSN, err := parseDSN(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DSN: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse DSN: %w", err)
 	}
 
 	config.Driver = driver
@@ -77,7 +182,7 @@ func ConnectWithConfig(dsn string, config Config) (*DB, error) {
 
 	dialect, err := GetDialect(driver)
 	if err != nil {
-		return nil, fmt.Errorf("unsupported database driver %q: %w", driver, err)
+		return nil, nil, fmt.Errorf("unsupported database driver %q: %w", driver, err)
 	}
 
 	// For SQLite, try multiple driver names for compatibility
@@ -119,15 +224,20 @@ func ConnectWithConfig(dsn string, config Config) (*DB, error) {
 		}
 
 		if sqlDB == nil {
+			logger.Error("failed to open SQLite database", "error", lastErr)
 			if lastErr != nil {
-				return nil, fmt.Errorf("failed to open SQLite database: %w. Make sure you import a SQLite driver: 'modernc.org/sqlite' (pure Go) or 'github.com/mattn/go-sqlite3' (requires CGO)", lastErr)
+				return nil, nil, fmt.Errorf("failed to open SQLite database: %w. Make sure you import a SQLite driver: 'modernc.org/sqlite' (pure Go) or 'github.com/mattn/go-sqlite3' (requires CGO)", lastErr)
 			}
-			return nil, fmt.Errorf("no SQLite driver available. Import 'modernc.org/sqlite' or 'github.com/mattn/go-sqlite3'")
+			return nil, nil, fmt.Errorf("no SQLite driver available. Import 'modernc.org/sqlite' or 'github.com/mattn/go-sqlite3'")
 		}
 	} else {
+		if driver == "postgres" {
+			driverName = postgresDriverName(config.PostgresDriver)
+		}
 		sqlDB, err = sql.Open(driverName, cleanDSN)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open database: %w", err)
+			logger.Error("failed to open database", "driver", driverName, "error", err)
+			return nil, nil, fmt.Errorf("failed to open database: %w", err)
 		}
 	}
 
@@ -145,21 +255,70 @@ func ConnectWithConfig(dsn string, config Config) (*DB, error) {
 		defer cancel()
 
 		if err := sqlDB.PingContext(ctx); err != nil {
+			logger.Error("failed to ping database", "driver", driverName, "error", err)
 			sqlDB.Close()
-			return nil, fmt.Errorf("failed to ping database: %w", err)
+			return nil, nil, fmt.Errorf("failed to ping database: %w", err)
 		}
 	}
 
+	return sqlDB, dialect, nil
+}
+
+// Open wraps an existing connection pool, such as one opened through pgbouncer, a
+// test container or an instrumented driver. dialectName selects the SQL dialect
+// ("postgres", "mysql" or "sqlite"). The pool settings of db are left as they are,
+// and DB.Close closes db.
+//
+// Open 包装已有的连接池，例如通过 pgbouncer、测试容器或带埋点的驱动打开的连接池。
+// dialectName 选择 SQL 方言（"postgres"、"mysql" 或 "sqlite"）。db 的连接池设置保持不变，
+// DB.Close 会关闭 db。
+//
+// Example / 示例:
+//
+//	sqlDB, _ := sql.Open("pgx", dsn)
+//	db, err := goorm.Open(sqlDB, "postgres", goorm.DefaultConfig())
+func Open(db *sql.DB, dialectName string, config Config) (*DB, error) {
+	if db == nil {
+		return nil, fmt.Errorf("sql.DB is nil")
+	}
+
+	dialect, err := GetDialect(strings.ToLower(dialectName))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported dialect %q: %w", dialectName, err)
+	}
+	config.Driver = dialect.Name()
+
+	logger := config.Logger
+	if logger == nil {
+		logger = newDefaultLogger(config)
+	}
+	return newDB(db, dialect, config, logger), nil
+}
+
+// newDB creates a DB on an open connection pool and registers the built-in hooks.
+// newDB 在已打开的连接池上创建 DB 并注册内置钩子。
+func newDB(sqlDB *sql.DB, dialect Dialect, config Config, logger Logger) *DB {
 	dbCtx, dbCancel := context.WithCancel(context.Background())
 
 	db := &DB{
-		config:     config,
-		sqlDB:      sqlDB,
-		dialect:    dialect,
-		registry:   NewRegistry(),
-		hooks:      NewHookManager(),
-		ctx:        dbCtx,
-		cancelFunc: dbCancel,
+		config:      config,
+		sqlDB:       sqlDB,
+		dialect:     dialect,
+		registry:    NewRegistry(),
+		hooks:       NewHookManager(),
+		ctx:         dbCtx,
+		cancelFunc:  dbCancel,
+		logger:      logger,
+		queryLogger: newQueryLogger(logger, config),
+		sqlFuncs:    newSQLFuncRegistry(),
+		middleware:  &middlewareChain{},
+		inflight:    &inflight{},
+	}
+	if config.PrepareStatements {
+		db.stmts = newStmtCache(config.StatementCacheSize)
+	}
+	if config.CacheSQLTemplates {
+		db.templates = newTemplateCache(config.SQLTemplateCacheSize)
 	}
 
 	// Register built-in hooks
@@ -167,12 +326,46 @@ func ConnectWithConfig(dsn string, config Config) (*DB, error) {
 	db.hooks.RegisterGlobal(HookBeforeCreate, TimestampHook(config.Naming))
 	db.hooks.RegisterGlobal(HookBeforeUpdate, TimestampHook(config.Naming))
 
-	return db, nil
+	// Audit runs last so it records the data other hooks produced
+	// 审计最后运行，以便记录其他钩子处理后的数据
+	if config.Security.AuditEnabled {
+		db.hooks.RegisterGlobalWithPriority(HookBeforeCreate, auditHookPriority, dbAuditHook)
+		db.hooks.RegisterGlobalWithPriority(HookAfterUpdate, auditHookPriority, dbAuditHook)
+		db.hooks.RegisterGlobalWithPriority(HookAfterDelete, auditHookPriority, dbAuditHook)
+	}
+
+	return db
+}
+
+// postgresDriverName returns the database/sql driver for Postgres: configured if set,
+// otherwise lib/pq's "postgres" or, when only pgx is registered, "pgx".
+// Placeholders and RETURNING come from the dialect, so they do not depend on the driver.
+//
+// postgresDriverName 返回 Postgres 使用的 database/sql 驱动：若已配置则使用配置值，
+// 否则使用 lib/pq 的 "postgres"，仅注册了 pgx 时使用 "pgx"。占位符和 RETURNING 由方言决定，与驱动无关。
+func postgresDriverName(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	registered := sql.Drivers()
+	if !slices.Contains(registered, "postgres") && slices.Contains(registered, "pgx") {
+		return "pgx"
+	}
+	return "postgres"
 }
 
 // parseDSN parses a DSN string and extracts the driver and clean DSN.
 // parseDSN 解析 DSN 字符串并提取驱动程序和干净的 DSN。
 func parseDSN(dsn string) (driver, cleanDSN string, err error) {
+	// SQLite paths such as :memory: are not valid URL hosts, so they are split by hand
+	// 诸如 :memory: 的 SQLite 路径不是合法的 URL 主机，因此手动拆分
+	if scheme, rest, ok := strings.Cut(dsn, "://"); ok {
+		if scheme = strings.ToLower(scheme); scheme == "sqlite" || scheme == "sqlite3" {
+			cleanDSN, err = sqliteDSN(rest)
+			return "sqlite3", cleanDSN, err
+		}
+	}
+
 	u, err := url.Parse(dsn)
 	if err != nil {
 		return "", "", err
@@ -191,22 +384,7 @@ func parseDSN(dsn string) (driver, cleanDSN string, err error) {
 		// PostgreSQL accepts the URL format directly
 		cleanDSN = dsn
 	case "mysql":
-		// MySQL requires: user:pass@tcp(host:port)/dbname
-		password, _ := u.User.Password()
-		host := u.Host
-		if !strings.Contains(host, ":") {
-			host += ":3306"
-		}
-		cleanDSN = fmt.Sprintf("%s:%s@tcp(%s)%s?%s",
-			u.User.Username(),
-			password,
-			host,
-			u.Path,
-			u.RawQuery,
-		)
-	case "sqlite", "sqlite3":
-		driver = "sqlite3"
-		cleanDSN = strings.TrimPrefix(dsn, u.Scheme+"://")
+		cleanDSN = mysqlDSN(u)
 	default:
 		return "", "", fmt.Errorf("unsupported driver: %s", driver)
 	}
@@ -214,11 +392,77 @@ func parseDSN(dsn string) (driver, cleanDSN string, err error) {
 	return driver, cleanDSN, nil
 }
 
+// sqliteDSN converts the part of a sqlite:// URL after the scheme to a database path.
+// An empty path or :memory: is an in-memory database. Query parameters are kept
+// by switching to the file: URI form both SQLite drivers understand.
+//
+// sqliteDSN 将 sqlite:// URL 中协议之后的部分转换为数据库路径。
+// 空路径或 :memory: 表示内存数据库。通过改用两个 SQLite 驱动都支持的 file: URI 形式来保留查询参数。
+func sqliteDSN(rest string) (string, error) {
+	path, query, _ := strings.Cut(rest, "?")
+	path, err := url.PathUnescape(path)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		path = ":memory:"
+	}
+
+	if query == "" {
+		return path, nil
+	}
+	return "file:" + path + "?" + query, nil
+}
+
+// mysqlDSN converts a mysql:// URL to the driver format user:pass@tcp(host:port)/dbname?params.
+// The user, password and database name are URL-decoded, and the port defaults to 3306.
+//
+// mysqlDSN 将 mysql:// URL 转换为驱动格式 user:pass@tcp(host:port)/dbname?params。
+// 用户名、密码和数据库名会进行 URL 解码，端口默认为 3306。
+func mysqlDSN(u *url.URL) string {
+	var sb strings.Builder
+
+	if u.User != nil {
+		sb.WriteString(u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			sb.WriteString(":")
+			sb.WriteString(password)
+		}
+		sb.WriteString("@")
+	}
+
+	// Hostname strips IPv6 brackets; JoinHostPort adds them back
+	// Hostname 会去掉 IPv6 的方括号；JoinHostPort 会重新加上
+	port := u.Port()
+	if port == "" {
+		port = "3306"
+	}
+	sb.WriteString("tcp(")
+	sb.WriteString(net.JoinHostPort(u.Hostname(), port))
+	sb.WriteString(")/")
+	sb.WriteString(strings.TrimPrefix(u.Path, "/"))
+
+	if u.RawQuery != "" {
+		sb.WriteString("?")
+		sb.WriteString(u.RawQuery)
+	}
+	return sb.String()
+}
+
 // Close closes the database connection and releases resources.
 // Close 关闭数据库连接并释放资源。
 func (db *DB) Close() error {
 	db.cancelFunc()
-	return db.sqlDB.Close()
+	err := db.stmts.close()
+	if cerr := db.sqlDB.Close(); err == nil {
+		err = cerr
+	}
+	if db.replicas != nil {
+		if rerr := db.replicas.close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
 }
 
 // Execute executes a JQL query string and returns the result.
@@ -249,46 +493,167 @@ func (db *DB) Query(jql string) *Result {
 func (db *DB) ExecuteContext(ctx context.Context, jql string) *Result {
 	query, err := ParseQuery(jql)
 	if err != nil {
+		return parseErrorResult(err)
+	}
+
+	return db.ExecuteQuery(ctx, query)
+}
+
+// parseErrorResult converts a ParseQuery error into a PARSE_ERROR result,
+// suggesting the intended key for unknown fields.
+//
+// parseErrorResult 将 ParseQuery 错误转换为 PARSE_ERROR 结果，并为未知字段建议正确的键。
+func parseErrorResult(err error) *Result {
+	r := &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:    "PARSE_ERROR",
+			Message: err.Error(),
+		},
+	}
+
+	var unknown *UnknownFieldError
+	if errors.As(err, &unknown) {
+		r.Error.Details = map[string]any{"field": unknown.Field}
+		if s := unknown.Suggestion(); s != "" {
+			r.Error.Suggestion = fmt.Sprintf("Use %q instead of %q", s, unknown.Field)
+		}
+	}
+	return r
+}
+
+// validationResult converts a Validate error into a failed result.
+// validationResult 将 Validate 返回的错误转换为失败结果。
+func validationResult(err error) *Result {
+	var identifier *IdentifierError
+	if errors.As(err, &identifier) {
 		return &Result{
 			Success: false,
 			Error: &ResultError{
-				Code:    "PARSE_ERROR",
-				Message: err.Error(),
+				Code:       "INVALID_IDENTIFIER",
+				Message:    err.Error(),
+				Suggestion: "Use the table and column names shown by describe",
+				Details:    map[string]any{"identifier": identifier.Identifier, "path": identifier.Path},
 			},
 		}
 	}
-
-	return db.ExecuteQuery(ctx, query)
-}
-
-// ExecuteQuery executes a parsed Query struct.
-// ExecuteQuery 执行解析后的 Query 结构体。
-func (db *DB) ExecuteQuery(ctx context.Context, query *Query) *Result {
-	// Validate the query
-	// 验证查询
-	if err := query.Validate(); err != nil {
+	var composite *CompositeKeyError
+	if errors.As(err, &composite) {
 		return &Result{
 			Success: false,
 			Error: &ResultError{
-				Code:    "VALIDATION_ERROR",
-				Message: err.Error(),
+				Code:       "COMPOSITE_PRIMARY_KEY",
+				Message:    err.Error(),
+				Suggestion: "Address the rows with where conditions on every key column instead",
+				Details:    map[string]any{"table": composite.Table, "columns": composite.Columns},
+			},
+		}
+	}
+	var groupBy *GroupByError
+	if errors.As(err, &groupBy) {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:       "INVALID_GROUP_BY",
+				Message:    err.Error(),
+				Suggestion: fmt.Sprintf("Add %q to group_by or wrap it in an aggregate function", groupBy.Column),
+				Details:    map[string]any{"column": groupBy.Column},
 			},
 		}
 	}
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:    "VALIDATION_ERROR",
+			Message: err.Error(),
+		},
+	}
+}
 
-	// Apply timeout if specified
-	// 如果指定了超时则应用
-	if query.Timeout != "" {
-		timeout, err := time.ParseDuration(query.Timeout)
-		if err == nil {
-			var cancel context.CancelFunc
-			ctx, cancel = context.WithTimeout(ctx, timeout)
-			defer cancel()
+// ExecuteQuery executes a parsed Query struct through the middleware added by Use.
+// ExecuteQuery 通过 Use 添加的中间件执行解析后的 Query 结构体。
+func (db *DB) ExecuteQuery(ctx context.Context, query *Query) *Result {
+	if !db.inflight.enter() {
+		return shuttingDownResult()
+	}
+	defer db.inflight.leave()
+
+	db.mu.RLock()
+	chain := db.middleware
+	db.mu.RUnlock()
+	return chain.then(db.executeQuery)(ctx, query)
+}
+
+// executeQuery validates and executes query; it is the innermost QueryHandler.
+// executeQuery 验证并执行查询；它是最内层的 QueryHandler。
+func (db *DB) executeQuery(ctx context.Context, query *Query) *Result {
+	// Hooks rewrite the query they run on (conditions, soft delete), so they get a
+	// copy and the caller's Query can be run again unchanged
+	// 钩子会改写其所运行的查询（条件、软删除），因此交给它们副本，调用方的 Query 可原样再次执行
+	run := *query
+	run.Data = maps.Clone(query.Data)
+	if query.DataBatch != nil {
+		run.DataBatch = make([]map[string]any, len(query.DataBatch))
+		for i, row := range query.DataBatch {
+			run.DataBatch[i] = maps.Clone(row)
+		}
+	}
+	query = &run
+	auditIDs := db.auditIDs(query)
+	if auditIDs {
+		query.ReturnIDs = true
+		ctx = withAuditIDs(ctx)
+	}
+
+	// Validate the query
+	// 验证查询
+	if err := db.validate(query); err != nil {
+		return validationResult(err)
+	}
+
+	// Reject writes in read-only mode
+	// 只读模式下拒绝写操作
+	if db.config.ReadOnly && query.IsWrite() {
+		return readOnlyResult(query.Action)
+	}
+
+	// Convert condition values to their column types
+	// 将条件值转换为对应列的类型
+	if db.config.CoerceTypes {
+		if r := db.coerceQuery(query); r != nil {
+			return r
 		}
 	}
 
-	// Execute based on action
-	// 根据操作执行
+	// Apply the query timeout, or the configured one for its action
+	// 应用查询超时，或按操作应用配置的超时
+	if timeout := db.timeoutFor(query); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Hooks may rewrite the action (e.g. soft delete), so record the original one
+	// 钩子可能改写操作（例如软删除），因此记录原始操作
+	action := query.Action
+	startTime := time.Now()
+	result := db.dispatch(ctx, query)
+	if auditIDs {
+		result.IDs, result.AffectedKeys = nil, nil
+	}
+
+	// Record metrics if enabled
+	// 如果启用则记录指标
+	if metrics := db.Metrics(); metrics != nil {
+		metrics.RecordQuery(action, time.Since(startTime), result.Err())
+	}
+
+	return result
+}
+
+// dispatch executes the query based on its action.
+// dispatch 根据操作执行查询。
+func (db *DB) dispatch(ctx context.Context, query *Query) *Result {
 	switch query.Action {
 	case ActionFind:
 		return db.executeFind(ctx, query)
@@ -304,6 +669,8 @@ func (db *DB) ExecuteQuery(ctx context.Context, query *Query) *Result {
 		return db.executeCount(ctx, query)
 	case ActionAggregate:
 		return db.executeAggregate(ctx, query)
+	case ActionExists:
+		return db.executeExists(ctx, query)
 	case ActionTransaction:
 		return db.executeTransaction(ctx, query)
 	case ActionExplain:
@@ -325,6 +692,273 @@ func (db *DB) ExecuteQuery(ctx context.Context, query *Query) *Result {
 	}
 }
 
+// FindOne finds a single record matching query, which is run with LIMIT 1.
+// It returns ErrNotFound when no record matches. Select, relations and other
+// find options of query are kept; query itself is not modified.
+//
+// FindOne 查找匹配查询的单条记录，查询以 LIMIT 1 执行。
+// 没有匹配记录时返回 ErrNotFound。查询的 Select、关联等选项会保留，且不会修改 query 本身。
+//
+// Example / 示例:
+//
+//	user, err := db.FindOne(ctx, &goorm.Query{
+//	    Table: "users",
+//	    Where: []goorm.Condition{{Field: "email", Op: goorm.OpEqual, Value: email}},
+//	})
+//	if errors.Is(err, goorm.ErrNotFound) { ... }
+func (db *DB) FindOne(ctx context.Context, query *Query) (map[string]any, error) {
+	q := *query
+	q.Action = ActionFind
+	q.Limit = 1
+
+	result := db.ExecuteQuery(ctx, &q)
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	if len(result.Data) == 0 {
+		return nil, ErrNotFound
+	}
+	return result.Data[0], nil
+}
+
+// Exists reports whether any record of query.Table matches query.Where,
+// without counting or loading rows. It runs as an exists query through
+// ExecuteQuery, so validation, before-find hooks and middleware apply; query
+// itself is not modified.
+//
+// Exists 判断 query.Table 中是否有记录匹配 query.Where，不会计数或加载行。它作为 exists 查询通过
+// ExecuteQuery 执行，因此验证、before-find 钩子和中间件都会生效；不会修改 query 本身。
+//
+// Example / 示例:
+//
+//	taken, err := db.Exists(ctx, &goorm.Query{
+//	    Table: "users",
+//	    Where: []goorm.Condition{{Field: "email", Op: goorm.OpEqual, Value: email}},
+//	})
+func (db *DB) Exists(ctx context.Context, query *Query) (bool, error) {
+	q := *query
+	q.Action = ActionExists
+
+	result := db.ExecuteQuery(ctx, &q)
+	if err := result.Err(); err != nil {
+		return false, err
+	}
+	return result.Count > 0, nil
+}
+
+// AggregateScalar runs an aggregate query without group_by and returns its single
+// row. Numeric results some drivers return as text, such as SUM over a PostgreSQL
+// numeric column, are converted to int64 or float64: those of count, sum and avg,
+// and of min and max over a numeric column of a registered model. Other values,
+// such as the MAX of a text column holding "007", are returned as scanned. Grouped
+// queries return an error; run them with ExecuteQuery to get one row per group.
+//
+// AggregateScalar 执行不带 group_by 的聚合查询并返回其唯一一行。部分驱动以文本返回的数值结果
+// （例如 PostgreSQL numeric 列上的 SUM）会被转换为 int64 或 float64：包括 count、sum 和 avg 的结果，
+// 以及已注册模型数值列上 min 和 max 的结果。其他值（例如保存 "007" 的文本列的 MAX）按扫描结果原样返回。
+// 分组查询返回错误；请使用 ExecuteQuery 执行以获得每组一行。
+//
+// Example / 示例:
+//
+//	totals, err := db.AggregateScalar(ctx, &goorm.Query{
+//	    Table:  "orders",
+//	    Select: []any{map[string]any{"fn": "sum", "field": "amount", "as": "total"}},
+//	})
+//	total := totals["total"] // float64 or int64
+func (db *DB) AggregateScalar(ctx context.Context, query *Query) (map[string]any, error) {
+	if len(query.GroupBy) > 0 {
+		return nil, fmt.Errorf("goorm: AggregateScalar does not support group_by; use ExecuteQuery for grouped rows")
+	}
+	q := *query
+	q.Action = ActionAggregate
+
+	result := db.ExecuteQuery(ctx, &q)
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	if len(result.Data) == 0 {
+		return nil, ErrNotFound
+	}
+
+	// The row may be shared with the query cache and other callers, so convert a copy
+	// 该行可能与查询缓存和其他调用方共享，因此转换其副本
+	row := maps.Clone(result.Data[0])
+	for _, col := range db.numericAggregates(&q) {
+		if value, ok := row[col]; ok {
+			row[col] = numericValue(value)
+		}
+	}
+	return row, nil
+}
+
+// numericAggregates returns the aliases of the select items of query whose result
+// is a number: count, sum and avg, and min and max over a numeric column.
+//
+// numericAggregates 返回 query 中结果为数值的 select 项的别名：count、sum 和 avg，以及数值列上的 min 和 max。
+func (db *DB) numericAggregates(query *Query) []string {
+	var cols []string
+	for _, sel := range query.Select {
+		item, ok := sel.(map[string]any)
+		if !ok {
+			continue
+		}
+		fn, _ := item["fn"].(string)
+		field, _ := item["field"].(string)
+		as, _ := item["as"].(string)
+		if as == "" {
+			continue
+		}
+		switch strings.ToLower(fn) {
+		case "count", "sum", "avg":
+			cols = append(cols, as)
+		case "min", "max":
+			goType := db.columnGoType(query.Table, field)
+			if strings.HasPrefix(goType, "int") || strings.HasPrefix(goType, "uint") || strings.HasPrefix(goType, "float") {
+				cols = append(cols, as)
+			}
+		}
+	}
+	return cols
+}
+
+// numericValue converts a decimal string or []byte, as drivers return numeric
+// aggregates, to int64, or float64 if it has a fraction or exponent; other values
+// are returned unchanged.
+//
+// numericValue 将驱动返回数值聚合结果所用的十进制字符串或 []byte 转换为 int64，带小数或指数时转换为 float64；
+// 其他值原样返回。
+func numericValue(value any) any {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return value
+	}
+	if s == "" || strings.Trim(s, "+-.0123456789eE") != "" {
+		return value
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// FindByIDs finds the records of table whose primary key is in ids.
+// The primary key column comes from the registered model, not an assumed "id";
+// tables with a composite key fail with COMPOSITE_PRIMARY_KEY.
+//
+// FindByIDs 查找表中主键在 ids 中的记录。
+// 主键列来自已注册的模型，而不是假定为 "id"；复合主键的表以 COMPOSITE_PRIMARY_KEY 失败。
+//
+// Example / 示例:
+//
+//	result := db.FindByIDs(ctx, "users", []any{1, 2, 3})
+func (db *DB) FindByIDs(ctx context.Context, table string, ids []any) *Result {
+	if len(ids) == 0 {
+		return &Result{Success: true, Data: []map[string]any{}}
+	}
+	cond, err := db.primaryKeyIn(table, ids)
+	if err != nil {
+		return validationResult(err)
+	}
+	return db.ExecuteQuery(ctx, &Query{
+		Table:  table,
+		Action: ActionFind,
+		Where:  []Condition{cond},
+	})
+}
+
+// DeleteByIDs deletes the records of table whose primary key is in ids.
+// It goes through the normal delete path, so soft delete hooks and the
+// destructive-operation confirmation apply. Affected reports the deleted rows.
+// Like FindByIDs, it rejects tables with a composite key.
+//
+// DeleteByIDs 删除表中主键在 ids 中的记录。
+// 它走普通的删除流程，因此软删除钩子和破坏性操作确认同样适用。Affected 返回删除的行数。
+// 与 FindByIDs 一样，它拒绝复合主键的表。
+//
+// Example / 示例:
+//
+//	result := db.DeleteByIDs(ctx, "users", []any{1, 2, 3})
+func (db *DB) DeleteByIDs(ctx context.Context, table string, ids []any) *Result {
+	if len(ids) == 0 {
+		return &Result{Success: true}
+	}
+	cond, err := db.primaryKeyIn(table, ids)
+	if err != nil {
+		return validationResult(err)
+	}
+	return db.ExecuteQuery(ctx, &Query{
+		Table:  table,
+		Action: ActionDelete,
+		Where:  []Condition{cond},
+	})
+}
+
+// primaryKeyIn returns a condition matching the primary key of table against ids.
+// primaryKeyIn 返回将表主键与 ids 匹配的条件。
+func (db *DB) primaryKeyIn(table string, ids []any) (Condition, error) {
+	pk, err := db.primaryKeyColumn(table)
+	if err != nil {
+		return Condition{}, err
+	}
+	return Condition{
+		Field: pk,
+		Op:    OpIn,
+		Value: ids,
+	}, nil
+}
+
+// timeoutFor returns the timeout for query: its own Timeout if set, otherwise
+// Config.WriteTimeout for writes or Config.QueryTimeout for reads, falling
+// back to Config.DefaultTimeout. Zero means no timeout.
+//
+// timeoutFor 返回查询的超时：优先使用查询自身的 Timeout，否则写操作使用 Config.WriteTimeout，
+// 读操作使用 Config.QueryTimeout，都未设置时使用 Config.DefaultTimeout。零表示不设超时。
+func (db *DB) timeoutFor(query *Query) time.Duration {
+	if query.Timeout != "" {
+		if timeout, err := time.ParseDuration(query.Timeout); err == nil {
+			return timeout
+		}
+	}
+
+	// Configure may change the timeouts while queries run
+	// 查询运行期间 Configure 可能修改超时
+	db.mu.RLock()
+	timeout := db.config.QueryTimeout
+	if query.IsWrite() {
+		timeout = db.config.WriteTimeout
+	}
+	if timeout <= 0 {
+		timeout = db.config.DefaultTimeout
+	}
+	db.mu.RUnlock()
+	return timeout
+}
+
+// naming returns the naming configuration, which Configure may change.
+// naming 返回命名配置，Configure 可能修改它。
+func (db *DB) naming() NamingConfig {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.config.Naming
+}
+
+// withTimeout is like context.WithTimeout, but never extends a deadline ctx already has.
+// withTimeout 类似 context.WithTimeout，但不会延长 ctx 已有的截止时间。
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // NL executes a natural language query.
 // The query is converted to JQL internally and then executed.
 //
@@ -365,22 +999,55 @@ func (db *DB) NLContext(ctx context.Context, query string) *Result {
 
 // Register registers one or more models with the database.
 // Models should embed goorm.Model and define their fields.
+// Fields with a `validate` tag are checked on create and update.
+// Two models for the same table fail with ErrDuplicateTable; pass
+// ReplaceModel among the arguments to let the later model replace the earlier one.
 //
 // Register 向数据库注册一个或多个模型。
 // 模型应嵌入 goorm.Model 并定义其字段。
+// 同一张表的两个模型会以 ErrDuplicateTable 失败；在参数中传入 ReplaceModel 可让后注册的模型替换先前的模型。
 //
 // Example / 示例:
 //
 //	db.Register(&User{}, &Order{})
+//	db.Register(&LegacyUser{}, goorm.ReplaceModel)
 func (db *DB) Register(models ...any) error {
+	replace := false
+	for _, model := range models {
+		if opt, ok := model.(RegisterOption); ok && opt == ReplaceModel {
+			replace = true
+		}
+	}
+
 	for _, model := range models {
-		if err := db.registry.Register(model, db.config.Naming); err != nil {
+		if _, ok := model.(RegisterOption); ok {
+			continue
+		}
+		meta, err := db.registry.register(model, db.naming(), replace)
+		if err != nil {
 			return err
 		}
+		db.registerValidation(meta)
 	}
 	return nil
 }
 
+// registerValidation registers a validation hook for models with `validate` tags.
+// registerValidation 为带有 `validate` 标签的模型注册验证钩子。
+func (db *DB) registerValidation(meta *ModelMeta) {
+	if db.hooks == nil {
+		return
+	}
+	for _, field := range meta.Fields {
+		if len(field.Validations) > 0 {
+			hook := ModelValidationHook(meta)
+			db.hooks.Register(meta.TableName, HookBeforeCreate, hook)
+			db.hooks.Register(meta.TableName, HookBeforeUpdate, hook)
+			return
+		}
+	}
+}
+
 // AutoSync synchronizes the database schema with registered models.
 // In aggressive mode (default), it will delete columns/tables not in models.
 //
@@ -393,29 +1060,53 @@ func (db *DB) AutoSync() error {
 // AutoSyncContext synchronizes the database schema with the given context.
 // AutoSyncContext 使用给定的上下文同步数据库模式。
 func (db *DB) AutoSyncContext(ctx context.Context) error {
+	if db.config.ReadOnly {
+		return fmt.Errorf("cannot sync schema: database is in read-only mode")
+	}
 	migrator := NewMigrator(db)
 	return migrator.AutoSync(ctx)
 }
 
-// Configure updates the database configuration.
-// Configure 更新数据库配置。
+// Configure updates the database configuration. Pool settings apply to the
+// primary and to every read replica.
+//
+// Configure 更新数据库配置。连接池设置同时应用于主库和所有只读副本。
 func (db *DB) Configure(config Config) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	pools := []*sql.DB{db.sqlDB}
+	if db.replicas != nil {
+		pools = append(pools, db.replicas.dbs...)
+	}
+
 	// Update pool settings if they changed
 	// 如果连接池设置已更改则更新
+	for _, pool := range pools {
+		if config.MaxOpenConns > 0 {
+			pool.SetMaxOpenConns(config.MaxOpenConns)
+		}
+		if config.MaxIdleConns > 0 {
+			pool.SetMaxIdleConns(config.MaxIdleConns)
+		}
+		if config.ConnMaxLifetime > 0 {
+			pool.SetConnMaxLifetime(config.ConnMaxLifetime)
+		}
+		if config.ConnMaxIdleTime > 0 {
+			pool.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+		}
+	}
 	if config.MaxOpenConns > 0 {
 		db.config.MaxOpenConns = config.MaxOpenConns
-		db.sqlDB.SetMaxOpenConns(config.MaxOpenConns)
 	}
 	if config.MaxIdleConns > 0 {
 		db.config.MaxIdleConns = config.MaxIdleConns
-		db.sqlDB.SetMaxIdleConns(config.MaxIdleConns)
 	}
 	if config.ConnMaxLifetime > 0 {
 		db.config.ConnMaxLifetime = config.ConnMaxLifetime
-		db.sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		db.config.ConnMaxIdleTime = config.ConnMaxIdleTime
 	}
 
 	// Update other settings
@@ -423,6 +1114,12 @@ func (db *DB) Configure(config Config) {
 	if config.DefaultTimeout > 0 {
 		db.config.DefaultTimeout = config.DefaultTimeout
 	}
+	if config.QueryTimeout > 0 {
+		db.config.QueryTimeout = config.QueryTimeout
+	}
+	if config.WriteTimeout > 0 {
+		db.config.WriteTimeout = config.WriteTimeout
+	}
 	if config.Naming.TableNamer != nil {
 		db.config.Naming = config.Naming
 	}
@@ -431,6 +1128,246 @@ func (db *DB) Configure(config Config) {
 	}
 }
 
+// ReadOnly returns a read-only view of the database.
+// The view shares the connection pool, models, hooks and middleware with db,
+// but rejects every write with a READ_ONLY_MODE error.
+// Closing the view closes the shared connection.
+//
+// ReadOnly 返回数据库的只读视图。
+// 视图与 db 共享连接池、模型、钩子和中间件，但会以 READ_ONLY_MODE 错误拒绝所有写操作。
+// 关闭视图会关闭共享的连接。
+func (db *DB) ReadOnly() *DB {
+	view := db.clone()
+	view.config.ReadOnly = true
+	return view
+}
+
+// IsReadOnly returns true if the database rejects writes.
+// IsReadOnly 如果数据库拒绝写操作则返回 true。
+func (db *DB) IsReadOnly() bool {
+	return db.config.ReadOnly
+}
+
+// clone returns a shallow copy of db sharing all underlying resources.
+// clone 返回 db 的浅拷贝，共享所有底层资源。
+func (db *DB) clone() *DB {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return &DB{
+		config:      db.config,
+		sqlDB:       db.sqlDB,
+		replicas:    db.replicas,
+		dialect:     db.dialect,
+		registry:    db.registry,
+		hooks:       db.hooks,
+		ctx:         db.ctx,
+		cancelFunc:  db.cancelFunc,
+		metrics:     db.metrics,
+		logger:      db.logger,
+		queryLogger: db.queryLogger,
+		auditSink:   db.auditSink,
+		sqlFuncs:    db.sqlFuncs,
+		stmts:       db.stmts,
+		templates:   db.templates,
+		middleware:  db.middleware,
+		inflight:    db.inflight,
+	}
+}
+
+// readOnlyResult returns the error result for a write attempted in read-only mode.
+// readOnlyResult 返回只读模式下尝试写操作的错误结果。
+func readOnlyResult(action Action) *Result {
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:       "READ_ONLY_MODE",
+			Message:    fmt.Sprintf("action %q is not allowed: database is in read-only mode", action),
+			Suggestion: "只读模式下仅允许查询操作 / Only read operations are allowed in read-only mode",
+		},
+	}
+}
+
+// EnableMetrics enables query metrics collection and returns the collector.
+// Calling it again returns the existing collector.
+//
+// EnableMetrics 启用查询指标收集并返回收集器。
+// 再次调用会返回已有的收集器。
+//
+// Example / 示例:
+//
+//	metrics := db.EnableMetrics()
+//	http.Handle("/metrics", metrics.PrometheusHandler())
+func (db *DB) EnableMetrics() *MetricsCollector {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.metrics == nil {
+		db.metrics = NewMetricsCollector()
+		if db.config.SlowThreshold > 0 {
+			db.metrics.SetSlowThreshold(db.config.SlowThreshold)
+		}
+	}
+	return db.metrics
+}
+
+// Metrics returns the metrics collector, or nil if metrics are not enabled.
+// Metrics 返回指标收集器，如果未启用指标则返回 nil。
+func (db *DB) Metrics() *MetricsCollector {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.metrics
+}
+
+// SetLogger sets the logger used by the database, including query logging.
+// SetLogger 设置数据库使用的日志记录器，包括查询日志。
+//
+// Example / 示例:
+//
+//	db.SetLogger(goorm.NewSlogLogger(slog.Default()))
+func (db *DB) SetLogger(logger Logger) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if logger == nil {
+		logger = newDefaultLogger(db.config)
+	}
+	db.config.Logger = logger
+	db.logger = logger
+	db.queryLogger = newQueryLogger(logger, db.config)
+}
+
+// Logger returns the logger used by the database.
+// Logger 返回数据库使用的日志记录器。
+func (db *DB) Logger() Logger {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.logger == nil {
+		return newDefaultLogger(db.config)
+	}
+	return db.logger
+}
+
+// SetAuditSink sets where audit entries are written.
+// A nil sink restores logging audits through the DB logger.
+//
+// SetAuditSink 设置审计条目的写入位置。
+// sink 为 nil 时恢复为通过 DB 日志记录器记录审计。
+func (db *DB) SetAuditSink(sink AuditSink) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.auditSink = sink
+}
+
+// AuditSink returns the audit sink, defaulting to a LoggerAuditSink over the DB logger.
+// AuditSink 返回审计 sink，默认为基于 DB 日志记录器的 LoggerAuditSink。
+func (db *DB) AuditSink() AuditSink {
+	db.mu.RLock()
+	sink := db.auditSink
+	db.mu.RUnlock()
+
+	if sink != nil {
+		return sink
+	}
+	return &LoggerAuditSink{Logger: db.Logger()}
+}
+
+// primaryKeyColumn returns the primary key column of a registered table,
+// falling back to the configured default. A table with a composite key has no
+// single key column, so it returns a *CompositeKeyError.
+//
+// primaryKeyColumn 返回已注册表的主键列，未注册时使用配置的默认值。复合主键的表没有单一主键列，
+// 因此返回 *CompositeKeyError。
+func (db *DB) primaryKeyColumn(table string) (string, error) {
+	if db.registry != nil {
+		if meta, ok := db.registry.Get(table); ok && len(meta.PrimaryKeys) > 1 {
+			columns := make([]string, len(meta.PrimaryKeys))
+			for i, f := range meta.PrimaryKeys {
+				columns[i] = f.ColumnName
+			}
+			return "", &CompositeKeyError{Table: table, Columns: columns}
+		}
+	}
+	return db.insertKeyColumn(table), nil
+}
+
+// insertKeyColumn returns the column an insert reports as the key of a row: the
+// primary key, or the first column of a composite key.
+//
+// insertKeyColumn 返回插入操作作为行主键报告的列：主键，复合主键时为其第一列。
+func (db *DB) insertKeyColumn(table string) string {
+	if db.registry != nil {
+		if meta, ok := db.registry.Get(table); ok && len(meta.PrimaryKeys) > 0 {
+			return meta.PrimaryKeys[0].ColumnName
+		}
+	}
+	if pk := db.naming().PrimaryKey; pk != "" {
+		return pk
+	}
+	return "id"
+}
+
+// CompositeKeyError is returned when an operation that addresses rows by a single
+// primary key, such as FindByIDs, BatchSize or ReturnIDs, is used on a table with
+// a composite primary key.
+//
+// CompositeKeyError 在按单一主键定位行的操作（如 FindByIDs、BatchSize 或 ReturnIDs）用于
+// 复合主键的表时返回。
+type CompositeKeyError struct {
+	// Table is the table with the composite key.
+	// Table 是具有复合主键的表。
+	Table string
+
+	// Columns are the columns of the key.
+	// Columns 是主键的各列。
+	Columns []string
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *CompositeKeyError) Error() string {
+	return fmt.Sprintf("table %q has a composite primary key (%s), so its rows cannot be addressed by a single key",
+		e.Table, strings.Join(e.Columns, ", "))
+}
+
+// newDefaultLogger creates the logger used when Config.Logger is nil.
+// newDefaultLogger 创建 Config.Logger 为 nil 时使用的日志记录器。
+func newDefaultLogger(config Config) Logger {
+	logger := NewDefaultLogger()
+	if config.Debug {
+		logger.SetLevel(LogLevelDebug)
+	}
+	return logger
+}
+
+// newQueryLogger creates the query logger writing to logger.
+// Debug mode logs every query; otherwise only slow and failed queries are logged.
+//
+// newQueryLogger 创建写入 logger 的查询日志记录器。
+// 调试模式下记录所有查询，否则仅记录慢查询和失败的查询。
+func newQueryLogger(logger Logger, config Config) *QueryLogger {
+	ql := NewQueryLogger(logger)
+	if config.SlowThreshold > 0 {
+		ql.SetSlowThreshold(config.SlowThreshold)
+	}
+	ql.LogAll(config.Debug)
+	return ql
+}
+
+// logQuery passes an executed statement to the query logger, if any.
+// logQuery 将已执行的语句交给查询日志记录器（如果有）。
+func (db *DB) logQuery(build *BuildResult, startTime time.Time, err error) {
+	db.mu.RLock()
+	ql := db.queryLogger
+	db.mu.RUnlock()
+
+	if ql == nil {
+		return
+	}
+	ql.LogQuery(build.SQL, build.Params, time.Since(startTime), err)
+}
+
 // SqlDB returns the underlying *sql.DB connection.
 // This is useful for advanced operations or integrating with other libraries.
 //
@@ -446,6 +1383,38 @@ func (db *DB) Dialect() Dialect {
 	return db.dialect
 }
 
+// ToSQL returns the SQL and parameters query would run, without executing it.
+// It supports find, create, create_batch, update, delete, count and aggregate.
+// Hooks do not run, so rewrites such as soft delete are not reflected, and a
+// large create_batch may be split into several statements when executed.
+//
+// ToSQL 返回 query 将要执行的 SQL 和参数，但不执行。
+// 支持 find、create、create_batch、update、delete、count 和 aggregate。
+// 不会运行钩子，因此不反映软删除等改写；较大的 create_batch 在执行时可能被拆分为多条语句。
+//
+// Example / 示例:
+//
+//	sql, params, err := db.ToSQL(&goorm.Query{Table: "users", Action: goorm.ActionFind, Limit: 10})
+func (db *DB) ToSQL(query *Query) (string, []any, error) {
+	if err := db.validate(query); err != nil {
+		return "", nil, err
+	}
+
+	switch query.Action {
+	case ActionFind:
+		query = db.checkOrderPriority(db.withDefaultOrder(query))
+	case ActionCreate, ActionCreateBatch, ActionUpdate, ActionDelete, ActionCount, ActionAggregate, ActionExists:
+	default:
+		return "", nil, fmt.Errorf("action %q does not build a SQL statement: ToSQL supports find, create, create_batch, update, delete, count, aggregate and exists", query.Action)
+	}
+
+	build, err := db.newBuilder(db.ctx, query).WithPrimaryKey(db.insertKeyColumn(query.Table)).Build()
+	if err != nil {
+		return "", nil, err
+	}
+	return build.SQL, build.Params, nil
+}
+
 // --- Internal execution methods ---
 // --- 内部执行方法 ---
 
@@ -484,6 +1453,11 @@ func (db *DB) executeAggregate(ctx context.Context, query *Query) *Result {
 	return executor.ExecuteAggregate(ctx, query)
 }
 
+func (db *DB) executeExists(ctx context.Context, query *Query) *Result {
+	executor := NewExecutor(db)
+	return executor.ExecuteExists(ctx, query)
+}
+
 func (db *DB) executeTransaction(ctx context.Context, query *Query) *Result {
 	return db.executeTransactionInternal(ctx, query)
 }
@@ -499,7 +1473,7 @@ func (db *DB) executeExplain(ctx context.Context, query *Query) *Result {
 		}
 	}
 
-	builder := NewSQLBuilder(db.dialect, query.QueryToExplain)
+	builder := db.newBuilder(ctx, query.QueryToExplain)
 	buildResult, err := builder.Build()
 	if err != nil {
 		return &Result{
@@ -511,27 +1485,41 @@ func (db *DB) executeExplain(ctx context.Context, query *Query) *Result {
 		}
 	}
 
-	return &Result{
-		Success: true,
-		Explain: &ExplainResult{
-			SQL:    buildResult.SQL,
-			Params: buildResult.Params,
-		},
+	explain := &ExplainResult{
+		SQL:            buildResult.SQL,
+		Params:         buildResult.Params,
+		IndexSuggested: db.AnalyzeQuery(query.QueryToExplain).IndexSuggested,
 	}
-}
 
-func (db *DB) executeValidate(ctx context.Context, query *Query) *Result {
-	if query.QueryToExplain != nil {
-		if err := query.QueryToExplain.Validate(); err != nil {
+	// Ask the database for its plan when requested
+	// 按需向数据库请求查询计划
+	if query.Plan {
+		if err := db.explainPlan(ctx, buildResult, explain); err != nil {
 			return &Result{
 				Success: false,
 				Error: &ResultError{
-					Code:    "VALIDATION_ERROR",
+					Code:    "EXPLAIN_ERROR",
 					Message: err.Error(),
 				},
 			}
 		}
 	}
+
+	return &Result{
+		Success: true,
+		Explain: explain,
+	}
+}
+
+func (db *DB) executeValidate(ctx context.Context, query *Query) *Result {
+	if query.QueryToExplain != nil {
+		if err := db.validate(query.QueryToExplain); err != nil {
+			return validationResult(err)
+		}
+		if r := db.checkFields(query.QueryToExplain); r != nil {
+			return r
+		}
+	}
 	return &Result{Success: true}
 }
 
@@ -544,6 +1532,9 @@ func (db *DB) executeListTables(ctx context.Context, query *Query) *Result {
 }
 
 func (db *DB) executeDescribe(ctx context.Context, query *Query) *Result {
+	if query.Live {
+		return db.executeDescribeLive(ctx, query)
+	}
 	schema, err := db.registry.GetSchema(query.Table)
 	if err != nil {
 		return &Result{
@@ -560,6 +1551,38 @@ func (db *DB) executeDescribe(ctx context.Context, query *Query) *Result {
 	}
 }
 
+// executeDescribeLive describes the table as it exists in the database.
+// executeDescribeLive 按数据库中实际存在的表结构描述表。
+func (db *DB) executeDescribeLive(ctx context.Context, query *Query) *Result {
+	schema, err := NewMigrator(db).scoped(ctx).describeDBTable(ctx, query.Table)
+	if err != nil {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "DESCRIBE_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+	if schema == nil {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:       "TABLE_NOT_FOUND",
+				Message:    fmt.Sprintf("table %s does not exist in the database", query.Table),
+				Suggestion: "Run AutoMigrate or check the table name and schema",
+			},
+		}
+	}
+	if meta, ok := db.registry.Get(query.Table); ok {
+		schema.Model = meta.ModelName
+	}
+	return &Result{
+		Success: true,
+		Schema:  schema,
+	}
+}
+
 // Hook registers a hook for a specific table.
 // Hook 为特定表注册钩子。
 func (db *DB) Hook(table string, hookType HookType, fn HookFunc) {
@@ -572,28 +1595,46 @@ func (db *DB) HookGlobal(hookType HookType, fn HookFunc) {
 	db.hooks.RegisterGlobal(hookType, fn)
 }
 
-// EnableSoftDelete enables soft delete for a table.
-// EnableSoftDelete 为表启用软删除。
+// EnableTenantScope scopes every read, update and delete of tables with column to
+// the tenant tenantFromCtx returns, and sets column on created rows. See TenantScopeHook.
+//
+// EnableTenantScope 将所有含 column 列的表的读取、更新和删除限定在 tenantFromCtx 返回的租户内，
+// 并在创建的行上设置 column。见 TenantScopeHook。
+func (db *DB) EnableTenantScope(column string, tenantFromCtx func(context.Context) any) {
+	hook := TenantScopeHook(column, tenantFromCtx)
+	for _, hookType := range []HookType{HookBeforeCreate, HookBeforeFind, HookBeforeUpdate, HookBeforeDelete} {
+		db.hooks.RegisterGlobal(hookType, hook)
+	}
+}
+
+// EnableSoftDelete enables soft delete for a table. When Config.Naming.DeletedByField
+// is set, that column records the actor of the deleting request.
+//
+// EnableSoftDelete 为表启用软删除。设置 Config.Naming.DeletedByField 时，该列记录执行删除的请求执行者。
 func (db *DB) EnableSoftDelete(table string, deletedAtField string) {
+	naming := db.naming()
 	if deletedAtField == "" {
-		deletedAtField = db.config.Naming.DeletedAtField
+		deletedAtField = naming.DeletedAtField
 	}
 	if deletedAtField == "" {
 		deletedAtField = "deleted_at"
 	}
-	db.hooks.Register(table, HookBeforeDelete, SoftDeleteHook(deletedAtField))
+	db.hooks.Register(table, HookBeforeDelete, SoftDeleteByHook(deletedAtField, naming.DeletedByField))
 }
 
-// EnableSoftDeleteGlobal enables soft delete for all tables.
-// EnableSoftDeleteGlobal 为所有表启用软删除。
+// EnableSoftDeleteGlobal enables soft delete for all tables, recording the actor
+// as EnableSoftDelete does.
+//
+// EnableSoftDeleteGlobal 为所有表启用软删除，并像 EnableSoftDelete 一样记录执行者。
 func (db *DB) EnableSoftDeleteGlobal(deletedAtField string) {
+	naming := db.naming()
 	if deletedAtField == "" {
-		deletedAtField = db.config.Naming.DeletedAtField
+		deletedAtField = naming.DeletedAtField
 	}
 	if deletedAtField == "" {
 		deletedAtField = "deleted_at"
 	}
-	db.hooks.RegisterGlobal(HookBeforeDelete, SoftDeleteHook(deletedAtField))
+	db.hooks.RegisterGlobal(HookBeforeDelete, SoftDeleteByHook(deletedAtField, naming.DeletedByField))
 }
 
 // Hooks returns the hook manager for advanced customization.
@@ -0,0 +1,138 @@
+package goorm
+
+import (
+	"crypto/rand"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyModelDefaults fills columns missing from create data using the registered
+// model of table: uuid fields get a generated UUID, autoCreateTime/autoUpdateTime
+// fields the current time, and literal `default:` values are copied in. It returns
+// a MISSING_REQUIRED_FIELD result for NOT NULL columns that remain unset.
+//
+// applyModelDefaults 使用表的已注册模型填充创建数据中缺失的列：uuid 字段生成 UUID，
+// autoCreateTime/autoUpdateTime 字段填入当前时间，字面量 `default:` 值直接复制。
+// 对于仍未设置的 NOT NULL 列返回 MISSING_REQUIRED_FIELD 结果。
+func (db *DB) applyModelDefaults(table string, data map[string]any) *Result {
+	if db.registry == nil || data == nil {
+		return nil
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return nil
+	}
+
+	var missing []string
+	for _, field := range meta.Fields {
+		if _, present := data[field.ColumnName]; present {
+			continue
+		}
+
+		switch {
+		case field.UUID:
+			id, err := newUUID()
+			if err != nil {
+				return &Result{
+					Success: false,
+					Error: &ResultError{
+						Code:    "UUID_ERROR",
+						Message: fmt.Sprintf("generating %s for %s: %v", field.ColumnName, table, err),
+					},
+				}
+			}
+			data[field.ColumnName] = id
+		case hasTag(field, "autoCreateTime"), hasTag(field, "autoUpdateTime"):
+			data[field.ColumnName] = db.now()
+		case field.Default != "":
+			// Expressions such as CURRENT_TIMESTAMP are left to the database
+			// 诸如 CURRENT_TIMESTAMP 的表达式交由数据库处理
+			if value, ok := defaultLiteral(field.Default); ok {
+				data[field.ColumnName] = value
+			}
+		case isRequiredColumn(field, len(meta.PrimaryKeys) > 1):
+			missing = append(missing, field.ColumnName)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:       "MISSING_REQUIRED_FIELD",
+				Message:    fmt.Sprintf("missing required fields for %s: %s", table, strings.Join(missing, ", ")),
+				Suggestion: "Provide values for these fields or declare a default in the model",
+				Details:    map[string]any{"fields": missing},
+			},
+		}
+	}
+	return nil
+}
+
+// isRequiredColumn reports whether a field maps to a NOT NULL column the database
+// cannot fill on its own. Columns of a composite key are always required.
+//
+// isRequiredColumn 判断字段是否映射到数据库无法自行填充的 NOT NULL 列。复合主键的列总是必填。
+func isRequiredColumn(field *FieldMeta, compositeKey bool) bool {
+	if field.PrimaryKey {
+		return compositeKey
+	}
+	if field.Nullable {
+		return false
+	}
+	// Struct fields other than time.Time are relations or scanner types
+	// 除 time.Time 外的结构体字段是关联或扫描器类型
+	if field.Type != nil && field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+		return false
+	}
+	return true
+}
+
+// hasTag reports whether the goorm tag of field contains key, ignoring case.
+// hasTag 判断字段的 goorm 标签是否包含 key（忽略大小写）。
+func hasTag(field *FieldMeta, key string) bool {
+	for k := range field.Tags {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultLiteral converts a SQL default literal to a Go value.
+// It returns false for expressions and NULL.
+//
+// defaultLiteral 将 SQL 默认字面量转换为 Go 值。
+// 对于表达式和 NULL 返回 false。
+func defaultLiteral(s string) (any, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), true
+	}
+	if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+		return strings.EqualFold(s, "true"), true
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+// newUUID returns a random (version 4) UUID string, or the error of the system's
+// random source.
+// newUUID 返回随机（版本 4）UUID 字符串；系统随机源出错时返回该错误。
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
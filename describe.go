@@ -0,0 +1,277 @@
+package goorm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// describeDBTable returns the columns, indexes and foreign keys table has in the
+// database, read from information_schema or SQLite PRAGMAs rather than from the
+// registered model, or nil if the database has no such table. Primary key indexes
+// are reported through ColumnSchema.Primary, and foreign keys as belongs_to relations.
+//
+// describeDBTable 返回 table 在数据库中的列、索引和外键，它们读取自 information_schema 或
+// SQLite PRAGMA，而非已注册的模型；数据库中没有该表时返回 nil。主键索引通过 ColumnSchema.Primary
+// 报告，外键以 belongs_to 关联报告。
+func (m *Migrator) describeDBTable(ctx context.Context, table string) (*TableSchema, error) {
+	tables, err := m.getDBTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var dbTable *DBTable
+	for i := range tables {
+		if tables[i].Name == table {
+			dbTable = &tables[i]
+			break
+		}
+	}
+	if dbTable == nil {
+		return nil, nil
+	}
+
+	infos := make([]ColumnInfo, 0, len(dbTable.Columns))
+	for _, col := range dbTable.Columns {
+		infos = append(infos, col)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Position < infos[j].Position })
+
+	schema := &TableSchema{Table: table, Columns: make([]ColumnSchema, len(infos))}
+	byName := make(map[string]*ColumnSchema, len(infos))
+	for i, info := range infos {
+		col := ColumnSchema{
+			Name:     info.Name,
+			Type:     info.Type,
+			Nullable: info.Nullable,
+			Primary:  info.Primary,
+		}
+		if info.Default != nil {
+			col.Default = *info.Default
+		}
+		schema.Columns[i] = col
+		byName[info.Name] = &schema.Columns[i]
+	}
+
+	indexes, err := m.getDBIndexes(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	for _, idx := range indexes {
+		if idx.primary {
+			for _, name := range idx.Columns {
+				if col := byName[name]; col != nil {
+					col.Primary = true
+				}
+			}
+			continue
+		}
+		if idx.Unique && len(idx.Columns) == 1 {
+			if col := byName[idx.Columns[0]]; col != nil {
+				col.Unique = true
+			}
+		}
+		schema.Indexes = append(schema.Indexes, idx.IndexSchema)
+	}
+
+	if schema.Relations, err = m.getDBForeignKeys(ctx, table); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// dbIndex is an index read from the database.
+// dbIndex 是从数据库读取的索引。
+type dbIndex struct {
+	IndexSchema
+	primary bool
+}
+
+// getDBIndexes returns the indexes of table in column order.
+// getDBIndexes 按列顺序返回 table 的索引。
+func (m *Migrator) getDBIndexes(ctx context.Context, table string) ([]dbIndex, error) {
+	switch m.dialect.Name() {
+	case "postgres":
+		return m.queryDBIndexes(ctx, `
+			SELECT i.relname, a.attname, ix.indisunique, ix.indisprimary
+			FROM pg_index ix
+			JOIN pg_class t ON t.oid = ix.indrelid
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_namespace n ON n.oid = t.relnamespace
+			JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+			WHERE n.nspname = $1 AND t.relname = $2
+			ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)
+		`, m.postgresSchema(), table)
+	case "mysql":
+		return m.queryDBIndexes(ctx, `
+			SELECT index_name, column_name, non_unique = 0, index_name = 'PRIMARY'
+			FROM information_schema.statistics
+			WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?
+			ORDER BY index_name, seq_in_index
+		`, m.schema, table)
+	case "sqlite", "sqlite3":
+		return m.getSQLiteIndexes(ctx, table)
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", m.dialect.Name())
+	}
+}
+
+// queryDBIndexes runs an index query returning one row per indexed column.
+// queryDBIndexes 执行每个被索引列返回一行的索引查询。
+func (m *Migrator) queryDBIndexes(ctx context.Context, query string, args ...any) ([]dbIndex, error) {
+	rows, err := m.db.sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []dbIndex
+	for rows.Next() {
+		var name, column string
+		var unique, primary bool
+		if err := rows.Scan(&name, &column, &unique, &primary); err != nil {
+			return nil, err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			continue
+		}
+		indexes = append(indexes, dbIndex{
+			IndexSchema: IndexSchema{Name: name, Columns: []string{column}, Unique: unique},
+			primary:     primary,
+		})
+	}
+	return indexes, rows.Err()
+}
+
+// getSQLiteIndexes reads the indexes of table with PRAGMA index_list and index_info.
+// getSQLiteIndexes 通过 PRAGMA index_list 和 index_info 读取 table 的索引。
+func (m *Migrator) getSQLiteIndexes(ctx context.Context, table string) ([]dbIndex, error) {
+	list, err := m.sqlitePragma(ctx, "index_list", table)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]dbIndex, 0, len(list))
+	for _, row := range list {
+		name, _ := row["name"].(string)
+		unique, _ := row["unique"].(int64)
+		origin, _ := row["origin"].(string)
+
+		info, err := m.sqlitePragma(ctx, "index_info", name)
+		if err != nil {
+			return nil, err
+		}
+		idx := dbIndex{IndexSchema: IndexSchema{Name: name, Unique: unique == 1}, primary: origin == "pk"}
+		for _, col := range info {
+			column, _ := col["name"].(string)
+			idx.Columns = append(idx.Columns, column)
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes, nil
+}
+
+// getDBForeignKeys returns the foreign keys of table as belongs_to relations.
+// getDBForeignKeys 以 belongs_to 关联返回 table 的外键。
+func (m *Migrator) getDBForeignKeys(ctx context.Context, table string) ([]RelationSchema, error) {
+	var query string
+	var args []any
+	switch m.dialect.Name() {
+	case "postgres":
+		query = `
+			SELECT kcu.column_name, ccu.table_name, ccu.column_name, rc.delete_rule, rc.update_rule
+			FROM information_schema.referential_constraints rc
+			JOIN information_schema.key_column_usage kcu
+				ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name
+			JOIN information_schema.constraint_column_usage ccu
+				ON ccu.constraint_schema = rc.constraint_schema AND ccu.constraint_name = rc.constraint_name
+			WHERE kcu.table_schema = $1 AND kcu.table_name = $2
+			ORDER BY kcu.constraint_name, kcu.ordinal_position
+		`
+		args = []any{m.postgresSchema(), table}
+	case "mysql":
+		query = `
+			SELECT kcu.column_name, kcu.referenced_table_name, kcu.referenced_column_name, rc.delete_rule, rc.update_rule
+			FROM information_schema.key_column_usage kcu
+			JOIN information_schema.referential_constraints rc
+				ON rc.constraint_schema = kcu.constraint_schema AND rc.constraint_name = kcu.constraint_name
+			WHERE kcu.table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND kcu.table_name = ?
+			ORDER BY kcu.constraint_name, kcu.ordinal_position
+		`
+		args = []any{m.schema, table}
+	case "sqlite", "sqlite3":
+		return m.getSQLiteForeignKeys(ctx, table)
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", m.dialect.Name())
+	}
+
+	rows, err := m.db.sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var relations []RelationSchema
+	for rows.Next() {
+		var column, target, ref, onDelete, onUpdate string
+		if err := rows.Scan(&column, &target, &ref, &onDelete, &onUpdate); err != nil {
+			return nil, err
+		}
+		relations = append(relations, foreignKeyRelation(column, target, ref, onDelete, onUpdate))
+	}
+	return relations, rows.Err()
+}
+
+// getSQLiteForeignKeys reads the foreign keys of table with PRAGMA foreign_key_list.
+// getSQLiteForeignKeys 通过 PRAGMA foreign_key_list 读取 table 的外键。
+func (m *Migrator) getSQLiteForeignKeys(ctx context.Context, table string) ([]RelationSchema, error) {
+	list, err := m.sqlitePragma(ctx, "foreign_key_list", table)
+	if err != nil {
+		return nil, err
+	}
+	var relations []RelationSchema
+	for _, row := range list {
+		column, _ := row["from"].(string)
+		target, _ := row["table"].(string)
+		ref, _ := row["to"].(string)
+		onDelete, _ := row["on_delete"].(string)
+		onUpdate, _ := row["on_update"].(string)
+		relations = append(relations, foreignKeyRelation(column, target, ref, onDelete, onUpdate))
+	}
+	return relations, nil
+}
+
+// foreignKeyRelation describes a foreign key of column referencing target.ref.
+// foreignKeyRelation 描述 column 引用 target.ref 的外键。
+func foreignKeyRelation(column, target, ref, onDelete, onUpdate string) RelationSchema {
+	return RelationSchema{
+		Name:         column,
+		Type:         "belongs_to",
+		Model:        target,
+		Target:       target,
+		ForeignKey:   column,
+		ReferenceKey: ref,
+		OnDelete:     onDelete,
+		OnUpdate:     onUpdate,
+	}
+}
+
+// sqlitePragma runs PRAGMA pragma(arg) in the migrator's schema and returns its rows.
+// sqlitePragma 在迁移器的 schema 中执行 PRAGMA pragma(arg) 并返回其结果行。
+func (m *Migrator) sqlitePragma(ctx context.Context, pragma, arg string) ([]map[string]any, error) {
+	prefix := "PRAGMA "
+	if m.schema != "" {
+		prefix += m.dialect.Quote(m.schema) + "."
+	}
+	rows, err := m.db.sqlDB.QueryContext(ctx, fmt.Sprintf("%s%s(%s)", prefix, pragma, m.dialect.Quote(arg)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data, r := scanRows(rows, nil)
+	if r != nil {
+		return nil, r.Err()
+	}
+	return data, nil
+}
@@ -2,7 +2,9 @@ package goorm
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Dialect is the interface for database-specific operations.
@@ -23,10 +25,19 @@ type Dialect interface {
 	// Quote 引用标识符（表名、列名）。
 	Quote(identifier string) string
 
+	// QualifiedTable returns the quoted table name qualified with schema, or just
+	// the quoted table name when schema is "".
+	// QualifiedTable 返回以 schema 限定的带引号表名，schema 为 "" 时仅返回带引号的表名。
+	QualifiedTable(schema, table string) string
+
 	// Placeholder returns the placeholder for the nth parameter.
 	// Placeholder 返回第 n 个参数的占位符。
 	Placeholder(n int) string
 
+	// MaxParams returns the maximum number of bound parameters in one statement.
+	// MaxParams 返回单条语句中绑定参数的最大数量。
+	MaxParams() int
+
 	// GoTypeToSQL converts a Go type to SQL type.
 	// GoTypeToSQL 将 Go 类型转换为 SQL 类型。
 	GoTypeToSQL(goType string, tags map[string]string) string
@@ -39,6 +50,15 @@ type Dialect interface {
 	// SupportsUpsert 表示方言是否支持 UPSERT。
 	SupportsUpsert() bool
 
+	// SupportsDefaultValue indicates if DEFAULT can stand for a value in INSERT ... VALUES.
+	// SupportsDefaultValue 表示 INSERT ... VALUES 中是否可以用 DEFAULT 代替值。
+	SupportsDefaultValue() bool
+
+	// SupportsEnum indicates if the dialect has an enum column type. Migrations
+	// enforce the values of enum columns on other dialects with a CHECK constraint.
+	// SupportsEnum 表示方言是否有枚举列类型。在其他方言上，迁移使用 CHECK 约束限制枚举列的取值。
+	SupportsEnum() bool
+
 	// AutoIncrementClause returns the auto-increment clause.
 	// AutoIncrementClause 返回自动递增子句。
 	AutoIncrementClause() string
@@ -46,6 +66,93 @@ type Dialect interface {
 	// CurrentTimestamp returns the SQL for current timestamp.
 	// CurrentTimestamp 返回当前时间戳的 SQL。
 	CurrentTimestamp() string
+
+	// LockClause returns the row locking clause for mode ("update" or "share"),
+	// or "" if the dialect does not support row locking.
+	// LockClause 返回 mode（"update" 或 "share"）对应的行锁子句，不支持行锁时返回 ""。
+	LockClause(mode string) string
+
+	// ExplainPrefix returns the statement prefix that asks the database for a query plan.
+	// ExplainPrefix 返回向数据库请求查询计划的语句前缀。
+	ExplainPrefix() string
+
+	// RollupClause returns the GROUP BY body that adds subtotal rows for the
+	// comma-separated columns, or "" if the dialect does not support ROLLUP.
+	// RollupClause 返回为逗号分隔的列添加小计行的 GROUP BY 内容，不支持 ROLLUP 时返回 ""。
+	RollupClause(columns string) string
+
+	// OrderNulls returns the ORDER BY item sorting field in direction ("ASC" or
+	// "DESC") with NULLs placed nulls ("first" or "last").
+	// OrderNulls 返回按 direction（"ASC" 或 "DESC"）排序 field、并将 NULL 放在 nulls（"first" 或 "last"）位置的 ORDER BY 项。
+	OrderNulls(field, direction, nulls string) string
+
+	// LimitOffsetClause returns the pagination clause for limit and offset (0 means
+	// unset), or "" if neither is set. hasOrder reports whether the statement has
+	// an ORDER BY, which some databases require for pagination.
+	// LimitOffsetClause 返回 limit 和 offset（0 表示未设置）对应的分页子句，两者都未设置时返回 ""。
+	// hasOrder 表示语句是否带有 ORDER BY，部分数据库分页时要求必须有。
+	LimitOffsetClause(limit, offset int, hasOrder bool) (string, error)
+
+	// DateFunc returns the SQL extracting part (year, month, day, hour, minute or
+	// second) from col as an integer, or "" for an unknown part.
+	// DateFunc 返回从 col 中以整数提取 part（year、month、day、hour、minute 或 second）的 SQL，未知部分返回 ""。
+	DateFunc(part, col string) string
+
+	// Collate returns expr compared or sorted under collation, or "" if collation is
+	// not a name the dialect can write. Names have passed isCollation.
+	// Collate 返回按 collation 排序规则比较或排序的 expr；collation 不是方言可写入的名称时返回 ""。名称已通过 isCollation 检查。
+	Collate(expr, collation string) string
+
+	// ColumnComment returns the statement attaching comment to col of table, both
+	// already quoted, or "" if the dialect declares column comments inline or does
+	// not support them.
+	// ColumnComment 返回为 table 的 col（均已加引号）添加注释 comment 的语句，方言以内联方式声明列注释或不支持时返回 ""。
+	ColumnComment(table, col, comment string) string
+
+	// InlineComment returns the clause declaring comment in a column definition,
+	// or "" if the dialect attaches comments with ColumnComment or does not support them.
+	// InlineComment 返回在列定义中声明注释 comment 的子句，方言通过 ColumnComment 添加注释或不支持注释时返回 ""。
+	InlineComment(comment string) string
+
+	// InsertIgnore returns the INSERT keyword and the clause following VALUES of an
+	// insert that skips rows conflicting with an existing primary or unique key.
+	// InsertIgnore 返回跳过与已有主键或唯一键冲突的行的插入语句所用的 INSERT 关键字及 VALUES 之后的子句。
+	InsertIgnore() (insert, clause string)
+
+	// NullSafeEqual returns the comparison of left and right that is true when
+	// both are NULL and false, not NULL, when only one is.
+	// NullSafeEqual 返回 left 与 right 的比较，两者都为 NULL 时为真，只有一个为 NULL 时为假而非 NULL。
+	NullSafeEqual(left, right string) string
+
+	// CaseInsensitiveEqual returns the comparison of left and right that ignores
+	// letter case and, unlike ILIKE, treats % and _ literally.
+	// CaseInsensitiveEqual 返回忽略字母大小写的 left 与 right 比较；与 ILIKE 不同，% 和 _ 按字面处理。
+	CaseInsensitiveEqual(left, right string) string
+
+	// StatementTimeout returns the statement making the server abort statements of
+	// the session (or only of the current transaction, when local is set) that run
+	// longer than timeout; 0 removes the limit. It returns "" if the dialect has no
+	// such setting.
+	// StatementTimeout 返回让服务器中止会话中（local 为 true 时仅为当前事务中）运行时间超过 timeout
+	// 的语句的设置语句；0 表示取消限制。方言没有该设置时返回 ""。
+	StatementTimeout(timeout time.Duration, local bool) string
+
+	// TimeoutHint returns query with a hint making the server abort it after
+	// timeout, or query unchanged if the dialect has no such hint or timeout is 0.
+	// TimeoutHint 返回带有让服务器在 timeout 后中止执行的提示的 query；方言没有该提示或 timeout 为 0 时原样返回。
+	TimeoutHint(query string, timeout time.Duration) string
+
+	// RowEstimate returns the query reading the planner's estimate of the number of
+	// rows in table of schema ("" for the current schema) and its arguments, or "" if
+	// the dialect keeps no such statistic.
+	// RowEstimate 返回读取规划器对 schema（"" 表示当前 schema）中 table 行数估计的查询及其参数；
+	// 方言没有该统计信息时返回 ""。
+	RowEstimate(schema, table string) (query string, args []any)
+
+	// ExistsQuery returns the query reporting whether selectOne, a SELECT 1 ...
+	// statement, matches any row.
+	// ExistsQuery 返回判断 selectOne（一条 SELECT 1 ... 语句）是否匹配任何行的查询。
+	ExistsQuery(selectOne string) string
 }
 
 // dialectRegistry holds all registered dialects.
@@ -95,10 +202,19 @@ func (d *PostgresDialect) DriverName() string {
 	return "postgres"
 }
 
-// Quote quotes an identifier with double quotes.
-// Quote 使用双引号引用标识符。
+// Quote quotes an identifier with double quotes, doubling any it contains.
+// Quote 使用双引号引用标识符，并将其中的双引号加倍。
 func (d *PostgresDialect) Quote(identifier string) string {
-	return `"` + identifier + `"`
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// QualifiedTable returns "schema"."table".
+// QualifiedTable 返回 "schema"."table"。
+func (d *PostgresDialect) QualifiedTable(schema, table string) string {
+	if schema == "" {
+		return d.Quote(table)
+	}
+	return d.Quote(schema) + "." + d.Quote(table)
 }
 
 // Placeholder returns $N style placeholder.
@@ -107,6 +223,12 @@ func (d *PostgresDialect) Placeholder(n int) string {
 	return fmt.Sprintf("$%d", n)
 }
 
+// MaxParams returns 65535, the limit of the wire protocol.
+// MaxParams 返回 65535，即协议的上限。
+func (d *PostgresDialect) MaxParams() int {
+	return 65535
+}
+
 // GoTypeToSQL converts Go type to PostgreSQL type.
 // GoTypeToSQL 将 Go 类型转换为 PostgreSQL 类型。
 func (d *PostgresDialect) GoTypeToSQL(goType string, tags map[string]string) string {
@@ -153,6 +275,13 @@ func (d *PostgresDialect) GoTypeToSQL(goType string, tags map[string]string) str
 		if len(goType) > 1 && goType[0] == '*' {
 			return d.GoTypeToSQL(goType[1:], tags)
 		}
+		// Slices of scalars tagged array are native arrays; untagged ones stay TEXT
+		// 带 array 标签的标量切片为原生数组；未带标签的仍为 TEXT
+		if _, ok := tags["array"]; ok {
+			if arrayType := pgArrayType(goType); arrayType != "" {
+				return arrayType
+			}
+		}
 		return "TEXT"
 	}
 }
@@ -169,6 +298,18 @@ func (d *PostgresDialect) SupportsUpsert() bool {
 	return true
 }
 
+// SupportsDefaultValue returns true.
+// SupportsDefaultValue 返回 true。
+func (d *PostgresDialect) SupportsDefaultValue() bool {
+	return true
+}
+
+// SupportsEnum returns true: enum columns use a named type created with CREATE TYPE.
+// SupportsEnum 返回 true：枚举列使用通过 CREATE TYPE 创建的具名类型。
+func (d *PostgresDialect) SupportsEnum() bool {
+	return true
+}
+
 // AutoIncrementClause returns SERIAL-based clause.
 // AutoIncrementClause 返回基于 SERIAL 的子句。
 func (d *PostgresDialect) AutoIncrementClause() string {
@@ -181,6 +322,118 @@ func (d *PostgresDialect) CurrentTimestamp() string {
 	return "NOW()"
 }
 
+// LockClause returns FOR UPDATE or FOR SHARE.
+// LockClause 返回 FOR UPDATE 或 FOR SHARE。
+func (d *PostgresDialect) LockClause(mode string) string {
+	switch mode {
+	case LockUpdate:
+		return "FOR UPDATE"
+	case LockShare:
+		return "FOR SHARE"
+	}
+	return ""
+}
+
+// ExplainPrefix returns EXPLAIN (FORMAT JSON).
+// ExplainPrefix 返回 EXPLAIN (FORMAT JSON)。
+func (d *PostgresDialect) ExplainPrefix() string {
+	return "EXPLAIN (FORMAT JSON) "
+}
+
+// RollupClause returns ROLLUP(columns).
+// RollupClause 返回 ROLLUP(columns)。
+func (d *PostgresDialect) RollupClause(columns string) string {
+	return "ROLLUP(" + columns + ")"
+}
+
+// OrderNulls returns field direction NULLS FIRST or NULLS LAST.
+// OrderNulls 返回 field direction NULLS FIRST 或 NULLS LAST。
+func (d *PostgresDialect) OrderNulls(field, direction, nulls string) string {
+	return field + " " + direction + " NULLS " + strings.ToUpper(nulls)
+}
+
+// LimitOffsetClause returns LIMIT n OFFSET m; either part is omitted when unset.
+// LimitOffsetClause 返回 LIMIT n OFFSET m；未设置的部分会被省略。
+func (d *PostgresDialect) LimitOffsetClause(limit, offset int, hasOrder bool) (string, error) {
+	return limitOffset(limit, offset, ""), nil
+}
+
+// DateFunc returns EXTRACT(PART FROM col).
+// DateFunc 返回 EXTRACT(PART FROM col)。
+func (d *PostgresDialect) DateFunc(part, col string) string {
+	if !isDatePart(part) {
+		return ""
+	}
+	return fmt.Sprintf("EXTRACT(%s FROM %s)", strings.ToUpper(part), col)
+}
+
+// Collate returns expr COLLATE "collation"; quoting keeps names such as "de-DE-x-icu" intact.
+// Collate 返回 expr COLLATE "collation"；加引号可保持 "de-DE-x-icu" 等名称不变。
+func (d *PostgresDialect) Collate(expr, collation string) string {
+	return fmt.Sprintf(`%s COLLATE "%s"`, expr, collation)
+}
+
+// ColumnComment returns COMMENT ON COLUMN table.col IS 'comment'.
+// ColumnComment 返回 COMMENT ON COLUMN table.col IS 'comment'。
+func (d *PostgresDialect) ColumnComment(table, col, comment string) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", table, col, quoteLiteral(comment))
+}
+
+// InlineComment returns "" since Postgres attaches comments with COMMENT ON COLUMN.
+// InlineComment 返回 ""，因为 Postgres 通过 COMMENT ON COLUMN 添加注释。
+func (d *PostgresDialect) InlineComment(comment string) string {
+	return ""
+}
+
+// InsertIgnore returns INSERT ... ON CONFLICT DO NOTHING.
+// InsertIgnore 返回 INSERT ... ON CONFLICT DO NOTHING。
+func (d *PostgresDialect) InsertIgnore() (insert, clause string) {
+	return "INSERT", " ON CONFLICT DO NOTHING"
+}
+
+// CaseInsensitiveEqual returns LOWER(left) = LOWER(right), which can use an index on
+// LOWER(col). ILIKE is avoided since it would treat % and _ in the value as wildcards.
+// CaseInsensitiveEqual 返回 LOWER(left) = LOWER(right)，可使用 LOWER(col) 上的索引。
+// 不使用 ILIKE，因为它会将值中的 % 和 _ 视为通配符。
+func (d *PostgresDialect) CaseInsensitiveEqual(left, right string) string {
+	return lowerEqual(left, right)
+}
+
+// NullSafeEqual returns left IS NOT DISTINCT FROM right.
+// NullSafeEqual 返回 left IS NOT DISTINCT FROM right。
+func (d *PostgresDialect) NullSafeEqual(left, right string) string {
+	return left + " IS NOT DISTINCT FROM " + right
+}
+
+// StatementTimeout returns SET [LOCAL] statement_timeout = <ms>.
+// StatementTimeout 返回 SET [LOCAL] statement_timeout = <毫秒>。
+func (d *PostgresDialect) StatementTimeout(timeout time.Duration, local bool) string {
+	set := "SET "
+	if local {
+		set = "SET LOCAL "
+	}
+	return fmt.Sprintf("%sstatement_timeout = %d", set, timeout.Milliseconds())
+}
+
+// TimeoutHint returns query unchanged; PostgreSQL limits statements with StatementTimeout.
+// TimeoutHint 原样返回 query；PostgreSQL 通过 StatementTimeout 限制语句。
+func (d *PostgresDialect) TimeoutHint(query string, timeout time.Duration) string {
+	return query
+}
+
+// RowEstimate reads reltuples from pg_class, which ANALYZE and VACUUM keep up to date.
+// RowEstimate 从 pg_class 读取 reltuples，该值由 ANALYZE 和 VACUUM 更新。
+func (d *PostgresDialect) RowEstimate(schema, table string) (string, []any) {
+	return "SELECT c.reltuples::bigint FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND c.relname = $2", []any{schema, table}
+}
+
+// ExistsQuery returns SELECT EXISTS(selectOne).
+// ExistsQuery 返回 SELECT EXISTS(selectOne)。
+func (d *PostgresDialect) ExistsQuery(selectOne string) string {
+	return "SELECT EXISTS(" + selectOne + ")"
+}
+
 // --- MySQL Dialect ---
 // --- MySQL 方言 ---
 
@@ -200,10 +453,19 @@ func (d *MySQLDialect) DriverName() string {
 	return "mysql"
 }
 
-// Quote quotes an identifier with backticks.
-// Quote 使用反引号引用标识符。
+// Quote quotes an identifier with backticks, doubling any it contains.
+// Quote 使用反引号引用标识符，并将其中的反引号加倍。
 func (d *MySQLDialect) Quote(identifier string) string {
-	return "`" + identifier + "`"
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// QualifiedTable returns `database`.`table`.
+// QualifiedTable 返回 `database`.`table`。
+func (d *MySQLDialect) QualifiedTable(schema, table string) string {
+	if schema == "" {
+		return d.Quote(table)
+	}
+	return d.Quote(schema) + "." + d.Quote(table)
 }
 
 // Placeholder returns ? style placeholder.
@@ -212,12 +474,21 @@ func (d *MySQLDialect) Placeholder(n int) string {
 	return "?"
 }
 
+// MaxParams returns 65535, the limit of prepared statements.
+// MaxParams 返回 65535，即预处理语句的上限。
+func (d *MySQLDialect) MaxParams() int {
+	return 65535
+}
+
 // GoTypeToSQL converts Go type to MySQL type.
 // GoTypeToSQL 将 Go 类型转换为 MySQL 类型。
 func (d *MySQLDialect) GoTypeToSQL(goType string, tags map[string]string) string {
 	if sqlType, ok := tags["type"]; ok {
 		return sqlType
 	}
+	if values, ok := tags["enum"]; ok {
+		return "ENUM(" + enumList(strings.Split(values, ",")) + ")"
+	}
 
 	switch goType {
 	case "int", "int32":
@@ -273,6 +544,18 @@ func (d *MySQLDialect) SupportsUpsert() bool {
 	return true
 }
 
+// SupportsDefaultValue returns true.
+// SupportsDefaultValue 返回 true。
+func (d *MySQLDialect) SupportsDefaultValue() bool {
+	return true
+}
+
+// SupportsEnum returns true: enum columns use ENUM(...).
+// SupportsEnum 返回 true：枚举列使用 ENUM(...)。
+func (d *MySQLDialect) SupportsEnum() bool {
+	return true
+}
+
 // AutoIncrementClause returns AUTO_INCREMENT.
 // AutoIncrementClause 返回 AUTO_INCREMENT。
 func (d *MySQLDialect) AutoIncrementClause() string {
@@ -285,6 +568,123 @@ func (d *MySQLDialect) CurrentTimestamp() string {
 	return "NOW()"
 }
 
+// LockClause returns FOR UPDATE or LOCK IN SHARE MODE.
+// LockClause 返回 FOR UPDATE 或 LOCK IN SHARE MODE。
+func (d *MySQLDialect) LockClause(mode string) string {
+	switch mode {
+	case LockUpdate:
+		return "FOR UPDATE"
+	case LockShare:
+		return "LOCK IN SHARE MODE"
+	}
+	return ""
+}
+
+// ExplainPrefix returns EXPLAIN FORMAT=JSON.
+// ExplainPrefix 返回 EXPLAIN FORMAT=JSON。
+func (d *MySQLDialect) ExplainPrefix() string {
+	return "EXPLAIN FORMAT=JSON "
+}
+
+// RollupClause returns columns WITH ROLLUP; MySQL has no ROLLUP() grouping function.
+// RollupClause 返回 columns WITH ROLLUP；MySQL 没有 ROLLUP() 分组函数。
+func (d *MySQLDialect) RollupClause(columns string) string {
+	return columns + " WITH ROLLUP"
+}
+
+// OrderNulls emulates NULLS FIRST/LAST, which MySQL lacks, by sorting on
+// whether field is NULL first.
+// OrderNulls 通过先按 field 是否为 NULL 排序来模拟 MySQL 不支持的 NULLS FIRST/LAST。
+func (d *MySQLDialect) OrderNulls(field, direction, nulls string) string {
+	if nulls == NullsFirst {
+		return "CASE WHEN " + field + " IS NULL THEN 0 ELSE 1 END, " + field + " " + direction
+	}
+	return "CASE WHEN " + field + " IS NULL THEN 1 ELSE 0 END, " + field + " " + direction
+}
+
+// LimitOffsetClause returns LIMIT n OFFSET m. MySQL does not accept OFFSET on its
+// own, so an offset without a limit uses the largest possible row count.
+// LimitOffsetClause 返回 LIMIT n OFFSET m。MySQL 不接受单独的 OFFSET，因此只有 offset 时使用最大行数作为 limit。
+func (d *MySQLDialect) LimitOffsetClause(limit, offset int, hasOrder bool) (string, error) {
+	return limitOffset(limit, offset, "18446744073709551615"), nil
+}
+
+// DateFunc returns PART(col), e.g. YEAR(col).
+// DateFunc 返回 PART(col)，例如 YEAR(col)。
+func (d *MySQLDialect) DateFunc(part, col string) string {
+	if !isDatePart(part) {
+		return ""
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(part), col)
+}
+
+// Collate returns expr COLLATE collation, e.g. utf8mb4_german2_ci.
+// Collate 返回 expr COLLATE collation，例如 utf8mb4_german2_ci。
+func (d *MySQLDialect) Collate(expr, collation string) string {
+	return bareCollate(expr, collation)
+}
+
+// ColumnComment returns "" since MySQL declares comments inline with COMMENT '...'.
+// ColumnComment 返回 ""，因为 MySQL 通过 COMMENT '...' 内联声明注释。
+func (d *MySQLDialect) ColumnComment(table, col, comment string) string {
+	return ""
+}
+
+// InlineComment returns COMMENT 'comment'.
+// InlineComment 返回 COMMENT 'comment'。
+func (d *MySQLDialect) InlineComment(comment string) string {
+	return "COMMENT " + quoteLiteral(strings.ReplaceAll(comment, `\`, `\\`))
+}
+
+// InsertIgnore returns INSERT IGNORE.
+// InsertIgnore 返回 INSERT IGNORE。
+func (d *MySQLDialect) InsertIgnore() (insert, clause string) {
+	return "INSERT IGNORE", ""
+}
+
+// CaseInsensitiveEqual returns LOWER(left) = LOWER(right). MySQL's default
+// collations already ignore case, but binary and _cs collations do not.
+// CaseInsensitiveEqual 返回 LOWER(left) = LOWER(right)。MySQL 的默认排序规则已忽略大小写，但二进制和 _cs 排序规则不会。
+func (d *MySQLDialect) CaseInsensitiveEqual(left, right string) string {
+	return lowerEqual(left, right)
+}
+
+// NullSafeEqual returns left <=> right.
+// NullSafeEqual 返回 left <=> right。
+func (d *MySQLDialect) NullSafeEqual(left, right string) string {
+	return left + " <=> " + right
+}
+
+// StatementTimeout returns ""; MySQL limits statements with TimeoutHint.
+// StatementTimeout 返回 ""；MySQL 通过 TimeoutHint 限制语句。
+func (d *MySQLDialect) StatementTimeout(timeout time.Duration, local bool) string {
+	return ""
+}
+
+// TimeoutHint adds the MAX_EXECUTION_TIME optimizer hint to a SELECT. MySQL has
+// no execution time limit for other statements.
+// TimeoutHint 为 SELECT 添加 MAX_EXECUTION_TIME 优化器提示。MySQL 对其他语句没有执行时间限制。
+func (d *MySQLDialect) TimeoutHint(query string, timeout time.Duration) string {
+	rest, ok := strings.CutPrefix(query, "SELECT ")
+	if !ok || timeout <= 0 {
+		return query
+	}
+	return fmt.Sprintf("SELECT /*+ MAX_EXECUTION_TIME(%d) */ %s", timeout.Milliseconds(), rest)
+}
+
+// RowEstimate reads table_rows from information_schema.tables; for InnoDB it is sampled.
+// RowEstimate 从 information_schema.tables 读取 table_rows；InnoDB 的该值为采样估计。
+func (d *MySQLDialect) RowEstimate(schema, table string) (string, []any) {
+	return "SELECT table_rows FROM information_schema.tables " +
+		"WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?", []any{schema, table}
+}
+
+// ExistsQuery returns selectOne with LIMIT 1, which returns no row when nothing matches.
+// ExistsQuery 返回带 LIMIT 1 的 selectOne，没有匹配时不返回行。
+func (d *MySQLDialect) ExistsQuery(selectOne string) string {
+	return selectOne + " LIMIT 1"
+}
+
 // --- SQLite Dialect ---
 // --- SQLite 方言 ---
 
@@ -304,10 +704,19 @@ func (d *SQLiteDialect) DriverName() string {
 	return "sqlite3"
 }
 
-// Quote quotes an identifier with double quotes.
-// Quote 使用双引号引用标识符。
+// Quote quotes an identifier with double quotes, doubling any it contains.
+// Quote 使用双引号引用标识符，并将其中的双引号加倍。
 func (d *SQLiteDialect) Quote(identifier string) string {
-	return `"` + identifier + `"`
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// QualifiedTable returns "schema"."table" for attached databases.
+// QualifiedTable 返回 "schema"."table"（用于附加的数据库）。
+func (d *SQLiteDialect) QualifiedTable(schema, table string) string {
+	if schema == "" {
+		return d.Quote(table)
+	}
+	return d.Quote(schema) + "." + d.Quote(table)
 }
 
 // Placeholder returns ? style placeholder.
@@ -316,6 +725,12 @@ func (d *SQLiteDialect) Placeholder(n int) string {
 	return "?"
 }
 
+// MaxParams returns 32766, the default SQLITE_MAX_VARIABLE_NUMBER since SQLite 3.32.
+// MaxParams 返回 32766，即 SQLite 3.32 起 SQLITE_MAX_VARIABLE_NUMBER 的默认值。
+func (d *SQLiteDialect) MaxParams() int {
+	return 32766
+}
+
 // GoTypeToSQL converts Go type to SQLite type.
 // GoTypeToSQL 将 Go 类型转换为 SQLite 类型。
 func (d *SQLiteDialect) GoTypeToSQL(goType string, tags map[string]string) string {
@@ -358,6 +773,18 @@ func (d *SQLiteDialect) SupportsUpsert() bool {
 	return true
 }
 
+// SupportsDefaultValue returns false: a column can only take its default by being left out.
+// SupportsDefaultValue 返回 false：列只能通过省略来使用其默认值。
+func (d *SQLiteDialect) SupportsDefaultValue() bool {
+	return false
+}
+
+// SupportsEnum returns false.
+// SupportsEnum 返回 false。
+func (d *SQLiteDialect) SupportsEnum() bool {
+	return false
+}
+
 // AutoIncrementClause returns AUTOINCREMENT.
 // AutoIncrementClause 返回 AUTOINCREMENT。
 func (d *SQLiteDialect) AutoIncrementClause() string {
@@ -370,6 +797,171 @@ func (d *SQLiteDialect) CurrentTimestamp() string {
 	return "CURRENT_TIMESTAMP"
 }
 
+// LockClause returns "" because SQLite locks the whole database instead of rows.
+// LockClause 返回 ""，因为 SQLite 锁定整个数据库而不是行。
+func (d *SQLiteDialect) LockClause(mode string) string {
+	return ""
+}
+
+// ExplainPrefix returns EXPLAIN QUERY PLAN.
+// ExplainPrefix 返回 EXPLAIN QUERY PLAN。
+func (d *SQLiteDialect) ExplainPrefix() string {
+	return "EXPLAIN QUERY PLAN "
+}
+
+// RollupClause returns "" since SQLite does not support ROLLUP.
+// RollupClause 返回 ""，因为 SQLite 不支持 ROLLUP。
+func (d *SQLiteDialect) RollupClause(columns string) string {
+	return ""
+}
+
+// OrderNulls returns field direction NULLS FIRST or NULLS LAST (SQLite 3.30+).
+// OrderNulls 返回 field direction NULLS FIRST 或 NULLS LAST（SQLite 3.30+）。
+func (d *SQLiteDialect) OrderNulls(field, direction, nulls string) string {
+	return field + " " + direction + " NULLS " + strings.ToUpper(nulls)
+}
+
+// LimitOffsetClause returns LIMIT n OFFSET m. SQLite requires LIMIT before OFFSET,
+// so an offset without a limit uses LIMIT -1 (no limit).
+// LimitOffsetClause 返回 LIMIT n OFFSET m。SQLite 要求 OFFSET 前必须有 LIMIT，因此只有 offset 时使用 LIMIT -1（不限制）。
+func (d *SQLiteDialect) LimitOffsetClause(limit, offset int, hasOrder bool) (string, error) {
+	return limitOffset(limit, offset, "-1"), nil
+}
+
+// limitOffset renders LIMIT n OFFSET m, using noLimit as the row count when only
+// an offset is set, or leaving LIMIT out if noLimit is "".
+// limitOffset 生成 LIMIT n OFFSET m；只设置 offset 时以 noLimit 作为行数，noLimit 为 "" 时省略 LIMIT。
+func limitOffset(limit, offset int, noLimit string) string {
+	var parts []string
+	if limit > 0 {
+		parts = append(parts, fmt.Sprintf("LIMIT %d", limit))
+	} else if offset > 0 && noLimit != "" {
+		parts = append(parts, "LIMIT "+noLimit)
+	}
+	if offset > 0 {
+		parts = append(parts, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(parts, " ")
+}
+
+// lowerEqual renders LOWER(left) = LOWER(right).
+// lowerEqual 生成 LOWER(left) = LOWER(right)。
+func lowerEqual(left, right string) string {
+	return fmt.Sprintf("LOWER(%s) = LOWER(%s)", left, right)
+}
+
+// bareCollate renders expr COLLATE collation with the name unquoted, as MySQL and
+// SQLite name collations with plain identifiers. It returns "" for other names.
+//
+// bareCollate 生成不加引号的 expr COLLATE collation，因为 MySQL 和 SQLite 的排序规则名称是普通标识符。
+// 其他名称返回 ""。
+func bareCollate(expr, collation string) string {
+	if strings.ContainsAny(collation, ".-@") {
+		return ""
+	}
+	return expr + " COLLATE " + collation
+}
+
+// sqliteDateFormats maps date parts to strftime formats.
+// sqliteDateFormats 将日期部分映射到 strftime 格式。
+var sqliteDateFormats = map[string]string{
+	DateYear:   "%Y",
+	DateMonth:  "%m",
+	DateDay:    "%d",
+	DateHour:   "%H",
+	DateMinute: "%M",
+	DateSecond: "%S",
+}
+
+// DateFunc returns CAST(strftime('%Y', col) AS INTEGER) and the like.
+// DateFunc 返回 CAST(strftime('%Y', col) AS INTEGER) 等形式。
+func (d *SQLiteDialect) DateFunc(part, col string) string {
+	format, ok := sqliteDateFormats[strings.ToLower(part)]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("CAST(strftime('%s', %s) AS INTEGER)", format, col)
+}
+
+// Collate returns expr COLLATE collation, e.g. NOCASE for case-insensitive text.
+// Collate 返回 expr COLLATE collation，例如用于不区分大小写文本的 NOCASE。
+func (d *SQLiteDialect) Collate(expr, collation string) string {
+	return bareCollate(expr, collation)
+}
+
+// ColumnComment returns "" since SQLite does not support column comments.
+// ColumnComment 返回 ""，因为 SQLite 不支持列注释。
+func (d *SQLiteDialect) ColumnComment(table, col, comment string) string {
+	return ""
+}
+
+// InlineComment returns "" since SQLite does not support column comments.
+// InlineComment 返回 ""，因为 SQLite 不支持列注释。
+func (d *SQLiteDialect) InlineComment(comment string) string {
+	return ""
+}
+
+// InsertIgnore returns INSERT ... ON CONFLICT DO NOTHING (SQLite 3.24+).
+// InsertIgnore 返回 INSERT ... ON CONFLICT DO NOTHING（SQLite 3.24+）。
+func (d *SQLiteDialect) InsertIgnore() (insert, clause string) {
+	return "INSERT", " ON CONFLICT DO NOTHING"
+}
+
+// CaseInsensitiveEqual returns left = right COLLATE NOCASE, which can use an index
+// declared COLLATE NOCASE. NOCASE folds ASCII letters only.
+// CaseInsensitiveEqual 返回 left = right COLLATE NOCASE，可使用声明了 COLLATE NOCASE 的索引。NOCASE 只折叠 ASCII 字母。
+func (d *SQLiteDialect) CaseInsensitiveEqual(left, right string) string {
+	return left + " = " + right + " COLLATE NOCASE"
+}
+
+// NullSafeEqual returns left IS right; SQLite's IS compares NULLs as equal.
+// NullSafeEqual 返回 left IS right；SQLite 的 IS 将 NULL 视为相等。
+func (d *SQLiteDialect) NullSafeEqual(left, right string) string {
+	return left + " IS " + right
+}
+
+// StatementTimeout returns ""; SQLite runs in process and stops when the context is done.
+// StatementTimeout 返回 ""；SQLite 在进程内运行，上下文结束时即停止。
+func (d *SQLiteDialect) StatementTimeout(timeout time.Duration, local bool) string {
+	return ""
+}
+
+// TimeoutHint returns query unchanged.
+// TimeoutHint 原样返回 query。
+func (d *SQLiteDialect) TimeoutHint(query string, timeout time.Duration) string {
+	return query
+}
+
+// RowEstimate returns ""; SQLite keeps no row count, so counts are exact.
+// RowEstimate 返回 ""；SQLite 不保存行数，因此计数总是精确的。
+func (d *SQLiteDialect) RowEstimate(schema, table string) (string, []any) {
+	return "", nil
+}
+
+// ExistsQuery returns SELECT EXISTS(selectOne).
+// ExistsQuery 返回 SELECT EXISTS(selectOne)。
+func (d *SQLiteDialect) ExistsQuery(selectOne string) string {
+	return "SELECT EXISTS(" + selectOne + ")"
+}
+
+// enumList returns values as a comma-separated list of string literals.
+// enumList 以逗号分隔的字符串字面量列表返回 values。
+func enumList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			quoted = append(quoted, quoteLiteral(v))
+		}
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteLiteral quotes s as a standard SQL string literal by doubling single quotes.
+// quoteLiteral 通过双写单引号将 s 引用为标准 SQL 字符串字面量。
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // init registers the default dialects.
 // init 注册默认方言。
 func init() {
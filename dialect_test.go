@@ -1,6 +1,7 @@
 package goorm
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -20,6 +21,12 @@ func TestSQLiteDialect(t *testing.T) {
 	if d.Quote("users") != `"users"` {
 		t.Errorf("expected '\"users\"', got %s", d.Quote("users"))
 	}
+	if got := d.Quote(`a"b`); got != `"a""b"` {
+		t.Errorf("expected embedded quotes doubled, got %s", got)
+	}
+	if got := (&MySQLDialect{}).Quote("a`b"); got != "`a``b`" {
+		t.Errorf("expected embedded backticks doubled, got %s", got)
+	}
 
 	if d.Placeholder(1) != "?" {
 		t.Errorf("expected '?', got %s", d.Placeholder(1))
@@ -151,6 +158,100 @@ func TestQueryOptimizerWithLimit(t *testing.T) {
 	}
 }
 
+// TestQueryOptimizerIndexSuggestions tests the CREATE INDEX statements suggested for WHERE columns.
+// TestQueryOptimizerIndexSuggestions 测试为 WHERE 列建议的 CREATE INDEX 语句。
+func TestQueryOptimizerIndexSuggestions(t *testing.T) {
+	type Order struct {
+		Model
+		CustomerID uint64
+		Status     string
+	}
+	db := &DB{registry: NewRegistry(), dialect: &PostgresDialect{}}
+	db.registry.Register(&Order{}, DefaultConfig().Naming)
+
+	result := db.AnalyzeQuery(&Query{
+		Table:  "orders",
+		Action: ActionFind,
+		Where: []Condition{
+			{Field: "id", Op: OpEqual, Value: 1},
+			{Field: "orders.customer_id", Op: OpEqual, Value: 7},
+			{Field: "status", Op: OpEqual, Value: "paid"},
+		},
+	})
+
+	var single []string
+	for _, hint := range result.Hints {
+		if hint.Type == "missing_index" {
+			single = append(single, hint.Suggestion)
+		}
+	}
+	wantSingle := []string{
+		`CREATE INDEX "idx_orders_customer_id" ON "orders" ("customer_id")`,
+		`CREATE INDEX "idx_orders_status" ON "orders" ("status")`,
+	}
+	if strings.Join(single, ";") != strings.Join(wantSingle, ";") {
+		t.Errorf("missing_index suggestions = %v, want %v", single, wantSingle)
+	}
+
+	wantComposite := `CREATE INDEX "idx_orders_customer_id_status" ON "orders" ("customer_id", "status")`
+	if len(result.IndexSuggested) != 1 || result.IndexSuggested[0] != wantComposite {
+		t.Errorf("IndexSuggested = %v, want [%s]", result.IndexSuggested, wantComposite)
+	}
+
+	// OR conditions get one index per column
+	// OR 条件为每列单独建议索引
+	result = db.AnalyzeQuery(&Query{
+		Table:  "orders",
+		Action: ActionFind,
+		Where: []Condition{
+			{Field: "customer_id", Op: OpEqual, Value: 7},
+			{Field: "status", Op: OpEqual, Value: "paid", Or: true},
+		},
+	})
+	if strings.Join(result.IndexSuggested, ";") != strings.Join(wantSingle, ";") {
+		t.Errorf("IndexSuggested = %v, want %v", result.IndexSuggested, wantSingle)
+	}
+}
+
+// TestQueryOptimizerLargeOffset tests detection of OFFSET-heavy pagination.
+// TestQueryOptimizerLargeOffset 测试大 OFFSET 分页的检测。
+func TestQueryOptimizerLargeOffset(t *testing.T) {
+	db := &DB{registry: NewRegistry()}
+	optimizer := NewQueryOptimizer(db)
+
+	query := &Query{
+		Table:  "events",
+		Action: ActionFind,
+		Select: []any{"id"},
+		Limit:  20,
+		Offset: 100000,
+	}
+
+	result := optimizer.Analyze(query)
+	found := false
+	for _, hint := range result.Hints {
+		if hint.Type == "large_offset" {
+			found = true
+			if !strings.Contains(hint.Suggestion, "keyset") {
+				t.Errorf("suggestion should mention keyset pagination: %s", hint.Suggestion)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected large_offset hint")
+	}
+	if result.Score != 85 {
+		t.Errorf("score = %d, want 85", result.Score)
+	}
+
+	optimizer.SetOffsetThreshold(200000)
+	for _, hint := range optimizer.Analyze(query).Hints {
+		if hint.Type == "large_offset" {
+			t.Error("offset below the threshold should not be flagged")
+		}
+	}
+}
+
 // TestQueryOptimizerOptimize tests the Optimize method.
 // TestQueryOptimizerOptimize 测试 Optimize 方法。
 func TestQueryOptimizerOptimize(t *testing.T) {
@@ -162,12 +263,26 @@ func TestQueryOptimizerOptimize(t *testing.T) {
 		Action: ActionFind,
 	}
 
+	// Limit injection is opt-in
+	// 限制注入需要显式启用
+	if optimized := optimizer.Optimize(query); optimized.Limit != 0 || optimized.AutoLimited {
+		t.Errorf("expected no injected limit, got %d", optimized.Limit)
+	}
+
+	optimizer.SetDefaultLimit(500)
 	optimized := optimizer.Optimize(query)
+	if optimized.Limit != 500 || !optimized.AutoLimited {
+		t.Errorf("expected injected limit 500, got %d (auto %v)", optimized.Limit, optimized.AutoLimited)
+	}
+	if query.Limit != 0 {
+		t.Error("Optimize should not modify the original query")
+	}
 
-	// Should add a default limit
-	// 应该添加默认限制
-	if optimized.Limit != 1000 {
-		t.Errorf("expected limit 1000, got %d", optimized.Limit)
+	// Explicit limits are kept
+	// 保留显式限制
+	explicit := optimizer.Optimize(&Query{Table: "users", Action: ActionFind, Limit: 10})
+	if explicit.Limit != 10 || explicit.AutoLimited {
+		t.Errorf("explicit limit changed: %d", explicit.Limit)
 	}
 }
 
@@ -188,3 +303,33 @@ func TestHealthThresholds(t *testing.T) {
 		t.Error("MaxWaitCount should be set")
 	}
 }
+
+// TestPostgresArrayTypeMapping tests that slices tagged array map to Postgres array
+// types and untagged ones keep mapping to TEXT.
+// TestPostgresArrayTypeMapping 测试带 array 标签的切片映射为 Postgres 数组类型，未带标签的仍映射为 TEXT。
+func TestPostgresArrayTypeMapping(t *testing.T) {
+	d := &PostgresDialect{}
+	array := map[string]string{"array": ""}
+	if result := d.GoTypeToSQL("[]string", nil); result != "TEXT" {
+		t.Errorf("untagged []string = %s, want TEXT", result)
+	}
+
+	tests := []struct {
+		goType   string
+		expected string
+	}{
+		{"[]int64", "BIGINT[]"},
+		{"[]int", "INTEGER[]"},
+		{"[]string", "TEXT[]"},
+		{"*[]float64", "DOUBLE PRECISION[]"},
+		{"[]uint8", "TEXT"},
+		{"[]main.Item", "TEXT"},
+	}
+
+	for _, tt := range tests {
+		result := d.GoTypeToSQL(tt.goType, array)
+		if result != tt.expected {
+			t.Errorf("GoTypeToSQL(%s) = %s, want %s", tt.goType, result, tt.expected)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package goorm
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -31,83 +34,50 @@ func NewExecutor(db *DB) *Executor {
 func (e *Executor) ExecuteFind(ctx context.Context, query *Query) *Result {
 	startTime := time.Now()
 
-	builder := NewSQLBuilder(e.dialect, query)
+	if r := e.db.runHooks(ctx, HookBeforeFind, query, nil); r != nil {
+		return r
+	}
+
+	e.db.warnLock(query, false)
+	query = e.db.withDefaultOrder(query)
+	query = e.db.checkOrderPriority(query)
+
+	builder := e.db.newBuilder(ctx, query)
 	buildResult, err := builder.Build()
-	if err != nil {
+	if errors.Is(err, ErrRollupNotSupported) {
 		return &Result{
 			Success: false,
 			Error: &ResultError{
-				Code:    "BUILD_ERROR",
-				Message: err.Error(),
+				Code:       "ROLLUP_NOT_SUPPORTED",
+				Message:    fmt.Sprintf("%s does not support ROLLUP", e.dialect.Name()),
+				Suggestion: "Remove rollup and compute subtotals from the grouped rows",
 			},
 		}
 	}
-
-	rows, err := e.db.sqlDB.QueryContext(ctx, buildResult.SQL, buildResult.Params...)
-	if err != nil {
-		return e.handleSQLError(err, buildResult)
-	}
-	defer rows.Close()
-
-	// Get column names
-	// 获取列名
-	columns, err := rows.Columns()
 	if err != nil {
 		return &Result{
 			Success: false,
 			Error: &ResultError{
-				Code:    "COLUMN_ERROR",
+				Code:    "BUILD_ERROR",
 				Message: err.Error(),
 			},
 		}
 	}
 
-	// Scan rows
-	// 扫描行
-	data := make([]map[string]any, 0)
-	for rows.Next() {
-		// Create slice of interface{} to hold column values
-		// 创建 interface{} 切片来保存列值
-		values := make([]any, len(columns))
-		valuePtrs := make([]any, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			return &Result{
-				Success: false,
-				Error: &ResultError{
-					Code:    "SCAN_ERROR",
-					Message: err.Error(),
-				},
-			}
-		}
-
-		// Convert to map
-		// 转换为 map
-		row := make(map[string]any, len(columns))
-		for i, col := range columns {
-			val := values[i]
-			// Handle []byte conversion to string
-			// 处理 []byte 到 string 的转换
-			if b, ok := val.([]byte); ok {
-				val = string(b)
-			}
-			row[col] = val
-		}
-		data = append(data, row)
+	rows, err := e.db.queryContext(ctx, e.db.reader(ctx, query), buildResult.SQL, buildResult.Params...)
+	e.db.logQuery(buildResult, startTime, err)
+	if err != nil {
+		return e.handleSQLError(err, buildResult)
 	}
+	defer rows.Close()
 
-	if err := rows.Err(); err != nil {
-		return &Result{
-			Success: false,
-			Error: &ResultError{
-				Code:    "ROWS_ERROR",
-				Message: err.Error(),
-			},
-		}
+	data, r := scanRows(rows, e.db.scanFields(query))
+	if r != nil {
+		return r
 	}
+	e.db.decodeJSONColumns(query.Table, data)
+	e.db.decodeArrayColumns(query.Table, data)
+	e.db.parseTimeColumns(query.Table, data)
 
 	result := &Result{
 		Success: true,
@@ -117,7 +87,7 @@ func (e *Executor) ExecuteFind(ctx context.Context, query *Query) *Result {
 
 	// Add debug info if requested
 	// 如果请求则添加调试信息
-	if query.Debug || e.db.config.Debug {
+	if e.db.debug(ctx, query) {
 		result.Meta = &ResultMeta{
 			SQL:          buildResult.SQL,
 			Params:       buildResult.Params,
@@ -126,6 +96,14 @@ func (e *Executor) ExecuteFind(ctx context.Context, query *Query) *Result {
 		}
 	}
 
+	if query.AutoLimited {
+		markAutoLimited(result, query.Limit, len(data))
+	}
+
+	if r := e.db.runHooks(ctx, HookAfterFind, query, result); r != nil {
+		return r
+	}
+
 	return result
 }
 
@@ -134,34 +112,25 @@ func (e *Executor) ExecuteFind(ctx context.Context, query *Query) *Result {
 func (e *Executor) ExecuteCreate(ctx context.Context, query *Query) *Result {
 	startTime := time.Now()
 
+	if len(query.Returning) > 0 && !e.dialect.SupportsReturning() {
+		return returningNotSupported(e.dialect)
+	}
+
 	// Execute before create hook
 	// 执行创建前钩子
-	hookCtx := &HookContext{
-		Context: ctx,
-		DB:      e.db,
-		Table:   query.Table,
-		Action:  ActionCreate,
-		Query:   query,
-		Data:    query.Data,
+	if r := e.db.runHooks(ctx, HookBeforeCreate, query, nil); r != nil {
+		return r
 	}
-
-	if err := e.db.hooks.Execute(hookCtx, HookBeforeCreate); err != nil {
-		return &Result{
-			Success: false,
-			Error: &ResultError{
-				Code:    "HOOK_ERROR",
-				Message: err.Error(),
-			},
-		}
+	if r := e.db.applyModelDefaults(query.Table, query.Data); r != nil {
+		return r
 	}
-
-	// Update query data with hook modifications
-	// 用钩子的修改更新查询数据
-	if hookCtx.Data != nil {
-		query.Data = hookCtx.Data
+	if r := e.db.encodeJSONColumns(query.Table, query.Data); r != nil {
+		return r
 	}
+	e.db.encodeArrayColumns(query.Table, query.Data)
 
-	builder := NewSQLBuilder(e.dialect, query)
+	pk := e.db.insertKeyColumn(query.Table)
+	builder := e.db.newBuilder(ctx, query).WithPrimaryKey(pk)
 	buildResult, err := builder.Build()
 	if err != nil {
 		return &Result{
@@ -173,33 +142,70 @@ func (e *Executor) ExecuteCreate(ctx context.Context, query *Query) *Result {
 		}
 	}
 
-	var lastID uint64
+	var key any
+	var data []map[string]any
+	affected := int64(1)
 
-	if e.dialect.SupportsReturning() {
+	if len(query.Returning) > 0 {
+		// The new row comes back with the requested columns
+		// 新行带着请求的列返回
+		rows, err := e.db.queryContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...)
+		e.db.logQuery(buildResult, startTime, err)
+		if err != nil {
+			return e.handleSQLError(err, buildResult)
+		}
+		defer rows.Close()
+
+		var errResult *Result
+		if data, errResult = scanRows(rows, e.db.scanFields(query)); errResult != nil {
+			return errResult
+		}
+		if len(data) > 0 {
+			key = data[0][pk]
+		} else {
+			// An ignored conflict returns no row
+			// 被忽略的冲突不返回行
+			key, affected = query.Data[pk], 0
+		}
+	} else if e.dialect.SupportsReturning() {
 		// PostgreSQL/SQLite: use RETURNING
-		err = e.db.sqlDB.QueryRowContext(ctx, buildResult.SQL, buildResult.Params...).Scan(&lastID)
-		if err != nil && err != sql.ErrNoRows {
+		err = e.db.queryRowContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...).Scan(&key)
+		if err == sql.ErrNoRows {
+			err = nil
+			key, affected = query.Data[pk], 0
+		}
+		e.db.logQuery(buildResult, startTime, err)
+		if err != nil {
 			return e.handleSQLError(err, buildResult)
 		}
 	} else {
-		// MySQL: use LastInsertId
-		result, err := e.db.sqlDB.ExecContext(ctx, buildResult.SQL, buildResult.Params...)
+		// MySQL: use the provided key, or LastInsertId for auto-increment keys
+		// MySQL：使用提供的主键，自增主键则使用 LastInsertId
+		result, err := e.db.execContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...)
+		e.db.logQuery(buildResult, startTime, err)
 		if err != nil {
 			return e.handleSQLError(err, buildResult)
 		}
-		id, err := result.LastInsertId()
-		if err == nil {
-			lastID = uint64(id)
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			affected = 0
+		}
+		if v, ok := query.Data[pk]; ok {
+			key = v
+		} else if id, err := result.LastInsertId(); err == nil && affected > 0 {
+			key = id
 		}
 	}
 
+	key, lastID := insertedKey(key)
 	r := &Result{
-		Success:  true,
-		ID:       lastID,
-		Affected: 1,
+		Success:     true,
+		Data:        data,
+		ID:          lastID,
+		InsertedKey: key,
+		Affected:    affected,
 	}
 
-	if query.Debug || e.db.config.Debug {
+	if e.db.debug(ctx, query) {
 		r.Meta = &ResultMeta{
 			SQL:        buildResult.SQL,
 			Params:     buildResult.Params,
@@ -207,6 +213,10 @@ func (e *Executor) ExecuteCreate(ctx context.Context, query *Query) *Result {
 		}
 	}
 
+	if hr := e.db.runHooks(ctx, HookAfterCreate, query, r); hr != nil {
+		return hr
+	}
+
 	return r
 }
 
@@ -215,65 +225,297 @@ func (e *Executor) ExecuteCreate(ctx context.Context, query *Query) *Result {
 func (e *Executor) ExecuteCreateBatch(ctx context.Context, query *Query) *Result {
 	startTime := time.Now()
 
-	builder := NewSQLBuilder(e.dialect, query)
-	buildResult, err := builder.Build()
-	if err != nil {
-		return &Result{
-			Success: false,
-			Error: &ResultError{
-				Code:    "BUILD_ERROR",
-				Message: err.Error(),
-			},
-		}
+	if len(query.Returning) > 0 && !e.dialect.SupportsReturning() {
+		return returningNotSupported(e.dialect)
 	}
 
-	var ids []uint64
-	affected := int64(len(query.DataBatch))
+	if r := e.db.prepareBatch(ctx, query.Table, query.DataBatch); r != nil {
+		return r
+	}
 
-	if e.dialect.SupportsReturning() {
-		// PostgreSQL: use RETURNING
-		rows, err := e.db.sqlDB.QueryContext(ctx, buildResult.SQL, buildResult.Params...)
+	pk := e.db.insertKeyColumn(query.Table)
+	chunks := insertChunks(query.DataBatch, e.dialect, pk)
+
+	// A batch that needs several statements runs in a transaction
+	// 需要多条语句的批量在事务中执行
+	var tx *Transaction
+	if len(chunks) > 1 {
+		sqlTx, err := e.db.sqlDB.BeginTx(ctx, nil)
 		if err != nil {
-			return e.handleSQLError(err, buildResult)
+			return &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:    "TX_BEGIN_ERROR",
+					Message: err.Error(),
+				},
+			}
 		}
-		defer rows.Close()
+		tx = &Transaction{db: e.db, tx: sqlTx}
+		defer tx.Rollback()
+	}
 
-		for rows.Next() {
-			var id uint64
-			if err := rows.Scan(&id); err == nil {
-				ids = append(ids, id)
+	var ids []uint64
+	var keys []any
+	var data []map[string]any
+	var builds []*BuildResult
+	for _, rows := range chunks {
+		chunk := *query
+		chunk.DataBatch = rows
+
+		buildResult, err := e.db.newBuilder(ctx, &chunk).WithPrimaryKey(pk).Build()
+		if err != nil {
+			return &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:    "BUILD_ERROR",
+					Message: err.Error(),
+				},
 			}
 		}
-	} else {
-		// MySQL: execute and get last insert ID
-		result, err := e.db.sqlDB.ExecContext(ctx, buildResult.SQL, buildResult.Params...)
+		chunkKeys, chunkData, err := e.insertBatch(ctx, tx, &chunk, buildResult, pk)
 		if err != nil {
 			return e.handleSQLError(err, buildResult)
 		}
-		lastID, _ := result.LastInsertId()
-		// MySQL auto-increment IDs are sequential
-		for i := int64(0); i < affected; i++ {
-			ids = append(ids, uint64(lastID+i))
+		keys = append(keys, chunkKeys...)
+		data = append(data, chunkData...)
+		builds = append(builds, buildResult)
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:    "TX_COMMIT_ERROR",
+					Message: err.Error(),
+				},
+			}
+		}
+	}
+
+	for i, key := range keys {
+		var id uint64
+		keys[i], id = insertedKey(key)
+		if id != 0 {
+			ids = append(ids, id)
 		}
 	}
 
 	r := &Result{
-		Success:  true,
-		IDs:      ids,
-		Affected: affected,
+		Success:      true,
+		IDs:          ids,
+		InsertedKeys: keys,
+		Affected:     int64(len(query.DataBatch)),
+		Data:         data,
 	}
 
-	if query.Debug || e.db.config.Debug {
+	if e.db.debug(ctx, query) {
 		r.Meta = &ResultMeta{
-			SQL:        buildResult.SQL,
-			Params:     buildResult.Params,
+			SQL:        builds[0].SQL,
+			Params:     builds[0].Params,
 			DurationMs: float64(time.Since(startTime).Microseconds()) / 1000,
 		}
+		if len(builds) > 1 {
+			r.Meta.Warnings = append(r.Meta.Warnings, fmt.Sprintf("batch split into %d statements; sql and params show the first", len(builds)))
+		}
+	}
+
+	if hr := e.db.runHooks(ctx, HookAfterCreate, query, r); hr != nil {
+		return hr
 	}
 
 	return r
 }
 
+// prepareBatch checks the columns of batch and runs the before-create hooks, model
+// defaults and JSON encoding on each of its rows, replacing them in place.
+//
+// prepareBatch 检查 batch 的列，并对每一行执行创建前钩子、模型默认值和 JSON 编码，就地替换各行。
+func (db *DB) prepareBatch(ctx context.Context, table string, batch []map[string]any) *Result {
+	if r := db.checkBatchColumns(table, batch); r != nil {
+		return r
+	}
+
+	// Execute before create hooks for each row
+	// 为每一行执行创建前钩子
+	for i, row := range batch {
+		rowQuery := &Query{Table: table, Action: ActionCreate, Data: row}
+		if r := db.runHooks(ctx, HookBeforeCreate, rowQuery, nil); r != nil {
+			return r
+		}
+		if r := db.applyModelDefaults(table, rowQuery.Data); r != nil {
+			return r
+		}
+		if r := db.encodeJSONColumns(table, rowQuery.Data); r != nil {
+			return r
+		}
+		db.encodeArrayColumns(table, rowQuery.Data)
+		batch[i] = rowQuery.Data
+	}
+	return nil
+}
+
+// checkBatchColumns returns an INVALID_COLUMN result if a record of batch has a
+// column that is not a field of the registered model of table.
+//
+// checkBatchColumns 在 batch 的某条记录包含不属于 table 已注册模型字段的列时返回 INVALID_COLUMN 结果。
+func (db *DB) checkBatchColumns(table string, batch []map[string]any) *Result {
+	if db.registry == nil {
+		return nil
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]bool, len(meta.Fields))
+	for _, field := range meta.Fields {
+		fields[field.ColumnName] = true
+	}
+	for i, record := range batch {
+		for _, col := range sortedColumns(record) {
+			if fields[col] {
+				continue
+			}
+			return &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:       "INVALID_COLUMN",
+					Message:    fmt.Sprintf("record %d of the batch has column %q, which is not a field of %s", i, col, table),
+					Suggestion: "检查列名拼写 / Check column name spelling",
+					Details:    map[string]any{"record": i, "column": col},
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// insertChunks splits batch into the rows of each INSERT statement, in order: runs
+// of rows under the parameter limit of dialect and, for dialects without DEFAULT in
+// VALUES, with the same columns, so a missing column takes its default there too.
+// For dialects without RETURNING, the rows of a run either all set the primary key
+// pk or all leave it to the database, so the generated keys are consecutive.
+//
+// insertChunks 按顺序将 batch 拆分为各条 INSERT 语句的行：每段行数不超过 dialect 的参数上限；
+// 对于 VALUES 不支持 DEFAULT 的方言，每段的列也相同，使缺失的列同样使用其默认值。
+// 对于不支持 RETURNING 的方言，每段的行要么都设置了主键 pk，要么都由数据库生成，使生成的主键连续。
+func insertChunks(batch []map[string]any, dialect Dialect, pk string) [][]map[string]any {
+	split := func(a, b map[string]any) bool {
+		return !dialect.SupportsDefaultValue() && !sameColumns(a, b) ||
+			!dialect.SupportsReturning() && (a[pk] == nil) != (b[pk] == nil)
+	}
+	var runs [][]map[string]any
+	start := 0
+	for i := 1; i <= len(batch); i++ {
+		if i == len(batch) || split(batch[i], batch[start]) {
+			runs = append(runs, batch[start:i])
+			start = i
+		}
+	}
+
+	var chunks [][]map[string]any
+	for _, run := range runs {
+		size := batchChunkSize(run, dialect.MaxParams())
+		for i := 0; i < len(run); i += size {
+			chunks = append(chunks, run[i:min(i+size, len(run))])
+		}
+	}
+	return chunks
+}
+
+// sameColumns reports whether a and b have the same keys.
+// sameColumns 判断 a 和 b 的键是否相同。
+func sameColumns(a, b map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for col := range a {
+		if _, ok := b[col]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// batchChunkSize returns how many rows of batch fit in one statement without
+// binding more than maxParams parameters.
+//
+// batchChunkSize 返回在绑定参数不超过 maxParams 的前提下，一条语句可容纳 batch 中的多少行。
+func batchChunkSize(batch []map[string]any, maxParams int) int {
+	return max(1, maxParams/max(1, len(batchColumns(batch))))
+}
+
+// insertBatch runs build, the INSERT of the rows of chunk, in tx if it is not nil,
+// and returns the keys of the inserted rows, plus the rows themselves when chunk
+// sets Returning.
+//
+// insertBatch 执行 chunk 中各行的 INSERT 语句 build（tx 不为 nil 时在事务中执行），并返回插入行的主键；
+// chunk 设置了 Returning 时还返回这些行本身。
+func (e *Executor) insertBatch(ctx context.Context, tx *Transaction, chunk *Query, build *BuildResult, pk string) ([]any, []map[string]any, error) {
+	startTime := time.Now()
+	rows := chunk.DataBatch
+	var keys []any
+
+	if e.dialect.SupportsReturning() {
+		// PostgreSQL: use RETURNING
+		var result queryRows
+		var err error
+		if tx != nil {
+			result, err = tx.queryContext(ctx, build.SQL, build.Params...)
+		} else {
+			result, err = e.db.queryContext(ctx, e.db.sqlDB, build.SQL, build.Params...)
+		}
+		e.db.logQuery(build, startTime, err)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer result.Close()
+
+		if len(chunk.Returning) > 0 {
+			// The new rows come back with the requested columns
+			// 新行带着请求的列返回
+			data, errResult := scanRows(result, e.db.scanFields(chunk))
+			if errResult != nil {
+				return nil, nil, errResult.Err()
+			}
+			return rowKeys(data, pk), data, nil
+		}
+		for result.Next() {
+			var key any
+			if err := result.Scan(&key); err == nil {
+				keys = append(keys, key)
+			}
+		}
+		return keys, nil, result.Err()
+	}
+
+	// MySQL: execute and get last insert ID
+	var result sql.Result
+	var err error
+	if tx != nil {
+		result, err = tx.execContext(ctx, build.SQL, build.Params...)
+	} else {
+		result, err = e.db.execContext(ctx, e.db.sqlDB, build.SQL, build.Params...)
+	}
+	e.db.logQuery(build, startTime, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Rows without a key got consecutive auto-increment ids from LastInsertId on
+	// 未提供主键的行从 LastInsertId 开始获得连续的自增 ID
+	next, _ := result.LastInsertId()
+	for _, row := range rows {
+		if key := row[pk]; key != nil {
+			keys = append(keys, key)
+		} else {
+			keys = append(keys, next)
+			next++
+		}
+	}
+	return keys, nil, nil
+}
+
 // ExecuteUpdate executes an update query.
 // ExecuteUpdate 执行更新查询。
 func (e *Executor) ExecuteUpdate(ctx context.Context, query *Query) *Result {
@@ -296,7 +538,7 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, query *Query) *Result {
 		}
 	}
 
-	return e.executeWriteQuery(ctx, query)
+	return e.executeWithHooks(ctx, query, HookBeforeUpdate, HookAfterUpdate)
 }
 
 // ExecuteDelete executes a delete query.
@@ -321,15 +563,62 @@ func (e *Executor) ExecuteDelete(ctx context.Context, query *Query) *Result {
 		}
 	}
 
-	return e.executeWriteQuery(ctx, query)
+	return e.executeWithHooks(ctx, query, HookBeforeDelete, HookAfterDelete)
+}
+
+// executeWithHooks runs an update or delete query between its before and after hooks.
+// A before_delete hook may turn the delete into an update (soft delete).
+//
+// executeWithHooks 在 before 和 after 钩子之间执行更新或删除查询。
+// before_delete 钩子可以将删除转换为更新（软删除）。
+func (e *Executor) executeWithHooks(ctx context.Context, query *Query, before, after HookType) *Result {
+	if r := e.db.runHooks(ctx, before, query, nil); r != nil {
+		return r
+	}
+	if r := e.db.encodeJSONColumns(query.Table, query.Data); r != nil {
+		return r
+	}
+	e.db.encodeArrayColumns(query.Table, query.Data)
+
+	var r *Result
+	if query.BatchSize > 0 {
+		r = e.executeBatchedWrite(ctx, query)
+	} else {
+		r = e.executeWriteQuery(ctx, query)
+	}
+	if !r.Success {
+		return r
+	}
+	r.SoftDeleted = softDeleted(before, query)
+
+	if hr := e.db.runHooks(ctx, after, query, r); hr != nil {
+		return hr
+	}
+
+	return r
+}
+
+// softDeleted reports whether the before hooks of type before turned a delete query
+// into an update.
+//
+// softDeleted 报告类型为 before 的钩子是否将删除查询转换为了更新。
+func softDeleted(before HookType, query *Query) bool {
+	return before == HookBeforeDelete && query.Action == ActionUpdate
 }
 
 // executeWriteQuery executes an update or delete query.
 // executeWriteQuery 执行更新或删除查询。
 func (e *Executor) executeWriteQuery(ctx context.Context, query *Query) *Result {
+	if query.ReturnIDs {
+		return e.executeWriteReturningIDs(ctx, query)
+	}
 	startTime := time.Now()
 
-	builder := NewSQLBuilder(e.dialect, query)
+	if len(query.Returning) > 0 && !e.dialect.SupportsReturning() {
+		return returningNotSupported(e.dialect)
+	}
+
+	builder := e.db.newBuilder(ctx, query)
 	buildResult, err := builder.Build()
 	if err != nil {
 		return &Result{
@@ -341,19 +630,42 @@ func (e *Executor) executeWriteQuery(ctx context.Context, query *Query) *Result
 		}
 	}
 
-	result, err := e.db.sqlDB.ExecContext(ctx, buildResult.SQL, buildResult.Params...)
-	if err != nil {
-		return e.handleSQLError(err, buildResult)
-	}
+	var r *Result
+	if len(query.Returning) > 0 {
+		// Changed rows come back as a result set
+		// 被修改的行以结果集形式返回
+		rows, err := e.db.queryContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...)
+		e.db.logQuery(buildResult, startTime, err)
+		if err != nil {
+			return e.handleSQLError(err, buildResult)
+		}
+		defer rows.Close()
 
-	affected, _ := result.RowsAffected()
+		data, errResult := scanRows(rows, e.db.scanFields(query))
+		if errResult != nil {
+			return errResult
+		}
+		r = &Result{
+			Success:  true,
+			Data:     data,
+			Count:    int64(len(data)),
+			Affected: int64(len(data)),
+		}
+	} else {
+		result, err := e.db.execContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...)
+		e.db.logQuery(buildResult, startTime, err)
+		if err != nil {
+			return e.handleSQLError(err, buildResult)
+		}
 
-	r := &Result{
-		Success:  true,
-		Affected: affected,
+		affected, _ := result.RowsAffected()
+		r = &Result{
+			Success:  true,
+			Affected: affected,
+		}
 	}
 
-	if query.Debug || e.db.config.Debug {
+	if e.db.debug(ctx, query) {
 		r.Meta = &ResultMeta{
 			SQL:        buildResult.SQL,
 			Params:     buildResult.Params,
@@ -364,12 +676,24 @@ func (e *Executor) executeWriteQuery(ctx context.Context, query *Query) *Result
 	return r
 }
 
-// ExecuteCount executes a count query.
-// ExecuteCount 执行计数查询。
+// ExecuteCount executes a count query. Before-find hooks run first; when they add
+// conditions, an Estimate count is counted exactly.
+//
+// ExecuteCount 执行计数查询。先运行 before-find 钩子；钩子添加了条件时，Estimate 计数改为精确计数。
 func (e *Executor) ExecuteCount(ctx context.Context, query *Query) *Result {
 	startTime := time.Now()
 
-	builder := NewSQLBuilder(e.dialect, query)
+	if r := e.db.runHooks(ctx, HookBeforeFind, query, nil); r != nil {
+		return r
+	}
+
+	if query.Estimate && len(query.Where) == 0 {
+		if r := e.estimateCount(ctx, query, startTime); r != nil {
+			return r
+		}
+	}
+
+	builder := e.db.newBuilder(ctx, query)
 	buildResult, err := builder.Build()
 	if err != nil {
 		return &Result{
@@ -382,7 +706,8 @@ func (e *Executor) ExecuteCount(ctx context.Context, query *Query) *Result {
 	}
 
 	var count int64
-	err = e.db.sqlDB.QueryRowContext(ctx, buildResult.SQL, buildResult.Params...).Scan(&count)
+	err = e.db.queryRowContext(ctx, e.db.reader(ctx, query), buildResult.SQL, buildResult.Params...).Scan(&count)
+	e.db.logQuery(buildResult, startTime, err)
 	if err != nil {
 		return e.handleSQLError(err, buildResult)
 	}
@@ -392,7 +717,198 @@ func (e *Executor) ExecuteCount(ctx context.Context, query *Query) *Result {
 		Count:   count,
 	}
 
-	if query.Debug || e.db.config.Debug {
+	if e.db.debug(ctx, query) {
+		r.Meta = &ResultMeta{
+			SQL:        buildResult.SQL,
+			Params:     buildResult.Params,
+			DurationMs: float64(time.Since(startTime).Microseconds()) / 1000,
+		}
+	}
+
+	return r
+}
+
+// estimateCount returns the database's row estimate for the table of query, or nil
+// when the dialect or the table has none and the rows must be counted.
+//
+// estimateCount 返回数据库对 query 所在表的行数估计；方言或表没有估计值、必须逐行计数时返回 nil。
+func (e *Executor) estimateCount(ctx context.Context, query *Query, startTime time.Time) *Result {
+	sqlStr, params := e.db.dialect.RowEstimate(SchemaFromContext(ctx), query.Table)
+	if sqlStr == "" {
+		return nil
+	}
+	build := &BuildResult{SQL: sqlStr, Params: params}
+
+	var estimate sql.NullInt64
+	err := e.db.queryRowContext(ctx, e.db.reader(ctx, query), sqlStr, params...).Scan(&estimate)
+	e.db.logQuery(build, startTime, err)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return e.handleSQLError(err, build)
+	}
+	// Postgres reports -1 for tables that were never analyzed
+	// Postgres 对从未分析过的表报告 -1
+	if !estimate.Valid || estimate.Int64 < 0 {
+		return nil
+	}
+
+	r := &Result{
+		Success: true,
+		Count:   estimate.Int64,
+		Meta:    &ResultMeta{Estimated: true},
+	}
+	if e.db.debug(ctx, query) {
+		r.Meta.SQL = sqlStr
+		r.Meta.Params = params
+		r.Meta.DurationMs = float64(time.Since(startTime).Microseconds()) / 1000
+	}
+	return r
+}
+
+// warnLock logs a warning when a row lock has no effect: outside a transaction
+// or on a dialect without row locking.
+//
+// warnLock 在行锁无效时记录警告：不在事务中或方言不支持行锁。
+func (db *DB) warnLock(query *Query, inTx bool) {
+	if query.Lock == "" {
+		return
+	}
+	if !inTx {
+		db.Logger().Warn("row lock has no effect outside a transaction",
+			"table", query.Table,
+			"lock", query.Lock,
+		)
+		return
+	}
+	if db.dialect.LockClause(query.Lock) == "" {
+		db.Logger().Warn("row lock is not supported by dialect, ignoring",
+			"dialect", db.dialect.Name(),
+			"table", query.Table,
+			"lock", query.Lock,
+		)
+	}
+}
+
+// checkOrderPriority returns query with the priority lists of order_by items whose
+// field is not a column of a registered model dropped, so those items sort by the
+// plain field instead.
+//
+// checkOrderPriority 返回去除了字段不是已注册模型列的 order_by 项优先级列表的查询，这些项改为按字段本身排序。
+func (db *DB) checkOrderPriority(query *Query) *Query {
+	var checked *Query
+	for i, o := range query.OrderBy {
+		if len(o.Priority) == 0 || db.columnGoType(query.Table, o.Field) != "" {
+			continue
+		}
+		db.Logger().Warn("order_by priority ignored: field is not a column of a registered model",
+			"table", query.Table,
+			"field", o.Field,
+		)
+		if checked == nil {
+			copied := *query
+			copied.OrderBy = append([]Order(nil), query.OrderBy...)
+			checked = &copied
+		}
+		checked.OrderBy[i].Priority = nil
+	}
+	if checked == nil {
+		return query
+	}
+	return checked
+}
+
+// withDefaultOrder returns query sorted by the default order of its model when it
+// has no OrderBy. Grouped and aggregate queries are left unsorted.
+//
+// withDefaultOrder 在查询没有 OrderBy 时返回按其模型默认排序的查询。分组和聚合查询不做排序。
+func (db *DB) withDefaultOrder(query *Query) *Query {
+	if len(query.OrderBy) > 0 || query.NoDefaultOrder || len(query.GroupBy) > 0 || db.registry == nil {
+		return query
+	}
+	if query.Action != ActionFind && query.Action != "" {
+		return query
+	}
+	for _, sel := range query.Select {
+		if _, ok := sel.(string); !ok {
+			return query
+		}
+	}
+	meta, ok := db.registry.Get(query.Table)
+	if !ok || len(meta.DefaultOrder) == 0 {
+		return query
+	}
+
+	// Qualify the columns when joins could make them ambiguous
+	// 当 JOIN 可能导致列名歧义时限定列名
+	ordered := *query
+	ordered.OrderBy = make([]Order, len(meta.DefaultOrder))
+	for i, order := range meta.DefaultOrder {
+		if len(query.Join) > 0 {
+			order.Field = query.Table + "." + order.Field
+		}
+		ordered.OrderBy[i] = order
+	}
+	return &ordered
+}
+
+// ExecuteExists executes an exists query. Result.Count is 1 when a row matches
+// and 0 otherwise. Before-find hooks run first, so hooks scoping finds also scope it.
+//
+// ExecuteExists 执行存在性查询。有行匹配时 Result.Count 为 1，否则为 0。
+// 先运行 before-find 钩子，因此限定查找的钩子同样限定它。
+func (e *Executor) ExecuteExists(ctx context.Context, query *Query) *Result {
+	startTime := time.Now()
+
+	if r := e.db.runHooks(ctx, HookBeforeFind, query, nil); r != nil {
+		return r
+	}
+
+	buildResult, err := e.db.newBuilder(ctx, query).Build()
+	if err != nil {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "BUILD_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	var exists any
+	err = e.db.queryRowContext(ctx, e.db.reader(ctx, query), buildResult.SQL, buildResult.Params...).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		// MySQL form returns no row when nothing matches
+		// MySQL 形式在没有匹配时不返回行
+		err, exists = nil, false
+	}
+	e.db.logQuery(buildResult, startTime, err)
+	if err != nil {
+		return e.handleSQLError(err, buildResult)
+	}
+
+	r := &Result{Success: true}
+	switch v := exists.(type) {
+	case bool:
+		if v {
+			r.Count = 1
+		}
+	case int64:
+		if v != 0 {
+			r.Count = 1
+		}
+	case []byte:
+		if string(v) != "0" && string(v) != "f" && string(v) != "false" {
+			r.Count = 1
+		}
+	default:
+		if exists != nil {
+			r.Count = 1
+		}
+	}
+
+	if e.db.debug(ctx, query) {
 		r.Meta = &ResultMeta{
 			SQL:        buildResult.SQL,
 			Params:     buildResult.Params,
@@ -411,78 +927,49 @@ func (e *Executor) ExecuteAggregate(ctx context.Context, query *Query) *Result {
 	return e.ExecuteFind(ctx, query)
 }
 
-// getAffectedCount gets the count of rows that would be affected.
-// getAffectedCount 获取将受影响的行数。
+// getAffectedCount gets the count of rows that would be affected, scoped by the
+// before-find hooks like any other count.
+// getAffectedCount 获取将受影响的行数，与其他计数一样由 before-find 钩子限定。
 func (e *Executor) getAffectedCount(ctx context.Context, query *Query) (int64, error) {
 	countQuery := &Query{
 		Table:  query.Table,
 		Action: ActionCount,
 		Where:  query.Where,
 	}
+	if r := e.db.runHooks(ctx, HookBeforeFind, countQuery, nil); r != nil {
+		return 0, r.Err()
+	}
 
-	builder := NewSQLBuilder(e.dialect, countQuery)
+	builder := e.db.newBuilder(ctx, countQuery)
 	buildResult, err := builder.Build()
 	if err != nil {
 		return 0, err
 	}
 
 	var count int64
-	err = e.db.sqlDB.QueryRowContext(ctx, buildResult.SQL, buildResult.Params...).Scan(&count)
+	err = e.db.queryRowContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...).Scan(&count)
 	return count, err
 }
 
-// handleSQLError converts SQL errors to Result errors.
-// handleSQLError 将 SQL 错误转换为 Result 错误。
+// handleSQLError converts SQL errors to Result errors, classified by classifySQLError.
+// handleSQLError 将 SQL 错误转换为 Result 错误，由 classifySQLError 分类。
 func (e *Executor) handleSQLError(err error, buildResult *BuildResult) *Result {
-	code := "SQL_ERROR"
-	message := err.Error()
-	suggestion := ""
-
-	// Detect specific error types
-	// 检测特定错误类型
-	errStr := err.Error()
-
-	switch {
-	case containsAny(errStr, []string{"duplicate key", "Duplicate entry", "unique constraint"}):
-		code = "DUPLICATE_KEY"
-		suggestion = "使用 UPDATE 而不是 INSERT，或检查唯一约束 / Use UPDATE instead of INSERT, or check unique constraint"
-
-	case containsAny(errStr, []string{"foreign key", "FOREIGN KEY", "a]"}):
-		code = "FK_VIOLATION"
-		suggestion = "确保引用的记录存在 / Ensure the referenced record exists"
-
-	case containsAny(errStr, []string{"column", "does not exist", "Unknown column"}):
-		code = "INVALID_COLUMN"
-		suggestion = "检查列名拼写 / Check column name spelling"
-
-	case containsAny(errStr, []string{"table", "does not exist", "doesn't exist"}):
-		code = "TABLE_NOT_FOUND"
-		suggestion = "运行 db.AutoSync() 创建表 / Run db.AutoSync() to create table"
-
-	case containsAny(errStr, []string{"syntax error", "near"}):
-		code = "SYNTAX_ERROR"
-		suggestion = "检查 JQL 语法 / Check JQL syntax"
-
-	case containsAny(errStr, []string{"timeout", "deadline exceeded"}):
-		code = "TIMEOUT"
-		suggestion = "增加超时时间或优化查询 / Increase timeout or optimize query"
-
-	case containsAny(errStr, []string{"connection refused", "no connection"}):
-		code = "CONNECTION_ERROR"
-		suggestion = "检查数据库连接 / Check database connection"
+	code := classifySQLError(err)
+	resultErr := &ResultError{
+		Code:       code,
+		Message:    err.Error(),
+		Suggestion: sqlErrorSuggestions[code],
+		Details: map[string]any{
+			"sql":    buildResult.SQL,
+			"params": buildResult.Params,
+		},
+	}
+	if code == "INVALID_COLUMN" {
+		e.db.suggestColumn(resultErr, buildResult.query, err.Error())
 	}
-
 	return &Result{
 		Success: false,
-		Error: &ResultError{
-			Code:       code,
-			Message:    message,
-			Suggestion: suggestion,
-			Details: map[string]any{
-				"sql":    buildResult.SQL,
-				"params": buildResult.Params,
-			},
-		},
+		Error:   resultErr,
 	}
 }
 
@@ -502,9 +989,149 @@ func containsAny(s string, subs []string) bool {
 }
 
 // generateConfirmToken generates a confirmation token.
+// Tokens gate destructive operations, so they must not be guessable.
+//
 // generateConfirmToken 生成确认令牌。
+// 令牌用于控制破坏性操作，因此必须不可猜测。
 func generateConfirmToken() string {
-	// Simple implementation - in production use crypto/rand
-	// 简单实现 - 生产环境使用 crypto/rand
-	return fmt.Sprintf("confirm_%d", time.Now().UnixNano())
+	return randomID("confirm_")
+}
+
+// randomID returns prefix followed by a random 128-bit hex string.
+// randomID 返回前缀加上随机的 128 位十六进制字符串。
+func randomID(prefix string) string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand should never fail; fall back to a timestamp so callers still get an ID
+		// crypto/rand 不应失败；回退到时间戳以保证调用方仍能获得 ID
+		return fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	}
+	return prefix + hex.EncodeToString(b[:])
+}
+
+// insertedKey normalizes a primary key returned by an insert and returns its
+// numeric form, or 0 when the key is not a non-negative integer.
+//
+// insertedKey 规范化插入返回的主键，并返回其数字形式；若主键不是非负整数则返回 0。
+func insertedKey(key any) (any, uint64) {
+	if b, ok := key.([]byte); ok {
+		key = string(b)
+	}
+	switch k := key.(type) {
+	case int64:
+		if k >= 0 {
+			return k, uint64(k)
+		}
+	case int:
+		if k >= 0 {
+			return k, uint64(k)
+		}
+	case int32:
+		if k >= 0 {
+			return k, uint64(k)
+		}
+	case uint64:
+		return k, k
+	case uint:
+		return k, uint64(k)
+	case uint32:
+		return k, uint64(k)
+	}
+	return key, 0
+}
+
+// markAutoLimited records an optimizer-injected limit in the result meta,
+// warning when the result may have been truncated by it.
+//
+// markAutoLimited 在结果元信息中记录优化器注入的限制，并在结果可能因此被截断时给出警告。
+func markAutoLimited(result *Result, limit, rows int) {
+	if result.Meta == nil {
+		result.Meta = &ResultMeta{}
+	}
+	result.Meta.AutoLimit = limit
+	if rows >= limit {
+		result.Meta.Truncated = true
+		result.Meta.Warnings = append(result.Meta.Warnings,
+			fmt.Sprintf("result capped at %d rows by the optimizer's default limit; more rows may exist", limit))
+	}
+}
+
+// scanRows reads every row into maps of column name to value. Columns that are
+// fields, which may be nil, scan through destinations of the field's type, so
+// NULL reads as nil only for Nullable fields and as the zero value otherwise.
+//
+// scanRows 将每一行读取为列名到值的映射。属于 fields（可以为 nil）的列通过字段类型的目标扫描，
+// 因此只有可为 NULL 的字段将 NULL 读取为 nil，其他字段读取为零值。
+func scanRows(rows queryRows, fields map[string]*FieldMeta) ([]map[string]any, *Result) {
+	// Get column names
+	// 获取列名
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "COLUMN_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	// Scan rows
+	// 扫描行
+	types, zero := fieldScanTypes(columns, fields)
+	data := make([]map[string]any, 0)
+	for rows.Next() {
+		// NULL-safe destinations to hold column values
+		// 用于保存列值的 NULL 安全扫描目标
+		dests := nullSafeDests(columns, types)
+		if err := rows.Scan(dests...); err != nil {
+			return nil, &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:    "SCAN_ERROR",
+					Message: err.Error(),
+				},
+			}
+		}
+
+		// Convert to map
+		// 转换为 map
+		values := nullSafeValues(dests, zero)
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			val := values[i]
+			// Handle []byte conversion to string
+			// 处理 []byte 到 string 的转换
+			if b, ok := val.([]byte); ok {
+				val = string(b)
+			}
+			row[col] = val
+		}
+		data = append(data, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "ROWS_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	return data, nil
+}
+
+// returningNotSupported reports that dialect cannot return changed rows.
+// returningNotSupported 报告方言无法返回被修改的行。
+func returningNotSupported(dialect Dialect) *Result {
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:       "RETURNING_NOT_SUPPORTED",
+			Message:    fmt.Sprintf("%s does not support RETURNING", dialect.Name()),
+			Suggestion: "Remove returning and query the rows separately",
+		},
+	}
 }
@@ -0,0 +1,1219 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRandomIDUnique tests that random IDs do not collide under concurrency.
+// TestRandomIDUnique 测试随机 ID 在并发下不会冲突。
+func TestRandomIDUnique(t *testing.T) {
+	const goroutines = 50
+	const perGoroutine = 200
+
+	var mu sync.Mutex
+	seen := make(map[string]bool, goroutines*perGoroutine)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]string, perGoroutine)
+			for i := range ids {
+				ids[i] = randomID("tx_")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range ids {
+				if seen[id] {
+					t.Errorf("duplicate ID generated: %s", id)
+				}
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("expected %d unique IDs, got %d", goroutines*perGoroutine, len(seen))
+	}
+}
+
+// TestRandomIDFormat tests the random ID format.
+// TestRandomIDFormat 测试随机 ID 的格式。
+func TestRandomIDFormat(t *testing.T) {
+	id := generateConfirmToken()
+	if !strings.HasPrefix(id, "confirm_") {
+		t.Errorf("expected confirm_ prefix, got %s", id)
+	}
+
+	// 128 bits = 32 hex characters
+	// 128 位 = 32 个十六进制字符
+	if hexPart := strings.TrimPrefix(id, "confirm_"); len(hexPart) != 32 {
+		t.Errorf("expected 32 hex characters, got %d (%s)", len(hexPart), hexPart)
+	}
+
+	if !strings.HasPrefix(generateTransactionID(), "tx_") {
+		t.Error("transaction ID should have tx_ prefix")
+	}
+}
+
+type defaultedTicket struct {
+	ID       string    `json:"id" goorm:"primaryKey;uuid"`
+	Title    string    `json:"title"`
+	Status   string    `json:"status" goorm:"default:'open'"`
+	Priority int       `json:"priority" goorm:"default:3"`
+	OpenedAt time.Time `json:"opened_at" goorm:"autoCreateTime"`
+	Note     *string   `json:"note"`
+}
+
+// TestCreateAppliesModelDefaults tests default filling and required fields on create.
+// TestCreateAppliesModelDefaults 测试创建时的默认值填充和必填字段。
+func TestCreateAppliesModelDefaults(t *testing.T) {
+	var args []driver.NamedValue
+	db, backend := newFakeDB(t, &MySQLDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		return nil, nil, nil
+	})
+	if err := db.Register(&defaultedTicket{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	result := db.ExecuteQuery(ctx, &Query{
+		Table:  "defaulted_tickets",
+		Action: ActionCreate,
+		Data:   map[string]any{"title": "Broken login"},
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+
+	queries := backend.Queries()
+	if len(queries) != 1 {
+		t.Fatalf("expected 1 statement, got %v", queries)
+	}
+	values := map[string]any{}
+	for _, col := range []string{"id", "title", "status", "priority", "opened_at"} {
+		i := strings.Index(queries[0], "`"+col+"`")
+		if i < 0 {
+			t.Fatalf("column %s missing from %s", col, queries[0])
+		}
+		values[col] = args[strings.Count(queries[0][:i], ",")].Value
+	}
+	if id, _ := values["id"].(string); len(id) != 36 || id[14] != '4' {
+		t.Errorf("expected a v4 UUID, got %v", values["id"])
+	}
+	if values["status"] != "open" || values["priority"] != int64(3) {
+		t.Errorf("unexpected defaults: %v", values)
+	}
+	if _, ok := values["opened_at"].(time.Time); !ok {
+		t.Errorf("expected opened_at to be set, got %v", values["opened_at"])
+	}
+	if strings.Contains(queries[0], "`note`") {
+		t.Errorf("nullable column should not be filled: %s", queries[0])
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "defaulted_tickets",
+		Action: ActionCreate,
+		Data:   map[string]any{"status": "closed"},
+	})
+	if result.Success || result.Error.Code != "MISSING_REQUIRED_FIELD" {
+		t.Fatalf("expected MISSING_REQUIRED_FIELD, got %+v", result)
+	}
+	if fields := result.Error.Details["fields"].([]string); len(fields) != 1 || fields[0] != "title" {
+		t.Errorf("unexpected missing fields: %v", fields)
+	}
+}
+
+// TestDefaultLiteral tests conversion of SQL default literals.
+// TestDefaultLiteral 测试 SQL 默认字面量的转换。
+func TestDefaultLiteral(t *testing.T) {
+	tests := []struct {
+		in   string
+		want any
+		ok   bool
+	}{
+		{"'it''s'", "it's", true},
+		{"TRUE", true, true},
+		{"42", int64(42), true},
+		{"1.5", 1.5, true},
+		{"CURRENT_TIMESTAMP", nil, false},
+		{"NULL", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := defaultLiteral(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("defaultLiteral(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type uuidDocument struct {
+	ID    string `json:"id" goorm:"primaryKey;uuid"`
+	Title string `json:"title"`
+}
+
+// TestCreateWithUUIDPrimaryKey tests inserts into a table keyed by a UUID string.
+// TestCreateWithUUIDPrimaryKey 测试向以 UUID 字符串为主键的表插入数据。
+func TestCreateWithUUIDPrimaryKey(t *testing.T) {
+	const key = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{[]byte(key)}}, nil
+	})
+	if err := db.Register(&uuidDocument{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := db.ExecuteQuery(context.Background(), &Query{
+		Table:  "uuid_documents",
+		Action: ActionCreate,
+		Data:   map[string]any{"id": key, "title": "Spec"},
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+	if result.InsertedKey != key || result.ID != 0 {
+		t.Errorf("InsertedKey = %v, ID = %d; want %s, 0", result.InsertedKey, result.ID, key)
+	}
+	if q := backend.Queries(); !strings.HasSuffix(q[0], `RETURNING "id"`) {
+		t.Errorf("unexpected SQL: %s", q[0])
+	}
+
+	mysqlDB, _ := newFakeDB(t, &MySQLDialect{}, nil)
+	if err := mysqlDB.Register(&uuidDocument{}); err != nil {
+		t.Fatal(err)
+	}
+	result = mysqlDB.ExecuteQuery(context.Background(), &Query{
+		Table:  "uuid_documents",
+		Action: ActionCreate,
+		Data:   map[string]any{"title": "Generated"},
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+	if generated, _ := result.InsertedKey.(string); len(generated) != 36 {
+		t.Errorf("expected generated UUID as InsertedKey, got %v", result.InsertedKey)
+	}
+}
+
+// TestFindMarksAutoLimitedResult tests that a result capped by an injected limit is flagged.
+// TestFindMarksAutoLimitedResult 测试被注入限制截断的结果会被标记。
+func TestFindMarksAutoLimitedResult(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}, {int64(2)}}, nil
+	})
+
+	optimizer := NewQueryOptimizer(db)
+	optimizer.SetDefaultLimit(2)
+	query := optimizer.Optimize(&Query{Table: "users", Action: ActionFind})
+
+	result := db.ExecuteQuery(context.Background(), query)
+	if !result.Success {
+		t.Fatalf("find failed: %v", result.Error)
+	}
+	if result.Meta == nil || result.Meta.AutoLimit != 2 || !result.Meta.Truncated {
+		t.Fatalf("expected truncated meta, got %+v", result.Meta)
+	}
+	if len(result.Meta.Warnings) != 1 {
+		t.Errorf("expected one warning, got %v", result.Meta.Warnings)
+	}
+
+	optimizer.SetDefaultLimit(5)
+	result = db.ExecuteQuery(context.Background(), optimizer.Optimize(&Query{Table: "users", Action: ActionFind}))
+	if result.Meta == nil || result.Meta.AutoLimit != 5 || result.Meta.Truncated {
+		t.Errorf("expected untruncated meta, got %+v", result.Meta)
+	}
+}
+
+type orderedEvent struct {
+	Model `goorm:"order:created_at desc,id"`
+	Name  string `json:"name"`
+}
+
+type badlyOrderedEvent struct {
+	Model `goorm:"order:missing desc"`
+}
+
+// TestFindAppliesDefaultOrder tests the model default order of find queries.
+// TestFindAppliesDefaultOrder 测试查找查询的模型默认排序。
+func TestFindAppliesDefaultOrder(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, nil)
+	if err := db.Register(&orderedEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Register(&badlyOrderedEvent{}); err == nil {
+		t.Error("expected an error for a default order on an unknown column")
+	}
+
+	ctx := context.Background()
+	queries := []*Query{
+		{Table: "ordered_events", Action: ActionFind},
+		{Table: "ordered_events", Action: ActionFind, OrderBy: []Order{{Field: "name"}}},
+		{Table: "ordered_events", Action: ActionFind, NoDefaultOrder: true},
+		{Table: "ordered_events", Action: ActionFind, GroupBy: []string{"name"}, Select: []any{"name"}},
+	}
+	for _, query := range queries {
+		if result := db.ExecuteQuery(ctx, query); !result.Success {
+			t.Fatalf("find failed: %+v", result.Error)
+		}
+	}
+	if queries[0].OrderBy != nil {
+		t.Error("the default order should not modify the caller's query")
+	}
+
+	sqls := backend.Queries()
+	if !strings.HasSuffix(sqls[0], `ORDER BY "created_at" DESC, "id" ASC`) {
+		t.Errorf("expected the default order: %s", sqls[0])
+	}
+	if !strings.HasSuffix(sqls[1], `ORDER BY "name" ASC`) {
+		t.Errorf("an explicit order should replace the default: %s", sqls[1])
+	}
+	for _, sql := range sqls[2:] {
+		if strings.Contains(sql, "ORDER BY") {
+			t.Errorf("expected no ORDER BY: %s", sql)
+		}
+	}
+
+	// Finds in a transaction are ordered the same way
+	// 事务中的查询以相同方式排序
+	tx := db.ExecuteQuery(ctx, &Query{Action: ActionTransaction, Operations: []Query{*queries[0]}})
+	if !tx.Success {
+		t.Fatalf("transaction failed: %+v", tx.Error)
+	}
+	if sql := backend.Queries()[len(sqls)]; sql != sqls[0] {
+		t.Errorf("expected the default order in a transaction, got %s", sql)
+	}
+}
+
+// smallParamsDialect is Postgres with a tiny parameter limit, to force chunking.
+// smallParamsDialect 是参数上限很小的 Postgres 方言，用于强制分块。
+type smallParamsDialect struct {
+	PostgresDialect
+}
+
+func (d *smallParamsDialect) MaxParams() int { return 10 }
+
+// TestCreateBatchChunks tests that a batch over the parameter limit is split into chunks.
+// TestCreateBatchChunks 测试超过参数上限的批量会被拆分为多个分块。
+func TestCreateBatchChunks(t *testing.T) {
+	if got := batchChunkSize(make([]map[string]any, 100000), (&PostgresDialect{}).MaxParams()); got != 65535 {
+		t.Errorf("expected 65535 single-column rows per chunk, got %d", got)
+	}
+
+	var nextID int64
+	db, backend := newFakeDB(t, &smallParamsDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if len(args) > 10 {
+			return nil, nil, fmt.Errorf("too many parameters: %d", len(args))
+		}
+		var rows [][]driver.Value
+		for i := 0; i < len(args)/3; i++ {
+			nextID++
+			rows = append(rows, []driver.Value{nextID})
+		}
+		return []string{"id"}, rows, nil
+	})
+
+	batch := make([]map[string]any, 7)
+	for i := range batch {
+		batch[i] = map[string]any{"name": fmt.Sprint(i), "kind": "click", "source": "web"}
+	}
+	result := db.ExecuteQuery(context.Background(), &Query{Table: "events", Action: ActionCreateBatch, DataBatch: batch})
+	if !result.Success {
+		t.Fatalf("batch create failed: %+v", result.Error)
+	}
+	if result.Affected != 7 || len(result.IDs) != 7 || result.IDs[6] != 7 {
+		t.Errorf("expected 7 rows with IDs 1-7, got %d affected, IDs %v", result.Affected, result.IDs)
+	}
+	if queries := backend.Queries(); len(queries) != 3 {
+		t.Errorf("expected chunks of 3, 3 and 1 rows, got %d statements", len(queries))
+	}
+}
+
+// TestCreateBatchColumnRuns tests that on SQLite a batch runs one statement per run
+// of records with the same columns, so missing columns take their defaults.
+// TestCreateBatchColumnRuns 测试在 SQLite 上批量按列相同的连续记录分段、每段一条语句执行，使缺失的列使用其默认值。
+func TestCreateBatchColumnRuns(t *testing.T) {
+	var nextID int64
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		var rows [][]driver.Value
+		for i := 0; i <= strings.Count(query, "), ("); i++ {
+			nextID++
+			rows = append(rows, []driver.Value{nextID})
+		}
+		return []string{"id"}, rows, nil
+	})
+
+	result := db.ExecuteQuery(context.Background(), &Query{Table: "events", Action: ActionCreateBatch, DataBatch: []map[string]any{
+		{"name": "a"},
+		{"name": "b"},
+		{"name": "c", "kind": "click"},
+		{"name": "d"},
+	}})
+	if !result.Success {
+		t.Fatalf("batch create failed: %+v", result.Error)
+	}
+	want := []string{
+		`INSERT INTO "events" ("name") VALUES (?), (?) RETURNING "id"`,
+		`INSERT INTO "events" ("kind", "name") VALUES (?, ?) RETURNING "id"`,
+		`INSERT INTO "events" ("name") VALUES (?) RETURNING "id"`,
+	}
+	if queries := backend.Queries(); !slices.Equal(queries, want) {
+		t.Errorf("expected one statement per run of columns:\n got %v\nwant %v", queries, want)
+	}
+	if !slices.Equal(result.IDs, []uint64{1, 2, 3, 4}) {
+		t.Errorf("expected the ids in record order, got %v", result.IDs)
+	}
+}
+
+// TestCreateBatchMixedKeys tests that on MySQL rows with and without a primary key
+// are inserted by separate statements, so each row reports its own key.
+// TestCreateBatchMixedKeys 测试在 MySQL 上有主键和无主键的行由不同语句插入，使每行报告各自的主键。
+func TestCreateBatchMixedKeys(t *testing.T) {
+	db, backend := newFakeDB(t, &MySQLDialect{}, nil)
+
+	result := db.ExecuteQuery(context.Background(), &Query{Table: "events", Action: ActionCreateBatch, DataBatch: []map[string]any{
+		{"name": "a"},
+		{"id": int64(50), "name": "b"},
+		{"id": int64(51), "name": "c"},
+		{"name": "d"},
+	}})
+	if !result.Success {
+		t.Fatalf("batch create failed: %+v", result.Error)
+	}
+	if queries := backend.Queries(); len(queries) != 3 {
+		t.Errorf("expected one statement per run of given or generated keys, got %v", queries)
+	}
+	// The fake driver reports 1 as the first generated id of every statement
+	// 假驱动对每条语句都报告 1 为第一个生成的 ID
+	if !slices.Equal(result.IDs, []uint64{1, 50, 51, 1}) {
+		t.Errorf("expected the given keys and the generated ids, got %v", result.IDs)
+	}
+}
+
+// TestCreateBatchReturning tests that a batch with returning gets the new rows back
+// from every statement, and that MySQL rejects it.
+// TestCreateBatchReturning 测试设置了 returning 的批量从每条语句取回新行，且 MySQL 会拒绝该请求。
+func TestCreateBatchReturning(t *testing.T) {
+	var nextID int64
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		var rows [][]driver.Value
+		for i := 0; i <= strings.Count(query, "), ("); i++ {
+			nextID++
+			rows = append(rows, []driver.Value{nextID, "2024-03-01"})
+		}
+		return []string{"id", "created_at"}, rows, nil
+	})
+
+	query := &Query{Table: "events", Action: ActionCreateBatch, Returning: []string{"created_at"}, DataBatch: []map[string]any{
+		{"name": "a"},
+		{"name": "b", "kind": "click"},
+	}}
+	result := db.ExecuteQuery(context.Background(), query)
+	if !result.Success {
+		t.Fatalf("batch create failed: %+v", result.Error)
+	}
+	if queries := backend.Queries(); len(queries) != 2 || !strings.HasSuffix(queries[0], `RETURNING "id", "created_at"`) {
+		t.Errorf("expected two statements returning the key and created_at, got %v", queries)
+	}
+	if len(result.Data) != 2 || result.Data[1]["created_at"] != "2024-03-01" {
+		t.Errorf("expected both new rows in data, got %v", result.Data)
+	}
+	if !slices.Equal(result.IDs, []uint64{1, 2}) {
+		t.Errorf("expected ids 1 and 2, got %v", result.IDs)
+	}
+
+	mysql, _ := newFakeDB(t, &MySQLDialect{}, nil)
+	result = mysql.ExecuteQuery(context.Background(), query)
+	if result.Success || result.Error.Code != "RETURNING_NOT_SUPPORTED" {
+		t.Errorf("expected RETURNING_NOT_SUPPORTED on MySQL, got %+v", result.Error)
+	}
+}
+
+// TestCreateBatchLeavesRows tests that the hooks of a batch create do not write to
+// the caller's rows.
+// TestCreateBatchLeavesRows 测试批量创建的钩子不会写入调用方的行。
+func TestCreateBatchLeavesRows(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, nil)
+	db.HookGlobal(HookBeforeCreate, func(ctx *HookContext) error {
+		ctx.Data["stamped"] = true
+		return nil
+	})
+
+	query := &Query{Table: "events", Action: ActionCreateBatch, DataBatch: []map[string]any{{"name": "a"}, {"name": "b"}}}
+	for i := 0; i < 2; i++ {
+		if result := db.ExecuteQuery(context.Background(), query); !result.Success {
+			t.Fatalf("batch create failed: %+v", result.Error)
+		}
+		for _, row := range query.DataBatch {
+			if len(row) != 1 {
+				t.Fatalf("the caller's row was written to: %v", row)
+			}
+		}
+	}
+	if queries := backend.Queries(); len(queries) != 2 || queries[0] != queries[1] || !strings.Contains(queries[0], `"stamped"`) {
+		t.Errorf("expected the batch to run twice unchanged with the hook's column, got %v", queries)
+	}
+}
+
+// TestCreateBatchUnknownColumn tests that a batch record with a column outside the model is rejected.
+// TestCreateBatchUnknownColumn 测试包含模型外列的批量记录会被拒绝。
+func TestCreateBatchUnknownColumn(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, nil)
+	if err := db.Register(&orderedEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := db.ExecuteQuery(context.Background(), &Query{
+		Table:  "ordered_events",
+		Action: ActionCreateBatch,
+		DataBatch: []map[string]any{
+			{"name": "signup"},
+			{"name": "login", "nmae": "typo"},
+		},
+	})
+	if result.Success || result.Error.Code != "INVALID_COLUMN" {
+		t.Fatalf("expected INVALID_COLUMN, got %+v", result.Error)
+	}
+	if result.Error.Details["record"] != 1 || result.Error.Details["column"] != "nmae" {
+		t.Errorf("unexpected details: %v", result.Error.Details)
+	}
+	if queries := backend.Queries(); len(queries) != 0 {
+		t.Errorf("no SQL should run, got %v", queries)
+	}
+}
+
+// TestFindOrderPriorityChecksColumns tests that priority ordering only applies to model columns.
+// TestFindOrderPriorityChecksColumns 测试优先级排序仅应用于模型列。
+func TestFindOrderPriorityChecksColumns(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, nil)
+	if err := db.Register(&orderedEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, field := range []string{"name", "missing"} {
+		result := db.ExecuteQuery(ctx, &Query{
+			Table:   "ordered_events",
+			Action:  ActionFind,
+			OrderBy: []Order{{Field: field, Priority: []any{"signup"}}},
+		})
+		if !result.Success {
+			t.Fatalf("find failed: %+v", result.Error)
+		}
+	}
+
+	queries := backend.Queries()
+	if !strings.HasSuffix(queries[0], `ORDER BY CASE "name" WHEN $1 THEN 0 ELSE 1 END ASC`) {
+		t.Errorf("expected a priority CASE: %s", queries[0])
+	}
+	if !strings.HasSuffix(queries[1], `ORDER BY "missing" ASC`) {
+		t.Errorf("an unknown column should fall back to plain ordering: %s", queries[1])
+	}
+}
+
+type jsonProfile struct {
+	ID       int64          `json:"id" goorm:"primaryKey;autoIncrement"`
+	Metadata map[string]any `json:"metadata" goorm:"type:jsonb"`
+	Tags     []string       `json:"tags" goorm:"type:json"`
+	Avatar   []byte         `json:"avatar"`
+}
+
+type arrayArticle struct {
+	ID     uint64   `json:"id" goorm:"primaryKey;autoIncrement"`
+	Tags   []string `json:"tags" goorm:"array"`
+	Scores []int64  `json:"scores" goorm:"array"`
+}
+
+// TestPostgresArrayColumns tests that slices are bound as Postgres arrays and scanned back.
+// TestPostgresArrayColumns 测试切片作为 Postgres 数组绑定并扫描回切片。
+func TestPostgresArrayColumns(t *testing.T) {
+	var args []driver.NamedValue
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"id", "tags", "scores"}, [][]driver.Value{
+				{int64(1), []byte(`{go,"a,b",NULL}`), []byte("{3,5}")},
+			}, nil
+		}
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	if err := db.Register(&arrayArticle{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result := db.ExecuteQuery(ctx, &Query{
+		Table:  "array_articles",
+		Action: ActionCreate,
+		Data:   map[string]any{"tags": []string{"go", "a,b"}, "scores": []int64{3, 5}},
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+	var values []any
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	for _, want := range []any{`{"go","a,b"}`, "{3,5}"} {
+		if !slices.Contains(values, want) {
+			t.Errorf("expected %s among the params, got %v", want, values)
+		}
+	}
+
+	db.config.CoerceTypes = true
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "array_articles",
+		Action: ActionFind,
+		Where:  []Condition{{Field: "scores", Op: OpArrayContains, Value: []any{"3"}}},
+	})
+	if !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+	if q := backend.Queries(); !strings.Contains(q[len(q)-1], `"scores" @> $1`) || args[0].Value != "{3}" {
+		t.Errorf("expected a containment test on scores, got %v %v", q[len(q)-1], args)
+	}
+	if tags, ok := result.Data[0]["tags"].([]string); !ok || !slices.Equal(tags, []string{"go", "a,b", ""}) {
+		t.Errorf("expected tags decoded to []string, got %#v", result.Data[0]["tags"])
+	}
+	if scores, ok := result.Data[0]["scores"].([]int64); !ok || !slices.Equal(scores, []int64{3, 5}) {
+		t.Errorf("expected scores decoded to []int64, got %#v", result.Data[0]["scores"])
+	}
+}
+
+type fixContact struct {
+	ID       uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+// TestInvalidColumnAutoFix tests that unknown column errors suggest a corrected query.
+// TestInvalidColumnAutoFix 测试未知列错误会建议修正后的查询。
+func TestInvalidColumnAutoFix(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, `"name"`) {
+			return nil, nil, fmt.Errorf("no such column: name")
+		}
+		return nil, nil, fmt.Errorf("no such column: phone")
+	})
+	if err := db.Register(&fixContact{}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := &Query{
+		Table:   "fix_contacts",
+		Action:  ActionFind,
+		Select:  []any{"id", "name"},
+		Where:   []Condition{{OrGroup: []Condition{{Field: "fix_contacts.name", Op: OpLike, Value: "A%"}}}},
+		OrderBy: []Order{{Field: "name"}},
+	}
+	result := db.ExecuteQuery(context.Background(), query)
+	if result.Success || result.Error.Code != "INVALID_COLUMN" {
+		t.Fatalf("expected INVALID_COLUMN, got %+v", result)
+	}
+	if !slices.Equal(result.Error.ValidFields, []string{"id", "full_name", "email"}) {
+		t.Errorf("expected the table's columns, got %v", result.Error.ValidFields)
+	}
+	fix := result.Error.AutoFix
+	if fix == nil {
+		t.Fatal("expected an AutoFix query")
+	}
+	if fix.Select[1] != "full_name" || fix.Where[0].OrGroup[0].Field != "fix_contacts.full_name" || fix.OrderBy[0].Field != "full_name" {
+		t.Errorf("expected every reference renamed, got %+v", fix)
+	}
+	if query.Select[1] != "name" || query.Where[0].OrGroup[0].Field != "fix_contacts.name" {
+		t.Error("the failed query should not be modified")
+	}
+
+	result = db.ExecuteQuery(context.Background(), &Query{Table: "fix_contacts", Action: ActionFind, Select: []any{"phone"}})
+	if result.Error.AutoFix != nil || len(result.Error.ValidFields) != 3 {
+		t.Errorf("expected valid fields without a fix for a column with no close match, got %+v", result.Error)
+	}
+
+	result = db.ExecuteQuery(context.Background(), &Query{Table: "fix_contacts", Action: ActionDelete,
+		Where: []Condition{{Field: "name", Op: OpEqual, Value: "Ann"}}})
+	if result.Error.AutoFix != nil || !strings.Contains(result.Error.Suggestion, "full_name") {
+		t.Errorf("expected a suggestion without a fix for a delete, got %+v", result.Error)
+	}
+
+	for name, want := range map[string]string{
+		"NAME":     "full_name",
+		"emial":    "email",
+		"Full_Nam": "full_name",
+		"mail":     "email",
+		"age":      "",
+		"ip":       "id",
+		"i":        "id",
+		"xyz":      "",
+	} {
+		if got := closestColumn(name, []string{"id", "Full_Name", "email"}); !strings.EqualFold(got, want) {
+			t.Errorf("closestColumn(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestJSONColumns tests that JSON columns are marshaled on writes and unmarshaled on find.
+// TestJSONColumns 测试 JSON 列在写入时序列化、在查询时反序列化。
+func TestJSONColumns(t *testing.T) {
+	var args []driver.NamedValue
+	db, _ := newFakeDB(t, &PostgresDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"id", "metadata", "tags", "avatar"}, [][]driver.Value{
+				{int64(1), []byte(`{"plan":"pro","seats":3}`), []byte(`["a","b"]`), []byte("raw")},
+			}, nil
+		}
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	if err := db.Register(&jsonProfile{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result := db.ExecuteQuery(ctx, &Query{
+		Table:  "json_profiles",
+		Action: ActionCreate,
+		Data: map[string]any{
+			"metadata": map[string]any{"plan": "pro"},
+			"tags":     []string{"a", "b"},
+			"avatar":   []byte("raw"),
+		},
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+	var values []any
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	for _, want := range []any{`{"plan":"pro"}`, `["a","b"]`} {
+		if !slices.Contains(values, want) {
+			t.Errorf("expected %s among the params, got %v", want, values)
+		}
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "json_profiles",
+		Action: ActionUpdate,
+		Data:   map[string]any{"metadata": map[string]any{"plan": "free"}},
+		Where:  []Condition{{Field: "id", Op: OpEqual, Value: 1}},
+	})
+	if !result.Success {
+		t.Fatalf("update failed: %+v", result.Error)
+	}
+	if args[0].Value != `{"plan":"free"}` {
+		t.Errorf("expected marshaled metadata, got %v", args[0].Value)
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{Table: "json_profiles", Action: ActionFind})
+	if !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+	row := result.Data[0]
+	if metadata, ok := row["metadata"].(map[string]any); !ok || metadata["plan"] != "pro" || metadata["seats"] != float64(3) {
+		t.Errorf("unexpected metadata: %#v", row["metadata"])
+	}
+	if tags, ok := row["tags"].([]any); !ok || len(tags) != 2 {
+		t.Errorf("unexpected tags: %#v", row["tags"])
+	}
+	if row["avatar"] != "raw" {
+		t.Errorf("non-JSON columns should be left alone, got %#v", row["avatar"])
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "json_profiles",
+		Action: ActionCreate,
+		Data:   map[string]any{"metadata": map[string]any{"bad": make(chan int)}, "tags": []string{}, "avatar": []byte{}},
+	})
+	if result.Success || result.Error.Code != "JSON_ENCODE_ERROR" {
+		t.Errorf("expected JSON_ENCODE_ERROR, got %+v", result)
+	}
+}
+
+// TestLocation tests that times are bound and read back in Config.Location.
+// TestLocation 测试时间按 Config.Location 绑定和读回。
+func TestLocation(t *testing.T) {
+	var args []driver.NamedValue
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"id", "opened_at"}, [][]driver.Value{
+				{"1", "2024-03-01 08:30:00"},
+				{"2", "2024-03-01 00:30:00+00:00"},
+			}, nil
+		}
+		return []string{"id"}, [][]driver.Value{{"1"}}, nil
+	})
+	shanghai := time.FixedZone("CST", 8*3600)
+	db.config.Location = shanghai
+	if err := db.Register(&defaultedTicket{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	opened := time.Date(2024, 3, 1, 0, 30, 0, 0, time.UTC)
+	result := db.ExecuteQuery(ctx, &Query{
+		Table:  "defaulted_tickets",
+		Action: ActionCreate,
+		Data:   map[string]any{"title": "Clock skew", "opened_at": opened},
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+	for _, arg := range args {
+		if v, ok := arg.Value.(time.Time); ok && (v.Location() != shanghai || !v.Equal(opened)) {
+			t.Errorf("expected %v bound in CST, got %v", opened, v)
+		}
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{Table: "defaulted_tickets", Action: ActionFind})
+	if !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+	for _, row := range result.Data {
+		got, ok := row["opened_at"].(time.Time)
+		if !ok || !got.Equal(opened) || got.Location() != shanghai {
+			t.Errorf("row %v: opened_at = %#v, want %v in CST", row["id"], row["opened_at"], opened)
+		}
+	}
+}
+
+// TestCreateOnConflictIgnore tests that an ignored create succeeds with Affected 0.
+// TestCreateOnConflictIgnore 测试被忽略的创建以 Affected 为 0 成功返回。
+func TestCreateOnConflictIgnore(t *testing.T) {
+	db, _ := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, nil, nil
+	})
+	ctx := context.Background()
+
+	result := db.ExecuteQuery(ctx, &Query{
+		Table:      "users",
+		Action:     ActionCreate,
+		Data:       map[string]any{"id": int64(7), "email": "a@example.com"},
+		OnConflict: OnConflictIgnore,
+	})
+	if !result.Success {
+		t.Fatalf("create failed: %+v", result.Error)
+	}
+	if result.Affected != 0 || result.InsertedKey != int64(7) {
+		t.Errorf("Affected = %d, InsertedKey = %v; want 0, 7", result.Affected, result.InsertedKey)
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Action: ActionTransaction,
+		Operations: []Query{{
+			Table:      "users",
+			Action:     ActionCreate,
+			Data:       map[string]any{"email": "a@example.com"},
+			OnConflict: OnConflictIgnore,
+		}},
+	})
+	if !result.Success {
+		t.Fatalf("transaction failed: %+v", result.Error)
+	}
+	if op := result.Results[0]; op.Affected != 0 || op.InsertedKey != nil {
+		t.Errorf("Affected = %d, InsertedKey = %v; want 0, nil", op.Affected, op.InsertedKey)
+	}
+}
+
+// TestCountEstimate tests counts answered from the database's row estimate.
+// TestCountEstimate 测试由数据库行数估计回答的计数。
+func TestCountEstimate(t *testing.T) {
+	estimate := int64(1234)
+	pg, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "reltuples") {
+			return []string{"reltuples"}, [][]driver.Value{{estimate}}, nil
+		}
+		return []string{"count"}, [][]driver.Value{{int64(7)}}, nil
+	})
+	if err := pg.Register(&defaultedTicket{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	count := &Query{Table: "defaulted_tickets", Action: ActionCount, Estimate: true}
+
+	result := pg.ExecuteQuery(ctx, count)
+	if !result.Success || result.Count != 1234 || result.Meta == nil || !result.Meta.Estimated {
+		t.Fatalf("expected an estimated count of 1234, got %+v %+v", result, result.Meta)
+	}
+	for _, q := range backend.Queries() {
+		if strings.Contains(q, "COUNT(") {
+			t.Errorf("an estimate should not count rows: %s", q)
+		}
+	}
+
+	// Tables that were never analyzed report -1 and are counted exactly
+	// 从未分析过的表报告 -1，改为精确计数
+	estimate = -1
+	result = pg.ExecuteQuery(ctx, count)
+	if !result.Success || result.Count != 7 || (result.Meta != nil && result.Meta.Estimated) {
+		t.Errorf("expected an exact count of 7, got %+v %+v", result, result.Meta)
+	}
+
+	sqlite, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"count"}, [][]driver.Value{{int64(7)}}, nil
+	})
+	if err := sqlite.Register(&defaultedTicket{}); err != nil {
+		t.Fatal(err)
+	}
+	result = sqlite.ExecuteQuery(ctx, count)
+	if !result.Success || result.Count != 7 || (result.Meta != nil && result.Meta.Estimated) {
+		t.Errorf("SQLite should count exactly, got %+v %+v", result, result.Meta)
+	}
+
+	filtered := &Query{Table: "defaulted_tickets", Action: ActionCount, Estimate: true, Where: []Condition{{Field: "title", Op: OpEqual, Value: "x"}}}
+	if err := filtered.Validate(); err == nil {
+		t.Error("estimate should not be combined with where")
+	}
+}
+
+// TestResultErrSentinels tests that Result.Err wraps the sentinel error for its code.
+// TestResultErrSentinels 测试 Result.Err 包装其代码对应的哨兵错误。
+func TestResultErrSentinels(t *testing.T) {
+	db, _ := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return nil, nil, fmt.Errorf(`pq: duplicate key value violates unique constraint "fix_contacts_email_key"`)
+	})
+	if err := db.Register(&fixContact{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := db.ExecuteQuery(context.Background(), &Query{
+		Table:  "fix_contacts",
+		Action: ActionCreate,
+		Data:   map[string]any{"full_name": "Ada", "email": "ada@example.com"},
+	})
+	err := result.Err()
+	if !errors.Is(err, ErrDuplicateKey) || errors.Is(err, ErrForeignKeyViolation) {
+		t.Fatalf("expected only ErrDuplicateKey, got %v", err)
+	}
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) || queryErr.Code != "DUPLICATE_KEY" || queryErr.Details["sql"] == nil {
+		t.Errorf("expected a QueryError with the code and details, got %+v", queryErr)
+	}
+
+	for code, want := range map[string]error{
+		"FK_VIOLATION":     ErrForeignKeyViolation,
+		"INVALID_FIELD":    ErrInvalidColumn,
+		"CONFIRM_REQUIRED": ErrConfirmRequired,
+		"READ_ONLY_MODE":   ErrReadOnly,
+		"PARSE_ERROR":      ErrValidation,
+		"MISSING_TENANT":   ErrValidation,
+		"UNSCOPED_JOIN":    ErrValidation,
+	} {
+		err := (&Result{Error: &ResultError{Code: code}}).Err()
+		if !errors.Is(err, want) {
+			t.Errorf("%s: expected %v, got %v", code, want, err)
+		}
+	}
+	if err := (&Result{Error: &ResultError{Code: "SQL_ERROR"}}).Err(); errors.Unwrap(err) != nil {
+		t.Errorf("expected no sentinel for SQL_ERROR, got %v", errors.Unwrap(err))
+	}
+	if err := (&Result{Success: true}).Err(); err != nil {
+		t.Errorf("expected no error for a successful result, got %v", err)
+	}
+}
+
+// sqlStateErr is a driver error carrying a SQLSTATE code, like lib/pq's and pgx's.
+// sqlStateErr 是携带 SQLSTATE 代码的驱动错误，类似 lib/pq 和 pgx 的错误。
+type sqlStateErr string
+
+func (e sqlStateErr) Error() string    { return "pq: constraint error" }
+func (e sqlStateErr) SQLState() string { return string(e) }
+
+// mysqlErr mirrors *mysql.MySQLError from go-sql-driver/mysql.
+// mysqlErr 模拟 go-sql-driver/mysql 的 *mysql.MySQLError。
+type mysqlErr struct {
+	Number   uint16
+	SQLState [5]byte
+	Message  string
+}
+
+func (e *mysqlErr) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+// pqErr mirrors *pq.Error from lib/pq versions without the SQLState method.
+// pqErr 模拟没有 SQLState 方法的 lib/pq 版本中的 *pq.Error。
+type pqErr struct {
+	Code    string
+	Message string
+}
+
+func (e *pqErr) Error() string { return "pq: " + e.Message }
+
+// sqlite3Err mirrors sqlite3.Error from mattn/go-sqlite3.
+// sqlite3Err 模拟 mattn/go-sqlite3 的 sqlite3.Error。
+type sqlite3Err struct {
+	Code         int
+	ExtendedCode int
+	err          string
+}
+
+func (e sqlite3Err) Error() string { return e.err }
+
+// moderncErr mirrors *sqlite.Error from modernc.org/sqlite.
+// moderncErr 模拟 modernc.org/sqlite 的 *sqlite.Error。
+type moderncErr struct {
+	code int
+	msg  string
+}
+
+func (e *moderncErr) Error() string { return e.msg }
+func (e *moderncErr) Code() int     { return e.code }
+
+// TestClassifySQLError tests that driver error codes take precedence over messages.
+// TestClassifySQLError 测试驱动错误代码优先于错误消息。
+func TestClassifySQLError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"localized MySQL duplicate", &mysqlErr{Number: 1062, Message: "Eintrag 'a' für Schlüssel 'email' doppelt vorhanden"}, "DUPLICATE_KEY"},
+		{"MySQL unknown table", &mysqlErr{Number: 1146, Message: "Table 'shop.x' doesn't exist"}, "TABLE_NOT_FOUND"},
+		{"MySQL unmapped", &mysqlErr{Number: 1366, Message: "Incorrect integer value for column 'age'"}, "SQL_ERROR"},
+		{"wrapped MySQL", fmt.Errorf("exec: %w", &mysqlErr{Number: 1452}), "FK_VIOLATION"},
+		{"pq code field", &pqErr{Code: "42P01", Message: "relation \"x\" does not exist"}, "TABLE_NOT_FOUND"},
+		{"pq unknown column", &pqErr{Code: "42703", Message: "column \"x\" does not exist"}, "INVALID_COLUMN"},
+		{"SQLState method", sqlStateErr("23505"), "DUPLICATE_KEY"},
+		{"query canceled", sqlStateErr("57014"), "TIMEOUT"},
+		{"mattn unique", sqlite3Err{Code: 19, ExtendedCode: 2067, err: "UNIQUE constraint failed: users.email"}, "DUPLICATE_KEY"},
+		{"mattn foreign key", sqlite3Err{Code: 19, ExtendedCode: 787, err: "FOREIGN KEY constraint failed"}, "FK_VIOLATION"},
+		{"mattn generic falls back", sqlite3Err{Code: 1, ExtendedCode: 1, err: "no such table: x"}, "TABLE_NOT_FOUND"},
+		{"modernc primary key", &moderncErr{code: 1555, msg: "constraint failed"}, "DUPLICATE_KEY"},
+		{"modernc generic falls back", &moderncErr{code: 1, msg: "no such column: x"}, "INVALID_COLUMN"},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), "TIMEOUT"},
+		{"unknown driver", fmt.Errorf("Duplicate entry 'a' for key 'email'"), "DUPLICATE_KEY"},
+	}
+	for _, tt := range tests {
+		if got := classifySQLError(tt.err); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestHandleSQLErrorForeignKey tests foreign key violation detection across drivers.
+// TestHandleSQLErrorForeignKey 测试各驱动的外键违规检测。
+func TestHandleSQLErrorForeignKey(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, nil)
+	e := NewExecutor(db)
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{sqlStateErr("23503"), "FK_VIOLATION"},
+		{fmt.Errorf("insert: %w", sqlStateErr("23503")), "FK_VIOLATION"},
+		{sqlStateErr("23514"), "SQL_ERROR"},
+		{fmt.Errorf(`ERROR: insert or update on table "orders" violates foreign key constraint "fk_user" (SQLSTATE 23503)`), "FK_VIOLATION"},
+		{fmt.Errorf("Error 1452 (23000): Cannot add or update a child row"), "FK_VIOLATION"},
+		{fmt.Errorf("Error 1451 (23000): Cannot delete or update a parent row"), "FK_VIOLATION"},
+		{fmt.Errorf("FOREIGN KEY constraint failed"), "FK_VIOLATION"},
+		{fmt.Errorf(`near "a]": syntax error`), "SYNTAX_ERROR"},
+		{fmt.Errorf("Error 14520: unrelated"), "SQL_ERROR"},
+	}
+	for _, tt := range tests {
+		result := e.handleSQLError(tt.err, &BuildResult{})
+		if result.Error.Code != tt.want {
+			t.Errorf("%v: expected %s, got %s", tt.err, tt.want, result.Error.Code)
+		}
+	}
+}
+
+// TestBatchedDelete tests that BatchSize deletes in primary key chunks and stops when ctx is done.
+// TestBatchedDelete 测试 BatchSize 按主键分块删除，并在 ctx 结束时停止。
+func TestBatchedDelete(t *testing.T) {
+	var mu sync.Mutex
+	remaining := []int64{1, 2, 3, 4, 5}
+	var afterDelete func()
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		var rows [][]driver.Value
+		switch {
+		case strings.HasPrefix(query, "SELECT"):
+			last := int64(0)
+			if strings.Contains(query, `"id" >`) {
+				last = args[len(args)-1].Value.(int64)
+			}
+			for _, id := range remaining {
+				if id > last && len(rows) < 2 {
+					rows = append(rows, []driver.Value{id})
+				}
+			}
+			return []string{"id"}, rows, nil
+		case strings.HasPrefix(query, "DELETE"):
+			remaining = slices.DeleteFunc(remaining, func(id int64) bool {
+				for _, a := range args {
+					if a.Value == id {
+						rows = append(rows, []driver.Value{id})
+						return true
+					}
+				}
+				return false
+			})
+			if afterDelete != nil {
+				afterDelete()
+			}
+		}
+		return nil, rows, nil
+	})
+	if err := db.Register(&fixContact{}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := &Query{Table: "fix_contacts", Action: ActionDelete, BatchSize: 2, Where: []Condition{{Field: "email", Op: OpLike, Value: "%@example.com"}}}
+	result := db.ExecuteQuery(context.Background(), query)
+	if !result.Success {
+		t.Fatalf("batched delete failed: %+v", result.Error)
+	}
+	if result.Affected != 5 || result.Meta == nil || result.Meta.Batches != 3 || len(remaining) != 0 {
+		t.Errorf("expected 5 rows deleted in 3 batches, got %+v (%v left)", result, remaining)
+	}
+	queries := backend.Queries()
+	if want := `DELETE FROM "fix_contacts" WHERE "email" LIKE $1 AND "id" IN ($2, $3)`; len(queries) != 6 || queries[1] != want {
+		t.Errorf("expected 3 selects and 3 chunked deletes, got %v", queries)
+	}
+
+	remaining = []int64{1, 2, 3, 4, 5}
+	ctx, cancel := context.WithCancel(context.Background())
+	afterDelete = cancel
+	result = db.ExecuteQuery(ctx, query)
+	if result.Success || result.Error.Code != "BATCH_INTERRUPTED" || result.Affected != 2 || len(remaining) != 3 {
+		t.Errorf("expected the delete to stop after the first batch, got %+v (%v left)", result, remaining)
+	}
+
+	remaining = []int64{1, 2, 3, 4, 5}
+	afterDelete = nil
+	query.ReturnIDs = true
+	result = db.ExecuteQuery(context.Background(), query)
+	if !result.Success || !slices.Equal(result.IDs, []uint64{1, 2, 3, 4, 5}) {
+		t.Errorf("expected the keys of every batch, got %+v", result)
+	}
+
+	if err := (&Query{Table: "fix_contacts", Action: ActionFind, BatchSize: 2}).Validate(); err == nil {
+		t.Error("expected batch_size to be rejected for find")
+	}
+}
+
+// TestReturnIDs tests that updates and deletes with return_ids report the keys of the
+// rows written, through RETURNING on PostgreSQL and a prior select on MySQL.
+// TestReturnIDs 测试设置了 return_ids 的更新和删除会报告写入行的主键：PostgreSQL 通过 RETURNING，MySQL 通过事先查询。
+func TestReturnIDs(t *testing.T) {
+	update := &Query{
+		Table:     "fix_contacts",
+		Action:    ActionUpdate,
+		Where:     []Condition{{Field: "email", Op: OpLike, Value: "%@example.com"}},
+		Data:      map[string]any{"full_name": "x"},
+		ReturnIDs: true,
+	}
+
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(7)}, {int64(9)}}, nil
+	})
+	result := db.ExecuteQuery(context.Background(), update)
+	if !result.Success {
+		t.Fatalf("update failed: %+v", result.Error)
+	}
+	if !slices.Equal(result.IDs, []uint64{7, 9}) || result.Affected != 2 || result.Data != nil {
+		t.Errorf("expected ids [7 9] without rows, got %+v", result)
+	}
+	if q := backend.Queries(); len(q) != 1 || !strings.HasSuffix(q[0], ` RETURNING "id"`) {
+		t.Errorf("expected a single update returning the key, got %v", q)
+	}
+
+	db, backend = newFakeDB(t, &MySQLDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"id"}, [][]driver.Value{{int64(3)}, {int64(4)}}, nil
+		}
+		return nil, [][]driver.Value{{}, {}}, nil
+	})
+	result = db.ExecuteQuery(context.Background(), update)
+	if !result.Success {
+		t.Fatalf("update failed: %+v", result.Error)
+	}
+	if !slices.Equal(result.IDs, []uint64{3, 4}) || result.Affected != 2 {
+		t.Errorf("expected ids [3 4], got %+v", result)
+	}
+	queries := backend.Queries()
+	if want := "UPDATE `fix_contacts` SET `full_name` = ? WHERE `email` LIKE ? AND `id` IN (?, ?)"; len(queries) != 2 || queries[1] != want {
+		t.Errorf("expected a key select and an update of those keys, got %v", queries)
+	}
+
+	tx := db.ExecuteQuery(context.Background(), &Query{Action: ActionTransaction, Operations: []Query{*update}})
+	if !tx.Success || len(tx.Results) != 1 || !slices.Equal(tx.Results[0].IDs, []uint64{3, 4}) {
+		t.Fatalf("expected ids [3 4] in the transaction, got %+v", tx)
+	}
+	if q := backend.Queries(); !strings.HasSuffix(q[len(q)-2], "FOR UPDATE") {
+		t.Errorf("expected the keys to be locked in the transaction, got %v", q)
+	}
+
+	if err := (&Query{Table: "fix_contacts", Action: ActionFind, ReturnIDs: true}).Validate(); err == nil {
+		t.Error("expected return_ids to be rejected for find")
+	}
+
+	// Without batch_size the keys selected in memory are capped
+	// 未设置 batch_size 时，查询到内存中的主键数量有上限
+	db, backend = newFakeDB(t, &MySQLDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		rows := make([][]driver.Value, maxReturnIDs+1)
+		for i := range rows {
+			rows[i] = []driver.Value{int64(i)}
+		}
+		return []string{"id"}, rows, nil
+	})
+	result = db.ExecuteQuery(context.Background(), update)
+	if result.Success || result.Error.Code != "TOO_MANY_ROWS" {
+		t.Fatalf("expected TOO_MANY_ROWS, got %+v", result.Error)
+	}
+	if q := backend.Queries(); len(q) != 1 || !strings.HasSuffix(q[0], fmt.Sprintf("LIMIT %d", maxReturnIDs+1)) {
+		t.Errorf("expected only a bounded key select, got %v", q)
+	}
+}
+
+// TestCompositeKeyRejected tests that operations addressing rows by a single primary
+// key reject tables with a composite key instead of using its first column.
+// TestCompositeKeyRejected 测试按单一主键定位行的操作会拒绝复合主键的表，而不是使用其第一列。
+func TestCompositeKeyRejected(t *testing.T) {
+	db, backend := newFakeDB(t, &MySQLDialect{}, nil)
+	if err := db.Register(&userRole{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	where := []Condition{{Field: "user_id", Op: OpEqual, Value: 1}}
+
+	results := map[string]*Result{
+		"FindByIDs":   db.FindByIDs(ctx, "user_roles", []any{1}),
+		"DeleteByIDs": db.DeleteByIDs(ctx, "user_roles", []any{1}),
+		"batch_size":  db.ExecuteQuery(ctx, &Query{Table: "user_roles", Action: ActionDelete, Where: where, BatchSize: 10}),
+		"return_ids":  db.ExecuteQuery(ctx, &Query{Table: "user_roles", Action: ActionDelete, Where: where, ReturnIDs: true}),
+	}
+	for name, result := range results {
+		if result.Success || result.Error.Code != "COMPOSITE_PRIMARY_KEY" {
+			t.Errorf("%s: expected COMPOSITE_PRIMARY_KEY, got %+v", name, result.Error)
+		}
+	}
+	result := db.ExecuteQuery(ctx, &Query{Action: ActionTransaction, Operations: []Query{
+		{Table: "user_roles", Action: ActionDelete, Where: where, ReturnIDs: true},
+	}})
+	if result.Success || !strings.Contains(result.Error.Message, "composite primary key") {
+		t.Errorf("expected the transaction to reject return_ids, got %+v", result.Error)
+	}
+	if q := backend.Queries(); len(q) != 0 {
+		t.Errorf("no statement should run, got %v", q)
+	}
+
+	// Audit ids are left out rather than failing the write
+	// 审计 ID 会被省略，而不会使写入失败
+	db.config.Security.AuditEnabled, db.config.Security.AuditIDs = true, true
+	result = db.ExecuteQuery(ctx, &Query{Table: "user_roles", Action: ActionDelete, Where: where})
+	if !result.Success {
+		t.Errorf("expected the delete to run without audit ids, got %+v", result.Error)
+	}
+}
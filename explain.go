@@ -0,0 +1,195 @@
+package goorm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqliteIndexPattern matches the index named in a SQLite query plan detail.
+// sqliteIndexPattern 匹配 SQLite 查询计划详情中的索引名。
+var sqliteIndexPattern = regexp.MustCompile(`USING (?:COVERING )?INDEX (\S+)`)
+
+// explainPlan runs the dialect's EXPLAIN for build and fills the estimates of explain.
+// explainPlan 对 build 执行方言的 EXPLAIN 并填充 explain 的估算信息。
+func (db *DB) explainPlan(ctx context.Context, build *BuildResult, explain *ExplainResult) error {
+	planSQL := &BuildResult{SQL: db.dialect.ExplainPrefix() + build.SQL, Params: build.Params}
+	startTime := time.Now()
+	traceStatement(ctx, planSQL.SQL)
+	rows, err := db.sqlDB.QueryContext(ctx, planSQL.SQL, planSQL.Params...)
+	db.logQuery(planSQL, startTime, err)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	data, r := scanRows(rows, nil)
+	if r != nil {
+		return r.Err()
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("EXPLAIN returned no rows")
+	}
+
+	switch db.dialect.Name() {
+	case "sqlite":
+		details := make([]string, 0, len(data))
+		for _, row := range data {
+			details = append(details, fmt.Sprint(row["detail"]))
+		}
+		parseSQLitePlan(details, explain)
+		return nil
+	case "postgres":
+		return parsePostgresPlan(planText(data[0]), explain)
+	case "mysql":
+		return parseMySQLPlan(planText(data[0]), explain)
+	}
+	return fmt.Errorf("EXPLAIN is not supported for dialect %s", db.dialect.Name())
+}
+
+// planText returns the single JSON column of a Postgres or MySQL EXPLAIN row.
+// planText 返回 Postgres 或 MySQL EXPLAIN 行中唯一的 JSON 列。
+func planText(row map[string]any) []byte {
+	for _, v := range row {
+		return []byte(fmt.Sprint(v))
+	}
+	return nil
+}
+
+// parsePostgresPlan reads the output of EXPLAIN (FORMAT JSON).
+// parsePostgresPlan 读取 EXPLAIN (FORMAT JSON) 的输出。
+func parsePostgresPlan(data []byte, explain *ExplainResult) error {
+	var plans []struct {
+		Plan map[string]any `json:"Plan"`
+	}
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return fmt.Errorf("failed to parse EXPLAIN output: %w", err)
+	}
+	if len(plans) == 0 || plans[0].Plan == nil {
+		return fmt.Errorf("EXPLAIN output has no plan")
+	}
+
+	root := plans[0].Plan
+	if rows, ok := root["Plan Rows"].(float64); ok {
+		explain.EstimatedRows = int64(rows)
+	}
+	if cost, ok := root["Total Cost"].(float64); ok {
+		explain.EstimatedCost = cost
+	}
+
+	var walk func(node map[string]any)
+	walk = func(node map[string]any) {
+		if index, ok := node["Index Name"].(string); ok {
+			explain.IndexUsed = appendUnique(explain.IndexUsed, index)
+		}
+		if node["Node Type"] == "Seq Scan" {
+			explain.Warnings = append(explain.Warnings, fmt.Sprintf("sequential scan on %v", node["Relation Name"]))
+		}
+		children, _ := node["Plans"].([]any)
+		for _, child := range children {
+			if m, ok := child.(map[string]any); ok {
+				walk(m)
+			}
+		}
+	}
+	walk(root)
+
+	return nil
+}
+
+// parseMySQLPlan reads the output of EXPLAIN FORMAT=JSON. EstimatedRows is the
+// largest per-table row estimate.
+//
+// parseMySQLPlan 读取 EXPLAIN FORMAT=JSON 的输出。EstimatedRows 为各表行数估算中的最大值。
+func parseMySQLPlan(data []byte, explain *ExplainResult) error {
+	var plan struct {
+		QueryBlock map[string]any `json:"query_block"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("failed to parse EXPLAIN output: %w", err)
+	}
+	if plan.QueryBlock == nil {
+		return fmt.Errorf("EXPLAIN output has no query_block")
+	}
+
+	if costInfo, ok := plan.QueryBlock["cost_info"].(map[string]any); ok {
+		explain.EstimatedCost = jsonNumber(costInfo["query_cost"])
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch node := v.(type) {
+		case map[string]any:
+			if table, ok := node["table"].(map[string]any); ok {
+				if key, ok := table["key"].(string); ok {
+					explain.IndexUsed = appendUnique(explain.IndexUsed, key)
+				}
+				if rows := int64(jsonNumber(table["rows_examined_per_scan"])); rows > explain.EstimatedRows {
+					explain.EstimatedRows = rows
+				}
+				if table["access_type"] == "ALL" {
+					explain.Warnings = append(explain.Warnings, fmt.Sprintf("full table scan on %v", table["table_name"]))
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(plan.QueryBlock)
+
+	return nil
+}
+
+// parseSQLitePlan reads the detail column of EXPLAIN QUERY PLAN.
+// SQLite does not report row or cost estimates.
+//
+// parseSQLitePlan 读取 EXPLAIN QUERY PLAN 的 detail 列。SQLite 不提供行数或成本估算。
+func parseSQLitePlan(details []string, explain *ExplainResult) {
+	for _, detail := range details {
+		if m := sqliteIndexPattern.FindStringSubmatch(detail); m != nil {
+			explain.IndexUsed = appendUnique(explain.IndexUsed, m[1])
+		} else if strings.Contains(detail, "USING INTEGER PRIMARY KEY") {
+			explain.IndexUsed = appendUnique(explain.IndexUsed, "PRIMARY KEY")
+		} else if strings.HasPrefix(detail, "SCAN ") {
+			// "SCAN users" (3.36+) or "SCAN TABLE users"
+			// "SCAN users"（3.36+）或 "SCAN TABLE users"
+			name := strings.TrimPrefix(strings.TrimPrefix(detail, "SCAN "), "TABLE ")
+			if fields := strings.Fields(name); len(fields) > 0 {
+				explain.Warnings = append(explain.Warnings, "full table scan on "+fields[0])
+			}
+		}
+	}
+}
+
+// jsonNumber converts a JSON number or numeric string to float64.
+// jsonNumber 将 JSON 数字或数字字符串转换为 float64。
+func jsonNumber(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// appendUnique appends s to list unless it is already present.
+// appendUnique 在 list 中不存在 s 时将其追加。
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
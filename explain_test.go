@@ -0,0 +1,90 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+// TestParsePostgresPlan tests reading EXPLAIN (FORMAT JSON) output.
+// TestParsePostgresPlan 测试读取 EXPLAIN (FORMAT JSON) 的输出。
+func TestParsePostgresPlan(t *testing.T) {
+	output := `[{"Plan": {"Node Type": "Nested Loop", "Total Cost": 16.5, "Plan Rows": 3, "Plans": [
+		{"Node Type": "Index Scan", "Index Name": "users_pkey", "Relation Name": "users"},
+		{"Node Type": "Seq Scan", "Relation Name": "orders"}
+	]}}]`
+
+	var explain ExplainResult
+	if err := parsePostgresPlan([]byte(output), &explain); err != nil {
+		t.Fatal(err)
+	}
+	if explain.EstimatedRows != 3 || explain.EstimatedCost != 16.5 {
+		t.Errorf("rows/cost = %d/%v, want 3/16.5", explain.EstimatedRows, explain.EstimatedCost)
+	}
+	if len(explain.IndexUsed) != 1 || explain.IndexUsed[0] != "users_pkey" {
+		t.Errorf("IndexUsed = %v", explain.IndexUsed)
+	}
+	if len(explain.Warnings) != 1 || !strings.Contains(explain.Warnings[0], "orders") {
+		t.Errorf("Warnings = %v", explain.Warnings)
+	}
+
+	if err := parsePostgresPlan([]byte("not json"), &explain); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
+
+// TestParseMySQLPlan tests reading EXPLAIN FORMAT=JSON output.
+// TestParseMySQLPlan 测试读取 EXPLAIN FORMAT=JSON 的输出。
+func TestParseMySQLPlan(t *testing.T) {
+	output := `{"query_block": {"select_id": 1, "cost_info": {"query_cost": "12.40"}, "nested_loop": [
+		{"table": {"table_name": "users", "access_type": "ref", "key": "idx_users_email", "rows_examined_per_scan": 1}},
+		{"table": {"table_name": "orders", "access_type": "ALL", "rows_examined_per_scan": 40}}
+	]}}`
+
+	var explain ExplainResult
+	if err := parseMySQLPlan([]byte(output), &explain); err != nil {
+		t.Fatal(err)
+	}
+	if explain.EstimatedRows != 40 || explain.EstimatedCost != 12.4 {
+		t.Errorf("rows/cost = %d/%v, want 40/12.4", explain.EstimatedRows, explain.EstimatedCost)
+	}
+	if len(explain.IndexUsed) != 1 || explain.IndexUsed[0] != "idx_users_email" {
+		t.Errorf("IndexUsed = %v", explain.IndexUsed)
+	}
+	if len(explain.Warnings) != 1 || !strings.Contains(explain.Warnings[0], "orders") {
+		t.Errorf("Warnings = %v", explain.Warnings)
+	}
+}
+
+// TestExplainPlanSQLite tests that explain with plan runs EXPLAIN QUERY PLAN.
+// TestExplainPlanSQLite 测试带 plan 的 explain 会执行 EXPLAIN QUERY PLAN。
+func TestExplainPlanSQLite(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id", "parent", "notused", "detail"}, [][]driver.Value{
+			{int64(2), int64(0), int64(0), "SEARCH users USING INDEX idx_users_email (email=?)"},
+			{int64(3), int64(0), int64(0), "SCAN orders"},
+		}, nil
+	})
+
+	inner := &Query{Table: "users", Action: ActionFind, Where: []Condition{{Field: "email", Op: OpEqual, Value: "a@b.c"}}}
+
+	result := db.ExecuteQuery(context.Background(), &Query{Action: ActionExplain, QueryToExplain: inner})
+	if !result.Success || len(backend.Queries()) != 0 {
+		t.Fatalf("explain without plan should not hit the database: %+v, %v", result.Error, backend.Queries())
+	}
+
+	result = db.ExecuteQuery(context.Background(), &Query{Action: ActionExplain, QueryToExplain: inner, Plan: true})
+	if !result.Success {
+		t.Fatalf("explain failed: %+v", result.Error)
+	}
+	if q := backend.Queries(); len(q) != 1 || !strings.HasPrefix(q[0], "EXPLAIN QUERY PLAN SELECT") {
+		t.Errorf("unexpected queries: %v", q)
+	}
+	if got := result.Explain.IndexUsed; len(got) != 1 || got[0] != "idx_users_email" {
+		t.Errorf("IndexUsed = %v", got)
+	}
+	if got := result.Explain.Warnings; len(got) != 1 || got[0] != "full table scan on orders" {
+		t.Errorf("Warnings = %v", got)
+	}
+}
@@ -0,0 +1,201 @@
+package goorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeHandler answers a statement with columns and rows, or an error.
+// fakeHandler 以列和行或错误响应语句。
+type fakeHandler func(query string, args []driver.NamedValue) (columns []string, rows [][]driver.Value, err error)
+
+// fakeBackend records executed statements and answers them through handler.
+// fakeBackend 记录执行的语句并通过 handler 响应。
+type fakeBackend struct {
+	mu      sync.Mutex
+	handler fakeHandler
+	queries []string
+
+	// prepared and closed count statements prepared and closed explicitly
+	// prepared 和 closed 记录显式预处理和关闭的语句数
+	prepared int
+	closed   int
+}
+
+func (b *fakeBackend) run(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+	b.mu.Lock()
+	b.queries = append(b.queries, query)
+	handler := b.handler
+	b.mu.Unlock()
+
+	if handler == nil {
+		return nil, nil, nil
+	}
+	return handler(query, args)
+}
+
+// Statements returns the number of statements prepared and closed so far.
+// Statements 返回目前已预处理和已关闭的语句数。
+func (b *fakeBackend) Statements() (prepared, closed int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.prepared, b.closed
+}
+
+// Queries returns the statements executed so far.
+// Queries 返回目前已执行的语句。
+func (b *fakeBackend) Queries() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.queries...)
+}
+
+var (
+	fakeBackends   sync.Map
+	fakeBackendSeq int64
+)
+
+func init() {
+	sql.Register("goorm_fake", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	backend, ok := fakeBackends.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown fake backend %q", name)
+	}
+	return &fakeConn{backend: backend.(*fakeBackend)}, nil
+}
+
+type fakeConn struct {
+	backend *fakeBackend
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.backend.mu.Lock()
+	c.backend.prepared++
+	c.backend.mu.Unlock()
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	_, rows, err := c.backend.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	affected := int64(len(rows))
+	if affected == 0 {
+		affected = 1
+	}
+	return fakeResult{affected: affected}, nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	columns, rows, err := c.backend.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	s.conn.backend.mu.Lock()
+	s.conn.backend.closed++
+	s.conn.backend.mu.Unlock()
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeResult struct {
+	affected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.affected, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+// newFakeDB returns a DB backed by the fake driver using the given dialect.
+// newFakeDB 返回使用给定方言、由假驱动支持的 DB。
+func newFakeDB(t testing.TB, dialect Dialect, handler fakeHandler) (*DB, *fakeBackend) {
+	t.Helper()
+
+	name := fmt.Sprintf("fake-%d", atomic.AddInt64(&fakeBackendSeq, 1))
+	backend := &fakeBackend{handler: handler}
+	fakeBackends.Store(name, backend)
+	t.Cleanup(func() { fakeBackends.Delete(name) })
+
+	sqlDB, err := sql.Open("goorm_fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	config := DefaultConfig()
+	config.Security.AuditEnabled = false
+
+	db := &DB{
+		config:   config,
+		sqlDB:    sqlDB,
+		dialect:  dialect,
+		registry: NewRegistry(),
+		hooks:    NewHookManager(),
+		ctx:      context.Background(),
+	}
+	return db, backend
+}
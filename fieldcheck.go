@@ -0,0 +1,196 @@
+package goorm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// checkFields verifies that the tables query uses are registered and that every
+// field it references in where, select, order_by, group_by and having is a column
+// of them. It returns nil when the query is valid.
+//
+// checkFields 检查 query 使用的表已注册，且其在 where、select、order_by、group_by 和 having 中
+// 引用的每个字段都是这些表的列。查询有效时返回 nil。
+func (db *DB) checkFields(query *Query) *Result {
+	return (&fieldChecker{db: db}).query("", query)
+}
+
+// fieldChecker resolves the fields of a query against the registry.
+// fieldChecker 根据注册表解析查询中的字段。
+type fieldChecker struct {
+	db *DB
+}
+
+// tableScope is the set of tables, and their columns, a query's fields resolve in.
+// tableScope 是查询字段解析所在的表及其列的集合。
+type tableScope struct {
+	tables  []string
+	columns map[string][]string
+	aliases []string
+}
+
+// query checks the fields of q, whose path is prefix.
+// query 检查路径为 prefix 的 q 中的字段。
+func (c *fieldChecker) query(prefix string, q *Query) *Result {
+	at := func(format string, args ...any) string {
+		return prefix + fmt.Sprintf(format, args...)
+	}
+
+	for i := range q.Operations {
+		if r := c.query(at("operations[%d].", i), &q.Operations[i]); r != nil {
+			return r
+		}
+	}
+	if q.Table == "" {
+		return nil
+	}
+
+	scope := &tableScope{columns: make(map[string][]string)}
+	if r := c.addTable(scope, at("table"), q.Table); r != nil {
+		return r
+	}
+	for i, j := range q.Join {
+		if r := c.addTable(scope, at("join[%d].table", i), j.Table); r != nil {
+			return r
+		}
+	}
+
+	for i, sel := range q.Select {
+		switch v := sel.(type) {
+		case string:
+			if r := c.field(scope, at("select[%d]", i), v); r != nil {
+				return r
+			}
+		case map[string]any:
+			if field, _ := v["field"].(string); field != "" {
+				if r := c.field(scope, at("select[%d].field", i), field); r != nil {
+					return r
+				}
+			}
+			if as, _ := v["as"].(string); as != "" {
+				scope.aliases = append(scope.aliases, as)
+			}
+		}
+	}
+	if r := c.conditions(scope, at("where"), q.Where); r != nil {
+		return r
+	}
+	for i, col := range q.GroupBy {
+		if slices.Contains(scope.aliases, col) {
+			continue
+		}
+		if r := c.field(scope, at("group_by[%d]", i), col); r != nil {
+			return r
+		}
+	}
+	for i, h := range q.Having {
+		if h.Field != "" {
+			if r := c.field(scope, at("having[%d].field", i), h.Field); r != nil {
+				return r
+			}
+		}
+	}
+	for i, o := range q.OrderBy {
+		if slices.Contains(scope.aliases, o.Field) {
+			continue
+		}
+		if r := c.field(scope, at("order_by[%d].field", i), o.Field); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// conditions checks the fields of conds, their nested groups and subqueries.
+// conditions 检查 conds 及其嵌套分组和子查询中的字段。
+func (c *fieldChecker) conditions(scope *tableScope, path string, conds []Condition) *Result {
+	for i, cond := range conds {
+		at := fmt.Sprintf("%s[%d]", path, i)
+		if cond.Field != "" {
+			if r := c.field(scope, at+".field", cond.Field); r != nil {
+				return r
+			}
+		}
+		if r := c.conditions(scope, at+".and", cond.And); r != nil {
+			return r
+		}
+		if r := c.conditions(scope, at+".or_group", cond.OrGroup); r != nil {
+			return r
+		}
+		if cond.Subquery != nil {
+			if r := c.query(at+".subquery.", cond.Subquery); r != nil {
+				return r
+			}
+		}
+	}
+	return nil
+}
+
+// addTable adds a registered table to scope.
+// addTable 将已注册的表加入 scope。
+func (c *fieldChecker) addTable(scope *tableScope, path, table string) *Result {
+	meta, ok := c.db.registry.Get(table)
+	if !ok {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:       "TABLE_NOT_FOUND",
+				Message:    fmt.Sprintf("table %q in %s is not registered", table, path),
+				Suggestion: "Use list_tables to see the registered tables",
+				Details:    map[string]any{"table": table, "path": path},
+			},
+		}
+	}
+	columns := make([]string, len(meta.Fields))
+	for i, field := range meta.Fields {
+		columns[i] = field.ColumnName
+	}
+	scope.tables = append(scope.tables, table)
+	scope.columns[table] = columns
+	return nil
+}
+
+// field checks that name, optionally qualified as table.column, is a column in scope.
+// Names qualified by a table outside scope, such as an outer query's, are not checked.
+//
+// field 检查 name（可以 table.column 形式限定）是 scope 中的列。以 scope 之外的表（例如外层查询的表）
+// 限定的名称不做检查。
+func (c *fieldChecker) field(scope *tableScope, path, name string) *Result {
+	if name == "*" {
+		return nil
+	}
+	tables := scope.tables
+	if table, column, ok := strings.Cut(name, "."); ok {
+		if _, inScope := scope.columns[table]; !inScope || column == "*" {
+			return nil
+		}
+		tables, name = []string{table}, column
+	}
+
+	for _, table := range tables {
+		if slices.Contains(scope.columns[table], name) {
+			return nil
+		}
+	}
+	return invalidFieldResult(path, name, tables[0], scope.columns[tables[0]])
+}
+
+// invalidFieldResult reports a field that is not a column of table, listing the valid ones.
+// invalidFieldResult 报告不是 table 列的字段，并列出有效的列。
+func invalidFieldResult(path, field, table string, columns []string) *Result {
+	suggestion := "Use one of valid_fields"
+	if best := closestColumn(field, columns); best != "" {
+		suggestion = fmt.Sprintf("Did you mean %q?", best)
+	}
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:        "INVALID_FIELD",
+			Message:     fmt.Sprintf("field %q in %s does not exist in table %q", field, path, table),
+			Suggestion:  suggestion,
+			ValidFields: columns,
+			Details:     map[string]any{"field": field, "table": table, "path": path},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package goorm
+
+import (
+	"fmt"
+	"sync"
+)
+
+// flightGroup collapses concurrent calls with the same key into one, in the manner
+// of golang.org/x/sync/singleflight. The zero value is ready to use.
+//
+// flightGroup 将具有相同键的并发调用合并为一次，类似 golang.org/x/sync/singleflight。零值即可使用。
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is a call in progress or completed within a flightGroup.
+// flightCall 是 flightGroup 中进行中或已完成的调用。
+type flightCall struct {
+	wg     sync.WaitGroup
+	result *Result
+}
+
+// do runs fn for key unless a call for key is already running, in which case it
+// waits for that call and returns its result. If fn panics, the waiters get a
+// PANIC error result and the panic continues in the caller that ran fn.
+//
+// do 为 key 运行 fn；若 key 的调用已在运行，则等待该调用并返回其结果。如果 fn 发生 panic，
+// 等待者会得到 PANIC 错误结果，panic 则在运行 fn 的调用方继续传播。
+func (g *flightGroup) do(key string, fn func() *Result) *Result {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.result
+	}
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		p := recover()
+		if p != nil {
+			c.result = &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:    "PANIC",
+					Message: fmt.Sprintf("shared query panicked: %v", p),
+				},
+			}
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+		if p != nil {
+			panic(p)
+		}
+	}()
+	c.result = fn()
+	return c.result
+}
@@ -3,6 +3,8 @@ package goorm
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"net/http"
 	"sync"
 	"time"
 )
@@ -98,10 +100,21 @@ type HealthChecker struct {
 	checkTimeout time.Duration
 	interval     time.Duration
 	thresholds   HealthThresholds
+	probeQuery   string
+	cacheTTL     time.Duration
+	degradedCode int
 	running      bool
 	stopCh       chan struct{}
 }
 
+// DefaultProbeQuery is the query a health check runs after pinging the database.
+// DefaultProbeQuery 是健康检查在 ping 数据库之后执行的查询。
+const DefaultProbeQuery = "SELECT 1"
+
+// DefaultHealthCacheTTL is how long HTTPHandler reuses the last check result.
+// DefaultHealthCacheTTL 是 HTTPHandler 复用上次检查结果的时长。
+const DefaultHealthCacheTTL = time.Second
+
 // HealthThresholds defines thresholds for health status.
 // HealthThresholds 定义健康状态的阈值。
 type HealthThresholds struct {
@@ -136,6 +149,9 @@ func NewHealthChecker(db *DB) *HealthChecker {
 		checkTimeout: 5 * time.Second,
 		interval:     30 * time.Second,
 		thresholds:   DefaultHealthThresholds(),
+		probeQuery:   DefaultProbeQuery,
+		cacheTTL:     DefaultHealthCacheTTL,
+		degradedCode: http.StatusOK,
 		stopCh:       make(chan struct{}),
 	}
 }
@@ -158,6 +174,66 @@ func (h *HealthChecker) SetThresholds(thresholds HealthThresholds) {
 	h.thresholds = thresholds
 }
 
+// SetProbeQuery sets the query run after the ping, e.g. a SELECT on a canary
+// table. Some poolers answer pings while the database behind them is read-only
+// or missing its schema. An empty query disables the probe.
+//
+// SetProbeQuery 设置在 ping 之后执行的查询，例如对金丝雀表的 SELECT。
+// 某些连接池代理在其后的数据库只读或缺少架构时仍会响应 ping。空查询会禁用探测。
+func (h *HealthChecker) SetProbeQuery(query string) {
+	h.probeQuery = query
+}
+
+// SetCacheTTL sets how long HTTPHandler answers from the last check instead of
+// checking again. Zero checks on every request.
+//
+// SetCacheTTL 设置 HTTPHandler 使用上次检查结果而不重新检查的时长。为零时每次请求都检查。
+func (h *HealthChecker) SetCacheTTL(ttl time.Duration) {
+	h.cacheTTL = ttl
+}
+
+// SetDegradedStatusCode sets the HTTP status HTTPHandler returns for a degraded
+// database: http.StatusOK (the default) keeps the instance in rotation,
+// http.StatusServiceUnavailable takes it out.
+//
+// SetDegradedStatusCode 设置数据库降级时 HTTPHandler 返回的 HTTP 状态码：
+// http.StatusOK（默认）保持实例在轮转中，http.StatusServiceUnavailable 则将其移出。
+func (h *HealthChecker) SetDegradedStatusCode(code int) {
+	h.degradedCode = code
+}
+
+// HTTPHandler returns a handler for liveness and readiness probes that writes
+// the HealthCheck as JSON, with status 200 when healthy and 503 when unhealthy.
+// Requests within the cache TTL of the last check reuse its result.
+//
+// HTTPHandler 返回用于存活和就绪探针的处理器，以 JSON 写出 HealthCheck，
+// 健康时状态码为 200，不健康时为 503。在上次检查的缓存时长内的请求复用其结果。
+//
+// Example / 示例:
+//
+//	checker := db.Health()
+//	http.Handle("/healthz", checker.HTTPHandler())
+func (h *HealthChecker) HTTPHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		check := h.LastCheck()
+		if check == nil || time.Since(check.LastCheck) >= h.cacheTTL {
+			check = h.Check(r.Context())
+		}
+
+		code := http.StatusOK
+		switch check.Status {
+		case HealthStatusDegraded:
+			code = h.degradedCode
+		case HealthStatusUnhealthy:
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(check)
+	}
+}
+
 // Check performs a health check and returns the result.
 // Check 执行健康检查并返回结果。
 func (h *HealthChecker) Check(ctx context.Context) *HealthCheck {
@@ -182,6 +258,25 @@ func (h *HealthChecker) Check(ctx context.Context) *HealthCheck {
 	}
 	check.Latency = time.Since(start)
 
+	// Run the probe query
+	// 执行探测查询
+	if h.probeQuery != "" {
+		probeStart := time.Now()
+		err := h.probe(pingCtx)
+		probeLatency := time.Since(probeStart)
+		check.Details["probe_latency"] = probeLatency.String()
+		if err != nil {
+			check.Status = HealthStatusUnhealthy
+			check.Error = "probe query failed: " + err.Error()
+			h.updateLastCheck(check)
+			return check
+		}
+		if probeLatency > h.thresholds.MaxLatency {
+			check.Status = HealthStatusDegraded
+			check.Details["probe_warning"] = "Probe query latency exceeds threshold"
+		}
+	}
+
 	// Get pool stats
 	// 获取连接池统计
 	check.Pool = h.getPoolStats()
@@ -199,6 +294,20 @@ func (h *HealthChecker) Check(ctx context.Context) *HealthCheck {
 	return check
 }
 
+// probe runs the probe query and reads its rows.
+// probe 执行探测查询并读取其结果行。
+func (h *HealthChecker) probe(ctx context.Context) error {
+	rows, err := h.db.sqlDB.QueryContext(ctx, h.probeQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
 // getPoolStats returns connection pool statistics.
 // getPoolStats 返回连接池统计信息。
 func (h *HealthChecker) getPoolStats() *PoolStats {
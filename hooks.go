@@ -2,8 +2,12 @@ package goorm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -36,8 +40,9 @@ const (
 	// HookAfterDelete 在删除记录之后调用。
 	HookAfterDelete HookType = "after_delete"
 
-	// HookBeforeFind is called before querying records.
-	// HookBeforeFind 在查询记录之前调用。
+	// HookBeforeFind is called before querying records, including counts, aggregates
+	// and exists checks; HookContext.Action tells them apart.
+	// HookBeforeFind 在查询记录之前调用，包括计数、聚合和存在性检查；可通过 HookContext.Action 区分。
 	HookBeforeFind HookType = "before_find"
 
 	// HookAfterFind is called after querying records.
@@ -68,6 +73,10 @@ type HookContext struct {
 	// Query 是原始查询。
 	Query *Query
 
+	// Actor is the acting user, taken from Context via WithActor.
+	// Actor 是执行操作的用户，通过 WithActor 从 Context 中获取。
+	Actor any
+
 	// Data is the data being created/updated.
 	// Data 是正在创建/更新的数据。
 	Data map[string]any
@@ -85,6 +94,49 @@ type HookContext struct {
 	Error error
 }
 
+// AddCondition scopes the operation with cond, ANDed with the query's conditions. It
+// is meant for before-find (which also run for counts, aggregates and exists), before-update and
+// before-delete hooks, which run after the query is validated and before its SQL is
+// built. cond is validated, and its value is converted to the column type when
+// Config.CoerceTypes is set. ExecuteQuery runs hooks on a copy of the query, so
+// the caller's Query, and the slice it passed in Where, are never written to.
+//
+// AddCondition 以 cond 限定操作，并与查询的条件以 AND 组合。它适用于 before-find（计数、聚合和 exists 也会运行）、
+// before-update 和 before-delete 钩子，这些钩子在查询验证之后、生成 SQL 之前运行。cond 会被验证，
+// 设置 Config.CoerceTypes 时其值会转换为列类型。ExecuteQuery 在查询的副本上运行钩子，因此调用方的 Query
+// 及其在 Where 中传入的切片都不会被写入。
+func (ctx *HookContext) AddCondition(cond Condition) error {
+	if ctx.Query == nil {
+		return fmt.Errorf("hook has no query to add conditions to")
+	}
+	switch ctx.Query.Action {
+	case ActionFind, ActionCount, ActionAggregate, ActionExists, ActionUpdate, ActionDelete:
+	default:
+		return fmt.Errorf("conditions cannot be added to action %q", ctx.Query.Action)
+	}
+	if err := validateCondition(fmt.Sprintf("where[%d]", len(ctx.Query.Where)), cond); err != nil {
+		return err
+	}
+	if ctx.DB != nil && ctx.DB.config.CoerceTypes {
+		coerced, r := ctx.DB.coerceConditions(ctx.Table, []Condition{cond})
+		if r != nil {
+			return r.Err()
+		}
+		cond = coerced[0]
+	}
+	ctx.Query.Where = append(slices.Clip(ctx.Query.Where), cond)
+	return nil
+}
+
+// Require scopes the operation to rows where field op value holds, e.g.
+// ctx.Require("tenant_id", OpEqual, tenantID). See AddCondition.
+//
+// Require 将操作限定在满足 field op value 的行上，例如 ctx.Require("tenant_id", OpEqual, tenantID)。
+// 见 AddCondition。
+func (ctx *HookContext) Require(field string, op Operator, value any) error {
+	return ctx.AddCondition(Condition{Field: field, Op: op, Value: value})
+}
+
 // HookFunc is the function signature for hooks.
 // HookFunc 是钩子的函数签名。
 type HookFunc func(ctx *HookContext) error
@@ -94,44 +146,83 @@ type HookFunc func(ctx *HookContext) error
 type HookManager struct {
 	// hooks maps table -> hook type -> handlers
 	// hooks 映射 表 -> 钩子类型 -> 处理器
-	hooks map[string]map[HookType][]HookFunc
+	hooks map[string]map[HookType][]hookEntry
 
 	// globalHooks are applied to all tables
 	// globalHooks 应用于所有表
-	globalHooks map[HookType][]HookFunc
+	globalHooks map[HookType][]hookEntry
+
+	// seq orders hooks with equal priority by registration
+	// seq 使相同优先级的钩子按注册顺序排列
+	seq int
+}
+
+// hookEntry is a registered hook with its priority.
+// hookEntry 是带优先级的已注册钩子。
+type hookEntry struct {
+	priority int
+	global   bool
+	seq      int
+	fn       HookFunc
 }
 
 // NewHookManager creates a new hook manager.
 // NewHookManager 创建新的钩子管理器。
 func NewHookManager() *HookManager {
 	return &HookManager{
-		hooks:       make(map[string]map[HookType][]HookFunc),
-		globalHooks: make(map[HookType][]HookFunc),
+		hooks:       make(map[string]map[HookType][]hookEntry),
+		globalHooks: make(map[HookType][]hookEntry),
 	}
 }
 
-// Register registers a hook for a specific table.
-// Register 为特定表注册钩子。
+// Register registers a hook for a specific table with priority 0.
+// Register 以优先级 0 为特定表注册钩子。
 func (m *HookManager) Register(table string, hookType HookType, fn HookFunc) {
+	m.RegisterWithPriority(table, hookType, 0, fn)
+}
+
+// RegisterWithPriority registers a hook for a specific table.
+// Hooks with lower priority run first; hooks with equal priority run
+// globals first, then in registration order.
+//
+// RegisterWithPriority 为特定表注册钩子。
+// 优先级数字越小越先执行；优先级相同时先执行全局钩子，再按注册顺序执行。
+func (m *HookManager) RegisterWithPriority(table string, hookType HookType, priority int, fn HookFunc) {
 	if m.hooks[table] == nil {
-		m.hooks[table] = make(map[HookType][]HookFunc)
+		m.hooks[table] = make(map[HookType][]hookEntry)
 	}
-	m.hooks[table][hookType] = append(m.hooks[table][hookType], fn)
+	m.hooks[table][hookType] = append(m.hooks[table][hookType], m.newEntry(priority, false, fn))
 }
 
-// RegisterGlobal registers a global hook for all tables.
-// RegisterGlobal 为所有表注册全局钩子。
+// RegisterGlobal registers a global hook for all tables with priority 0.
+// RegisterGlobal 以优先级 0 为所有表注册全局钩子。
 func (m *HookManager) RegisterGlobal(hookType HookType, fn HookFunc) {
-	m.globalHooks[hookType] = append(m.globalHooks[hookType], fn)
+	m.RegisterGlobalWithPriority(hookType, 0, fn)
+}
+
+// RegisterGlobalWithPriority registers a global hook for all tables.
+// Global hooks are interleaved with table hooks by priority.
+//
+// RegisterGlobalWithPriority 为所有表注册全局钩子。
+// 全局钩子按优先级与表钩子交错执行。
+func (m *HookManager) RegisterGlobalWithPriority(hookType HookType, priority int, fn HookFunc) {
+	m.globalHooks[hookType] = append(m.globalHooks[hookType], m.newEntry(priority, true, fn))
+}
+
+func (m *HookManager) newEntry(priority int, global bool, fn HookFunc) hookEntry {
+	m.seq++
+	return hookEntry{priority: priority, global: global, seq: m.seq, fn: fn}
 }
 
-// Execute executes all hooks of the given type for the table.
-// Execute 执行给定类型的所有钩子。
+// Execute executes all hooks of the given type for the table, ordered by priority.
+// Execute 按优先级执行表的所有给定类型钩子。
 func (m *HookManager) Execute(ctx *HookContext, hookType HookType) error {
-	// Execute global hooks first
-	// 首先执行全局钩子
-	for _, fn := range m.globalHooks[hookType] {
-		if err := fn(ctx); err != nil {
+	if ctx.Actor == nil {
+		ctx.Actor = ActorFromContext(ctx.Context)
+	}
+
+	for _, entry := range m.ordered(ctx.Table, hookType) {
+		if err := entry.fn(ctx); err != nil {
 			return err
 		}
 		if ctx.Skip || ctx.Error != nil {
@@ -139,16 +230,105 @@ func (m *HookManager) Execute(ctx *HookContext, hookType HookType) error {
 		}
 	}
 
-	// Execute table-specific hooks
-	// 执行表特定的钩子
-	if tableHooks, ok := m.hooks[ctx.Table]; ok {
-		for _, fn := range tableHooks[hookType] {
-			if err := fn(ctx); err != nil {
-				return err
-			}
-			if ctx.Skip || ctx.Error != nil {
-				return ctx.Error
-			}
+	return nil
+}
+
+// ordered returns the global and table hooks of hookType sorted by priority.
+// ordered 返回按优先级排序的 hookType 全局钩子和表钩子。
+func (m *HookManager) ordered(table string, hookType HookType) []hookEntry {
+	global := m.globalHooks[hookType]
+	var local []hookEntry
+	if tableHooks, ok := m.hooks[table]; ok {
+		local = tableHooks[hookType]
+	}
+
+	entries := make([]hookEntry, 0, len(global)+len(local))
+	entries = append(entries, global...)
+	entries = append(entries, local...)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+		if a.global != b.global {
+			return a.global
+		}
+		return a.seq < b.seq
+	})
+	return entries
+}
+
+// runHooks executes the hooks of hookType for query.
+// Before hooks may modify query.Data; after hooks receive result.
+// It returns a non-nil Result when a hook fails, sets ctx.Error or skips the operation.
+//
+// runHooks 为查询执行 hookType 类型的钩子。
+// before 钩子可以修改 query.Data；after 钩子会收到 result。
+// 当钩子失败、设置 ctx.Error 或跳过操作时返回非 nil 的 Result。
+func (db *DB) runHooks(ctx context.Context, hookType HookType, query *Query, result *Result) *Result {
+	if db.hooks == nil {
+		return nil
+	}
+
+	hookCtx := &HookContext{
+		Context: ctx,
+		DB:      db,
+		Table:   query.Table,
+		Action:  query.Action,
+		Query:   query,
+		Data:    query.Data,
+		Result:  result,
+	}
+
+	err := db.hooks.Execute(hookCtx, hookType)
+	if err == nil {
+		err = hookCtx.Error
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "VALIDATION_ERROR",
+				Message: validationErr.Error(),
+				Details: map[string]any{"fields": validationErr.Errors},
+			},
+		}
+	}
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:       queryErr.Code,
+				Message:    queryErr.Message,
+				Suggestion: queryErr.Suggestion,
+				Details:    queryErr.Details,
+			},
+		}
+	}
+	if err != nil {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "HOOK_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+
+	// Update query data with hook modifications
+	// 用钩子的修改更新查询数据
+	if (hookType == HookBeforeCreate || hookType == HookBeforeUpdate) && hookCtx.Data != nil {
+		query.Data = hookCtx.Data
+	}
+
+	if hookCtx.Skip {
+		return &Result{
+			Success: true,
+			Status:  "skipped",
 		}
 	}
 
@@ -273,8 +453,8 @@ func (m *HookManager) RegisterModelHooks(model any, tableName string) {
 // --- Built-in Hooks ---
 // --- 内置钩子 ---
 
-// TimestampHook automatically sets created_at and updated_at.
-// TimestampHook 自动设置 created_at 和 updated_at。
+// TimestampHook automatically sets created_at and updated_at, in the DB's Config.Location.
+// TimestampHook 自动设置 created_at 和 updated_at，使用 DB 的 Config.Location 时区。
 func TimestampHook(config NamingConfig) HookFunc {
 	return func(ctx *HookContext) error {
 		if ctx.Data == nil {
@@ -282,6 +462,9 @@ func TimestampHook(config NamingConfig) HookFunc {
 		}
 
 		now := time.Now()
+		if ctx.DB != nil {
+			now = ctx.DB.now()
+		}
 
 		switch ctx.Action {
 		case ActionCreate:
@@ -322,6 +505,15 @@ func TimestampHook(config NamingConfig) HookFunc {
 // SoftDeleteHook converts delete to update with deleted_at.
 // SoftDeleteHook 将删除转换为带 deleted_at 的更新。
 func SoftDeleteHook(deletedAtField string) HookFunc {
+	return SoftDeleteByHook(deletedAtField, "")
+}
+
+// SoftDeleteByHook converts delete to update with deleted_at, and also sets
+// deletedByField to the actor of the request when both are present.
+//
+// SoftDeleteByHook 将删除转换为带 deleted_at 的更新，并在 deletedByField 和请求执行者
+// 都存在时将该列设置为执行者。
+func SoftDeleteByHook(deletedAtField, deletedByField string) HookFunc {
 	if deletedAtField == "" {
 		deletedAtField = "deleted_at"
 	}
@@ -331,11 +523,18 @@ func SoftDeleteHook(deletedAtField string) HookFunc {
 			return nil
 		}
 
-		// Convert delete to update with deleted_at = NOW()
-		// 将删除转换为 deleted_at = NOW() 的更新
+		// Convert delete to update with deleted_at set to the current time
+		// 将删除转换为将 deleted_at 设为当前时间的更新
+		now := time.Now()
+		if ctx.DB != nil {
+			now = ctx.DB.now()
+		}
 		ctx.Query.Action = ActionUpdate
 		ctx.Query.Data = map[string]any{
-			deletedAtField: "NOW()",
+			deletedAtField: now,
+		}
+		if deletedByField != "" && ctx.Actor != nil {
+			ctx.Query.Data[deletedByField] = ctx.Actor
 		}
 		ctx.Action = ActionUpdate
 
@@ -343,20 +542,180 @@ func SoftDeleteHook(deletedAtField string) HookFunc {
 	}
 }
 
-// AuditHook logs all database operations.
-// AuditHook 记录所有数据库操作。
-func AuditHook(logger Logger) HookFunc {
+// TenantScopeHook scopes reads (finds, counts, aggregates and exists checks),
+// updates and deletes to rows whose column equals the tenant tenantFromCtx returns
+// for the request, and sets column on created rows. Subqueries and inner joins on
+// scoped tables are scoped too; outer joins on them are refused, as they cannot be
+// scoped without changing which rows they return. Requests without a tenant fail
+// with MISSING_TENANT rather than see, touch or create rows outside every tenant.
+// An update setting column is made to set it to the tenant, so rows cannot be
+// moved to another tenant. Tables whose registered model has no such column are
+// not scoped.
+//
+// TenantScopeHook 将读取（查询、计数、聚合和存在性检查）、更新和删除限定在 column 等于 tenantFromCtx
+// 为请求返回的租户的行上，并在创建的行上设置 column。受限定表上的子查询和内连接同样会被限定；其上的外连接会被拒绝，
+// 因为无法在不改变其返回行的情况下限定它们。没有租户的请求会以 MISSING_TENANT 失败，而不会看到、影响或创建
+// 不属于任何租户的行。设置 column 的更新会被改为将其设置为该租户，因此行不能被移到其他租户。
+// 已注册模型中没有该列的表不受限定。
+func TenantScopeHook(column string, tenantFromCtx func(context.Context) any) HookFunc {
 	return func(ctx *HookContext) error {
-		if logger == nil {
+		switch ctx.Action {
+		case ActionCreate, ActionUpdate, ActionDelete, ActionFind, ActionCount, ActionAggregate, ActionExists:
+		default:
 			return nil
 		}
+		tenant := tenantFromCtx(ctx.Context)
+		missing := func() error {
+			return &QueryError{
+				Code:       "MISSING_TENANT",
+				Message:    fmt.Sprintf("refusing to %s %s without a tenant", ctx.Action, ctx.Table),
+				Suggestion: "Attach the tenant to the request context",
+			}
+		}
 
-		logger.Info("DB operation",
-			"table", ctx.Table,
-			"action", ctx.Action,
-		)
-		return nil
+		own := tenantScoped(ctx.DB, ctx.Table, column)
+		if ctx.Action == ActionCreate {
+			if !own {
+				return nil
+			}
+			if tenant == nil {
+				return missing()
+			}
+			if ctx.Data == nil {
+				ctx.Data = make(map[string]any)
+			}
+			ctx.Data[column] = tenant
+			return nil
+		}
+
+		// Subqueries and joins may read scoped tables even when this one is not
+		// 即使本表不受限定，子查询和连接也可能读取受限定的表
+		scoped := own
+		var where []Condition
+		var joined []string
+		if ctx.Query != nil {
+			var subScoped bool
+			var err error
+			where, subScoped, err = scopeSubqueries(ctx.DB, ctx.Query.Where, column, tenant)
+			if err != nil {
+				return err
+			}
+			scoped = scoped || subScoped
+			for _, j := range ctx.Query.Join {
+				if !tenantScoped(ctx.DB, j.Table, column) {
+					continue
+				}
+				if t := strings.ToLower(j.Type); t != "" && t != "inner" {
+					return &QueryError{
+						Code:       "UNSCOPED_JOIN",
+						Message:    fmt.Sprintf("refusing to %s join %s, which cannot be scoped to the tenant", j.Type, j.Table),
+						Suggestion: "Use an inner join, or query the joined table separately",
+						Details:    map[string]any{"table": j.Table},
+					}
+				}
+				joined = append(joined, j.Table)
+			}
+		}
+		if !scoped && len(joined) == 0 {
+			return nil
+		}
+		if tenant == nil {
+			return missing()
+		}
+
+		if ctx.Query != nil {
+			ctx.Query.Where = where
+			for _, table := range joined {
+				if err := ctx.Require(table+"."+column, OpEqual, tenant); err != nil {
+					return err
+				}
+			}
+		}
+		if !own {
+			return nil
+		}
+		if _, ok := ctx.Data[column]; ok && ctx.Action == ActionUpdate {
+			ctx.Data[column] = tenant
+		}
+		field := column
+		if ctx.Query != nil && len(ctx.Query.Join) > 0 {
+			// Unqualified, the column would be ambiguous with the joined tables'
+			// 不加限定时，该列会与被连接表的同名列产生歧义
+			field = ctx.Table + "." + column
+		}
+		return ctx.Require(field, OpEqual, tenant)
+	}
+}
+
+// tenantScoped reports whether TenantScopeHook scopes table: every table but those
+// whose registered model has no column.
+// tenantScoped 判断 TenantScopeHook 是否限定 table：除已注册模型中没有 column 的表外的所有表。
+func tenantScoped(db *DB, table, column string) bool {
+	if db == nil || db.registry == nil {
+		return true
+	}
+	meta, ok := db.registry.Get(table)
+	return !ok || slices.ContainsFunc(meta.Fields, func(f *FieldMeta) bool {
+		return f.ColumnName == column
+	})
+}
+
+// scopeSubqueries returns conds with every subquery on a scoped table, at any
+// depth, limited to rows whose column equals tenant, and whether there was any.
+// conds and the subqueries in it are copied, never written to.
+//
+// scopeSubqueries 返回 conds 的副本，其中任意深度上针对受限定表的子查询都被限定在 column 等于 tenant 的行上，
+// 并返回是否存在这样的子查询。conds 及其中的子查询会被复制，不会被写入。
+func scopeSubqueries(db *DB, conds []Condition, column string, tenant any) ([]Condition, bool, error) {
+	if len(conds) == 0 {
+		return conds, false, nil
+	}
+	out := make([]Condition, len(conds))
+	var scoped bool
+	for i, c := range conds {
+		var inner bool
+		var err error
+		if c.And, inner, err = scopeSubqueries(db, c.And, column, tenant); err != nil {
+			return nil, false, err
+		}
+		scoped = scoped || inner
+		if c.OrGroup, inner, err = scopeSubqueries(db, c.OrGroup, column, tenant); err != nil {
+			return nil, false, err
+		}
+		scoped = scoped || inner
+		if c.Subquery != nil {
+			sub := *c.Subquery
+			if sub.Where, inner, err = scopeSubqueries(db, sub.Where, column, tenant); err != nil {
+				return nil, false, err
+			}
+			scoped = scoped || inner
+			if tenantScoped(db, sub.Table, column) {
+				scoped = true
+				scope := []Condition{{Field: column, Op: OpEqual, Value: tenant}}
+				if db != nil && db.config.CoerceTypes {
+					coerced, r := db.coerceConditions(sub.Table, scope)
+					if r != nil {
+						return nil, false, r.Err()
+					}
+					scope = coerced
+				}
+				sub.Where = append(slices.Clip(sub.Where), scope...)
+			}
+			c.Subquery = &sub
+		}
+		out[i] = c
+	}
+	return out, scoped, nil
+}
+
+// AuditHook logs all database operations with the actor, data and, when the write
+// reports them, the IDs written. See AuditHookWithSink.
+// AuditHook 记录所有数据库操作，包括执行者、数据，以及写入报告时所写入的 ID。参见 AuditHookWithSink。
+func AuditHook(logger Logger) HookFunc {
+	if logger == nil {
+		return func(ctx *HookContext) error { return nil }
 	}
+	return AuditHookWithSink(&LoggerAuditSink{Logger: logger})
 }
 
 // ValidationHook validates data before create/update.
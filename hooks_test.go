@@ -1,8 +1,13 @@
 package goorm
 
 import (
+	"context"
+	"database/sql/driver"
 	"errors"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestHookManager tests the hook manager.
@@ -267,3 +272,615 @@ func TestHookOrder(t *testing.T) {
 		t.Error("table hooks should run in order")
 	}
 }
+
+// TestHookPriority tests that hooks run by priority with globals interleaved.
+// TestHookPriority 测试钩子按优先级运行，全局钩子交错执行。
+func TestHookPriority(t *testing.T) {
+	m := NewHookManager()
+
+	var order []string
+	record := func(name string) HookFunc {
+		return func(ctx *HookContext) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	m.RegisterWithPriority("users", HookBeforeCreate, 10, record("table-10"))
+	m.RegisterGlobalWithPriority(HookBeforeCreate, 20, record("global-20"))
+	m.RegisterWithPriority("users", HookBeforeCreate, -5, record("table-minus-5"))
+	m.Register("users", HookBeforeCreate, record("table-0"))
+	m.RegisterGlobal(HookBeforeCreate, record("global-0"))
+
+	m.Execute(&HookContext{Table: "users"}, HookBeforeCreate)
+
+	expected := []string{"table-minus-5", "global-0", "table-0", "table-10", "global-20"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i], order[i])
+		}
+	}
+}
+
+// TestAfterHookReceivesResult tests that after hooks see the operation result.
+// TestAfterHookReceivesResult 测试 after 钩子能看到操作结果。
+func TestAfterHookReceivesResult(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(7)}}, nil
+	})
+
+	var gotID uint64
+	db.Hook("users", HookAfterCreate, func(ctx *HookContext) error {
+		if ctx.Result != nil {
+			gotID = ctx.Result.ID
+		}
+		return nil
+	})
+
+	db.ExecuteQuery(context.Background(), &Query{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "x"}})
+	if gotID != 7 {
+		t.Errorf("expected after_create to see ID 7, got %d", gotID)
+	}
+}
+
+// TestAuditHookWithSink tests that audit entries carry the actor and data.
+// TestAuditHookWithSink 测试审计条目包含执行者和数据。
+func TestAuditHookWithSink(t *testing.T) {
+	var entries []*AuditEntry
+	sink := AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+
+	m := NewHookManager()
+	m.RegisterGlobal(HookBeforeCreate, AuditHookWithSink(sink))
+
+	hookCtx := &HookContext{
+		Context: WithActor(context.Background(), "user-42"),
+		Table:   "users",
+		Action:  ActionCreate,
+		Data:    map[string]any{"name": "Alice"},
+	}
+	if err := m.Execute(hookCtx, HookBeforeCreate); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Actor != "user-42" {
+		t.Errorf("expected actor user-42, got %v", entry.Actor)
+	}
+	if entry.Table != "users" || entry.Action != ActionCreate {
+		t.Errorf("unexpected table/action: %s/%s", entry.Table, entry.Action)
+	}
+	if entry.Data["name"] != "Alice" {
+		t.Errorf("expected data to be recorded, got %v", entry.Data)
+	}
+}
+
+// TestDBAuditSink tests the DB audit sink accessors.
+// TestDBAuditSink 测试 DB 审计 sink 访问器。
+func TestDBAuditSink(t *testing.T) {
+	db := &DB{}
+	if _, ok := db.AuditSink().(*LoggerAuditSink); !ok {
+		t.Error("audit sink should default to the logger sink")
+	}
+
+	var got *AuditEntry
+	db.SetAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+		got = entry
+		return nil
+	}))
+
+	err := dbAuditHook(&HookContext{DB: db, Table: "orders", Action: ActionCreate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Table != "orders" {
+		t.Errorf("expected audit entry for orders, got %+v", got)
+	}
+}
+
+// TestAuditIDs tests that updates and deletes are audited without a pre-query, with
+// the keys of the rows written only when Config.Security.AuditIDs asks for them.
+// TestAuditIDs 测试更新和删除的审计不执行预查询，仅在 Config.Security.AuditIDs 要求时才包含所写入行的主键。
+func TestAuditIDs(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(3)}, {int64(5)}}, nil
+	})
+	var entries []*AuditEntry
+	db.SetAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}))
+	db.config.Security.AuditEnabled = true
+	db.hooks.RegisterGlobalWithPriority(HookAfterUpdate, auditHookPriority, dbAuditHook)
+	db.hooks.RegisterGlobalWithPriority(HookAfterDelete, auditHookPriority, dbAuditHook)
+	del := &Query{Table: "users", Action: ActionDelete, Where: []Condition{{Field: "age", Op: OpLess, Value: 18}}}
+
+	if result := db.ExecuteQuery(context.Background(), del); !result.Success {
+		t.Fatalf("delete failed: %+v", result.Error)
+	}
+	if q := backend.Queries(); len(q) != 1 || strings.Contains(q[0], "RETURNING") {
+		t.Errorf("expected only the delete, got %v", q)
+	}
+	if len(entries) != 1 || entries[0].IDs != nil {
+		t.Errorf("expected an entry without ids, got %+v", entries)
+	}
+
+	db.config.Security.AuditIDs = true
+	result := db.ExecuteQuery(context.Background(), del)
+	if !result.Success {
+		t.Fatalf("delete failed: %+v", result.Error)
+	}
+	if q := backend.Queries()[1]; !strings.HasSuffix(q, `RETURNING "id"`) {
+		t.Errorf("expected the keys to be returned by the delete, got %s", q)
+	}
+	if len(entries) != 2 || !slices.Equal(entries[1].IDs, []any{int64(3), int64(5)}) {
+		t.Errorf("expected the written keys in the entry, got %+v", entries[len(entries)-1])
+	}
+	if result.IDs != nil || result.AffectedKeys != nil {
+		t.Errorf("keys only requested for the audit should not be reported, got %+v", result)
+	}
+
+	// Writes that cannot report their keys are audited without them
+	// 无法报告主键的写入在审计时不带主键
+	if err := db.Register(&userRole{}); err != nil {
+		t.Fatal(err)
+	}
+	delRoles := &Query{Table: "user_roles", Action: ActionDelete, Where: []Condition{{Field: "user_id", Op: OpEqual, Value: 1}}}
+	if result := db.ExecuteQuery(context.Background(), delRoles); !result.Success {
+		t.Fatalf("delete on a composite key failed: %+v", result.Error)
+	}
+	if q := backend.Queries()[2]; strings.Contains(q, "RETURNING") {
+		t.Errorf("expected a plain delete on a composite key, got %s", q)
+	}
+	if len(entries) != 3 || entries[2].IDs != nil {
+		t.Errorf("expected an entry without ids, got %+v", entries[len(entries)-1])
+	}
+
+	many := make([][]driver.Value, maxReturnIDs+1)
+	for i := range many {
+		many[i] = []driver.Value{int64(i + 1)}
+	}
+	mysql, mysqlBackend := newFakeDB(t, &MySQLDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, many, nil
+	})
+	mysql.SetAuditSink(db.AuditSink())
+	mysql.config.Security.AuditEnabled, mysql.config.Security.AuditIDs = true, true
+	mysql.hooks.RegisterGlobalWithPriority(HookAfterDelete, auditHookPriority, dbAuditHook)
+	if result := mysql.ExecuteQuery(context.Background(), del); !result.Success {
+		t.Fatalf("delete of more rows than return_ids reports failed: %+v", result.Error)
+	}
+	if q := mysqlBackend.Queries(); len(q) != 2 || q[1] != "DELETE FROM `users` WHERE `age` < ?" {
+		t.Errorf("expected the key select, then the delete as given, got %v", q)
+	}
+	if len(entries) != 4 || entries[3].IDs != nil {
+		t.Errorf("expected an entry without ids, got %+v", entries[len(entries)-1])
+	}
+}
+
+// TestHooksFireDuringExecution tests that before and after hooks run around query execution.
+// TestHooksFireDuringExecution 测试 before 和 after 钩子在查询执行前后运行。
+func TestHooksFireDuringExecution(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+
+	var fired []HookType
+	record := func(hookType HookType) HookFunc {
+		return func(ctx *HookContext) error {
+			fired = append(fired, hookType)
+			if hookType == HookAfterFind && (ctx.Result == nil || len(ctx.Result.Data) != 1) {
+				t.Error("after_find should receive the result")
+			}
+			return nil
+		}
+	}
+	for _, hookType := range []HookType{
+		HookBeforeFind, HookAfterFind,
+		HookBeforeCreate, HookAfterCreate,
+		HookBeforeUpdate, HookAfterUpdate,
+		HookBeforeDelete, HookAfterDelete,
+	} {
+		db.Hook("users", hookType, record(hookType))
+	}
+
+	ctx := context.Background()
+	where := []Condition{{Field: "id", Op: "=", Value: 1}}
+	queries := []*Query{
+		{Table: "users", Action: ActionFind},
+		{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "Alice"}},
+		{Table: "users", Action: ActionUpdate, Where: where, Data: map[string]any{"name": "Bob"}},
+		{Table: "users", Action: ActionDelete, Where: where},
+	}
+	for _, q := range queries {
+		if result := db.ExecuteQuery(ctx, q); !result.Success {
+			t.Fatalf("%s failed: %v", q.Action, result.Error)
+		}
+	}
+
+	expected := []HookType{
+		HookBeforeFind, HookAfterFind,
+		HookBeforeCreate, HookAfterCreate,
+		HookBeforeUpdate, HookAfterUpdate,
+		HookBeforeDelete, HookAfterDelete,
+	}
+	if len(fired) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, fired)
+	}
+	for i := range expected {
+		if fired[i] != expected[i] {
+			t.Errorf("hook %d: expected %s, got %s", i, expected[i], fired[i])
+		}
+	}
+}
+
+// TestHookAbortsExecution tests that Skip and Error stop the query before SQL runs.
+// TestHookAbortsExecution 测试 Skip 和 Error 会在执行 SQL 之前停止查询。
+func TestHookAbortsExecution(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, nil)
+
+	db.Hook("users", HookBeforeCreate, func(ctx *HookContext) error {
+		ctx.Skip = true
+		return nil
+	})
+	db.Hook("users", HookBeforeUpdate, func(ctx *HookContext) error {
+		ctx.Error = errors.New("updates disabled")
+		return nil
+	})
+
+	ctx := context.Background()
+	result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "x"}})
+	if !result.Success || result.Status != "skipped" {
+		t.Errorf("expected skipped result, got %+v", result)
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "users",
+		Action: ActionUpdate,
+		Where:  []Condition{{Field: "id", Op: "=", Value: 1}},
+		Data:   map[string]any{"name": "x"},
+	})
+	if result.Success || result.Error.Code != "HOOK_ERROR" {
+		t.Errorf("expected HOOK_ERROR, got %+v", result)
+	}
+
+	if n := len(backend.Queries()); n != 0 {
+		t.Errorf("expected no SQL to run, got %d statements", n)
+	}
+}
+
+// TestSoftDeleteDuringExecution tests that soft delete turns DELETE into UPDATE.
+// TestSoftDeleteDuringExecution 测试软删除会将 DELETE 转换为 UPDATE。
+func TestSoftDeleteDuringExecution(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, nil)
+	db.EnableSoftDelete("users", "")
+
+	result := db.ExecuteQuery(context.Background(), &Query{
+		Table:  "users",
+		Action: ActionDelete,
+		Where:  []Condition{{Field: "id", Op: "=", Value: 1}},
+	})
+	if !result.Success {
+		t.Fatalf("delete failed: %v", result.Error)
+	}
+
+	queries := backend.Queries()
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "UPDATE") {
+		t.Errorf("expected a single UPDATE, got %v", queries)
+	}
+	if !result.SoftDeleted {
+		t.Error("expected the result to report a soft delete")
+	}
+
+	result = db.ExecuteQuery(context.Background(), &Query{
+		Table:  "orders",
+		Action: ActionDelete,
+		Where:  []Condition{{Field: "id", Op: "=", Value: 1}},
+	})
+	if !result.Success || result.SoftDeleted {
+		t.Errorf("expected a hard delete on a table without soft delete, got %+v", result)
+	}
+
+	result = db.ExecuteQuery(context.Background(), &Query{
+		Action: ActionTransaction,
+		Operations: []Query{
+			{Table: "users", Action: ActionDelete, Where: []Condition{{Field: "id", Op: "=", Value: 2}}},
+			{Table: "orders", Action: ActionDelete, Where: []Condition{{Field: "id", Op: "=", Value: 2}}},
+		},
+	})
+	if !result.Success || !result.Results[0].SoftDeleted || result.Results[1].SoftDeleted {
+		t.Errorf("expected only the users operation to report a soft delete, got %+v", result)
+	}
+}
+
+// TestSoftDeleteRecordsActor tests that soft delete sets DeletedByField from the request actor.
+// TestSoftDeleteRecordsActor 测试软删除会根据请求执行者设置 DeletedByField。
+func TestSoftDeleteRecordsActor(t *testing.T) {
+	var args []driver.NamedValue
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		return nil, nil, nil
+	})
+	db.config.Naming.DeletedByField = "deleted_by"
+	db.EnableSoftDelete("users", "")
+
+	del := func(ctx context.Context) {
+		t.Helper()
+		result := db.ExecuteQuery(ctx, &Query{
+			Table:  "users",
+			Action: ActionDelete,
+			Where:  []Condition{{Field: "id", Op: OpEqual, Value: 1}},
+		})
+		if !result.Success {
+			t.Fatalf("delete failed: %+v", result.Error)
+		}
+	}
+
+	del(WithActor(context.Background(), "admin"))
+	queries := backend.Queries()
+	if want := `UPDATE "users" SET "deleted_at" = $1, "deleted_by" = $2 WHERE "id" = $3`; queries[0] != want {
+		t.Errorf("SQL = %s, want %s", queries[0], want)
+	}
+	if at, ok := args[0].Value.(time.Time); !ok || time.Since(at).Abs() > time.Minute {
+		t.Errorf("deleted_at = %#v, want the current time", args[0].Value)
+	}
+	if args[1].Value != "admin" {
+		t.Errorf("deleted_by = %v, want admin", args[1].Value)
+	}
+
+	del(context.Background())
+	if q := backend.Queries()[1]; strings.Contains(q, "deleted_by") {
+		t.Errorf("deleted_by should be left alone without an actor: %s", q)
+	}
+}
+
+// TestHookAddsConditions tests scoping finds, updates and deletes from before hooks.
+// TestHookAddsConditions 测试在 before 钩子中限定查询、更新和删除的范围。
+func TestHookAddsConditions(t *testing.T) {
+	var args []driver.NamedValue
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	tenant := func(ctx *HookContext) error {
+		return ctx.Require("tenant_id", OpEqual, 7)
+	}
+	db.Hook("users", HookBeforeFind, tenant)
+	db.Hook("users", HookBeforeDelete, tenant)
+	db.Hook("users", HookBeforeCreate, tenant)
+
+	ctx := context.Background()
+	where := make([]Condition, 1, 4)
+	where[0] = Condition{Field: "id", Op: OpEqual, Value: 1}
+	find := &Query{Table: "users", Action: ActionFind, Where: where}
+	if result := db.ExecuteQuery(ctx, find); !result.Success {
+		t.Fatalf("find failed: %v", result.Error)
+	}
+	sql := backend.Queries()[0]
+	if !strings.Contains(sql, `"id" = ?`) || !strings.Contains(sql, `"tenant_id" = ?`) {
+		t.Errorf("expected the find scoped to the tenant, got %s", sql)
+	}
+	if len(args) != 2 || args[1].Value != int64(7) {
+		t.Errorf("expected the tenant bound last, got %v", args)
+	}
+	if extra := where[:2][1]; extra.Field != "" {
+		t.Errorf("the caller's Where slice should not be written to, got %+v", extra)
+	}
+
+	// Running the same Query again adds the condition once more, not twice
+	// 再次执行同一个 Query 只会再添加一次条件，而不是两次
+	if result := db.ExecuteQuery(ctx, find); !result.Success {
+		t.Fatalf("find failed: %v", result.Error)
+	}
+	if len(find.Where) != 1 {
+		t.Errorf("the caller's Query should not be written to, got %+v", find.Where)
+	}
+	if n := strings.Count(backend.Queries()[1], `"tenant_id" = ?`); n != 1 {
+		t.Errorf("expected one tenant condition on reuse, got %s", backend.Queries()[1])
+	}
+
+	if result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionDelete, Where: where}); !result.Success {
+		t.Fatalf("delete failed: %v", result.Error)
+	}
+	if sql := backend.Queries()[2]; !strings.HasPrefix(sql, "DELETE") || !strings.Contains(sql, `"tenant_id" = ?`) {
+		t.Errorf("expected the delete scoped to the tenant, got %s", sql)
+	}
+
+	result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "x"}})
+	if result.Success || result.Error.Code != "HOOK_ERROR" {
+		t.Errorf("creates have no conditions to scope, got %+v", result)
+	}
+
+	hookCtx := &HookContext{Query: &Query{Table: "users", Action: ActionFind}}
+	if err := hookCtx.Require("tenant_id", "~~", 7); err == nil {
+		t.Error("expected an invalid operator to be rejected")
+	}
+}
+
+type tenantNote struct {
+	ID       uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+	TenantID int64  `json:"tenant_id"`
+	Body     string `json:"body"`
+}
+
+type sharedSetting struct {
+	ID  uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+	Key string `json:"key"`
+}
+
+type tenantKey struct{}
+
+// TestTenantScope tests the built-in tenant scoping hook.
+// TestTenantScope 测试内置的租户范围限定钩子。
+func TestTenantScope(t *testing.T) {
+	var args []driver.NamedValue
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = a
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	if err := db.Register(&tenantNote{}, &sharedSetting{}); err != nil {
+		t.Fatal(err)
+	}
+	db.EnableTenantScope("tenant_id", func(ctx context.Context) any { return ctx.Value(tenantKey{}) })
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+	if result := db.ExecuteQuery(ctx, &Query{Table: "tenant_notes", Action: ActionFind}); !result.Success {
+		t.Fatalf("find failed: %v", result.Error)
+	}
+	if sql := backend.Queries()[0]; !strings.Contains(sql, `"tenant_id" = ?`) {
+		t.Errorf("expected the find scoped to the tenant, got %s", sql)
+	}
+
+	if result := db.ExecuteQuery(ctx, &Query{Table: "tenant_notes", Action: ActionCreate, Data: map[string]any{"body": "hi", "tenant_id": 8}}); !result.Success {
+		t.Fatalf("create failed: %v", result.Error)
+	}
+	var bound []any
+	for _, a := range args {
+		bound = append(bound, a.Value)
+	}
+	if sql := backend.Queries()[1]; !strings.Contains(sql, `"tenant_id"`) || !slices.Contains(bound, any(int64(7))) || slices.Contains(bound, any(int64(8))) {
+		t.Errorf("expected the created row to belong to the tenant, got %s %v", sql, bound)
+	}
+
+	if result := db.ExecuteQuery(ctx, &Query{Table: "shared_settings", Action: ActionFind}); !result.Success {
+		t.Fatalf("find failed: %v", result.Error)
+	}
+	if sql := backend.Queries()[2]; strings.Contains(sql, "tenant_id") {
+		t.Errorf("tables without the tenant column should not be scoped, got %s", sql)
+	}
+
+	where := []Condition{{Field: "id", Op: OpEqual, Value: 1}}
+	for _, q := range []*Query{
+		{Table: "tenant_notes", Action: ActionDelete, Where: where},
+		{Table: "tenant_notes", Action: ActionUpdate, Where: where, Data: map[string]any{"body": "x"}},
+		{Table: "tenant_notes", Action: ActionCreate, Data: map[string]any{"body": "x"}},
+		{Table: "tenant_notes", Action: ActionCreateBatch, DataBatch: []map[string]any{{"body": "x"}}},
+	} {
+		result := db.ExecuteQuery(context.Background(), q)
+		if result.Success || result.Error.Code != "MISSING_TENANT" {
+			t.Errorf("%s without a tenant: expected MISSING_TENANT, got %+v", q.Action, result.Error)
+		}
+	}
+	if n := len(backend.Queries()); n != 3 {
+		t.Errorf("writes without a tenant should not run, got %d statements", n)
+	}
+
+	update := &Query{Table: "tenant_notes", Action: ActionUpdate, Where: where, Data: map[string]any{"tenant_id": int64(8)}}
+	if result := db.ExecuteQuery(ctx, update); !result.Success {
+		t.Fatalf("update failed: %v", result.Error)
+	}
+	bound = bound[:0]
+	for _, a := range args {
+		bound = append(bound, a.Value)
+	}
+	if slices.Contains(bound, any(int64(8))) {
+		t.Errorf("an update should not move rows to another tenant, got %s %v", backend.Queries()[3], bound)
+	}
+}
+
+// TestTenantScopeReads tests that every read action is scoped to the tenant and
+// fails without one.
+// TestTenantScopeReads 测试每种读取操作都被限定在租户内，且没有租户时失败。
+func TestTenantScopeReads(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "pg_class") {
+			return []string{"reltuples"}, [][]driver.Value{{int64(1000)}}, nil
+		}
+		if strings.HasPrefix(query, "SELECT EXISTS") {
+			return []string{"exists"}, [][]driver.Value{{true}}, nil
+		}
+		return []string{"n"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	if err := db.Register(&tenantNote{}); err != nil {
+		t.Fatal(err)
+	}
+	db.EnableTenantScope("tenant_id", func(ctx context.Context) any { return ctx.Value(tenantKey{}) })
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+
+	reads := []*Query{
+		{Table: "tenant_notes", Action: ActionFind},
+		{Table: "tenant_notes", Action: ActionCount},
+		{Table: "tenant_notes", Action: ActionCount, Estimate: true},
+		{Table: "tenant_notes", Action: ActionAggregate, Select: []any{map[string]any{"fn": "count", "as": "n"}}},
+		{Table: "tenant_notes", Action: ActionExists},
+	}
+	for i, q := range reads {
+		if result := db.ExecuteQuery(ctx, q); !result.Success {
+			t.Fatalf("%s failed: %v", q.Action, result.Error)
+		}
+		if sql := backend.Queries()[i]; !strings.Contains(sql, `"tenant_id" = $1`) {
+			t.Errorf("%s: expected the read scoped to the tenant, got %s", q.Action, sql)
+		}
+	}
+	if ok, err := db.Exists(ctx, &Query{Table: "tenant_notes"}); err != nil || !ok {
+		t.Errorf("expected Exists to find the tenant's rows, got %v, %v", ok, err)
+	}
+
+	for _, q := range reads {
+		result := db.ExecuteQuery(context.Background(), q)
+		if result.Success || result.Error.Code != "MISSING_TENANT" {
+			t.Errorf("%s without a tenant: expected MISSING_TENANT, got %+v", q.Action, result.Error)
+		}
+	}
+	if _, err := db.Exists(context.Background(), &Query{Table: "tenant_notes"}); err == nil {
+		t.Error("expected Exists without a tenant to fail")
+	}
+	if n := len(backend.Queries()); n != len(reads)+1 {
+		t.Errorf("reads without a tenant should not run, got %d statements", n)
+	}
+}
+
+// TestTenantScopeSubqueries tests that subqueries and joins on scoped tables are
+// scoped to the tenant as well.
+// TestTenantScopeSubqueries 测试受限定表上的子查询和连接同样被限定在租户内。
+func TestTenantScopeSubqueries(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, nil, nil
+	})
+	if err := db.Register(&tenantNote{}, &sharedSetting{}); err != nil {
+		t.Fatal(err)
+	}
+	db.EnableTenantScope("tenant_id", func(ctx context.Context) any { return ctx.Value(tenantKey{}) })
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+
+	where := []Condition{{OrGroup: []Condition{
+		{Field: "id", Op: OpIn, Subquery: &Query{Table: "tenant_notes", Select: []any{"id"},
+			Where: []Condition{{Field: "body", Op: OpLike, Value: "a%"}}}},
+		{Field: "id", Op: OpIn, Subquery: &Query{Table: "shared_settings", Select: []any{"id"}}},
+	}}}
+	if result := db.ExecuteQuery(ctx, &Query{Table: "tenant_notes", Action: ActionDelete, Where: where}); !result.Success {
+		t.Fatalf("delete failed: %v", result.Error)
+	}
+	want := `DELETE FROM "tenant_notes" WHERE ("id" IN (SELECT "id" FROM "tenant_notes" WHERE "body" LIKE $1 AND "tenant_id" = $2) ` +
+		`OR "id" IN (SELECT "id" FROM "shared_settings")) AND "tenant_id" = $3`
+	if sql := backend.Queries()[0]; sql != want {
+		t.Errorf("expected the subquery scoped to the tenant:\n got %s\nwant %s", sql, want)
+	}
+	if len(where[0].OrGroup[0].Subquery.Where) != 1 {
+		t.Error("scoping should not modify the caller's subquery")
+	}
+
+	join := &Query{Table: "shared_settings", Action: ActionFind,
+		Join: []JoinClause{{Table: "tenant_notes", On: map[string]string{"shared_settings.id": "tenant_notes.id"}}}}
+	if result := db.ExecuteQuery(ctx, join); !result.Success {
+		t.Fatalf("find failed: %v", result.Error)
+	}
+	if sql := backend.Queries()[1]; !strings.HasSuffix(sql, `WHERE tenant_notes.tenant_id = $1`) {
+		t.Errorf("expected the joined table scoped to the tenant, got %s", sql)
+	}
+
+	join.Join[0].Type = "left"
+	if result := db.ExecuteQuery(ctx, join); result.Success || result.Error.Code != "UNSCOPED_JOIN" {
+		t.Errorf("expected UNSCOPED_JOIN for an outer join, got %+v", result.Error)
+	}
+	if n := len(backend.Queries()); n != 2 {
+		t.Errorf("refused joins should not run, got %d statements", n)
+	}
+}
@@ -0,0 +1,255 @@
+package goorm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultIdentifierPattern matches the table, column and alias names a query may
+// use: a name, optionally qualified by a table ("users.id").
+//
+// DefaultIdentifierPattern 匹配查询可使用的表名、列名和别名：一个名称，可选地以表名限定（"users.id"）。
+var DefaultIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// IdentifierError is returned when a query names a table, column or alias that
+// does not match the identifier pattern. Identifiers are written into the SQL,
+// so they are checked before any SQL is built.
+//
+// IdentifierError 在查询中的表名、列名或别名不符合标识符模式时返回。
+// 标识符会被写入 SQL，因此在构建任何 SQL 之前进行检查。
+type IdentifierError struct {
+	// Identifier is the rejected name.
+	// Identifier 是被拒绝的名称。
+	Identifier string
+
+	// Path locates the identifier in the query, e.g. "where[0].field".
+	// Path 指出标识符在查询中的位置，例如 "where[0].field"。
+	Path string
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *IdentifierError) Error() string {
+	return fmt.Sprintf("invalid identifier %q in %s: use letters, digits and underscores, optionally qualified as table.column", e.Identifier, e.Path)
+}
+
+// identifierPattern returns the configured identifier pattern, or DefaultIdentifierPattern.
+// identifierPattern 返回配置的标识符模式，未配置时返回 DefaultIdentifierPattern。
+func (db *DB) identifierPattern() *regexp.Regexp {
+	if db.config.IdentifierPattern != nil {
+		return db.config.IdentifierPattern
+	}
+	return DefaultIdentifierPattern
+}
+
+// validate runs Query.Validate and checks every identifier in query.
+// validate 运行 Query.Validate 并检查 query 中的每个标识符。
+func (db *DB) validate(query *Query) error {
+	if err := query.Validate(); err != nil {
+		return err
+	}
+	if (query.BatchSize > 0 || query.ReturnIDs) && query.Table != "" {
+		// Both address the written rows by their single primary key
+		// 两者都通过单一主键定位写入的行
+		if _, err := db.primaryKeyColumn(query.Table); err != nil {
+			return err
+		}
+	}
+	c := &identifierChecker{pattern: db.identifierPattern(), funcs: db.sqlFuncs}
+	return c.query("", query)
+}
+
+// identifierChecker walks a query and rejects identifiers that do not match pattern.
+// identifierChecker 遍历查询并拒绝不符合 pattern 的标识符。
+type identifierChecker struct {
+	pattern *regexp.Regexp
+	funcs   *sqlFuncRegistry
+}
+
+// check rejects name at path unless it matches the pattern. allowStar also
+// accepts "*" and "table.*".
+//
+// check 除非 name 符合模式，否则拒绝 path 处的 name。allowStar 还接受 "*" 和 "table.*"。
+func (c *identifierChecker) check(path, name string, allowStar bool) error {
+	if allowStar {
+		if name == "*" {
+			return nil
+		}
+		if table, ok := strings.CutSuffix(name, ".*"); ok {
+			name = table
+		}
+	}
+	if !c.pattern.MatchString(name) {
+		return &IdentifierError{Identifier: name, Path: path}
+	}
+	return nil
+}
+
+// query checks the identifiers of q, whose path is prefix.
+// query 检查路径为 prefix 的 q 中的标识符。
+func (c *identifierChecker) query(prefix string, q *Query) error {
+	at := func(format string, args ...any) string {
+		return prefix + fmt.Sprintf(format, args...)
+	}
+
+	if q.Table != "" {
+		if err := c.check(at("table"), q.Table, false); err != nil {
+			return err
+		}
+	}
+	for i, sel := range q.Select {
+		switch v := sel.(type) {
+		case string:
+			if err := c.check(at("select[%d]", i), v, true); err != nil {
+				return err
+			}
+		case map[string]any:
+			if field, _ := v["field"].(string); field != "" {
+				if err := c.check(at("select[%d].field", i), field, true); err != nil {
+					return err
+				}
+			}
+			if as, _ := v["as"].(string); as != "" {
+				if err := c.check(at("select[%d].as", i), as, false); err != nil {
+					return err
+				}
+			}
+			fn, _ := v["fn"].(string)
+			if err := c.function(at("select[%d].fn", i), fn); err != nil {
+				return err
+			}
+			args, _ := v["args"].([]any)
+			if err := c.funcArgs(at("select[%d].args", i), fn, args); err != nil {
+				return err
+			}
+		}
+	}
+	for col := range q.Data {
+		if err := c.check(at("data"), col, false); err != nil {
+			return err
+		}
+	}
+	for i, record := range q.DataBatch {
+		for col := range record {
+			if err := c.check(at("data_batch[%d]", i), col, false); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.conditions(at("where"), q.Where); err != nil {
+		return err
+	}
+	for i, col := range q.GroupBy {
+		if err := c.check(at("group_by[%d]", i), col, false); err != nil {
+			return err
+		}
+	}
+	for i, h := range q.Having {
+		if h.Field != "" {
+			if err := c.check(at("having[%d].field", i), h.Field, true); err != nil {
+				return err
+			}
+		}
+		if err := c.function(at("having[%d].fn", i), h.Fn); err != nil {
+			return err
+		}
+		if err := c.funcArgs(at("having[%d].args", i), h.Fn, h.Args); err != nil {
+			return err
+		}
+	}
+	for i, o := range q.OrderBy {
+		if err := c.check(at("order_by[%d].field", i), o.Field, false); err != nil {
+			return err
+		}
+	}
+	for i, col := range q.Returning {
+		if err := c.check(at("returning[%d]", i), col, true); err != nil {
+			return err
+		}
+	}
+	for i, j := range q.Join {
+		if err := c.check(at("join[%d].table", i), j.Table, false); err != nil {
+			return err
+		}
+		for left, right := range j.On {
+			if err := c.check(at("join[%d].on", i), left, false); err != nil {
+				return err
+			}
+			if err := c.check(at("join[%d].on", i), right, false); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range q.Operations {
+		if err := c.query(at("operations[%d].", i), &q.Operations[i]); err != nil {
+			return err
+		}
+	}
+	if q.QueryToExplain != nil {
+		if err := c.query(at("query."), q.QueryToExplain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conditions checks the fields of conds and of their nested groups and subqueries.
+// conditions 检查 conds 及其嵌套分组和子查询中的字段。
+func (c *identifierChecker) conditions(path string, conds []Condition) error {
+	for i, cond := range conds {
+		at := fmt.Sprintf("%s[%d]", path, i)
+		if cond.Field != "" {
+			if err := c.check(at+".field", cond.Field, false); err != nil {
+				return err
+			}
+		}
+		if cond.Ref != "" {
+			if err := c.check(at+".ref", cond.Ref, false); err != nil {
+				return err
+			}
+		}
+		if err := c.conditions(at+".and", cond.And); err != nil {
+			return err
+		}
+		if err := c.conditions(at+".or_group", cond.OrGroup); err != nil {
+			return err
+		}
+		if cond.Subquery != nil {
+			if err := c.query(at+".subquery.", cond.Subquery); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// function rejects name at path unless it is an aggregate function or a
+// registered SQL function, as it is written into the SQL.
+// function 拒绝 path 处既不是聚合函数也不是已注册 SQL 函数的 name，因为它会被写入 SQL。
+func (c *identifierChecker) function(path, name string) error {
+	if isAggregateFn(name) {
+		return nil
+	}
+	if _, ok := c.funcs.get(name); ok {
+		return nil
+	}
+	return fmt.Errorf("unknown function %q in %s: use count, sum, avg, min, max or a function registered with RegisterSQLFunc", name, path)
+}
+
+// funcArgs checks the string arguments a registered SQL function writes as identifiers.
+// funcArgs 检查已注册 SQL 函数作为标识符写入的字符串参数。
+func (c *identifierChecker) funcArgs(path, name string, args []any) error {
+	fn, ok := c.funcs.get(name)
+	if !ok {
+		return nil
+	}
+	for i, arg := range args {
+		if s, ok := arg.(string); ok && fn.quotesArg(i) {
+			if err := c.check(fmt.Sprintf("%s[%d]", path, i), s, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
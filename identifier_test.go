@@ -0,0 +1,97 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+)
+
+// TestIdentifierValidation tests that names which could inject SQL are rejected before any SQL runs.
+// TestIdentifierValidation 测试可能注入 SQL 的名称会在执行任何 SQL 之前被拒绝。
+func TestIdentifierValidation(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, nil, nil
+	})
+	if err := db.RegisterSQLFunc("date_trunc", "date_trunc('{0}', {1})"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	evil := "users.id; DROP TABLE users; --"
+
+	tests := []struct {
+		name  string
+		query *Query
+		path  string
+	}{
+		{"where field", &Query{Table: "users", Action: ActionFind, Where: []Condition{{Field: evil, Op: OpEqual, Value: 1}}}, "where[0].field"},
+		{"nested ref", &Query{Table: "users", Action: ActionFind, Where: []Condition{{OrGroup: []Condition{{Field: "a", Op: OpEqual, Ref: evil}}}}}, "where[0].or_group[0].ref"},
+		{"group by", &Query{Table: "users", Action: ActionAggregate, Select: []any{map[string]any{"fn": "count", "as": "n"}}, GroupBy: []string{evil}}, "group_by[0]"},
+		{"order by", &Query{Table: "users", Action: ActionFind, OrderBy: []Order{{Field: evil}}}, "order_by[0].field"},
+		{"table", &Query{Table: "users u", Action: ActionFind}, "table"},
+		{"data column", &Query{Table: "users", Action: ActionCreate, Data: map[string]any{evil: 1}}, "data"},
+		{"join on", &Query{Table: "users", Action: ActionFind, Join: []JoinClause{{Table: "orders", On: map[string]string{"users.id": evil}}}}, "join[0].on"},
+		{"function column", &Query{Table: "users", Action: ActionAggregate, Select: []any{map[string]any{"fn": "date_trunc", "args": []any{"day", evil}, "as": "day"}}}, "select[0].args[1]"},
+		{"transaction", &Query{Action: ActionTransaction, Operations: []Query{{Table: "users", Action: ActionDelete, Where: []Condition{{Field: evil, Op: OpEqual, Value: 1}}}}}, "operations[0].where[0].field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.ExecuteQuery(ctx, tt.query)
+			if result.Success || result.Error.Code != "INVALID_IDENTIFIER" || result.Error.Details["path"] != tt.path {
+				t.Errorf("expected INVALID_IDENTIFIER at %s, got %+v", tt.path, result.Error)
+			}
+		})
+	}
+
+	// Function names are written into the SQL too
+	// 函数名同样会被写入 SQL
+	for _, q := range []*Query{
+		{Table: "users", Action: ActionFind, Select: []any{map[string]any{"fn": "1; DROP TABLE users; --"}}},
+		{Table: "users", Action: ActionFind, Select: []any{map[string]any{"fn": "pg_sleep", "field": "id"}}},
+		{Table: "users", Action: ActionAggregate, Select: []any{map[string]any{"fn": "count", "as": "n"}},
+			GroupBy: []string{"name"}, Having: []HavingCondition{{Fn: "pg_sleep", Args: []any{"id"}, Op: OpGreater, Value: 1}}},
+	} {
+		if result := db.ExecuteQuery(ctx, q); result.Success || result.Error.Code != "VALIDATION_ERROR" {
+			t.Errorf("expected VALIDATION_ERROR for %v, got %+v", q.Select, result.Error)
+		}
+	}
+	if err := (&Query{Table: "users", Action: ActionFind, Select: []any{map[string]any{"fn": "1; DROP TABLE users; --"}}}).Validate(); err == nil {
+		t.Error("Validate should reject a function that is not a name")
+	}
+	if _, err := NewSQLBuilder(&PostgresDialect{}, &Query{Table: "users", Action: ActionFind,
+		Select: []any{map[string]any{"fn": "1; DROP TABLE users; --"}}}).Build(); err == nil {
+		t.Error("the builder should reject an unknown function")
+	}
+	if err := db.RegisterSQLFunc("f(); --", "{0}"); err == nil {
+		t.Error("RegisterSQLFunc should reject a name that is not an identifier")
+	}
+
+	tx, err := db.BeginContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := tx.ExecuteContext(ctx, `{"table": "users", "action": "find", "where": [{"field": "id; DROP TABLE users", "op": "=", "value": 1}]}`)
+	tx.Rollback()
+	if result.Success || result.Error.Code != "INVALID_IDENTIFIER" {
+		t.Errorf("expected INVALID_IDENTIFIER in a transaction, got %+v", result.Error)
+	}
+
+	if n := len(backend.Queries()); n != 0 {
+		t.Errorf("rejected queries should not run, got %d", n)
+	}
+
+	valid := &Query{
+		Table:   "users",
+		Action:  ActionAggregate,
+		Select:  []any{"users.name", map[string]any{"fn": "date_trunc", "args": []any{"day", "created_at"}, "as": "day"}, map[string]any{"fn": "count", "field": "*", "as": "n"}},
+		GroupBy: []string{"users.name", "day"},
+	}
+	if result := db.ExecuteQuery(ctx, valid); !result.Success {
+		t.Errorf("valid identifiers rejected: %+v", result.Error)
+	}
+
+	db.config.IdentifierPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*$`)
+	if result := db.ExecuteQuery(ctx, &Query{Table: "用户", Action: ActionFind, Where: []Condition{{Field: "年龄", Op: OpGreater, Value: 18}}}); !result.Success {
+		t.Errorf("configured pattern should allow non-ASCII names: %+v", result.Error)
+	}
+}
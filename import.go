@@ -0,0 +1,356 @@
+package goorm
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// defaultImportBatchSize is the number of CSV rows written per chunk by default.
+// defaultImportBatchSize 是默认每个分块写入的 CSV 行数。
+const defaultImportBatchSize = 500
+
+// ImportOptions configures ImportCSV.
+// ImportOptions 配置 ImportCSV。
+type ImportOptions struct {
+	// BatchSize is the number of rows written per chunk; 0 uses 500.
+	// BatchSize 是每个分块写入的行数；0 表示使用 500。
+	BatchSize int
+
+	// Columns maps CSV headers to column names where they differ. Other headers
+	// are matched to the model's column, field or JSON names, ignoring case.
+	// Columns 将与列名不同的 CSV 表头映射到列名。其他表头按模型的列名、字段名或 JSON 名匹配，不区分大小写。
+	Columns map[string]string
+
+	// UpsertKey names a unique column; rows whose key already exists are updated
+	// instead of inserted, and of rows repeating a key the last one wins. Empty
+	// inserts every row.
+	// UpsertKey 指定一个唯一列；键已存在的行会被更新而不是插入，重复同一键的行以最后一行为准。为空时插入所有行。
+	UpsertKey string
+
+	// MaxErrors is the number of invalid rows skipped and reported before the
+	// import fails; 0 fails on the first.
+	// MaxErrors 是导入失败前跳过并报告的无效行数；0 表示遇到第一个即失败。
+	MaxErrors int
+
+	// Comma is the field delimiter; 0 uses ','.
+	// Comma 是字段分隔符；0 表示使用 ','。
+	Comma rune
+}
+
+// ImportResult reports the outcome of ImportCSV.
+// ImportResult 报告 ImportCSV 的结果。
+type ImportResult struct {
+	// Inserted is the number of rows inserted.
+	// Inserted 是插入的行数。
+	Inserted int64 `json:"inserted"`
+
+	// Updated is the number of existing rows updated through UpsertKey.
+	// Updated 是通过 UpsertKey 更新的已有行数。
+	Updated int64 `json:"updated"`
+
+	// Skipped is the number of invalid rows left out.
+	// Skipped 是被跳过的无效行数。
+	Skipped int64 `json:"skipped"`
+
+	// Errors describes the invalid rows.
+	// Errors 描述无效的行。
+	Errors []ImportRowError `json:"errors,omitempty"`
+}
+
+// ImportRowError describes why a CSV row was skipped.
+// ImportRowError 描述 CSV 行被跳过的原因。
+type ImportRowError struct {
+	// Line is the line of the row in the CSV input, starting at 1 for the header.
+	// Line 是该行在 CSV 输入中的行号，表头为第 1 行。
+	Line int `json:"line"`
+
+	// Column is the column whose cell was invalid, if any.
+	// Column 是单元格无效的列（如有）。
+	Column string `json:"column,omitempty"`
+
+	// Message describes the problem.
+	// Message 描述问题。
+	Message string `json:"message"`
+}
+
+// ImportCSV reads CSV rows with a header row from r and writes them to table in
+// one transaction, chunk by chunk. Cells are converted to the Go types of the
+// model's fields; empty cells become NULL, or "" in string columns. Invalid rows
+// are skipped and reported in Result.Import until there are more than
+// opts.MaxErrors of them. Like a transaction, the import holds off Shutdown until
+// it returns, and fails with SHUTTING_DOWN once Shutdown has begun.
+//
+// An error is returned with a nil Result when the import cannot start, e.g. the
+// table is not registered or a header matches no column. When a row fails to
+// write, or too many rows are invalid, the transaction is rolled back and the
+// failed Result is returned along with its error.
+//
+// ImportCSV 从 r 读取带表头的 CSV 行，并在一个事务中逐块写入 table。单元格会转换为模型字段的 Go 类型；
+// 空单元格为 NULL，字符串列中为 ""。无效行会被跳过并在 Result.Import 中报告，直到其数量超过 opts.MaxErrors。
+// 与事务一样，导入在返回前会推迟 Shutdown，Shutdown 开始后则以 SHUTTING_DOWN 失败。
+//
+// 导入无法开始时（例如表未注册或表头不匹配任何列）返回错误且 Result 为 nil。写入某行失败或无效行过多时，
+// 事务会回滚，并同时返回失败的 Result 及其错误。
+func (db *DB) ImportCSV(ctx context.Context, table string, r io.Reader, opts ImportOptions) (*Result, error) {
+	if db.config.ReadOnly {
+		return nil, readOnlyResult(ActionCreate).Err()
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return nil, fmt.Errorf("table %s is not registered", table)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatchSize
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	columns, err := importColumns(meta, header, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	if opts.UpsertKey != "" && !slices.Contains(columns, opts.UpsertKey) {
+		return nil, fmt.Errorf("upsert key %s is not a column of the CSV", opts.UpsertKey)
+	}
+
+	tx, err := db.BeginContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stats := &ImportResult{}
+	fail := func(code, message string) (*Result, error) {
+		result := &Result{
+			Success:  false,
+			Affected: stats.Inserted + stats.Updated,
+			Import:   stats,
+			Error: &ResultError{
+				Code:    code,
+				Message: message,
+			},
+		}
+		return result, result.Err()
+	}
+
+	var chunk []map[string]any
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var row map[string]any
+		var rowErr *ImportRowError
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			rowErr = &ImportRowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()}
+		case err != nil:
+			return fail("IMPORT_READ_ERROR", err.Error())
+		default:
+			line, _ := reader.FieldPos(0)
+			row, rowErr = db.importRow(meta, columns, record, line)
+		}
+		if rowErr != nil {
+			stats.Skipped++
+			stats.Errors = append(stats.Errors, *rowErr)
+			if len(stats.Errors) > opts.MaxErrors {
+				return fail("IMPORT_INVALID_ROWS", fmt.Sprintf("line %d: %s", rowErr.Line, rowErr.Message))
+			}
+			continue
+		}
+
+		chunk = append(chunk, row)
+		if len(chunk) == opts.BatchSize {
+			if r := tx.importChunk(ctx, table, chunk, opts.UpsertKey, stats); r != nil {
+				return fail(r.Error.Code, r.Error.Message)
+			}
+			chunk = chunk[:0:0]
+		}
+	}
+	if len(chunk) > 0 {
+		if r := tx.importChunk(ctx, table, chunk, opts.UpsertKey, stats); r != nil {
+			return fail(r.Error.Code, r.Error.Message)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail("TX_COMMIT_ERROR", err.Error())
+	}
+	return &Result{
+		Success:  true,
+		Affected: stats.Inserted + stats.Updated,
+		Import:   stats,
+	}, nil
+}
+
+// importColumns maps each CSV header to a column of meta.
+// importColumns 将每个 CSV 表头映射到 meta 的列。
+func importColumns(meta *ModelMeta, header []string, mapping map[string]string) ([]string, error) {
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if col, ok := mapping[name]; ok {
+			name = col
+		}
+		for _, field := range meta.Fields {
+			if strings.EqualFold(name, field.ColumnName) || strings.EqualFold(name, field.Name) ||
+				(field.JSONName != "" && strings.EqualFold(name, field.JSONName)) {
+				columns[i] = field.ColumnName
+				break
+			}
+		}
+		if columns[i] == "" {
+			return nil, fmt.Errorf("CSV column %q matches no column of %s", header[i], meta.TableName)
+		}
+	}
+	return columns, nil
+}
+
+// importRow converts a CSV record read at line into row data for columns.
+// importRow 将在 line 行读取的 CSV 记录转换为 columns 的行数据。
+func (db *DB) importRow(meta *ModelMeta, columns, record []string, line int) (map[string]any, *ImportRowError) {
+	if len(record) != len(columns) {
+		return nil, &ImportRowError{Line: line, Message: fmt.Sprintf("expected %d fields, got %d", len(columns), len(record))}
+	}
+
+	row := make(map[string]any, len(columns))
+	for i, col := range columns {
+		cell := record[i]
+		goType := db.columnGoType(meta.TableName, col)
+		if cell == "" && goType != "string" {
+			if !fieldNullable(meta, col) {
+				return nil, &ImportRowError{Line: line, Column: col, Message: "value is required"}
+			}
+			row[col] = nil
+			continue
+		}
+		value, err := coerceValue(cell, goType)
+		if err != nil {
+			return nil, &ImportRowError{Line: line, Column: col, Message: err.Error()}
+		}
+		row[col] = value
+	}
+	return row, nil
+}
+
+// fieldNullable reports whether column of meta accepts NULL.
+// fieldNullable 判断 meta 的 column 是否接受 NULL。
+func fieldNullable(meta *ModelMeta, column string) bool {
+	for _, field := range meta.Fields {
+		if field.ColumnName == column {
+			return field.Nullable
+		}
+	}
+	return false
+}
+
+// lastPerKey returns rows without those whose key column is repeated by a later row.
+// lastPerKey 返回去掉键列被后续行重复的行之后的 rows。
+func lastPerKey(rows []map[string]any, key string) []map[string]any {
+	last := make(map[string]int, len(rows))
+	for i, row := range rows {
+		last[fmt.Sprint(row[key])] = i
+	}
+	if len(last) == len(rows) {
+		return rows
+	}
+	kept := make([]map[string]any, 0, len(last))
+	for i, row := range rows {
+		if last[fmt.Sprint(row[key])] == i {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// importChunk writes rows to table, updating those whose upsertKey already exists
+// and inserting the rest, and adds the counts to stats.
+//
+// importChunk 将 rows 写入 table：更新 upsertKey 已存在的行，插入其余行，并将计数累加到 stats。
+func (t *Transaction) importChunk(ctx context.Context, table string, rows []map[string]any, upsertKey string, stats *ImportResult) *Result {
+	inserts := rows
+	if upsertKey != "" {
+		rows = lastPerKey(rows, upsertKey)
+		keys := make([]any, len(rows))
+		for i, row := range rows {
+			keys[i] = row[upsertKey]
+		}
+		found := t.executeOperation(ctx, &Query{
+			Table:  table,
+			Action: ActionFind,
+			Select: []any{upsertKey},
+			Where:  []Condition{{Field: upsertKey, Op: OpIn, Value: keys}},
+		})
+		if !found.Success {
+			return found
+		}
+		existing := make(map[string]bool, len(found.Data))
+		for _, row := range found.Data {
+			existing[fmt.Sprint(row[upsertKey])] = true
+		}
+
+		inserts = nil
+		for _, row := range rows {
+			if !existing[fmt.Sprint(row[upsertKey])] {
+				inserts = append(inserts, row)
+				continue
+			}
+			data := make(map[string]any, len(row)-1)
+			for col, value := range row {
+				if col != upsertKey {
+					data[col] = value
+				}
+			}
+			r := t.executeOperation(ctx, &Query{
+				Table:  table,
+				Action: ActionUpdate,
+				Where:  []Condition{{Field: upsertKey, Op: OpEqual, Value: row[upsertKey]}},
+				Data:   data,
+			})
+			if !r.Success {
+				return r
+			}
+			stats.Updated++
+		}
+	}
+	if len(inserts) == 0 {
+		return nil
+	}
+
+	if r := t.db.prepareBatch(ctx, table, inserts); r != nil {
+		return r
+	}
+	executor := NewExecutor(t.db)
+	pk := t.db.insertKeyColumn(table)
+	for _, batch := range insertChunks(inserts, t.db.dialect, pk) {
+		chunk := &Query{Table: table, Action: ActionCreateBatch, DataBatch: batch}
+		build, err := t.db.newBuilder(ctx, chunk).WithPrimaryKey(pk).Build()
+		if err != nil {
+			return &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:    "BUILD_ERROR",
+					Message: err.Error(),
+				},
+			}
+		}
+		if _, _, err := executor.insertBatch(ctx, t, chunk, build, pk); err != nil {
+			return executor.handleSQLError(err, build)
+		}
+		stats.Inserted += int64(len(batch))
+	}
+	return nil
+}
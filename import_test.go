@@ -0,0 +1,166 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type importedProduct struct {
+	ID         uint64     `json:"id" goorm:"primaryKey;autoIncrement"`
+	SKU        string     `json:"sku" goorm:"unique"`
+	Name       string     `json:"name"`
+	Price      float64    `json:"price"`
+	Stock      int        `json:"stock"`
+	ReleasedAt *time.Time `json:"released_at"`
+}
+
+// TestImportCSV tests importing CSV rows in chunks with row errors.
+// TestImportCSV 测试分块导入 CSV 行并报告行错误。
+func TestImportCSV(t *testing.T) {
+	var args [][]driver.NamedValue
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		args = append(args, a)
+		return nil, nil, nil
+	})
+	if err := db.Register(&importedProduct{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	csv := "\ufeffSKU,Name,price,Stock,released_at\n" +
+		"A1,Lamp,19.5,3,2024-03-01\n" +
+		"A2,Desk,cheap,1,\n" +
+		"A3,Chair,45,7,\n" +
+		"A4,Rug\n" +
+		"A5,Shelf,80,2,\n"
+	result, err := db.ImportCSV(ctx, "imported_products", strings.NewReader(csv), ImportOptions{BatchSize: 2, MaxErrors: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := result.Import
+	if !result.Success || stats.Inserted != 3 || stats.Skipped != 2 || result.Affected != 3 {
+		t.Fatalf("unexpected result %+v %+v", result, stats)
+	}
+	if e := stats.Errors[0]; e.Line != 3 || e.Column != "price" {
+		t.Errorf("expected the price on line 3 to be reported, got %+v", e)
+	}
+	if e := stats.Errors[1]; e.Line != 5 || !strings.Contains(e.Message, "expected 5 fields") {
+		t.Errorf("expected the short row on line 5 to be reported, got %+v", e)
+	}
+
+	queries := backend.Queries()
+	if len(queries) != 2 || !strings.HasPrefix(queries[0], "INSERT") {
+		t.Fatalf("expected two batch inserts, got %v", queries)
+	}
+	var bound []any
+	for _, a := range args[0] {
+		bound = append(bound, a.Value)
+	}
+	if !slices.Contains(bound, any(19.5)) || !slices.Contains(bound, any(int64(3))) || !slices.Contains(bound, nil) {
+		t.Errorf("expected cells converted to the field types, got %v", bound)
+	}
+
+	result, err = db.ImportCSV(ctx, "imported_products", strings.NewReader(csv), ImportOptions{})
+	if err == nil || result.Success || result.Error.Code != "IMPORT_INVALID_ROWS" || result.Import.Skipped != 1 {
+		t.Errorf("expected the first invalid row to fail the import, got %v %+v", err, result)
+	}
+
+	if _, err := db.ImportCSV(ctx, "imported_products", strings.NewReader("sku,colour\nA1,red\n"), ImportOptions{}); err == nil {
+		t.Error("expected an unknown header to be rejected")
+	}
+}
+
+// TestImportCSVUpsert tests updating rows whose upsert key already exists.
+// TestImportCSVUpsert 测试更新 upsert 键已存在的行。
+func TestImportCSVUpsert(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"sku"}, [][]driver.Value{{"A1"}}, nil
+		}
+		return nil, nil, nil
+	})
+	if err := db.Register(&importedProduct{}); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "product,name,price,stock,released_at\nA1,Lamp,21,3,\nB2,Vase,12,9,\n"
+	result, err := db.ImportCSV(context.Background(), "imported_products", strings.NewReader(csv), ImportOptions{
+		Columns:   map[string]string{"product": "sku"},
+		UpsertKey: "sku",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Import.Updated != 1 || result.Import.Inserted != 1 {
+		t.Errorf("expected one update and one insert, got %+v", result.Import)
+	}
+
+	var kinds []string
+	for _, q := range backend.Queries() {
+		kinds = append(kinds, strings.Fields(q)[0])
+	}
+	if strings.Join(kinds, ",") != "SELECT,UPDATE,INSERT" {
+		t.Errorf("expected a lookup, an update and an insert, got %v", backend.Queries())
+	}
+}
+
+// TestImportCSVDuplicateKeys tests that a key repeated within the CSV keeps its last row.
+// TestImportCSVDuplicateKeys 测试 CSV 中重复的键保留其最后一行。
+func TestImportCSVDuplicateKeys(t *testing.T) {
+	var args []driver.NamedValue
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, a []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT") {
+			return []string{"sku"}, nil, nil
+		}
+		args = a
+		return nil, nil, nil
+	})
+	if err := db.Register(&importedProduct{}); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "sku,name,price,stock,released_at\nA1,Lamp,21,3,\nB2,Vase,12,9,\nA1,Lantern,25,4,\n"
+	result, err := db.ImportCSV(context.Background(), "imported_products", strings.NewReader(csv), ImportOptions{UpsertKey: "sku"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Import.Inserted != 2 || result.Import.Updated != 0 {
+		t.Errorf("expected two inserts, got %+v", result.Import)
+	}
+	var bound []any
+	for _, a := range args {
+		bound = append(bound, a.Value)
+	}
+	if !slices.Contains(bound, any("Lantern")) || slices.Contains(bound, any("Lamp")) {
+		t.Errorf("expected only the last A1 row to be written, got %v (%v)", bound, backend.Queries())
+	}
+}
+
+// TestImportCSVShutdown tests that an import is rejected once Shutdown has begun.
+// TestImportCSVShutdown 测试 Shutdown 开始后导入会被拒绝。
+func TestImportCSVShutdown(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, nil)
+	if err := db.Register(&importedProduct{}); err != nil {
+		t.Fatal(err)
+	}
+	db.inflight = &inflight{}
+	db.cancelFunc = func() {}
+	if err := db.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "sku,name,price,stock,released_at\nA1,Lamp,21,3,\n"
+	result, err := db.ImportCSV(context.Background(), "imported_products", strings.NewReader(csv), ImportOptions{})
+	var queryErr *QueryError
+	if result != nil || !errors.As(err, &queryErr) || queryErr.Code != "SHUTTING_DOWN" {
+		t.Errorf("expected SHUTTING_DOWN, got %+v %v", result, err)
+	}
+	if q := backend.Queries(); len(q) != 0 {
+		t.Errorf("no statement should run, got %v", q)
+	}
+}
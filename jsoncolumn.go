@@ -0,0 +1,131 @@
+package goorm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// isJSONType reports whether sqlType is a JSON column type (JSON or JSONB).
+// isJSONType 判断 sqlType 是否为 JSON 列类型（JSON 或 JSONB）。
+func isJSONType(sqlType string) bool {
+	switch strings.ToLower(strings.TrimSpace(sqlType)) {
+	case "json", "jsonb":
+		return true
+	}
+	return false
+}
+
+// jsonColumns returns the columns of table whose declared SQL type on the current
+// dialect is a JSON type, or nil when the table is not registered.
+//
+// jsonColumns 返回 table 中在当前方言上声明为 JSON 类型的列；表未注册时返回 nil。
+func (db *DB) jsonColumns(table string) map[string]bool {
+	if db.registry == nil {
+		return nil
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return nil
+	}
+	var cols map[string]bool
+	for _, field := range meta.Fields {
+		if isJSONType(field.SQLTypeFor(db.dialect.Name())) {
+			if cols == nil {
+				cols = make(map[string]bool)
+			}
+			cols[field.ColumnName] = true
+		}
+	}
+	return cols
+}
+
+// encodeJSONColumns marshals the maps, slices and structs in data that are bound
+// for JSON columns of table, so drivers receive the JSON text. Strings, []byte and
+// other scalars are assumed to be JSON already and pass through.
+//
+// encodeJSONColumns 将 data 中写入 table 的 JSON 列的 map、切片和结构体序列化，
+// 使驱动收到 JSON 文本。字符串、[]byte 及其他标量视为已是 JSON，原样传递。
+func (db *DB) encodeJSONColumns(table string, data map[string]any) *Result {
+	if len(data) == 0 {
+		return nil
+	}
+	cols := db.jsonColumns(table)
+	for col, value := range data {
+		if !cols[col] || !isJSONValue(value) {
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return &Result{
+				Success: false,
+				Error: &ResultError{
+					Code:       "JSON_ENCODE_ERROR",
+					Message:    fmt.Sprintf("cannot encode %s as JSON: %v", col, err),
+					Suggestion: "Use values encoding/json can marshal, or pass the JSON text as a string",
+					Details:    map[string]any{"field": col},
+				},
+			}
+		}
+		data[col] = string(encoded)
+	}
+	return nil
+}
+
+// isJSONValue reports whether value is a map, slice or struct that must be
+// marshaled. Values implementing driver.Valuer convert themselves.
+//
+// isJSONValue 判断 value 是否为需要序列化的 map、切片或结构体。实现 driver.Valuer 的值自行转换。
+func isJSONValue(value any) bool {
+	switch value.(type) {
+	case nil, time.Time, driver.Valuer:
+		return false
+	}
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Array:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// decodeJSONColumns unmarshals the JSON columns of table in rows, so objects come
+// back as map[string]any and arrays as []any. Values that are not valid JSON are
+// left as scanned.
+//
+// decodeJSONColumns 反序列化 rows 中 table 的 JSON 列，使对象返回为 map[string]any，
+// 数组返回为 []any。不是合法 JSON 的值保持扫描时的原样。
+func (db *DB) decodeJSONColumns(table string, rows []map[string]any) {
+	if len(rows) == 0 {
+		return
+	}
+	cols := db.jsonColumns(table)
+	if len(cols) == 0 {
+		return
+	}
+	for _, row := range rows {
+		for col := range cols {
+			var raw []byte
+			switch v := row[col].(type) {
+			case string:
+				raw = []byte(v)
+			case []byte:
+				raw = v
+			default:
+				continue
+			}
+			var decoded any
+			if err := json.Unmarshal(raw, &decoded); err == nil {
+				row[col] = decoded
+			}
+		}
+	}
+}
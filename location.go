@@ -0,0 +1,59 @@
+package goorm
+
+import (
+	"strings"
+	"time"
+)
+
+// location returns the configured time zone, or UTC.
+// location 返回配置的时区，未配置时返回 UTC。
+func (db *DB) location() *time.Location {
+	if db.config.Location != nil {
+		return db.config.Location
+	}
+	return time.UTC
+}
+
+// now returns the current time in the configured time zone.
+// now 返回配置时区下的当前时间。
+func (db *DB) now() time.Time {
+	return time.Now().In(db.location())
+}
+
+// parseTimeColumns converts the timestamp strings SQLite returns for registered
+// time.Time columns of table into time.Time values in the configured time zone.
+// Strings in none of coerceTimeLayouts are left as scanned.
+//
+// parseTimeColumns 将 SQLite 为 table 中已注册的 time.Time 列返回的时间戳字符串转换为
+// 配置时区下的 time.Time 值。不符合 coerceTimeLayouts 中任何格式的字符串保持扫描时的原样。
+func (db *DB) parseTimeColumns(table string, rows []map[string]any) {
+	if len(rows) == 0 || db.dialect.Name() != "sqlite" || db.registry == nil {
+		return
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return
+	}
+	var cols []string
+	for _, field := range meta.Fields {
+		if strings.TrimPrefix(field.GoType, "*") == "time.Time" {
+			cols = append(cols, field.ColumnName)
+		}
+	}
+
+	loc := db.location()
+	for _, row := range rows {
+		for _, col := range cols {
+			s, ok := row[col].(string)
+			if !ok {
+				continue
+			}
+			for _, layout := range coerceTimeLayouts {
+				if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+					row[col] = t.In(loc)
+					break
+				}
+			}
+		}
+	}
+}
@@ -3,7 +3,9 @@ package goorm
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"slices"
 	"sync"
 	"time"
 )
@@ -37,6 +39,10 @@ func (l LogLevel) String() string {
 	}
 }
 
+// DefaultSlowThreshold is the default duration above which a query is considered slow.
+// DefaultSlowThreshold 是查询被视为慢查询的默认时长。
+const DefaultSlowThreshold = 200 * time.Millisecond
+
 // DefaultLogger is the default logger implementation.
 // DefaultLogger 是默认的日志记录器实现。
 type DefaultLogger struct {
@@ -158,6 +164,52 @@ func (l *DefaultLogger) log(level LogLevel, msg string, args ...any) {
 	l.output.Write(buf)
 }
 
+// SlogLogger adapts a *slog.Logger to the Logger interface.
+// The variadic key/value args are passed through as slog attributes.
+//
+// SlogLogger 将 *slog.Logger 适配为 Logger 接口。
+// 可变的键值对参数会作为 slog 属性传递。
+//
+// Example / 示例:
+//
+//	db.SetLogger(goorm.NewSlogLogger(slog.Default()))
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger creates a new slog adapter. A nil logger uses slog.Default().
+// NewSlogLogger 创建新的 slog 适配器。logger 为 nil 时使用 slog.Default()。
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger}
+}
+
+// Debug logs a debug message.
+// Debug 记录调试消息。
+func (l *SlogLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, args...)
+}
+
+// Info logs an info message.
+// Info 记录信息消息。
+func (l *SlogLogger) Info(msg string, args ...any) {
+	l.logger.Info(msg, args...)
+}
+
+// Warn logs a warning message.
+// Warn 记录警告消息。
+func (l *SlogLogger) Warn(msg string, args ...any) {
+	l.logger.Warn(msg, args...)
+}
+
+// Error logs an error message.
+// Error 记录错误消息。
+func (l *SlogLogger) Error(msg string, args ...any) {
+	l.logger.Error(msg, args...)
+}
+
 // QueryLogger logs query execution.
 // QueryLogger 记录查询执行。
 type QueryLogger struct {
@@ -171,7 +223,7 @@ type QueryLogger struct {
 func NewQueryLogger(logger Logger) *QueryLogger {
 	return &QueryLogger{
 		logger:        logger,
-		slowThreshold: 200 * time.Millisecond,
+		slowThreshold: DefaultSlowThreshold,
 		logAll:        false,
 	}
 }
@@ -225,7 +277,12 @@ type MetricsCollector struct {
 	totalDuration time.Duration
 	slowQueries   int64
 	errorQueries  int64
+	slowThreshold time.Duration
 	byAction      map[Action]*ActionMetrics
+
+	// latencyBuckets is the collector's copy of DefaultLatencyBuckets.
+	// latencyBuckets 是收集器持有的 DefaultLatencyBuckets 副本。
+	latencyBuckets []float64
 }
 
 // ActionMetrics contains metrics for a specific action.
@@ -234,16 +291,32 @@ type ActionMetrics struct {
 	Count    int64
 	Duration time.Duration
 	Errors   int64
+	Slow     int64
+
+	// buckets holds cumulative latency histogram counts, one per latency bucket of the collector.
+	// buckets 保存累计延迟直方图计数，与收集器的延迟桶一一对应。
+	buckets []int64
 }
 
-// NewMetricsCollector creates a new metrics collector.
-// NewMetricsCollector 创建新的指标收集器。
+// NewMetricsCollector creates a new metrics collector. Its latency histogram uses
+// DefaultLatencyBuckets as they are at this call.
+// NewMetricsCollector 创建新的指标收集器。其延迟直方图使用调用时的 DefaultLatencyBuckets。
 func NewMetricsCollector() *MetricsCollector {
 	return &MetricsCollector{
-		byAction: make(map[Action]*ActionMetrics),
+		slowThreshold:  DefaultSlowThreshold,
+		byAction:       make(map[Action]*ActionMetrics),
+		latencyBuckets: slices.Clone(DefaultLatencyBuckets),
 	}
 }
 
+// SetSlowThreshold sets the duration above which a query is counted as slow.
+// SetSlowThreshold 设置查询被计为慢查询的时长阈值。
+func (m *MetricsCollector) SetSlowThreshold(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slowThreshold = d
+}
+
 // RecordQuery records a query execution.
 // RecordQuery 记录查询执行。
 func (m *MetricsCollector) RecordQuery(action Action, duration time.Duration, err error) {
@@ -253,7 +326,8 @@ func (m *MetricsCollector) RecordQuery(action Action, duration time.Duration, er
 	m.totalQueries++
 	m.totalDuration += duration
 
-	if duration >= 200*time.Millisecond {
+	slow := duration >= m.slowThreshold
+	if slow {
 		m.slowQueries++
 	}
 
@@ -262,13 +336,26 @@ func (m *MetricsCollector) RecordQuery(action Action, duration time.Duration, er
 	}
 
 	if m.byAction[action] == nil {
-		m.byAction[action] = &ActionMetrics{}
+		m.byAction[action] = &ActionMetrics{
+			buckets: make([]int64, len(m.latencyBuckets)),
+		}
 	}
 
-	m.byAction[action].Count++
-	m.byAction[action].Duration += duration
+	am := m.byAction[action]
+	am.Count++
+	am.Duration += duration
 	if err != nil {
-		m.byAction[action].Errors++
+		am.Errors++
+	}
+	if slow {
+		am.Slow++
+	}
+
+	seconds := duration.Seconds()
+	for i, le := range m.latencyBuckets {
+		if seconds <= le {
+			am.buckets[i]++
+		}
 	}
 }
 
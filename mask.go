@@ -0,0 +1,65 @@
+package goorm
+
+import "strings"
+
+// Masking strategies for the mask tag of sensitive fields.
+// 敏感字段 mask 标签的脱敏方式。
+const (
+	MaskPartial = "partial" // Keep the first 3 and last 4 characters: 138****8888 / 保留前 3 位和后 4 位
+	MaskHide    = "hide"    // Leave the field out / 不输出该字段
+)
+
+// sensitiveColumns returns the sensitive columns of table mapped to their masking
+// strategy, or nil when masking is disabled or the table is not registered.
+//
+// sensitiveColumns 返回 table 的敏感列到其脱敏方式的映射；未启用脱敏或表未注册时返回 nil。
+func (db *DB) sensitiveColumns(table string) map[string]string {
+	if !db.config.Security.MaskSensitive || db.registry == nil {
+		return nil
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return nil
+	}
+	var cols map[string]string
+	for _, field := range meta.Fields {
+		if !field.Sensitive {
+			continue
+		}
+		if cols == nil {
+			cols = make(map[string]string)
+		}
+		cols[field.ColumnName] = field.Mask
+	}
+	return cols
+}
+
+// maskRow masks the sensitive columns of row in place. Columns masked partially
+// keep their ends; all others, including those without a mask tag, are removed.
+//
+// maskRow 就地脱敏 row 中的敏感列。部分脱敏的列保留首尾字符；其余列（包括没有 mask 标签的列）会被移除。
+func maskRow(row map[string]any, cols map[string]string) {
+	for col, mask := range cols {
+		value, ok := row[col]
+		if !ok || value == nil {
+			continue
+		}
+		if s, isString := value.(string); isString && mask == MaskPartial {
+			row[col] = maskPartial(s)
+			continue
+		}
+		delete(row, col)
+	}
+}
+
+// maskPartial replaces all but the first 3 and last 4 characters of s with
+// asterisks, or all of them when s is 7 characters or shorter.
+//
+// maskPartial 将 s 中除前 3 个和后 4 个字符以外的字符替换为星号；s 不超过 7 个字符时全部替换。
+func maskPartial(s string) string {
+	runes := []rune(s)
+	if len(runes) <= 7 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:3]) + strings.Repeat("*", len(runes)-7) + string(runes[len(runes)-4:])
+}
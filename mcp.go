@@ -1,11 +1,15 @@
 package goorm
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,16 +20,28 @@ import (
 // MCPServer 实现用于 AI 集成的模型上下文协议服务器。
 // 它将 GoORM 操作公开为 AI 可以发现和调用的 MCP 工具。
 type MCPServer struct {
-	db      *DB
-	name    string
-	version string
-	tools   map[string]*MCPTool
-	mu      sync.RWMutex
-	running bool
-	input   io.Reader
-	output  io.Writer
+	db       *DB
+	name     string
+	version  string
+	tools    map[string]*MCPTool
+	mu       sync.RWMutex
+	running  bool
+	stopCh   chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	timeout  time.Duration
+	readOnly bool
+	input    io.Reader
+	output   io.Writer
+	outMu    sync.Mutex
+	decoder  *json.Decoder
+	cache    *CacheManager
 }
 
+// DefaultMCPToolTimeout is the default time limit of a single tools/call.
+// DefaultMCPToolTimeout 是单次 tools/call 的默认时间限制。
+const DefaultMCPToolTimeout = 30 * time.Second
+
 // MCPTool represents an MCP tool definition.
 // MCPTool 表示 MCP 工具定义。
 type MCPTool struct {
@@ -66,6 +82,7 @@ func NewMCPServer(db *DB) *MCPServer {
 		name:    "goorm-mcp",
 		version: Version,
 		tools:   make(map[string]*MCPTool),
+		timeout: DefaultMCPToolTimeout,
 		input:   os.Stdin,
 		output:  os.Stdout,
 	}
@@ -121,6 +138,10 @@ func (s *MCPServer) registerBuiltinTools() {
 				"table": {
 					"type": "string",
 					"description": "The table name to describe"
+				},
+				"live": {
+					"type": "boolean",
+					"description": "Read columns, indexes and foreign keys from the database instead of the registered model"
 				}
 			},
 			"required": ["table"]
@@ -238,7 +259,8 @@ func (s *MCPServer) registerBuiltinTools() {
 		InputSchema: json.RawMessage(`{
 			"type": "object",
 			"properties": {
-				"query": {"type": "object", "description": "The JQL query to explain"}
+				"query": {"type": "object", "description": "The JQL query to explain"},
+				"plan": {"type": "boolean", "description": "Run EXPLAIN on the database to estimate rows, cost and indexes used"}
 			},
 			"required": ["query"]
 		}`),
@@ -282,7 +304,7 @@ func (s *MCPServer) registerBuiltinTools() {
 	// 13. get_stats - 获取数据库统计信息
 	s.RegisterTool(&MCPTool{
 		Name:        "get_stats",
-		Description: "Get database statistics including connection pool status and query metrics. / 获取数据库统计信息，包括连接池状态和查询指标。",
+		Description: "Get database statistics including connection pool status, query metrics and cache hit rate. / 获取数据库统计信息，包括连接池状态、查询指标和缓存命中率。",
 		InputSchema: json.RawMessage(`{
 			"type": "object",
 			"properties": {}
@@ -314,56 +336,206 @@ func (s *MCPServer) RegisterTool(tool *MCPTool) {
 	s.tools[tool.Name] = tool
 }
 
-// Start starts the MCP server.
-// Start 启动 MCP 服务器。
+// SetReadOnly restricts the server to read operations.
+// Any write generated by the AI is rejected with READ_ONLY_MODE,
+// and sync_schema is only allowed in preview mode.
+//
+// SetReadOnly 将服务器限制为只读操作。
+// AI 生成的任何写操作都会以 READ_ONLY_MODE 拒绝，sync_schema 仅允许预览模式。
+func (s *MCPServer) SetReadOnly(readOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.readOnly = readOnly
+	if readOnly && s.db != nil && !s.db.IsReadOnly() {
+		s.db = s.db.ReadOnly()
+	}
+}
+
+// SetToolTimeout sets the time limit of a single tools/call; zero or less means no limit.
+// SetToolTimeout 设置单次 tools/call 的时间限制；零或负值表示不限制。
+func (s *MCPServer) SetToolTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeout = timeout
+}
+
+// SetCache sets the cache manager whose statistics get_stats reports while it is enabled.
+// SetCache 设置缓存管理器，启用时 get_stats 会报告其统计信息。
+func (s *MCPServer) SetCache(cache *CacheManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = cache
+}
+
+// Start starts the MCP server. It reads messages until the input ends,
+// ctx is cancelled, Stop is called or a shutdown request arrives.
+//
+// Start 启动 MCP 服务器。它持续读取消息，直到输入结束、ctx 被取消、调用 Stop 或收到 shutdown 请求。
 func (s *MCPServer) Start(ctx context.Context) error {
+	// Tool calls derive from this context, so stopping cancels them
+	// 工具调用派生自此上下文，因此停止时会取消它们
+	ctx, cancel := context.WithCancel(ctx)
+	stopCh := make(chan struct{})
+	s.mu.Lock()
 	s.running = true
+	s.stopCh = stopCh
+	s.ctx = ctx
+	s.cancel = cancel
+	s.mu.Unlock()
+	defer s.Stop()
+
+	// Decode on a separate goroutine so the loop blocks instead of spinning
+	// 在单独的 goroutine 中解码，使循环阻塞等待而非空转
+	reads := make(chan mcpRead)
+	go func() {
+		for {
+			msg, err := s.readMessage()
+			select {
+			case reads <- mcpRead{msg: msg, err: err}:
+			case <-stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+			if err != nil && !isMCPParseError(err) {
+				return
+			}
+		}
+	}()
 
-	for s.running {
+	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		default:
-			if err := s.processMessage(); err != nil {
-				if err == io.EOF {
-					return nil
-				}
-				// Log error but continue
-				continue
+		case <-stopCh:
+			return nil
+		case read := <-reads:
+			response, err := s.respond(read.msg, read.err)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := s.writeMessage(response); err != nil {
+				return err
+			}
+			// Stop before reading more once a shutdown was handled
+			// 处理 shutdown 后不再读取后续消息
+			select {
+			case <-stopCh:
+				return nil
+			default:
 			}
 		}
 	}
+}
 
-	return nil
+// mcpRead is the outcome of reading one message from the input.
+// mcpRead 是从输入读取一条消息的结果。
+type mcpRead struct {
+	msg *MCPMessage
+	err error
 }
 
 // Stop stops the MCP server.
 // Stop 停止 MCP 服务器。
 func (s *MCPServer) Stop() {
-	s.running = false
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		close(s.stopCh)
+		s.cancel()
+		s.running = false
+	}
 }
 
 // processMessage processes a single MCP message.
 // processMessage 处理单个 MCP 消息。
 func (s *MCPServer) processMessage() error {
-	decoder := json.NewDecoder(s.input)
-	var msg MCPMessage
-	if err := decoder.Decode(&msg); err != nil {
+	response, err := s.respond(s.readMessage())
+	if err != nil {
 		return err
 	}
+	return s.writeMessage(response)
+}
 
-	response := s.handleMessage(&msg)
-	if response != nil {
-		encoder := json.NewEncoder(s.output)
-		return encoder.Encode(response)
+// respond returns the response to a read message. Malformed input gets a
+// JSON-RPC parse error (-32700); other read errors are returned.
+//
+// respond 返回已读取消息的响应。格式错误的输入得到 JSON-RPC 解析错误（-32700）；其他读取错误直接返回。
+func (s *MCPServer) respond(msg *MCPMessage, err error) (*MCPMessage, error) {
+	if err == nil {
+		return s.handleMessage(msg), nil
 	}
+	if !isMCPParseError(err) {
+		return nil, err
+	}
+	// JSON-RPC requires an explicit null id when the request id is unknown
+	// 请求 id 未知时，JSON-RPC 要求显式的 null id
+	return &MCPMessage{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage("null"),
+		Error: &MCPError{
+			Code:    -32700,
+			Message: "Parse error",
+			Data:    err.Error(),
+		},
+	}, nil
+}
 
-	return nil
+// readMessage decodes the next message from a single long-lived decoder, so data
+// it buffered is not lost between messages. After a syntax error the rest of the
+// offending line is discarded and decoding resumes on the next line.
+//
+// readMessage 从单个长期存在的解码器中解码下一条消息，以免其缓冲的数据在消息之间丢失。
+// 出现语法错误后，会丢弃出错行的剩余部分，并从下一行继续解码。
+func (s *MCPServer) readMessage() (*MCPMessage, error) {
+	if s.decoder == nil {
+		s.decoder = json.NewDecoder(s.input)
+	}
+	var msg MCPMessage
+	if err := s.decoder.Decode(&msg); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			rest := bufio.NewReader(io.MultiReader(s.decoder.Buffered(), s.input))
+			rest.ReadString('\n')
+			s.decoder = json.NewDecoder(rest)
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// isMCPParseError reports whether err means the input was not a valid message.
+// isMCPParseError 判断 err 是否表示输入不是有效的消息。
+func isMCPParseError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// writeMessage encodes response to the output; nil responses are skipped.
+// writeMessage 将响应编码到输出；nil 响应会被跳过。
+func (s *MCPServer) writeMessage(response *MCPMessage) error {
+	if response == nil {
+		return nil
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	return json.NewEncoder(s.output).Encode(response)
 }
 
 // handleMessage handles an MCP message and returns a response.
-// handleMessage 处理 MCP 消息并返回响应。
+// Notifications (messages without an id) never get a response.
+//
+// handleMessage 处理 MCP 消息并返回响应。通知（没有 id 的消息）不会得到响应。
 func (s *MCPServer) handleMessage(msg *MCPMessage) *MCPMessage {
+	if msg.ID == nil {
+		return nil
+	}
+
 	switch msg.Method {
 	case "initialize":
 		return s.handleInitialize(msg)
@@ -371,6 +543,17 @@ func (s *MCPServer) handleMessage(msg *MCPMessage) *MCPMessage {
 		return s.handleToolsList(msg)
 	case "tools/call":
 		return s.handleToolsCall(msg)
+	case "resources/list":
+		return s.handleResourcesList(msg)
+	case "resources/read":
+		return s.handleResourcesRead(msg)
+	case "shutdown":
+		s.Stop()
+		return &MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  map[string]any{},
+		}
 	default:
 		return &MCPMessage{
 			JSONRPC: "2.0",
@@ -396,8 +579,10 @@ func (s *MCPServer) handleInitialize(msg *MCPMessage) *MCPMessage {
 				"version": s.version,
 			},
 			"capabilities": map[string]any{
-				"tools": map[string]any{},
+				"tools":     map[string]any{},
+				"resources": map[string]any{},
 			},
+			"readOnly": s.readOnly,
 		},
 	}
 }
@@ -432,6 +617,9 @@ func (s *MCPServer) handleToolsCall(msg *MCPMessage) *MCPMessage {
 	var params struct {
 		Name      string         `json:"name"`
 		Arguments map[string]any `json:"arguments"`
+		Meta      struct {
+			ProgressToken any `json:"progressToken"`
+		} `json:"_meta"`
 	}
 
 	if err := json.Unmarshal(msg.Params, &params); err != nil {
@@ -460,9 +648,13 @@ func (s *MCPServer) handleToolsCall(msg *MCPMessage) *MCPMessage {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := s.toolContext()
 	defer cancel()
 
+	if token := params.Meta.ProgressToken; token != nil {
+		ctx = WithProgress(ctx, s.progressNotifier(token))
+	}
+
 	result, err := tool.Handler(ctx, params.Arguments)
 	if err != nil {
 		return &MCPMessage{
@@ -495,6 +687,134 @@ func (s *MCPServer) handleToolsCall(msg *MCPMessage) *MCPMessage {
 	}
 }
 
+// toolContext returns the context of a tool call: derived from the running
+// server's context and limited by the tool timeout.
+//
+// toolContext 返回工具调用的上下文：派生自运行中服务器的上下文，并受工具超时限制。
+func (s *MCPServer) toolContext() (context.Context, context.CancelFunc) {
+	s.mu.RLock()
+	ctx, timeout := s.ctx, s.timeout
+	s.mu.RUnlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// progressNotifier returns a ProgressFunc that writes notifications/progress
+// messages for token, interleaved with the responses on the output.
+//
+// progressNotifier 返回为 token 写入 notifications/progress 消息的 ProgressFunc，这些消息与响应交错写入输出。
+func (s *MCPServer) progressNotifier(token any) ProgressFunc {
+	return func(done, total int, message string) {
+		params, _ := json.Marshal(map[string]any{
+			"progressToken": token,
+			"progress":      done,
+			"total":         total,
+			"message":       message,
+		})
+		s.writeMessage(&MCPMessage{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params:  params,
+		})
+	}
+}
+
+// mcpTableResourcePrefix is the URI prefix of table schema resources.
+// mcpTableResourcePrefix 是表 Schema 资源的 URI 前缀。
+const mcpTableResourcePrefix = "goorm://table/"
+
+// handleResourcesList handles the resources/list request.
+// Each registered table is listed as a goorm://table/<name> resource.
+//
+// handleResourcesList 处理 resources/list 请求。
+// 每个已注册的表都以 goorm://table/<name> 资源列出。
+func (s *MCPServer) handleResourcesList(msg *MCPMessage) *MCPMessage {
+	var tables []TableInfo
+	if s.db != nil && s.db.registry != nil {
+		tables = s.db.registry.ListTables()
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	resources := make([]map[string]any, 0, len(tables))
+	for _, table := range tables {
+		description := table.Description
+		if description == "" {
+			description = fmt.Sprintf("Schema of table %s", table.Name)
+		}
+		resources = append(resources, map[string]any{
+			"uri":         mcpTableResourcePrefix + table.Name,
+			"name":        table.Name,
+			"description": description,
+			"mimeType":    "application/json",
+		})
+	}
+
+	return &MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result: map[string]any{
+			"resources": resources,
+		},
+	}
+}
+
+// handleResourcesRead handles the resources/read request, returning the table schema as JSON.
+// handleResourcesRead 处理 resources/read 请求，以 JSON 返回表 Schema。
+func (s *MCPServer) handleResourcesRead(msg *MCPMessage) *MCPMessage {
+	var params struct {
+		URI string `json:"uri"`
+	}
+
+	if err := json.Unmarshal(msg.Params, &params); err != nil || !strings.HasPrefix(params.URI, mcpTableResourcePrefix) {
+		return &MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+			},
+		}
+	}
+
+	table := strings.TrimPrefix(params.URI, mcpTableResourcePrefix)
+	var schema *TableSchema
+	err := fmt.Errorf("table %q not found", table)
+	if s.db != nil && s.db.registry != nil {
+		schema, err = s.db.registry.GetSchema(table)
+	}
+	if err != nil {
+		return &MCPMessage{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Error: &MCPError{
+				Code:    -32002,
+				Message: fmt.Sprintf("Resource not found: %s", params.URI),
+			},
+		}
+	}
+
+	schemaJSON, _ := json.MarshalIndent(schema, "", "  ")
+	return &MCPMessage{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Result: map[string]any{
+			"contents": []map[string]any{
+				{
+					"uri":      params.URI,
+					"mimeType": "application/json",
+					"text":     string(schemaJSON),
+				},
+			},
+		},
+	}
+}
+
 // --- Tool Handlers ---
 // --- 工具处理器 ---
 
@@ -522,7 +842,8 @@ func (s *MCPServer) handleDescribeTable(ctx context.Context, params map[string]a
 	if table == "" {
 		return nil, fmt.Errorf("table is required")
 	}
-	return s.db.ExecuteQuery(ctx, &Query{Action: ActionDescribe, Table: table}), nil
+	live, _ := params["live"].(bool)
+	return s.db.ExecuteQuery(ctx, &Query{Action: ActionDescribe, Table: table, Live: live}), nil
 }
 
 func (s *MCPServer) handleFindRecords(ctx context.Context, params map[string]any) (any, error) {
@@ -647,9 +968,12 @@ func (s *MCPServer) handleExplainQuery(ctx context.Context, params map[string]an
 		return nil, err
 	}
 
+	plan, _ := params["plan"].(bool)
+
 	return s.db.ExecuteQuery(ctx, &Query{
 		Action:         ActionExplain,
 		QueryToExplain: query,
+		Plan:           plan,
 	}), nil
 }
 
@@ -686,6 +1010,9 @@ func (s *MCPServer) handleAggregate(ctx context.Context, params map[string]any)
 
 func (s *MCPServer) handleSyncSchema(ctx context.Context, params map[string]any) (any, error) {
 	preview, _ := params["preview"].(bool)
+	if !preview && s.db.IsReadOnly() {
+		return nil, fmt.Errorf("sync_schema is not allowed in read-only mode, use preview instead")
+	}
 
 	migrator := NewMigrator(s.db)
 	plan, err := migrator.Plan(ctx)
@@ -712,7 +1039,7 @@ func (s *MCPServer) handleSyncSchema(ctx context.Context, params map[string]any)
 
 func (s *MCPServer) handleGetStats(ctx context.Context, params map[string]any) (any, error) {
 	stats := s.db.sqlDB.Stats()
-	return map[string]any{
+	result := map[string]any{
 		"connection_pool": map[string]any{
 			"open_connections":    stats.OpenConnections,
 			"in_use":              stats.InUse,
@@ -723,8 +1050,22 @@ func (s *MCPServer) handleGetStats(ctx context.Context, params map[string]any) (
 			"max_idle_closed":     stats.MaxIdleClosed,
 			"max_lifetime_closed": stats.MaxLifetimeClosed,
 		},
-		"tables": len(s.db.registry.ListTables()),
-	}, nil
+		"tables":    len(s.db.registry.ListTables()),
+		"read_only": s.db.IsReadOnly(),
+	}
+
+	if metrics := s.db.Metrics(); metrics != nil {
+		result["query_metrics"] = metrics.GetStats()
+	}
+
+	s.mu.RLock()
+	cache := s.cache
+	s.mu.RUnlock()
+	if cache != nil && cache.enabled {
+		result["cache"] = cache.Stats()
+	}
+
+	return result, nil
 }
 
 func (s *MCPServer) handleNaturalLanguage(ctx context.Context, params map[string]any) (any, error) {
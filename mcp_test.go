@@ -3,7 +3,12 @@ package goorm
 import (
 	"bytes"
 	"context"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -179,6 +184,51 @@ func TestMCPMethodNotFound(t *testing.T) {
 	}
 }
 
+// TestMCPResources tests listing and reading table schema resources.
+// TestMCPResources 测试列出和读取表 Schema 资源。
+func TestMCPResources(t *testing.T) {
+	db := &DB{registry: NewRegistry()}
+	db.registry.Register(&streamedUser{}, NamingConfig{})
+	s := &MCPServer{db: db, tools: make(map[string]*MCPTool)}
+
+	response := s.handleMessage(&MCPMessage{JSONRPC: "2.0", ID: 1, Method: "resources/list"})
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %v", response.Error)
+	}
+	resources := response.Result.(map[string]any)["resources"].([]map[string]any)
+	if len(resources) != 1 || resources[0]["uri"] != "goorm://table/streamed_users" {
+		t.Fatalf("unexpected resources: %v", resources)
+	}
+
+	response = s.handleMessage(&MCPMessage{
+		JSONRPC: "2.0",
+		ID:      2,
+		Method:  "resources/read",
+		Params:  json.RawMessage(`{"uri": "goorm://table/streamed_users"}`),
+	})
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %v", response.Error)
+	}
+	contents := response.Result.(map[string]any)["contents"].([]map[string]any)
+	var schema TableSchema
+	if err := json.Unmarshal([]byte(contents[0]["text"].(string)), &schema); err != nil {
+		t.Fatalf("resource is not schema JSON: %v", err)
+	}
+	if schema.Table != "streamed_users" || len(schema.Columns) == 0 {
+		t.Errorf("unexpected schema: %+v", schema)
+	}
+
+	response = s.handleMessage(&MCPMessage{
+		JSONRPC: "2.0",
+		ID:      3,
+		Method:  "resources/read",
+		Params:  json.RawMessage(`{"uri": "goorm://table/missing"}`),
+	})
+	if response.Error == nil || response.Error.Code != -32002 {
+		t.Errorf("expected resource not found error, got %+v", response.Error)
+	}
+}
+
 // TestMCPProcessMessage tests processing a message via input/output.
 // TestMCPProcessMessage 测试通过输入/输出处理消息。
 func TestMCPProcessMessage(t *testing.T) {
@@ -222,6 +272,191 @@ func TestMCPProcessMessage(t *testing.T) {
 	}
 }
 
+// TestMCPStartShutdown tests notifications and the shutdown request in the Start loop.
+// TestMCPStartShutdown 测试 Start 循环中的通知和 shutdown 请求。
+func TestMCPStartShutdown(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize"}`,
+		`{"jsonrpc": "2.0", "method": "notifications/initialized"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "shutdown"}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "tools/list"}`,
+	}, "\n")
+	output := &bytes.Buffer{}
+	s := &MCPServer{
+		name:   "test-server",
+		tools:  make(map[string]*MCPTool),
+		input:  strings.NewReader(input),
+		output: output,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after shutdown")
+	}
+
+	decoder := json.NewDecoder(output)
+	var ids []any
+	for decoder.More() {
+		var response MCPMessage
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Error != nil {
+			t.Errorf("unexpected error response: %+v", response.Error)
+		}
+		ids = append(ids, response.ID)
+	}
+	if len(ids) != 2 || ids[0] != float64(1) || ids[1] != float64(2) {
+		t.Errorf("expected responses to requests 1 and 2 only, got %v", ids)
+	}
+}
+
+// TestMCPStartParseError tests that malformed input gets a parse error and the loop continues.
+// TestMCPStartParseError 测试格式错误的输入得到解析错误且循环继续。
+func TestMCPStartParseError(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": }`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "initialize"}`,
+	}, "\n")
+	output := &bytes.Buffer{}
+	s := &MCPServer{
+		name:   "test-server",
+		tools:  make(map[string]*MCPTool),
+		input:  strings.NewReader(input),
+		output: output,
+	}
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if line, _, _ := strings.Cut(output.String(), "\n"); !strings.Contains(line, `"id":null`) {
+		t.Errorf("expected the parse error to carry a null id, got %s", line)
+	}
+	decoder := json.NewDecoder(output)
+	var responses []MCPMessage
+	for decoder.More() {
+		var response MCPMessage
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	if responses[0].Error == nil || responses[0].Error.Code != -32700 {
+		t.Errorf("expected parse error, got %+v", responses[0])
+	}
+	if responses[1].Error != nil || responses[1].ID != float64(2) {
+		t.Errorf("expected initialize response, got %+v", responses[1])
+	}
+}
+
+// TestMCPToolCallContext tests the tool timeout and cancellation on Stop.
+// TestMCPToolCallContext 测试工具超时以及 Stop 时的取消。
+func TestMCPToolCallContext(t *testing.T) {
+	started := make(chan struct{}, 1)
+	s := &MCPServer{tools: make(map[string]*MCPTool), timeout: 10 * time.Millisecond}
+	s.RegisterTool(&MCPTool{
+		Name: "wait",
+		Handler: func(ctx context.Context, params map[string]any) (any, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	call := &MCPMessage{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: json.RawMessage(`{"name": "wait"}`)}
+
+	response := s.handleMessage(call)
+	<-started
+	if text := response.Result.(map[string]any)["content"].([]map[string]any)[0]["text"]; !strings.Contains(text.(string), "deadline exceeded") {
+		t.Errorf("expected timeout error, got %v", text)
+	}
+
+	// Without a timeout, stopping the server cancels the call
+	// 没有超时时，停止服务器会取消调用
+	s.SetToolTimeout(0)
+	input, writer := io.Pipe()
+	defer writer.Close()
+	s.input = input
+	s.output = &bytes.Buffer{}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start(context.Background()) }()
+	go json.NewEncoder(writer).Encode(call)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tool was not called")
+	}
+	s.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not cancel the tool call")
+	}
+}
+
+// TestMCPProgressNotifications tests progress notifications from execute_transaction.
+// TestMCPProgressNotifications 测试 execute_transaction 的进度通知。
+func TestMCPProgressNotifications(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	output := &bytes.Buffer{}
+	s := NewMCPServer(db)
+	s.output = output
+
+	response := s.handleMessage(&MCPMessage{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "tools/call",
+		Params: json.RawMessage(`{
+			"name": "execute_transaction",
+			"arguments": {"operations": [
+				{"action": "create", "table": "users", "data": {"name": "a"}},
+				{"action": "create", "table": "users", "data": {"name": "b"}}
+			]},
+			"_meta": {"progressToken": "tx-1"}
+		}`),
+	})
+	if response.Error != nil {
+		t.Fatalf("unexpected error: %v", response.Error)
+	}
+
+	decoder := json.NewDecoder(output)
+	var progress []float64
+	for decoder.More() {
+		var msg struct {
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		if err := decoder.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Method != "notifications/progress" || msg.Params["progressToken"] != "tx-1" || msg.Params["total"] != float64(2) {
+			t.Errorf("unexpected notification: %+v", msg)
+		}
+		progress = append(progress, msg.Params["progress"].(float64))
+	}
+	if len(progress) != 2 || progress[0] != 1 || progress[1] != 2 {
+		t.Errorf("expected progress 1, 2, got %v", progress)
+	}
+}
+
 // TestDefaultLogger tests the default logger.
 // TestDefaultLogger 测试默认日志记录器。
 func TestDefaultLogger(t *testing.T) {
@@ -266,6 +501,56 @@ func TestLoggerLevel(t *testing.T) {
 	}
 }
 
+// TestSlogLogger tests the slog adapter.
+// TestSlogLogger 测试 slog 适配器。
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	var logger Logger = NewSlogLogger(slog.New(handler))
+
+	logger.Debug("debug message", "table", "users")
+	logger.Warn("slow query", "duration_ms", 250)
+
+	out := buf.String()
+	if !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "table=users") {
+		t.Errorf("expected debug record with attribute, got %q", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "duration_ms=250") {
+		t.Errorf("expected warn record with attribute, got %q", out)
+	}
+
+	db := &DB{}
+	db.SetLogger(logger)
+	if db.queryLogger == nil {
+		t.Error("SetLogger should enable query logging")
+	}
+}
+
+// TestDBLogger tests the DB logger accessors and the audit hook.
+// TestDBLogger 测试 DB 日志记录器访问器和审计钩子。
+func TestDBLogger(t *testing.T) {
+	db := &DB{}
+	if _, ok := db.Logger().(*DefaultLogger); !ok {
+		t.Error("Logger should default to DefaultLogger")
+	}
+
+	var buf bytes.Buffer
+	logger := NewDefaultLogger()
+	logger.SetOutput(&buf)
+	db.SetLogger(logger)
+
+	if db.Logger() != logger {
+		t.Error("Logger should return the configured logger")
+	}
+
+	if err := dbAuditHook(&HookContext{DB: db, Table: "users", Action: ActionCreate}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "table=users") {
+		t.Errorf("audit hook should log through the DB logger, got %q", buf.String())
+	}
+}
+
 // TestMetricsCollector tests the metrics collector.
 // TestMetricsCollector 测试指标收集器。
 func TestMetricsCollector(t *testing.T) {
@@ -292,6 +577,67 @@ func TestMetricsCollector(t *testing.T) {
 	}
 }
 
+// TestMetricsSlowThreshold tests a custom slow query threshold.
+// TestMetricsSlowThreshold 测试自定义慢查询阈值。
+func TestMetricsSlowThreshold(t *testing.T) {
+	m := NewMetricsCollector()
+	m.SetSlowThreshold(50 * time.Millisecond)
+
+	m.RecordQuery(ActionFind, 10*time.Millisecond, nil)
+	m.RecordQuery(ActionFind, 100*time.Millisecond, nil) // slow
+
+	if m.GetStats()["slow_queries"].(int64) != 1 {
+		t.Errorf("expected 1 slow query, got %v", m.GetStats()["slow_queries"])
+	}
+
+	db := &DB{config: Config{SlowThreshold: time.Second}}
+	db.EnableMetrics().RecordQuery(ActionFind, 500*time.Millisecond, nil)
+	if db.Metrics().GetStats()["slow_queries"].(int64) != 0 {
+		t.Error("collector should use the threshold from Config")
+	}
+}
+
+// TestQueryLoggerWiring tests that the DB query logger logs slow and failed queries.
+// TestQueryLoggerWiring 测试 DB 的查询日志记录器会记录慢查询和失败的查询。
+func TestQueryLoggerWiring(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewDefaultLogger()
+	logger.SetOutput(&buf)
+
+	config := DefaultConfig()
+	config.Logger = logger
+	config.SlowThreshold = time.Nanosecond
+	db := &DB{config: config, queryLogger: newQueryLogger(logger, config)}
+
+	build := &BuildResult{SQL: "SELECT 1"}
+	db.logQuery(build, time.Now().Add(-time.Millisecond), nil)
+	db.logQuery(build, time.Now(), errors.New("boom"))
+
+	out := buf.String()
+	if !strings.Contains(out, "slow query") {
+		t.Errorf("expected slow query log, got %q", out)
+	}
+	if !strings.Contains(out, "query failed") {
+		t.Errorf("expected failed query log, got %q", out)
+	}
+
+	// Replacing the logger while queries run is safe (go test -race)
+	// 查询运行时替换日志记录器是安全的（go test -race）
+	quiet := NewDefaultLogger()
+	quiet.SetOutput(io.Discard)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			db.SetLogger(quiet)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		db.logQuery(build, time.Now(), nil)
+	}
+	<-done
+}
+
 // TestMetricsReset tests metrics reset.
 // TestMetricsReset 测试指标重置。
 func TestMetricsReset(t *testing.T) {
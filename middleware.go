@@ -0,0 +1,59 @@
+package goorm
+
+import (
+	"context"
+	"sync"
+)
+
+// QueryHandler executes a query and returns its result.
+// QueryHandler 执行查询并返回结果。
+type QueryHandler func(ctx context.Context, query *Query) *Result
+
+// Middleware wraps a QueryHandler, e.g. to trace, rewrite or reject queries.
+// Middleware 包装 QueryHandler，例如用于追踪、改写或拒绝查询。
+type Middleware func(next QueryHandler) QueryHandler
+
+// middlewareChain holds the middleware registered with Use.
+// middlewareChain 保存通过 Use 注册的中间件。
+type middlewareChain struct {
+	mu  sync.RWMutex
+	mws []Middleware
+}
+
+// then wraps final in the registered middleware, the first registered outermost.
+// then 用已注册的中间件包装 final，最先注册的位于最外层。
+func (c *middlewareChain) then(final QueryHandler) QueryHandler {
+	if c == nil {
+		return final
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	h := final
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		h = c.mws[i](h)
+	}
+	return h
+}
+
+// Use adds middleware around every ExecuteQuery call, including calls made by
+// Execute, the MCP server and relation loading. Middleware runs in the order it
+// was added and sees the query before validation, so it can rewrite it (e.g.
+// inject a tenant condition) or return a result without calling next. The
+// operations of a transaction run inside the single call for the transaction.
+//
+// Use 在每次 ExecuteQuery 调用外添加中间件，包括 Execute、MCP 服务器和关联加载发起的调用。
+// 中间件按添加顺序运行，并在验证之前看到查询，因此可以改写查询（例如注入租户条件），
+// 也可以不调用 next 直接返回结果。事务中的各个操作在该事务的单次调用内运行。
+func (db *DB) Use(mw ...Middleware) {
+	db.mu.Lock()
+	if db.middleware == nil {
+		db.middleware = &middlewareChain{}
+	}
+	chain := db.middleware
+	db.mu.Unlock()
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	chain.mws = append(chain.mws, mw...)
+}
@@ -0,0 +1,58 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMiddleware tests that middleware wraps ExecuteQuery in order and can rewrite or stop queries.
+// TestMiddleware 测试中间件按顺序包装 ExecuteQuery，并且可以改写或终止查询。
+func TestMiddleware(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	ctx := context.Background()
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next QueryHandler) QueryHandler {
+			return func(ctx context.Context, query *Query) *Result {
+				calls = append(calls, name+" "+string(query.Action))
+				return next(ctx, query)
+			}
+		}
+	}
+	tenant := func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query *Query) *Result {
+			query.Where = append(query.Where, Condition{Field: "tenant_id", Op: OpEqual, Value: 7})
+			return next(ctx, query)
+		}
+	}
+	db.Use(trace("outer"), trace("inner"))
+	db.ReadOnly().Use(tenant)
+
+	if result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionFind}); !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+	if want := []string{"outer find", "inner find"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if queries := backend.Queries(); len(queries) != 1 || !strings.Contains(queries[0], `WHERE "tenant_id" = $1`) {
+		t.Errorf("middleware added on a view should rewrite the query, got %v", queries)
+	}
+
+	db.Use(func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query *Query) *Result {
+			return &Result{Success: false, Error: &ResultError{Code: "RATE_LIMITED", Message: "slow down"}}
+		}
+	})
+	if result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionFind}); result.Error == nil || result.Error.Code != "RATE_LIMITED" {
+		t.Errorf("expected the middleware's result, got %+v", result)
+	}
+	if n := len(backend.Queries()); n != 1 {
+		t.Errorf("a stopped query should not reach the database, got %d queries", n)
+	}
+}
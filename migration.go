@@ -3,6 +3,7 @@ package goorm
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
@@ -19,6 +20,11 @@ const (
 	MigrationActionModifyColumn MigrationAction = "MODIFY_COLUMN"
 	MigrationActionAddIndex     MigrationAction = "ADD_INDEX"
 	MigrationActionDropIndex    MigrationAction = "DROP_INDEX"
+	MigrationActionComment      MigrationAction = "COMMENT_COLUMN"
+	MigrationActionAddCheck     MigrationAction = "ADD_CHECK"
+	MigrationActionAddFK        MigrationAction = "ADD_FOREIGN_KEY"
+	MigrationActionCreateType   MigrationAction = "CREATE_TYPE"
+	MigrationActionAddEnumValue MigrationAction = "ADD_ENUM_VALUE"
 )
 
 // MigrationChange represents a single schema change.
@@ -58,6 +64,7 @@ type BackupInfo struct {
 type Migrator struct {
 	db      *DB
 	dialect Dialect
+	schema  string
 }
 
 // NewMigrator creates a new migrator.
@@ -69,6 +76,24 @@ func NewMigrator(db *DB) *Migrator {
 	}
 }
 
+// scoped returns a copy of m that works in the schema set on ctx by WithSchema.
+// scoped 返回在 ctx 中由 WithSchema 设置的 schema 内工作的 m 副本。
+func (m *Migrator) scoped(ctx context.Context) *Migrator {
+	schema := SchemaFromContext(ctx)
+	if schema == m.schema {
+		return m
+	}
+	scoped := *m
+	scoped.schema = schema
+	return &scoped
+}
+
+// table returns the quoted name of table, qualified with the migrator's schema.
+// table 返回带引号、并以迁移器的 schema 限定的表名。
+func (m *Migrator) table(name string) string {
+	return m.dialect.QualifiedTable(m.schema, name)
+}
+
 // AutoSync synchronizes the database schema with registered models.
 // In aggressive mode, it also removes columns/tables not in models.
 //
@@ -86,6 +111,14 @@ func (m *Migrator) AutoSync(ctx context.Context) error {
 // Plan generates a migration plan without executing.
 // Plan 生成迁移计划但不执行。
 func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
+	return m.plan(ctx, m.db.config.Migration.Aggressive)
+}
+
+// plan generates a migration plan, dropping tables and columns not in any model
+// when aggressive is set.
+// plan 生成迁移计划；aggressive 为 true 时删除不属于任何模型的表和列。
+func (m *Migrator) plan(ctx context.Context, aggressive bool) (*MigrationPlan, error) {
+	m = m.scoped(ctx)
 	plan := &MigrationPlan{
 		Changes:   make([]MigrationChange, 0),
 		Backups:   make([]BackupInfo, 0),
@@ -116,11 +149,23 @@ func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
 		modelTableMap[table.Name] = meta
 	}
 
+	// Postgres enum columns use named types that must exist before the column
+	// Postgres 枚举列使用具名类型，该类型必须先于列存在
+	var enums map[string][]string
+	if m.dialect.Name() == "postgres" {
+		if enums, err = m.getPostgresEnums(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Find tables to create
 	// 查找要创建的表
+	var created []*ModelMeta
 	for _, model := range modelTables {
 		if _, exists := dbTableMap[model.Name]; !exists {
 			meta, _ := m.db.registry.Get(model.Name)
+			created = append(created, meta)
+			plan.Changes = append(plan.Changes, m.enumChanges(model.Name, enums, meta.Fields...)...)
 			sql := m.generateCreateTableSQL(meta)
 			plan.Changes = append(plan.Changes, MigrationChange{
 				Action:      MigrationActionCreateTable,
@@ -128,11 +173,30 @@ func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
 				SQL:         sql,
 				Destructive: false,
 			})
+			plan.Changes = append(plan.Changes, m.commentChanges(model.Name, meta.Fields...)...)
+		}
+	}
+
+	// Foreign keys of new tables are added once every table exists, so the
+	// creation order does not matter. SQLite declares them in CREATE TABLE.
+	// 新表的外键在所有表创建后再添加，因此与建表顺序无关。SQLite 在 CREATE TABLE 中声明外键。
+	if !m.inlineForeignKeys() {
+		for _, meta := range created {
+			for _, rel := range m.foreignKeys(meta) {
+				if change, ok := m.foreignKeyChange(meta.TableName, rel); ok {
+					plan.Changes = append(plan.Changes, change)
+				}
+			}
 		}
 	}
 
 	// Find columns to add/modify
 	// 查找要添加/修改的列
+	// Names of existing CHECK constraints, read once a checked column exists
+	// 已有 CHECK 约束的名称，在存在带检查的列时读取一次
+	var checks map[string]bool
+	checksRead := false
+
 	for tableName, meta := range modelTableMap {
 		dbCols, exists := dbTableMap[tableName]
 		if !exists {
@@ -144,15 +208,32 @@ func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
 			if !colExists {
 				// Add column
 				// 添加列
+				plan.Changes = append(plan.Changes, m.enumChanges(tableName, enums, field)...)
 				sql := m.generateAddColumnSQL(tableName, field)
 				plan.Changes = append(plan.Changes, MigrationChange{
 					Action:      MigrationActionAddColumn,
 					Table:       tableName,
 					Column:      field.ColumnName,
-					NewType:     field.SQLType,
+					NewType:     field.SQLTypeFor(m.dialect.Name()),
 					SQL:         sql,
 					Destructive: false,
 				})
+				plan.Changes = append(plan.Changes, m.commentChanges(tableName, field)...)
+				if change, ok := m.checkChange(tableName, field); ok {
+					plan.Changes = append(plan.Changes, change)
+				}
+				for _, rel := range m.foreignKeys(meta) {
+					if rel.ForeignKey != field.ColumnName {
+						continue
+					}
+					if change, ok := m.foreignKeyChange(tableName, rel); ok {
+						plan.Changes = append(plan.Changes, change)
+					}
+				}
+			} else if enums != nil && len(field.Enum) > 0 {
+				// The column keeps its enum type; only new values are added
+				// 列保留其枚举类型，只添加新的取值
+				plan.Changes = append(plan.Changes, m.enumChanges(tableName, enums, field)...)
 			} else {
 				// Check if modification needed
 				// 检查是否需要修改
@@ -170,12 +251,28 @@ func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
 					})
 				}
 			}
+
+			// An existing column whose check tag is new gets its constraint
+			// 已有列新增 check 标签时为其添加约束
+			if colExists && field.Check != "" {
+				if !checksRead {
+					if checks, err = m.getCheckConstraints(ctx); err != nil {
+						return nil, err
+					}
+					checksRead = true
+				}
+				if checks != nil && !checks[m.checkName(tableName, field)] {
+					if change, ok := m.checkChange(tableName, field); ok {
+						plan.Changes = append(plan.Changes, change)
+					}
+				}
+			}
 		}
 	}
 
 	// Aggressive mode: find columns/tables to drop
 	// 激进模式：查找要删除的列/表
-	if m.db.config.Migration.Aggressive {
+	if aggressive {
 		// Find tables to drop
 		for tableName := range dbTableMap {
 			if _, exists := modelTableMap[tableName]; !exists {
@@ -186,7 +283,7 @@ func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
 				plan.Changes = append(plan.Changes, MigrationChange{
 					Action:      MigrationActionDropTable,
 					Table:       tableName,
-					SQL:         fmt.Sprintf("DROP TABLE %s", m.dialect.Quote(tableName)),
+					SQL:         fmt.Sprintf("DROP TABLE %s", m.table(tableName)),
 					Destructive: true,
 				})
 			}
@@ -226,6 +323,8 @@ func (m *Migrator) Plan(ctx context.Context) (*MigrationPlan, error) {
 // Execute applies a migration plan.
 // Execute 应用迁移计划。
 func (m *Migrator) Execute(ctx context.Context, plan *MigrationPlan) error {
+	m = m.scoped(ctx)
+
 	// Group changes: non-destructive first
 	// 分组变更：先执行非破坏性的
 	var nonDestructive, destructive []MigrationChange
@@ -237,12 +336,17 @@ func (m *Migrator) Execute(ctx context.Context, plan *MigrationPlan) error {
 		}
 	}
 
+	applied := 0
+	total := len(plan.Changes)
+
 	// Execute non-destructive changes first
 	// 先执行非破坏性变更
 	for _, change := range nonDestructive {
 		if err := m.executeChange(ctx, change); err != nil {
 			return fmt.Errorf("failed to execute %s on %s: %w", change.Action, change.Table, err)
 		}
+		applied++
+		ReportProgress(ctx, applied, total, changeSummary(change))
 	}
 
 	// Create backups for destructive changes if enabled
@@ -263,15 +367,40 @@ func (m *Migrator) Execute(ctx context.Context, plan *MigrationPlan) error {
 		if err := m.executeChange(ctx, change); err != nil {
 			return fmt.Errorf("failed to execute %s on %s: %w", change.Action, change.Table, err)
 		}
+		applied++
+		ReportProgress(ctx, applied, total, changeSummary(change))
 	}
 
 	return nil
 }
 
+// changeSummary describes a migration change for progress reports, e.g. "ADD_COLUMN users.age".
+// changeSummary 为进度报告描述迁移变更，例如 "ADD_COLUMN users.age"。
+func changeSummary(change MigrationChange) string {
+	if change.Column == "" {
+		return fmt.Sprintf("%s %s", change.Action, change.Table)
+	}
+	return fmt.Sprintf("%s %s.%s", change.Action, change.Table, change.Column)
+}
+
 // executeChange executes a single migration change.
 // executeChange 执行单个迁移变更。
 func (m *Migrator) executeChange(ctx context.Context, change MigrationChange) error {
+	logger := m.db.Logger()
+	logger.Info("migration step",
+		"action", change.Action,
+		"table", change.Table,
+		"sql", change.SQL,
+	)
+
 	_, err := m.db.sqlDB.ExecContext(ctx, change.SQL)
+	if err != nil {
+		logger.Error("migration step failed",
+			"action", change.Action,
+			"table", change.Table,
+			"error", err,
+		)
+	}
 	return err
 }
 
@@ -284,21 +413,26 @@ func (m *Migrator) createBackup(ctx context.Context, change MigrationChange) err
 		// 备份单列
 		sql = fmt.Sprintf(
 			"CREATE TABLE %s AS SELECT %s, %s FROM %s",
-			m.dialect.Quote(change.BackupTable),
+			m.table(change.BackupTable),
 			m.dialect.Quote("id"),
 			m.dialect.Quote(change.Column),
-			m.dialect.Quote(change.Table),
+			m.table(change.Table),
 		)
 	} else {
 		// Backup entire table
 		// 备份整个表
 		sql = fmt.Sprintf(
 			"CREATE TABLE %s AS SELECT * FROM %s",
-			m.dialect.Quote(change.BackupTable),
-			m.dialect.Quote(change.Table),
+			m.table(change.BackupTable),
+			m.table(change.Table),
 		)
 	}
 
+	m.db.Logger().Info("migration backup",
+		"table", change.Table,
+		"backup", change.BackupTable,
+	)
+
 	_, err := m.db.sqlDB.ExecContext(ctx, sql)
 	return err
 }
@@ -309,38 +443,198 @@ func (m *Migrator) generateCreateTableSQL(meta *ModelMeta) string {
 	var sb strings.Builder
 
 	sb.WriteString("CREATE TABLE ")
-	sb.WriteString(m.dialect.Quote(meta.TableName))
+	sb.WriteString(m.table(meta.TableName))
 	sb.WriteString(" (\n")
 
-	columns := make([]string, 0, len(meta.Fields))
+	// Composite keys are declared as a table-level constraint
+	// 复合主键以表级约束声明
+	composite := len(meta.PrimaryKeys) > 1
+
+	columns := make([]string, 0, len(meta.Fields)+1)
 	for _, field := range meta.Fields {
-		col := m.generateColumnDef(field)
+		col := m.generateColumnDef(meta.TableName, field, !composite)
 		columns = append(columns, "  "+col)
 	}
 
+	if composite {
+		keys := make([]string, len(meta.PrimaryKeys))
+		for i, pk := range meta.PrimaryKeys {
+			keys[i] = m.dialect.Quote(pk.ColumnName)
+		}
+		columns = append(columns, "  PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+
+	for _, field := range meta.Fields {
+		if field.Check != "" {
+			columns = append(columns, "  "+m.checkConstraint(meta.TableName, field))
+		}
+	}
+
+	if m.inlineForeignKeys() {
+		for _, rel := range m.foreignKeys(meta) {
+			columns = append(columns, "  "+m.foreignKeyConstraint(meta.TableName, rel))
+		}
+	}
+
 	sb.WriteString(strings.Join(columns, ",\n"))
 	sb.WriteString("\n)")
 
 	return sb.String()
 }
 
-// generateColumnDef generates a column definition.
-// generateColumnDef 生成列定义。
-func (m *Migrator) generateColumnDef(field *FieldMeta) string {
+// commentChanges returns the statements documenting fields with their desc tag,
+// for dialects that comment columns outside CREATE TABLE and ADD COLUMN.
+//
+// commentChanges 返回以 desc 标签为字段添加注释的语句，适用于在 CREATE TABLE 和 ADD COLUMN
+// 之外为列添加注释的方言。
+func (m *Migrator) commentChanges(table string, fields ...*FieldMeta) []MigrationChange {
+	var changes []MigrationChange
+	for _, field := range fields {
+		if field.Description == "" {
+			continue
+		}
+		sql := m.dialect.ColumnComment(m.table(table), m.dialect.Quote(field.ColumnName), field.Description)
+		if sql == "" {
+			continue
+		}
+		changes = append(changes, MigrationChange{
+			Action:      MigrationActionComment,
+			Table:       table,
+			Column:      field.ColumnName,
+			SQL:         sql,
+			Destructive: false,
+		})
+	}
+	return changes
+}
+
+// checkConstraint returns the named CHECK constraint of field's check tag.
+// checkConstraint 返回字段 check 标签对应的具名 CHECK 约束。
+func (m *Migrator) checkConstraint(table string, field *FieldMeta) string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", m.dialect.Quote(m.checkName(table, field)), field.Check)
+}
+
+// checkName returns the name of the CHECK constraint of field in table.
+// checkName 返回 table 中 field 的 CHECK 约束名。
+func (m *Migrator) checkName(table string, field *FieldMeta) string {
+	return fmt.Sprintf("chk_%s_%s", table, field.ColumnName)
+}
+
+// checkChange returns the change adding the CHECK constraint of a column.
+// SQLite only accepts CHECK constraints in CREATE TABLE, so the constraint is
+// skipped there with a warning.
+//
+// checkChange 返回为列添加 CHECK 约束的变更。
+// SQLite 只接受 CREATE TABLE 中的 CHECK 约束，因此在 SQLite 上会跳过该约束并记录警告。
+func (m *Migrator) checkChange(table string, field *FieldMeta) (MigrationChange, bool) {
+	if field.Check == "" {
+		return MigrationChange{}, false
+	}
+	switch m.dialect.Name() {
+	case "sqlite", "sqlite3":
+		m.db.Logger().Warn("CHECK constraint can only be added when the table is created, skipping",
+			"table", table,
+			"column", field.ColumnName,
+			"check", field.Check,
+		)
+		return MigrationChange{}, false
+	}
+	return MigrationChange{
+		Action:      MigrationActionAddCheck,
+		Table:       table,
+		Column:      field.ColumnName,
+		SQL:         fmt.Sprintf("ALTER TABLE %s ADD %s", m.table(table), m.checkConstraint(table, field)),
+		Destructive: false,
+	}, true
+}
+
+// inlineForeignKeys reports whether foreign keys must be declared in CREATE TABLE.
+// SQLite cannot add them to existing tables.
+//
+// inlineForeignKeys 判断外键是否必须在 CREATE TABLE 中声明。SQLite 无法为已有表添加外键。
+func (m *Migrator) inlineForeignKeys() bool {
+	switch m.dialect.Name() {
+	case "sqlite", "sqlite3":
+		return true
+	}
+	return false
+}
+
+// foreignKeys returns the belongs_to relations of meta whose foreign key is a column of meta.
+// foreignKeys 返回 meta 中外键为 meta 自身列的 belongs_to 关联。
+func (m *Migrator) foreignKeys(meta *ModelMeta) []RelationSchema {
+	columns := make(map[string]bool, len(meta.Fields))
+	for _, field := range meta.Fields {
+		columns[field.ColumnName] = true
+	}
+
+	var rels []RelationSchema
+	for _, rel := range meta.Relations {
+		if RelationType(rel.Type) == RelationBelongsTo && rel.Model != "" && columns[rel.ForeignKey] {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// foreignKeyConstraint returns the named FOREIGN KEY constraint of a belongs_to relation.
+// foreignKeyConstraint 返回 belongs_to 关联对应的具名 FOREIGN KEY 约束。
+func (m *Migrator) foreignKeyConstraint(table string, rel RelationSchema) string {
+	sql := fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		m.dialect.Quote(fmt.Sprintf("fk_%s_%s", table, rel.ForeignKey)),
+		m.dialect.Quote(rel.ForeignKey),
+		m.table(rel.Model),
+		m.dialect.Quote(rel.ReferenceKey),
+	)
+	if rel.OnDelete != "" {
+		sql += " ON DELETE " + rel.OnDelete
+	}
+	if rel.OnUpdate != "" {
+		sql += " ON UPDATE " + rel.OnUpdate
+	}
+	return sql
+}
+
+// foreignKeyChange returns the change adding the foreign key of rel to table.
+// SQLite only accepts foreign keys in CREATE TABLE, so the constraint is
+// skipped there with a warning.
+//
+// foreignKeyChange 返回为 table 添加 rel 外键的变更。
+// SQLite 只接受 CREATE TABLE 中的外键，因此在 SQLite 上会跳过该约束并记录警告。
+func (m *Migrator) foreignKeyChange(table string, rel RelationSchema) (MigrationChange, bool) {
+	if m.inlineForeignKeys() {
+		m.db.Logger().Warn("foreign key can only be added when the table is created, skipping",
+			"table", table,
+			"column", rel.ForeignKey,
+			"references", rel.Model,
+		)
+		return MigrationChange{}, false
+	}
+	return MigrationChange{
+		Action:      MigrationActionAddFK,
+		Table:       table,
+		Column:      rel.ForeignKey,
+		SQL:         fmt.Sprintf("ALTER TABLE %s ADD %s", m.table(table), m.foreignKeyConstraint(table, rel)),
+		Destructive: false,
+	}, true
+}
+
+// generateColumnDef generates a column definition. When inlinePK is false the
+// primary key is declared at table level, so the column only gets NOT NULL.
+//
+// generateColumnDef 生成列定义。当 inlinePK 为 false 时主键在表级声明，列上只添加 NOT NULL。
+func (m *Migrator) generateColumnDef(table string, field *FieldMeta, inlinePK bool) string {
 	var parts []string
 
 	parts = append(parts, m.dialect.Quote(field.ColumnName))
 
 	// Determine SQL type
 	// 确定 SQL 类型
-	sqlType := field.SQLType
-	if sqlType == "" {
-		sqlType = m.dialect.GoTypeToSQL(field.GoType, field.Tags)
-	}
+	sqlType := m.columnType(table, field)
 
 	// Handle auto-increment primary key
 	// 处理自增主键
-	if field.PrimaryKey && field.AutoIncrement {
+	if field.PrimaryKey && field.AutoIncrement && inlinePK {
 		// SQLite requires: INTEGER PRIMARY KEY AUTOINCREMENT
 		// PostgreSQL uses: SERIAL PRIMARY KEY
 		// MySQL uses: INT AUTO_INCREMENT PRIMARY KEY
@@ -357,11 +651,9 @@ func (m *Migrator) generateColumnDef(field *FieldMeta) string {
 	} else {
 		parts = append(parts, sqlType)
 
-		if field.PrimaryKey {
+		if field.PrimaryKey && inlinePK {
 			parts = append(parts, "PRIMARY KEY")
-		}
-
-		if !field.Nullable && !field.PrimaryKey {
+		} else if field.PrimaryKey || !field.Nullable {
 			parts = append(parts, "NOT NULL")
 		}
 
@@ -374,43 +666,118 @@ func (m *Migrator) generateColumnDef(field *FieldMeta) string {
 		}
 	}
 
+	// Without an enum type the values are enforced with a CHECK
+	// 没有枚举类型时用 CHECK 约束取值
+	if len(field.Enum) > 0 && !m.dialect.SupportsEnum() {
+		parts = append(parts, fmt.Sprintf("CHECK (%s IN (%s))", m.dialect.Quote(field.ColumnName), enumList(field.Enum)))
+	}
+
+	// Some dialects declare column comments inline
+	// 部分方言以内联方式声明列注释
+	if field.Description != "" {
+		if comment := m.dialect.InlineComment(field.Description); comment != "" {
+			parts = append(parts, comment)
+		}
+	}
+
 	return strings.Join(parts, " ")
 }
 
+// columnType returns the SQL type of field in table. Postgres enum columns use
+// the named type created by enumChanges.
+//
+// columnType 返回 table 中 field 的 SQL 类型。Postgres 枚举列使用由 enumChanges 创建的具名类型。
+func (m *Migrator) columnType(table string, field *FieldMeta) string {
+	if sqlType := field.SQLTypeFor(m.dialect.Name()); sqlType != "" {
+		return sqlType
+	}
+	if len(field.Enum) > 0 && m.dialect.Name() == "postgres" {
+		return m.table(enumTypeName(table, field.ColumnName))
+	}
+	return m.dialect.GoTypeToSQL(field.GoType, field.Tags)
+}
+
+// enumTypeName returns the name of the Postgres enum type of a column.
+// enumTypeName 返回列对应的 Postgres 枚举类型名。
+func enumTypeName(table, column string) string {
+	return table + "_" + column
+}
+
+// enumChanges returns the changes creating the Postgres enum types of fields,
+// or adding the values missing from existing types. enums maps existing type
+// names to their values; it is nil for dialects without enum types.
+//
+// enumChanges 返回创建 fields 的 Postgres 枚举类型、或为已有类型补充缺失取值的变更。
+// enums 将已有类型名映射到其取值；对于没有枚举类型的方言为 nil。
+func (m *Migrator) enumChanges(table string, enums map[string][]string, fields ...*FieldMeta) []MigrationChange {
+	if enums == nil {
+		return nil
+	}
+
+	var changes []MigrationChange
+	for _, field := range fields {
+		if len(field.Enum) == 0 || field.SQLTypeFor(m.dialect.Name()) != "" {
+			continue
+		}
+		name := enumTypeName(table, field.ColumnName)
+		existing, ok := enums[name]
+		if !ok {
+			changes = append(changes, MigrationChange{
+				Action:      MigrationActionCreateType,
+				Table:       table,
+				Column:      field.ColumnName,
+				SQL:         fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", m.table(name), enumList(field.Enum)),
+				Destructive: false,
+			})
+			continue
+		}
+		for _, value := range field.Enum {
+			if slices.Contains(existing, value) {
+				continue
+			}
+			changes = append(changes, MigrationChange{
+				Action:      MigrationActionAddEnumValue,
+				Table:       table,
+				Column:      field.ColumnName,
+				SQL:         fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", m.table(name), quoteLiteral(value)),
+				Destructive: false,
+			})
+		}
+	}
+	return changes
+}
+
 // generateAddColumnSQL generates ADD COLUMN SQL.
 // generateAddColumnSQL 生成 ADD COLUMN SQL。
 func (m *Migrator) generateAddColumnSQL(table string, field *FieldMeta) string {
 	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s",
-		m.dialect.Quote(table),
-		m.generateColumnDef(field),
+		m.table(table),
+		m.generateColumnDef(table, field, true),
 	)
 }
 
 // generateModifyColumnSQL generates MODIFY COLUMN SQL.
 // generateModifyColumnSQL 生成 MODIFY COLUMN SQL。
 func (m *Migrator) generateModifyColumnSQL(table string, field *FieldMeta) string {
-	sqlType := field.SQLType
-	if sqlType == "" {
-		sqlType = m.dialect.GoTypeToSQL(field.GoType, field.Tags)
-	}
+	sqlType := m.columnType(table, field)
 
 	// Different syntax for different databases
 	// 不同数据库的语法不同
 	switch m.dialect.Name() {
 	case "postgres":
 		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s",
-			m.dialect.Quote(table),
+			m.table(table),
 			m.dialect.Quote(field.ColumnName),
 			sqlType,
 		)
 	case "mysql":
 		return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s",
-			m.dialect.Quote(table),
-			m.generateColumnDef(field),
+			m.table(table),
+			m.generateColumnDef(table, field, true),
 		)
 	default:
 		return fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s",
-			m.dialect.Quote(table),
+			m.table(table),
 			m.dialect.Quote(field.ColumnName),
 			sqlType,
 		)
@@ -421,7 +788,7 @@ func (m *Migrator) generateModifyColumnSQL(table string, field *FieldMeta) strin
 // generateDropColumnSQL 生成 DROP COLUMN SQL。
 func (m *Migrator) generateDropColumnSQL(table, column string) string {
 	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
-		m.dialect.Quote(table),
+		m.table(table),
 		m.dialect.Quote(column),
 	)
 }
@@ -457,10 +824,90 @@ func (m *Migrator) getPostgresTables(ctx context.Context) ([]DBTable, error) {
 	query := `
 		SELECT table_name, column_name, data_type, is_nullable, column_default
 		FROM information_schema.columns
-		WHERE table_schema = 'public'
+		WHERE table_schema = $1
 		ORDER BY table_name, ordinal_position
 	`
-	return m.queryDBTables(ctx, query)
+	return m.queryDBTables(ctx, query, m.postgresSchema())
+}
+
+// getPostgresEnums returns the values of every enum type, keyed by type name.
+// getPostgresEnums 返回所有枚举类型的取值，以类型名为键。
+func (m *Migrator) getPostgresEnums(ctx context.Context) (map[string][]string, error) {
+	rows, err := m.db.sqlDB.QueryContext(ctx, `
+		SELECT t.typname, e.enumlabel
+		FROM pg_type t
+		JOIN pg_enum e ON e.enumtypid = t.oid
+		JOIN pg_namespace n ON n.oid = t.typnamespace
+		WHERE n.nspname = $1
+		ORDER BY t.typname, e.enumsortorder
+	`, m.postgresSchema())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enums := make(map[string][]string)
+	for rows.Next() {
+		var name, label string
+		if err := rows.Scan(&name, &label); err != nil {
+			return nil, err
+		}
+		enums[name] = append(enums[name], label)
+	}
+	return enums, rows.Err()
+}
+
+// getCheckConstraints returns the names of the CHECK constraints in the schema, or
+// nil for SQLite, where they can only be declared in CREATE TABLE.
+//
+// getCheckConstraints 返回 schema 中 CHECK 约束的名称；SQLite 只能在 CREATE TABLE 中声明 CHECK 约束，因此返回 nil。
+func (m *Migrator) getCheckConstraints(ctx context.Context) (map[string]bool, error) {
+	var query string
+	var arg any
+	switch m.dialect.Name() {
+	case "postgres":
+		query = `
+			SELECT c.conname
+			FROM pg_constraint c
+			JOIN pg_namespace n ON n.oid = c.connamespace
+			WHERE c.contype = 'c' AND n.nspname = $1
+		`
+		arg = m.postgresSchema()
+	case "mysql":
+		query = `
+			SELECT constraint_name
+			FROM information_schema.table_constraints
+			WHERE constraint_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND constraint_type = 'CHECK'
+		`
+		arg = m.schema
+	default:
+		return nil, nil
+	}
+
+	rows, err := m.db.sqlDB.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		checks[name] = true
+	}
+	return checks, rows.Err()
+}
+
+// postgresSchema returns the migrator's schema, defaulting to "public".
+// postgresSchema 返回迁移器的 schema，默认为 "public"。
+func (m *Migrator) postgresSchema() string {
+	if m.schema == "" {
+		return "public"
+	}
+	return m.schema
 }
 
 // getMySQLTables gets MySQL database schema.
@@ -469,10 +916,10 @@ func (m *Migrator) getMySQLTables(ctx context.Context) ([]DBTable, error) {
 	query := `
 		SELECT table_name, column_name, data_type, is_nullable, column_default
 		FROM information_schema.columns
-		WHERE table_schema = DATABASE()
+		WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE())
 		ORDER BY table_name, ordinal_position
 	`
-	return m.queryDBTables(ctx, query)
+	return m.queryDBTables(ctx, query, m.schema)
 }
 
 // getSQLiteTables gets SQLite database schema.
@@ -480,7 +927,7 @@ func (m *Migrator) getMySQLTables(ctx context.Context) ([]DBTable, error) {
 func (m *Migrator) getSQLiteTables(ctx context.Context) ([]DBTable, error) {
 	// Get list of tables
 	// 获取表列表
-	rows, err := m.db.sqlDB.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'")
+	rows, err := m.db.sqlDB.QueryContext(ctx, "SELECT name FROM "+m.table("sqlite_master")+" WHERE type='table' AND name NOT LIKE 'sqlite_%'")
 	if err != nil {
 		return nil, err
 	}
@@ -501,7 +948,11 @@ func (m *Migrator) getSQLiteTables(ctx context.Context) ([]DBTable, error) {
 	for _, tableName := range tableNames {
 		// Get columns for each table using PRAGMA
 		// 使用 PRAGMA 获取每个表的列
-		colRows, err := m.db.sqlDB.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", tableName))
+		pragma := "PRAGMA "
+		if m.schema != "" {
+			pragma += m.dialect.Quote(m.schema) + "."
+		}
+		colRows, err := m.db.sqlDB.QueryContext(ctx, fmt.Sprintf("%stable_info(%s)", pragma, tableName))
 		if err != nil {
 			return nil, err
 		}
@@ -523,6 +974,8 @@ func (m *Migrator) getSQLiteTables(ctx context.Context) ([]DBTable, error) {
 				Type:     colType,
 				Nullable: notNull == 0,
 				Default:  dfltValue,
+				Primary:  pk > 0,
+				Position: cid + 1,
 			}
 		}
 		colRows.Close()
@@ -538,8 +991,8 @@ func (m *Migrator) getSQLiteTables(ctx context.Context) ([]DBTable, error) {
 
 // queryDBTables executes a schema query and returns table info.
 // queryDBTables 执行架构查询并返回表信息。
-func (m *Migrator) queryDBTables(ctx context.Context, query string) ([]DBTable, error) {
-	rows, err := m.db.sqlDB.QueryContext(ctx, query)
+func (m *Migrator) queryDBTables(ctx context.Context, query string, args ...any) ([]DBTable, error) {
+	rows, err := m.db.sqlDB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -564,6 +1017,7 @@ func (m *Migrator) queryDBTables(ctx context.Context, query string) ([]DBTable,
 			Type:     dataType,
 			Nullable: isNullable == "YES",
 			Default:  colDefault,
+			Position: len(tablesMap[tableName]) + 1,
 		}
 	}
 
@@ -592,6 +1046,14 @@ type ColumnInfo struct {
 	Type     string
 	Nullable bool
 	Default  *string
+
+	// Primary is only read for SQLite; other dialects report keys as indexes.
+	// Primary 仅对 SQLite 读取；其他方言通过索引报告主键。
+	Primary bool
+
+	// Position is the 1-based position of the column in the table.
+	// Position 是列在表中从 1 开始的位置。
+	Position int
 }
 
 // typesCompatible checks if two SQL types are compatible.
@@ -602,6 +1064,12 @@ func (m *Migrator) typesCompatible(dbType, modelType string) bool {
 	dbType = strings.ToUpper(dbType)
 	modelType = strings.ToUpper(modelType)
 
+	// information_schema reports every Postgres array column as ARRAY
+	// information_schema 将所有 Postgres 数组列报告为 ARRAY
+	if dbType == "ARRAY" {
+		return strings.HasSuffix(modelType, "[]")
+	}
+
 	// Handle common equivalences
 	// 处理常见等价类型
 	equivalences := map[string][]string{
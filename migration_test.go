@@ -1,6 +1,10 @@
 package goorm
 
 import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -56,6 +60,146 @@ func TestMigratorGenerateCreateTableSQL(t *testing.T) {
 	}
 }
 
+type userRole struct {
+	UserID uint64 `json:"user_id" goorm:"primaryKey"`
+	RoleID uint64 `json:"role_id" goorm:"primaryKey"`
+	Scope  string `json:"scope"`
+}
+
+// TestMigratorCompositePrimaryKey tests table-level PRIMARY KEY for composite keys.
+// TestMigratorCompositePrimaryKey 测试复合主键的表级 PRIMARY KEY。
+func TestMigratorCompositePrimaryKey(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&userRole{}, DefaultConfig().Naming); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := registry.Get("user_roles")
+	if len(meta.PrimaryKeys) != 2 {
+		t.Fatalf("expected 2 primary keys, got %d", len(meta.PrimaryKeys))
+	}
+
+	tables := registry.ListTables()
+	if len(tables) != 1 || strings.Join(tables[0].PrimaryKey, ",") != "user_id,role_id" {
+		t.Errorf("unexpected TableInfo.PrimaryKey: %+v", tables)
+	}
+
+	sql := (&Migrator{dialect: &PostgresDialect{}}).generateCreateTableSQL(meta)
+	if !strings.Contains(sql, `PRIMARY KEY ("user_id", "role_id")`) {
+		t.Errorf("missing table-level primary key: %s", sql)
+	}
+	if strings.Count(sql, "PRIMARY KEY") != 1 {
+		t.Errorf("columns should not declare PRIMARY KEY inline: %s", sql)
+	}
+	if !strings.Contains(sql, `"user_id" BIGINT NOT NULL`) {
+		t.Errorf("composite key columns should be NOT NULL: %s", sql)
+	}
+}
+
+type embeddedOnly struct {
+	Model
+}
+
+type untaggedKey struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// TestRegistryDefaultPrimaryKey tests that models without a tagged key get "id".
+// TestRegistryDefaultPrimaryKey 测试未标注主键的模型以 "id" 作为主键。
+func TestRegistryDefaultPrimaryKey(t *testing.T) {
+	registry := NewRegistry()
+	naming := DefaultConfig().Naming
+	for _, model := range []any{&embeddedOnly{}, &untaggedKey{}} {
+		if err := registry.Register(model, naming); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, table := range []string{"embedded_onlies", "untagged_keys"} {
+		meta, ok := registry.Get(table)
+		if !ok {
+			t.Fatalf("table %s not registered", table)
+		}
+		if len(meta.PrimaryKeys) != 1 {
+			t.Fatalf("%s: expected 1 primary key, got %d", table, len(meta.PrimaryKeys))
+		}
+		pk := meta.PrimaryKeys[0]
+		if pk.ColumnName != "id" || !pk.PrimaryKey || !pk.AutoIncrement {
+			t.Errorf("%s: unexpected primary key %+v", table, pk)
+		}
+	}
+}
+
+type userAccount struct {
+	Model `table:"users"`
+	Email string `json:"email"`
+}
+
+type userProfile struct {
+	Model `table:"users"`
+	Bio   string `json:"bio"`
+}
+
+// TestRegistryDuplicateTable tests that two models for one table are rejected unless replaced.
+// TestRegistryDuplicateTable 测试同一张表的两个模型会被拒绝，除非指定替换。
+func TestRegistryDuplicateTable(t *testing.T) {
+	db := &DB{registry: NewRegistry(), config: DefaultConfig()}
+	if err := db.Register(&userAccount{}, &userAccount{}); err != nil {
+		t.Fatalf("registering the same model twice should succeed: %v", err)
+	}
+
+	err := db.Register(&userProfile{})
+	if !errors.Is(err, ErrDuplicateTable) || !strings.Contains(err.Error(), "userAccount") || !strings.Contains(err.Error(), "userProfile") {
+		t.Fatalf("expected ErrDuplicateTable naming both models, got %v", err)
+	}
+	if meta, _ := db.registry.Get("users"); meta.ModelName != "userAccount" {
+		t.Errorf("a rejected model should not replace the table, got %s", meta.ModelName)
+	}
+
+	if err := db.Register(&userProfile{}, ReplaceModel); err != nil {
+		t.Fatalf("ReplaceModel should allow replacing: %v", err)
+	}
+	if meta, _ := db.registry.Get("users"); meta.ModelName != "userProfile" {
+		t.Errorf("expected userProfile to replace the table, got %s", meta.ModelName)
+	}
+}
+
+type taggedSettings struct {
+	Model    `table:"tagged_settings"`
+	Settings string `json:"settings" goorm:"type:jsonb;type_mysql:json;type_sqlite:text"`
+}
+
+// TestMigratorDialectColumnType tests that type_<dialect> options override the type on that dialect.
+// TestMigratorDialectColumnType 测试 type_<dialect> 选项会在对应方言上覆盖类型。
+func TestMigratorDialectColumnType(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&taggedSettings{}, DefaultConfig().Naming); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := registry.Get("tagged_settings")
+	var field *FieldMeta
+	for _, f := range meta.Fields {
+		if f.ColumnName == "settings" {
+			field = f
+		}
+	}
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{&PostgresDialect{}, `"settings" jsonb NOT NULL`},
+		{&MySQLDialect{}, "`settings` json NOT NULL"},
+		{&SQLiteDialect{}, `"settings" text NOT NULL`},
+	}
+	for _, tt := range tests {
+		m := &Migrator{dialect: tt.dialect}
+		if got := m.generateColumnDef("tagged_settings", field, false); got != tt.want {
+			t.Errorf("%s: generateColumnDef() = %q, want %q", tt.dialect.Name(), got, tt.want)
+		}
+	}
+}
+
 // TestMigratorGenerateAddColumnSQL tests ADD COLUMN generation.
 // TestMigratorGenerateAddColumnSQL 测试 ADD COLUMN 生成。
 func TestMigratorGenerateAddColumnSQL(t *testing.T) {
@@ -74,6 +218,302 @@ func TestMigratorGenerateAddColumnSQL(t *testing.T) {
 	}
 }
 
+// TestMigratorColumnComments tests that desc tags are written to the database.
+// TestMigratorColumnComments 测试 desc 标签被写入数据库。
+func TestMigratorColumnComments(t *testing.T) {
+	field := &FieldMeta{
+		Name:        "Name",
+		ColumnName:  "name",
+		GoType:      "string",
+		Description: "user's display name",
+	}
+
+	pg := &Migrator{dialect: &PostgresDialect{}}
+	changes := pg.commentChanges("users", field, &FieldMeta{Name: "Age", ColumnName: "age", GoType: "int"})
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 comment change, got %d", len(changes))
+	}
+	if want := `COMMENT ON COLUMN "users"."name" IS 'user''s display name'`; changes[0].SQL != want {
+		t.Errorf("expected %s, got %s", want, changes[0].SQL)
+	}
+	if changes[0].Action != MigrationActionComment || changes[0].Destructive {
+		t.Errorf("unexpected change: %+v", changes[0])
+	}
+
+	mysql := &Migrator{dialect: &MySQLDialect{}}
+	if changes := mysql.commentChanges("users", field); len(changes) != 0 {
+		t.Errorf("MySQL should comment inline, got %+v", changes)
+	}
+	if def := mysql.generateColumnDef("users", field, true); !strings.HasSuffix(def, ` COMMENT 'user''s display name'`) {
+		t.Errorf("MySQL column missing inline comment: %s", def)
+	}
+
+	sqlite := &Migrator{dialect: &SQLiteDialect{}}
+	if changes := sqlite.commentChanges("users", field); len(changes) != 0 {
+		t.Errorf("SQLite should not comment columns, got %+v", changes)
+	}
+	if def := sqlite.generateColumnDef("users", field, true); strings.Contains(def, "COMMENT") {
+		t.Errorf("SQLite column should not have a comment: %s", def)
+	}
+}
+
+type checkedAccount struct {
+	ID      uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+	Age     int    `json:"age" goorm:"check:age >= 0"`
+	Balance int    `json:"balance"`
+}
+
+// TestMigratorCheckConstraints tests CHECK constraints from check tags.
+// TestMigratorCheckConstraints 测试由 check 标签生成的 CHECK 约束。
+func TestMigratorCheckConstraints(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&checkedAccount{}, DefaultConfig().Naming); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := registry.Get("checked_accounts")
+	age := meta.Fields[1]
+	if age.Check != "age >= 0" {
+		t.Fatalf("expected check tag to be parsed, got %q", age.Check)
+	}
+
+	pg := &Migrator{dialect: &PostgresDialect{}}
+	sql := pg.generateCreateTableSQL(meta)
+	if !strings.Contains(sql, `CONSTRAINT "chk_checked_accounts_age" CHECK (age >= 0)`) {
+		t.Errorf("CREATE TABLE missing CHECK constraint: %s", sql)
+	}
+	if strings.Count(sql, "CHECK") != 1 {
+		t.Errorf("expected a single CHECK constraint: %s", sql)
+	}
+
+	change, ok := pg.checkChange("checked_accounts", age)
+	if !ok || change.Action != MigrationActionAddCheck {
+		t.Fatalf("expected an ADD_CHECK change, got %+v", change)
+	}
+	if want := `ALTER TABLE "checked_accounts" ADD CONSTRAINT "chk_checked_accounts_age" CHECK (age >= 0)`; change.SQL != want {
+		t.Errorf("expected %s, got %s", want, change.SQL)
+	}
+	if _, ok := pg.checkChange("checked_accounts", meta.Fields[2]); ok {
+		t.Error("fields without a check tag should not add constraints")
+	}
+
+	db, _ := newFakeDB(t, &SQLiteDialect{}, nil)
+	sqlite := NewMigrator(db)
+	if !strings.Contains(sqlite.generateCreateTableSQL(meta), "CHECK (age >= 0)") {
+		t.Error("SQLite CREATE TABLE should include the CHECK constraint")
+	}
+	if _, ok := sqlite.checkChange("checked_accounts", age); ok {
+		t.Error("SQLite cannot add CHECK constraints to existing tables")
+	}
+
+	// Existing column: the constraint is added unless the database has it
+	// 已有列：数据库中没有该约束时才添加
+	for existing, want := range map[string]int{"chk_other": 1, "chk_checked_accounts_age": 0} {
+		db, _ := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			if strings.Contains(query, "pg_enum") {
+				return []string{"typname", "enumlabel"}, nil, nil
+			}
+			if strings.Contains(query, "pg_constraint") {
+				return []string{"conname"}, [][]driver.Value{{existing}}, nil
+			}
+			var rows [][]driver.Value
+			for _, field := range meta.Fields {
+				rows = append(rows, []driver.Value{"checked_accounts", field.ColumnName, "bigint", "NO", nil})
+			}
+			return []string{"table_name", "column_name", "data_type", "is_nullable", "column_default"}, rows, nil
+		})
+		db.registry = registry
+		plan, err := NewMigrator(db).Plan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var added []MigrationChange
+		for _, change := range plan.Changes {
+			if change.Action == MigrationActionAddCheck {
+				added = append(added, change)
+			}
+		}
+		if len(added) != want {
+			t.Errorf("existing constraint %q: expected %d ADD_CHECK changes, got %+v", existing, want, added)
+		}
+	}
+}
+
+type fkCustomer struct {
+	Model
+	Name string `json:"name"`
+}
+
+type fkOrder struct {
+	Model
+	CustomerID uint64      `json:"customer_id"`
+	Customer   *fkCustomer `rel:"belongs_to" model:"fk_customers" fk:"customer_id;on_delete:cascade;on_update:set null"`
+}
+
+// TestMigratorForeignKeys tests FOREIGN KEY constraints for belongs_to relations.
+// TestMigratorForeignKeys 测试 belongs_to 关联的 FOREIGN KEY 约束。
+func TestMigratorForeignKeys(t *testing.T) {
+	db, _ := newFakeDB(t, &PostgresDialect{}, nil)
+	if err := db.Register(&fkOrder{}, &fkCustomer{}); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := db.registry.Get("fk_orders")
+	for _, field := range meta.Fields {
+		if field.Name == "Customer" {
+			t.Fatal("relation fields should not be registered as columns")
+		}
+	}
+	if len(meta.Relations) != 1 || meta.Relations[0].ForeignKey != "customer_id" {
+		t.Fatalf("unexpected relations: %+v", meta.Relations)
+	}
+
+	plan, err := NewMigrator(db).Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, change := range plan.Changes {
+		actions = append(actions, string(change.Action))
+		if change.Action == MigrationActionCreateTable && strings.Contains(change.SQL, "FOREIGN KEY") {
+			t.Errorf("Postgres should add foreign keys after creating tables: %s", change.SQL)
+		}
+	}
+	if got := strings.Join(actions, ","); got != "CREATE_TABLE,CREATE_TABLE,ADD_FOREIGN_KEY" {
+		t.Fatalf("unexpected plan: %s", got)
+	}
+	want := `ALTER TABLE "fk_orders" ADD CONSTRAINT "fk_fk_orders_customer_id" FOREIGN KEY ("customer_id") ` +
+		`REFERENCES "fk_customers" ("id") ON DELETE CASCADE ON UPDATE SET NULL`
+	if sql := plan.Changes[2].SQL; sql != want {
+		t.Errorf("expected %s, got %s", want, sql)
+	}
+
+	sqliteDB, _ := newFakeDB(t, &SQLiteDialect{}, nil)
+	sqlite := NewMigrator(sqliteDB)
+	if sql := sqlite.generateCreateTableSQL(meta); !strings.Contains(sql, `FOREIGN KEY ("customer_id") REFERENCES "fk_customers" ("id") ON DELETE CASCADE`) {
+		t.Errorf("SQLite CREATE TABLE should declare the foreign key: %s", sql)
+	}
+	if _, ok := sqlite.foreignKeyChange("fk_orders", meta.Relations[0]); ok {
+		t.Error("SQLite cannot add foreign keys to existing tables")
+	}
+
+	type badOrder struct {
+		Model
+		CustomerID uint64      `json:"customer_id"`
+		Customer   *fkCustomer `rel:"belongs_to" model:"fk_customers" fk:"customer_id;on_delete:explode"`
+	}
+	if err := NewRegistry().Register(&badOrder{}, DefaultConfig().Naming); err == nil {
+		t.Error("expected an error for an unknown fk action")
+	}
+}
+
+type enumShipment struct {
+	Model
+	Status string `json:"status" goorm:"enum:pending, paid,shipped"`
+}
+
+// TestMigratorEnums tests enum columns on every dialect.
+// TestMigratorEnums 测试各方言上的枚举列。
+func TestMigratorEnums(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Register(&enumShipment{}, DefaultConfig().Naming); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := registry.Get("enum_shipments")
+	status := meta.Fields[len(meta.Fields)-1]
+	if strings.Join(status.Enum, ",") != "pending,paid,shipped" {
+		t.Fatalf("unexpected enum values: %q", status.Enum)
+	}
+
+	mysql := &Migrator{dialect: &MySQLDialect{}}
+	if sql := mysql.generateCreateTableSQL(meta); !strings.Contains(sql, "`status` ENUM('pending', 'paid', 'shipped') NOT NULL") {
+		t.Errorf("MySQL should use an ENUM column: %s", sql)
+	}
+
+	sqlite := &Migrator{dialect: &SQLiteDialect{}}
+	if sql := sqlite.generateCreateTableSQL(meta); !strings.Contains(sql, `CHECK ("status" IN ('pending', 'paid', 'shipped'))`) {
+		t.Errorf("SQLite should check enum values: %s", sql)
+	}
+
+	// Fresh database: the type is created before the table
+	// 新数据库：类型先于表创建
+	db, _ := newFakeDB(t, &PostgresDialect{}, nil)
+	db.registry = registry
+	plan, err := NewMigrator(db).Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 2 || plan.Changes[0].Action != MigrationActionCreateType {
+		t.Fatalf("unexpected plan: %+v", plan.Changes)
+	}
+	if want := `CREATE TYPE "enum_shipments_status" AS ENUM ('pending', 'paid', 'shipped')`; plan.Changes[0].SQL != want {
+		t.Errorf("expected %s, got %s", want, plan.Changes[0].SQL)
+	}
+	if !strings.Contains(plan.Changes[1].SQL, `"status" "enum_shipments_status" NOT NULL`) {
+		t.Errorf("column should use the enum type: %s", plan.Changes[1].SQL)
+	}
+
+	// Existing type: only the new value is added
+	// 已有类型：只添加新取值
+	db, _ = newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.Contains(query, "pg_enum") {
+			return []string{"typname", "enumlabel"}, [][]driver.Value{
+				{"enum_shipments_status", "pending"},
+				{"enum_shipments_status", "paid"},
+			}, nil
+		}
+		var rows [][]driver.Value
+		for _, field := range meta.Fields {
+			rows = append(rows, []driver.Value{"enum_shipments", field.ColumnName, "USER-DEFINED", "NO", nil})
+		}
+		return []string{"table_name", "column_name", "data_type", "is_nullable", "column_default"}, rows, nil
+	})
+	db.registry = registry
+	plan, err = NewMigrator(db).Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enumChanges []MigrationChange
+	for _, change := range plan.Changes {
+		if change.Column == "status" {
+			enumChanges = append(enumChanges, change)
+		}
+	}
+	if len(enumChanges) != 1 || enumChanges[0].SQL != `ALTER TYPE "enum_shipments_status" ADD VALUE 'shipped'` {
+		t.Errorf("expected a single ADD VALUE change, got %+v", enumChanges)
+	}
+}
+
+// TestMigratorSchema tests migrating inside the schema of the context.
+// TestMigratorSchema 测试在上下文的 schema 内执行迁移。
+func TestMigratorSchema(t *testing.T) {
+	var schemaArgs []any
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		for _, arg := range args {
+			schemaArgs = append(schemaArgs, arg.Value)
+		}
+		return nil, nil, nil
+	})
+	if err := db.Register(&fkCustomer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := WithSchema(context.Background(), "tenant_42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMigrator(db).AutoSync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range schemaArgs {
+		if arg != "tenant_42" {
+			t.Errorf("schema queries should look in tenant_42, got %v", arg)
+		}
+	}
+	queries := backend.Queries()
+	if last := queries[len(queries)-1]; !strings.HasPrefix(last, `CREATE TABLE "tenant_42"."fk_customers"`) {
+		t.Errorf("table should be created in the tenant schema: %s", last)
+	}
+}
+
 // TestMigratorTypesCompatible tests type compatibility checking.
 // TestMigratorTypesCompatible 测试类型兼容性检查。
 func TestMigratorTypesCompatible(t *testing.T) {
@@ -148,3 +588,150 @@ func TestMigratorGenerateBackupName(t *testing.T) {
 		t.Errorf("backup name should contain '_backup_users_': %s", name)
 	}
 }
+
+// TestDescribeLive tests describing a table from the database catalog.
+// TestDescribeLive 测试从数据库目录描述表。
+func TestDescribeLive(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		switch {
+		case strings.Contains(query, "sqlite_master"):
+			return []string{"name"}, [][]driver.Value{{"fk_orders"}}, nil
+		case strings.Contains(query, "table_info"):
+			return []string{"cid", "name", "type", "notnull", "dflt_value", "pk"}, [][]driver.Value{
+				{int64(0), "id", "INTEGER", int64(1), nil, int64(1)},
+				{int64(1), "customer_id", "INTEGER", int64(1), nil, int64(0)},
+				{int64(2), "code", "TEXT", int64(0), "'new'", int64(0)},
+			}, nil
+		case strings.Contains(query, "index_list"):
+			return []string{"seq", "name", "unique", "origin", "partial"}, [][]driver.Value{
+				{int64(0), "idx_fk_orders_code", int64(1), "c", int64(0)},
+			}, nil
+		case strings.Contains(query, "index_info"):
+			return []string{"seqno", "cid", "name"}, [][]driver.Value{{int64(0), int64(2), "code"}}, nil
+		case strings.Contains(query, "foreign_key_list"):
+			return []string{"id", "seq", "table", "from", "to", "on_update", "on_delete", "match"}, [][]driver.Value{
+				{int64(0), int64(0), "fk_customers", "customer_id", "id", "NO ACTION", "CASCADE", "NONE"},
+			}, nil
+		}
+		return nil, nil, nil
+	})
+
+	result := db.ExecuteQuery(context.Background(), &Query{Action: ActionDescribe, Table: "fk_orders", Live: true})
+	if !result.Success {
+		t.Fatalf("describe failed: %+v", result.Error)
+	}
+	schema := result.Schema
+	var names []string
+	for _, col := range schema.Columns {
+		names = append(names, col.Name)
+	}
+	if strings.Join(names, ",") != "id,customer_id,code" {
+		t.Errorf("expected columns in table order, got %v", names)
+	}
+	if !schema.Columns[0].Primary || schema.Columns[0].Nullable {
+		t.Errorf("expected id to be a non-null primary key, got %+v", schema.Columns[0])
+	}
+	if code := schema.Columns[2]; !code.Unique || !code.Nullable || code.Default != "'new'" {
+		t.Errorf("expected code to be unique, nullable and defaulted, got %+v", code)
+	}
+	if len(schema.Indexes) != 1 || schema.Indexes[0].Name != "idx_fk_orders_code" || !schema.Indexes[0].Unique {
+		t.Errorf("expected the unique code index, got %+v", schema.Indexes)
+	}
+	if len(schema.Relations) != 1 {
+		t.Fatalf("expected one foreign key, got %+v", schema.Relations)
+	}
+	if fk := schema.Relations[0]; fk.Type != "belongs_to" || fk.Target != "fk_customers" || fk.ForeignKey != "customer_id" || fk.ReferenceKey != "id" || fk.OnDelete != "CASCADE" {
+		t.Errorf("unexpected foreign key: %+v", fk)
+	}
+
+	missing := db.ExecuteQuery(context.Background(), &Query{Action: ActionDescribe, Table: "missing", Live: true})
+	if missing.Success || missing.Error.Code != "TABLE_NOT_FOUND" {
+		t.Errorf("expected TABLE_NOT_FOUND for a missing table, got %+v", missing.Error)
+	}
+
+	if err := (&Query{Action: ActionFind, Table: "fk_orders", Live: true}).Validate(); err == nil {
+		t.Error("live should only be valid for describe")
+	}
+}
+
+type driftAccount struct {
+	ID    uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+	Email string `json:"email" goorm:"unique"`
+	Code  string `json:"code" goorm:"index"`
+	Age   int    `json:"age"`
+	Nick  string `json:"nick"`
+}
+
+type driftInvoice struct {
+	ID uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+}
+
+// TestSchemaDiff tests comparing registered models with the live schema.
+// TestSchemaDiff 测试比较已注册的模型与实际数据库结构。
+func TestSchemaDiff(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		switch {
+		case strings.Contains(query, "sqlite_master"):
+			return []string{"name"}, [][]driver.Value{{"drift_accounts"}, {"legacy_notes"}, {"_backup_old"}}, nil
+		case strings.Contains(query, "table_info(drift_accounts)"):
+			return []string{"cid", "name", "type", "notnull", "dflt_value", "pk"}, [][]driver.Value{
+				{int64(0), "id", "INTEGER", int64(1), nil, int64(1)},
+				{int64(1), "email", "TEXT", int64(1), nil, int64(0)},
+				{int64(2), "code", "BLOB", int64(1), nil, int64(0)},
+				{int64(3), "age", "INTEGER", int64(1), nil, int64(0)},
+				{int64(4), "fax", "TEXT", int64(0), nil, int64(0)},
+			}, nil
+		case strings.Contains(query, "table_info"):
+			return []string{"cid", "name", "type", "notnull", "dflt_value", "pk"}, [][]driver.Value{
+				{int64(0), "body", "TEXT", int64(0), nil, int64(0)},
+			}, nil
+		case strings.Contains(query, "index_list"):
+			return []string{"seq", "name", "unique", "origin", "partial"}, [][]driver.Value{
+				{int64(0), "sqlite_autoindex_drift_accounts_1", int64(1), "u", int64(0)},
+			}, nil
+		case strings.Contains(query, "index_info"):
+			return []string{"seqno", "cid", "name"}, [][]driver.Value{{int64(0), int64(1), "email"}}, nil
+		}
+		return nil, nil, nil
+	})
+	if err := db.Register(&driftAccount{}, &driftInvoice{}); err != nil {
+		t.Fatal(err)
+	}
+	db.config.Migration.Aggressive = false
+
+	diff, err := db.SchemaDiff(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.HasDrift() {
+		t.Fatal("expected drift")
+	}
+	if len(diff.MissingTables) != 1 || diff.MissingTables[0] != "drift_invoices" {
+		t.Errorf("expected drift_invoices to be missing, got %v", diff.MissingTables)
+	}
+	if len(diff.ExtraTables) != 1 || diff.ExtraTables[0] != "legacy_notes" {
+		t.Errorf("expected legacy_notes to be extra, got %v", diff.ExtraTables)
+	}
+	if len(diff.Tables) != 1 {
+		t.Fatalf("expected one table diff, got %+v", diff.Tables)
+	}
+	table := diff.Tables[0]
+	if table.Table != "drift_accounts" {
+		t.Errorf("unexpected table %s", table.Table)
+	}
+	if len(table.MissingColumns) != 1 || table.MissingColumns[0] != "nick" {
+		t.Errorf("expected nick to be missing, got %v", table.MissingColumns)
+	}
+	if len(table.ExtraColumns) != 1 || table.ExtraColumns[0] != "fax" {
+		t.Errorf("expected fax to be extra, got %v", table.ExtraColumns)
+	}
+	if len(table.TypeMismatches) != 1 || table.TypeMismatches[0].Column != "code" || table.TypeMismatches[0].DBType != "BLOB" {
+		t.Errorf("expected code to mismatch, got %+v", table.TypeMismatches)
+	}
+	if len(table.MissingIndexes) != 1 || table.MissingIndexes[0].Name != "idx_drift_accounts_code" || table.MissingIndexes[0].Unique {
+		t.Errorf("expected the code index to be missing, got %+v", table.MissingIndexes)
+	}
+	if want := "drift_accounts: missing index idx_drift_accounts_code (code)"; !strings.Contains(diff.String(), want) {
+		t.Errorf("expected %q in:\n%s", want, diff.String())
+	}
+}
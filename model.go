@@ -18,8 +18,9 @@ import (
 //	    Email string `json:"email"`
 //	}
 type Model struct {
-	// ID is the primary key, auto-incremented.
-	// ID 是主键，自动递增。
+	// ID is the primary key, auto-incremented. Models embedding Model are
+	// always registered with "id" as their primary key.
+	// ID 是主键，自动递增。嵌入 Model 的模型在注册时总以 "id" 作为主键。
 	ID uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
 
 	// CreatedAt is automatically set when the record is created.
@@ -63,3 +64,18 @@ type TableNamer interface {
 type ModelDescriber interface {
 	ModelDescription() string
 }
+
+// DefaultOrderer is an interface for models whose find queries have a default sort order.
+// The order applies when a query has no order_by and does not set no_default_order.
+//
+// DefaultOrderer 是一个接口，用于为模型的查找查询提供默认排序。
+// 当查询没有 order_by 且未设置 no_default_order 时应用该排序。
+//
+// Example / 示例:
+//
+//	func (Event) DefaultOrder() []goorm.Order {
+//	    return []goorm.Order{{Field: "created_at", Desc: true}}
+//	}
+type DefaultOrderer interface {
+	DefaultOrder() []Order
+}
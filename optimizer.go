@@ -5,12 +5,18 @@ import (
 	"strings"
 )
 
+// DefaultOffsetThreshold is the OFFSET above which the optimizer flags pagination.
+// DefaultOffsetThreshold 是优化器标记分页问题的 OFFSET 阈值。
+const DefaultOffsetThreshold = 10000
+
 // QueryOptimizer analyzes and optimizes JQL queries.
 // QueryOptimizer 分析和优化 JQL 查询。
 type QueryOptimizer struct {
-	db      *DB
-	enabled bool
-	hints   []OptimizationHint
+	db              *DB
+	enabled         bool
+	hints           []OptimizationHint
+	offsetThreshold int
+	defaultLimit    int
 }
 
 // OptimizationHint represents a query optimization suggestion.
@@ -51,18 +57,38 @@ type OptimizationResult struct {
 	// Score is the optimization score (0-100).
 	// Score 是优化评分（0-100）。
 	Score int `json:"score"`
+
+	// IndexSuggested lists CREATE INDEX statements that would help the query.
+	// IndexSuggested 列出有助于该查询的 CREATE INDEX 语句。
+	IndexSuggested []string `json:"index_suggested,omitempty"`
 }
 
 // NewQueryOptimizer creates a new query optimizer.
 // NewQueryOptimizer 创建新的查询优化器。
 func NewQueryOptimizer(db *DB) *QueryOptimizer {
 	return &QueryOptimizer{
-		db:      db,
-		enabled: true,
-		hints:   make([]OptimizationHint, 0),
+		db:              db,
+		enabled:         true,
+		hints:           make([]OptimizationHint, 0),
+		offsetThreshold: DefaultOffsetThreshold,
 	}
 }
 
+// SetDefaultLimit enables limit injection: Optimize caps find queries without a
+// limit at n rows. n <= 0 disables it, which is the default.
+//
+// SetDefaultLimit 启用限制注入：Optimize 将没有限制的查找查询限制为 n 行。
+// n <= 0 时禁用，这也是默认值。
+func (o *QueryOptimizer) SetDefaultLimit(n int) {
+	o.defaultLimit = n
+}
+
+// SetOffsetThreshold sets the OFFSET above which a query is flagged.
+// SetOffsetThreshold 设置查询被标记的 OFFSET 阈值。
+func (o *QueryOptimizer) SetOffsetThreshold(n int) {
+	o.offsetThreshold = n
+}
+
 // Analyze analyzes a query and returns optimization suggestions.
 // Analyze 分析查询并返回优化建议。
 func (o *QueryOptimizer) Analyze(query *Query) *OptimizationResult {
@@ -92,6 +118,10 @@ func (o *QueryOptimizer) Analyze(query *Query) *OptimizationResult {
 	// 检查低效操作符
 	o.checkInefficiententOperators(query, result)
 
+	// Check for large OFFSET pagination
+	// 检查大 OFFSET 分页
+	o.checkLargeOffset(query, result)
+
 	return result
 }
 
@@ -116,25 +146,74 @@ func (o *QueryOptimizer) checkMissingIndexes(query *Query, result *OptimizationR
 		}
 	}
 
-	// Check each WHERE condition
-	// 检查每个 WHERE 条件
+	// Check each WHERE condition on this table
+	// 检查该表上的每个 WHERE 条件
+	var missing []string
+	hasOr := false
 	for _, cond := range query.Where {
+		if cond.Or {
+			hasOr = true
+		}
 		field := cond.Field
-		if strings.Contains(field, ".") {
-			parts := strings.Split(field, ".")
-			field = parts[len(parts)-1]
+		if i := strings.LastIndex(field, "."); i >= 0 {
+			if field[:i] != query.Table {
+				continue
+			}
+			field = field[i+1:]
 		}
-
-		if !indexedFields[field] {
-			result.Hints = append(result.Hints, OptimizationHint{
-				Type:       "missing_index",
-				Severity:   "warning",
-				Message:    fmt.Sprintf("Column '%s' in WHERE clause may not be indexed", field),
-				Suggestion: fmt.Sprintf("Consider adding an index on '%s.%s'", query.Table, field),
-			})
-			result.Score -= 10
+		if field == "" || indexedFields[field] {
+			continue
 		}
+		indexedFields[field] = true
+
+		stmt := o.createIndexSQL(query.Table, field)
+		result.Hints = append(result.Hints, OptimizationHint{
+			Type:       "missing_index",
+			Severity:   "warning",
+			Message:    fmt.Sprintf("Column '%s' in WHERE clause may not be indexed", field),
+			Suggestion: stmt,
+		})
+		result.Score -= 10
+		missing = append(missing, field)
+	}
+
+	if len(missing) == 0 {
+		return
 	}
+
+	// Columns ANDed together are best served by one composite index
+	// 以 AND 组合的多个列最适合使用一个复合索引
+	if len(missing) > 1 && !hasOr {
+		stmt := o.createIndexSQL(query.Table, missing...)
+		result.Hints = append(result.Hints, OptimizationHint{
+			Type:       "composite_index",
+			Severity:   "info",
+			Message:    fmt.Sprintf("Columns %s are filtered together", strings.Join(missing, ", ")),
+			Suggestion: stmt,
+		})
+		result.IndexSuggested = append(result.IndexSuggested, stmt)
+		return
+	}
+
+	for _, field := range missing {
+		result.IndexSuggested = append(result.IndexSuggested, o.createIndexSQL(query.Table, field))
+	}
+}
+
+// createIndexSQL returns a CREATE INDEX statement named idx_<table>_<columns>.
+// createIndexSQL 返回名为 idx_<table>_<columns> 的 CREATE INDEX 语句。
+func (o *QueryOptimizer) createIndexSQL(table string, columns ...string) string {
+	quote := func(s string) string { return s }
+	if o.db.dialect != nil {
+		quote = o.db.dialect.Quote
+	}
+
+	name := "idx_" + table + "_" + strings.Join(columns, "_")
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quote(col)
+	}
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", quote(name), quote(table), strings.Join(quoted, ", "))
 }
 
 // checkSelectAll checks for SELECT * usage.
@@ -235,6 +314,22 @@ func (o *QueryOptimizer) checkInefficiententOperators(query *Query, result *Opti
 	}
 }
 
+// checkLargeOffset checks for pagination with a large OFFSET.
+// checkLargeOffset 检查使用大 OFFSET 的分页。
+func (o *QueryOptimizer) checkLargeOffset(query *Query, result *OptimizationResult) {
+	if query.Offset <= o.offsetThreshold {
+		return
+	}
+
+	result.Hints = append(result.Hints, OptimizationHint{
+		Type:       "large_offset",
+		Severity:   "warning",
+		Message:    fmt.Sprintf("OFFSET %d makes the database scan and discard %d rows", query.Offset, query.Offset),
+		Suggestion: "Use keyset (cursor) pagination: filter on the last seen sort key, e.g. id > last_id, instead of OFFSET",
+	})
+	result.Score -= 15
+}
+
 // Optimize applies automatic optimizations to a query.
 // Optimize 对查询应用自动优化。
 func (o *QueryOptimizer) Optimize(query *Query) *Query {
@@ -245,10 +340,11 @@ func (o *QueryOptimizer) Optimize(query *Query) *Query {
 	// Apply automatic optimizations
 	// 应用自动优化
 
-	// Add default limit if missing for find queries
-	// 如果查找查询缺少限制，添加默认限制
-	if optimized.Action == ActionFind && optimized.Limit == 0 {
-		optimized.Limit = 1000 // Default reasonable limit
+	// Add the default limit if enabled and missing for find queries
+	// 如果已启用且查找查询缺少限制，添加默认限制
+	if o.defaultLimit > 0 && optimized.Action == ActionFind && optimized.Limit == 0 {
+		optimized.Limit = o.defaultLimit
+		optimized.AutoLimited = true
 	}
 
 	return &optimized
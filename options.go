@@ -0,0 +1,62 @@
+package goorm
+
+import "context"
+
+// Options are per-request settings carried in a context by WithOptions. They
+// add to the DB config and the query for calls made with that context, without
+// changing state shared by other requests.
+//
+// Options 是通过 WithOptions 放入上下文的单次请求设置。对使用该上下文的调用，
+// 它们叠加在 DB 配置和查询之上，而不会修改其他请求共享的状态。
+type Options struct {
+	// Debug returns the SQL in results, as Query.Debug does.
+	// Debug 在结果中返回 SQL，与 Query.Debug 相同。
+	Debug bool
+
+	// Actor is the acting user recorded in audit logs, as WithActor sets it.
+	// Actor 是审计日志中记录的执行操作用户，与 WithActor 设置的相同。
+	Actor any
+
+	// Primary runs reads on the primary instead of a replica, as Query.Primary does.
+	// Primary 让读操作在主库而非副本上执行，与 Query.Primary 相同。
+	Primary bool
+}
+
+// optionsKey is the context key for per-request options.
+// optionsKey 是单次请求选项的上下文键。
+type optionsKey struct{}
+
+// WithOptions returns a copy of ctx carrying opts, replacing options set by an
+// earlier WithOptions.
+//
+// WithOptions 返回携带 opts 的 ctx 副本，会替换之前 WithOptions 设置的选项。
+//
+// Example / 示例:
+//
+//	ctx := goorm.WithOptions(r.Context(), goorm.Options{Debug: true, Actor: userID})
+//	db.ExecuteContext(ctx, jql)
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	if opts.Actor != nil {
+		ctx = WithActor(ctx, opts.Actor)
+	}
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// OptionsFromContext returns the options stored by WithOptions, or zero Options.
+// OptionsFromContext 返回 WithOptions 存储的选项，没有则返回零值 Options。
+func OptionsFromContext(ctx context.Context) Options {
+	if ctx == nil {
+		return Options{}
+	}
+	opts, _ := ctx.Value(optionsKey{}).(Options)
+	return opts
+}
+
+// debug reports whether results for query should include the SQL.
+// debug 判断 query 的结果是否应包含 SQL。
+func (db *DB) debug(ctx context.Context, query *Query) bool {
+	db.mu.RLock()
+	debug := db.config.Debug
+	db.mu.RUnlock()
+	return query.Debug || debug || OptionsFromContext(ctx).Debug
+}
@@ -0,0 +1,245 @@
+package goorm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pgArrayType returns the Postgres array type for a slice Go type such as
+// []int64 or []string, or "" if goType is not a slice of scalars.
+//
+// pgArrayType 返回 []int64、[]string 等切片 Go 类型对应的 Postgres 数组类型；goType 不是标量切片时返回 ""。
+func pgArrayType(goType string) string {
+	elem, ok := strings.CutPrefix(goType, "[]")
+	if !ok {
+		return ""
+	}
+	switch elem {
+	case "int", "int32", "uint", "uint32":
+		return "INTEGER[]"
+	case "int8", "int16", "uint16":
+		return "SMALLINT[]"
+	case "int64", "uint64":
+		return "BIGINT[]"
+	case "float32":
+		return "REAL[]"
+	case "float64":
+		return "DOUBLE PRECISION[]"
+	case "bool":
+		return "BOOLEAN[]"
+	case "string":
+		return "TEXT[]"
+	case "time.Time":
+		return "TIMESTAMP WITH TIME ZONE[]"
+	}
+	return ""
+}
+
+// pgArrayLiteral formats values as a one-dimensional Postgres array literal,
+// e.g. {1,2,NULL} or {"a","b \"c\""}.
+//
+// pgArrayLiteral 将 values 格式化为一维 Postgres 数组字面量，例如 {1,2,NULL} 或 {"a","b \"c\""}。
+func pgArrayLiteral(values []any) string {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		switch v := v.(type) {
+		case nil:
+			sb.WriteString("NULL")
+		case bool:
+			sb.WriteString(strconv.FormatBool(v))
+		case float64:
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case float32:
+			sb.WriteString(strconv.FormatFloat(float64(v), 'f', -1, 32))
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			fmt.Fprint(&sb, v)
+		case time.Time:
+			sb.WriteString(pgArrayQuote(v.Format(time.RFC3339Nano)))
+		case []byte:
+			sb.WriteString(pgArrayQuote(string(v)))
+		default:
+			sb.WriteString(pgArrayQuote(fmt.Sprint(v)))
+		}
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// pgArrayQuote quotes s as an array element, escaping quotes and backslashes.
+// pgArrayQuote 将 s 作为数组元素加引号，并转义引号和反斜杠。
+func pgArrayQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// parsePGArray parses a one-dimensional Postgres array literal into its elements,
+// with nil for NULL.
+//
+// parsePGArray 将一维 Postgres 数组字面量解析为其元素，NULL 解析为 nil。
+func parsePGArray(s string) ([]any, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("not an array literal: %q", s)
+	}
+	body := s[1 : len(s)-1]
+	elems := []any{}
+	if body == "" {
+		return elems, nil
+	}
+
+	for i := 0; i <= len(body); {
+		if i < len(body) && body[i] == '"' {
+			var sb strings.Builder
+			i++
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				sb.WriteByte(body[i])
+			}
+			if i >= len(body) {
+				return nil, fmt.Errorf("unterminated element in %q", s)
+			}
+			elems = append(elems, sb.String())
+			i += 2 // closing quote and comma
+			continue
+		}
+
+		end := strings.IndexByte(body[i:], ',')
+		if end < 0 {
+			end = len(body) - i
+		}
+		elem := strings.TrimSpace(body[i : i+end])
+		if elem == "" || elem[0] == '{' {
+			return nil, fmt.Errorf("unsupported array literal %q", s)
+		}
+		if strings.EqualFold(elem, "NULL") {
+			elems = append(elems, nil)
+		} else {
+			elems = append(elems, elem)
+		}
+		i += end + 1
+	}
+	return elems, nil
+}
+
+// arrayColumns returns the array columns of table mapped to their Go slice types.
+// Only Postgres has array columns.
+//
+// arrayColumns 返回 table 的数组列到其 Go 切片类型的映射。只有 Postgres 支持数组列。
+func (db *DB) arrayColumns(table string) map[string]reflect.Type {
+	if db.dialect.Name() != "postgres" || db.registry == nil {
+		return nil
+	}
+	meta, ok := db.registry.Get(table)
+	if !ok {
+		return nil
+	}
+	var cols map[string]reflect.Type
+	for _, field := range meta.Fields {
+		t := field.Type
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8 {
+			continue
+		}
+		sqlType := field.SQLTypeFor(db.dialect.Name())
+		if sqlType == "" {
+			sqlType = db.dialect.GoTypeToSQL(field.GoType, field.Tags)
+		}
+		if !strings.HasSuffix(strings.TrimSpace(sqlType), "[]") {
+			continue
+		}
+		if cols == nil {
+			cols = make(map[string]reflect.Type)
+		}
+		cols[field.ColumnName] = t
+	}
+	return cols
+}
+
+// encodeArrayColumns formats the slices in data that are bound for array columns
+// of table as array literals, which every Postgres driver accepts.
+//
+// encodeArrayColumns 将 data 中写入 table 数组列的切片格式化为数组字面量，所有 Postgres 驱动都接受该格式。
+func (db *DB) encodeArrayColumns(table string, data map[string]any) {
+	if len(data) == 0 {
+		return
+	}
+	cols := db.arrayColumns(table)
+	for col, value := range data {
+		if cols[col] == nil {
+			continue
+		}
+		if _, isString := value.(string); isString {
+			continue
+		}
+		if values, ok := sliceValues(value); ok {
+			data[col] = pgArrayLiteral(values)
+		}
+	}
+}
+
+// decodeArrayColumns parses the array columns of table in rows into slices of
+// the fields' Go types. Values that cannot be parsed are left as scanned.
+//
+// decodeArrayColumns 将 rows 中 table 的数组列解析为字段 Go 类型的切片。无法解析的值保持扫描时的原样。
+func (db *DB) decodeArrayColumns(table string, rows []map[string]any) {
+	if len(rows) == 0 {
+		return
+	}
+	cols := db.arrayColumns(table)
+	for _, row := range rows {
+		for col, sliceType := range cols {
+			var literal string
+			switch v := row[col].(type) {
+			case string:
+				literal = v
+			case []byte:
+				literal = string(v)
+			default:
+				continue
+			}
+			if slice, err := decodePGArray(literal, sliceType); err == nil {
+				row[col] = slice
+			}
+		}
+	}
+}
+
+// decodePGArray parses literal into a slice of sliceType. NULL elements become
+// zero values unless the element type is a pointer.
+//
+// decodePGArray 将 literal 解析为 sliceType 类型的切片。NULL 元素为零值，元素类型为指针时为 nil。
+func decodePGArray(literal string, sliceType reflect.Type) (any, error) {
+	elems, err := parsePGArray(literal)
+	if err != nil {
+		return nil, err
+	}
+	slice := reflect.MakeSlice(sliceType, len(elems), len(elems))
+	for i, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		if err := assignValue(slice.Index(i), elem); err != nil {
+			// Parse numbers, bools and times from their text form
+			// 从文本形式解析数字、布尔值和时间
+			target := slice.Index(i)
+			elemType := strings.TrimPrefix(target.Type().String(), "*")
+			value, cerr := coerceValue(elem, elemType)
+			if cerr != nil {
+				return nil, cerr
+			}
+			if err := assignValue(target, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return slice.Interface(), nil
+}
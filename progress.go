@@ -0,0 +1,40 @@
+package goorm
+
+import "context"
+
+// ProgressFunc receives the progress of a long-running operation:
+// done of total steps have finished, and message describes the last one.
+//
+// ProgressFunc 接收长时间运行操作的进度：total 个步骤中已完成 done 个，message 描述最后完成的步骤。
+type ProgressFunc func(done, total int, message string)
+
+// progressKey is the context key for the progress callback.
+// progressKey 是进度回调的上下文键。
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx that reports progress to fn.
+// Migrations report one step per applied change and transactions one per operation.
+//
+// WithProgress 返回向 fn 报告进度的 ctx 副本。
+// 迁移每应用一个变更报告一步，事务每执行一个操作报告一步。
+//
+// Example / 示例:
+//
+//	ctx := goorm.WithProgress(ctx, func(done, total int, message string) {
+//	    log.Printf("%d/%d %s", done, total, message)
+//	})
+//	migrator.Execute(ctx, plan)
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress to the callback stored by WithProgress, if any.
+// ReportProgress 向 WithProgress 存储的回调（如果有）报告进度。
+func ReportProgress(ctx context.Context, done, total int, message string) {
+	if ctx == nil {
+		return
+	}
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(done, total, message)
+	}
+}
@@ -0,0 +1,106 @@
+package goorm
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the query latency histogram.
+// NewMetricsCollector copies them, so a change applies to collectors created after it.
+// DefaultLatencyBuckets 是查询延迟直方图的上界（秒）。NewMetricsCollector 会复制它们，因此修改只对之后创建的收集器生效。
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusHandler returns an http.Handler that exports metrics
+// in the Prometheus text exposition format.
+//
+// Exported metrics:
+//   - goorm_queries_total{action}
+//   - goorm_query_errors_total{action}
+//   - goorm_slow_queries_total{action}
+//   - goorm_query_duration_seconds{action} (histogram)
+//
+// PrometheusHandler 返回以 Prometheus 文本格式导出指标的 http.Handler。
+//
+// Example / 示例:
+//
+//	http.Handle("/metrics", metrics.PrometheusHandler())
+func (m *MetricsCollector) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+// WritePrometheus 以 Prometheus 文本格式写出所有指标。
+func (m *MetricsCollector) WritePrometheus(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Sort actions for stable output
+	// 对操作排序以获得稳定的输出
+	actions := make([]string, 0, len(m.byAction))
+	for action := range m.byAction {
+		actions = append(actions, string(action))
+	}
+	sort.Strings(actions)
+
+	pw := &promWriter{w: w}
+
+	pw.header("goorm_queries_total", "counter", "Total number of executed queries.")
+	for _, action := range actions {
+		pw.sample("goorm_queries_total", action, "", float64(m.byAction[Action(action)].Count))
+	}
+
+	pw.header("goorm_query_errors_total", "counter", "Total number of failed queries.")
+	for _, action := range actions {
+		pw.sample("goorm_query_errors_total", action, "", float64(m.byAction[Action(action)].Errors))
+	}
+
+	pw.header("goorm_slow_queries_total", "counter", "Total number of queries exceeding the slow query threshold.")
+	for _, action := range actions {
+		pw.sample("goorm_slow_queries_total", action, "", float64(m.byAction[Action(action)].Slow))
+	}
+
+	pw.header("goorm_query_duration_seconds", "histogram", "Query latency in seconds.")
+	for _, action := range actions {
+		am := m.byAction[Action(action)]
+		for i, le := range m.latencyBuckets {
+			pw.sample("goorm_query_duration_seconds_bucket", action, strconv.FormatFloat(le, 'g', -1, 64), float64(am.buckets[i]))
+		}
+		pw.sample("goorm_query_duration_seconds_bucket", action, "+Inf", float64(am.Count))
+		pw.sample("goorm_query_duration_seconds_sum", action, "", am.Duration.Seconds())
+		pw.sample("goorm_query_duration_seconds_count", action, "", float64(am.Count))
+	}
+
+	return pw.err
+}
+
+// promWriter writes Prometheus text lines and remembers the first error.
+// promWriter 写出 Prometheus 文本行并记录第一个错误。
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *promWriter) header(name, typ, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (p *promWriter) sample(name, action, le string, value float64) {
+	if le != "" {
+		p.printf("%s{action=%q,le=%q} %s\n", name, action, le, strconv.FormatFloat(value, 'g', -1, 64))
+		return
+	}
+	p.printf("%s{action=%q} %s\n", name, action, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (p *promWriter) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
@@ -0,0 +1,68 @@
+package goorm
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPrometheusHandler tests the Prometheus metrics exporter.
+// TestPrometheusHandler 测试 Prometheus 指标导出器。
+func TestPrometheusHandler(t *testing.T) {
+	m := NewMetricsCollector()
+	m.RecordQuery(ActionFind, 3*time.Millisecond, nil)
+	m.RecordQuery(ActionFind, 300*time.Millisecond, nil)
+	m.RecordQuery(ActionCreate, 20*time.Millisecond, errors.New("boom"))
+
+	rec := httptest.NewRecorder()
+	m.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	expected := []string{
+		"# TYPE goorm_queries_total counter",
+		`goorm_queries_total{action="find"} 2`,
+		`goorm_query_errors_total{action="create"} 1`,
+		`goorm_slow_queries_total{action="find"} 1`,
+		"# TYPE goorm_query_duration_seconds histogram",
+		`goorm_query_duration_seconds_bucket{action="find",le="0.005"} 1`,
+		`goorm_query_duration_seconds_bucket{action="find",le="0.5"} 2`,
+		`goorm_query_duration_seconds_bucket{action="find",le="+Inf"} 2`,
+		`goorm_query_duration_seconds_count{action="create"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing %q in output:\n%s", line, body)
+		}
+	}
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	// GetStats output is unchanged
+	// GetStats 输出保持不变
+	if m.GetStats()["total_queries"].(int64) != 3 {
+		t.Error("GetStats should still report total queries")
+	}
+}
+
+// TestLatencyBucketsCopied tests that changing DefaultLatencyBuckets does not affect
+// an existing collector.
+// TestLatencyBucketsCopied 测试修改 DefaultLatencyBuckets 不影响已有的收集器。
+func TestLatencyBucketsCopied(t *testing.T) {
+	m := NewMetricsCollector()
+	saved := DefaultLatencyBuckets
+	DefaultLatencyBuckets = DefaultLatencyBuckets[:2]
+	defer func() { DefaultLatencyBuckets = saved }()
+
+	m.RecordQuery(ActionFind, 3*time.Second, nil)
+	var sb strings.Builder
+	if err := m.WritePrometheus(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), `goorm_query_duration_seconds_bucket{action="find",le="5"} 1`) {
+		t.Errorf("expected the buckets of the collector's creation:\n%s", sb.String())
+	}
+}
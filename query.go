@@ -1,8 +1,15 @@
 package goorm
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 )
 
 // Action represents the type of database operation in JQL.
@@ -40,6 +47,10 @@ const (
 	// ActionAggregate 执行聚合操作（SUM、AVG 等）。
 	ActionAggregate Action = "aggregate"
 
+	// ActionExists checks whether any record matches; Result.Count is 1 if one does and 0 otherwise.
+	// ActionExists 检查是否有记录匹配；有则 Result.Count 为 1，否则为 0。
+	ActionExists Action = "exists"
+
 	// ActionTransaction executes multiple operations atomically.
 	// ActionTransaction 原子地执行多个操作。
 	ActionTransaction Action = "transaction"
@@ -79,10 +90,45 @@ const (
 	OpLike        Operator = "like"     // Pattern match / 模式匹配
 	OpILike       Operator = "ilike"    // Case-insensitive pattern match / 不区分大小写模式匹配
 	OpNotLike     Operator = "not_like" // Not pattern match / 不匹配模式
-	OpBetween     Operator = "between"  // Between range / 在范围内
+	OpBetween     Operator = "between"  // Between range, a null bound is open / 在范围内，null 边界表示不设限
 	OpNull        Operator = "null"     // Is null / 为空
 	OpNotNull     Operator = "not_null" // Is not null / 不为空
 	OpExists      Operator = "exists"   // Exists subquery / 存在子查询
+
+	OpNullSafeEqual Operator = "<=>"            // Equal, treating NULLs as equal / 等于，NULL 视为相等
+	OpArrayContains Operator = "array_contains" // Array column contains all values, Postgres only / 数组列包含所有值，仅 Postgres
+	OpIEqual        Operator = "iequal"         // Case-insensitive equal, no wildcards / 不区分大小写的等于，无通配符
+)
+
+// Row lock modes for find queries inside transactions.
+// 事务中查找查询的行锁模式。
+const (
+	LockUpdate = "update" // SELECT ... FOR UPDATE / 排他锁
+	LockShare  = "share"  // SELECT ... FOR SHARE / 共享锁
+)
+
+// NULL placements for Order.Nulls.
+// Order.Nulls 的 NULL 位置。
+const (
+	NullsFirst = "first" // NULLs sort before other values / NULL 排在其他值之前
+	NullsLast  = "last"  // NULLs sort after other values / NULL 排在其他值之后
+)
+
+// Conflict handling modes for Query.OnConflict.
+// Query.OnConflict 的冲突处理模式。
+const (
+	OnConflictIgnore = "ignore" // Skip rows that already exist / 跳过已存在的行
+)
+
+// Date parts a condition's Fn can extract from a date/time column.
+// 条件的 Fn 可以从日期/时间列中提取的日期部分。
+const (
+	DateYear   = "year"   // Year / 年
+	DateMonth  = "month"  // Month (1-12) / 月（1-12）
+	DateDay    = "day"    // Day of month (1-31) / 日（1-31）
+	DateHour   = "hour"   // Hour (0-23) / 小时（0-23）
+	DateMinute = "minute" // Minute (0-59) / 分钟（0-59）
+	DateSecond = "second" // Second (0-59) / 秒（0-59）
 )
 
 // Query represents a JQL query structure.
@@ -119,10 +165,18 @@ type Query struct {
 	// OrderBy 指定排序顺序。
 	OrderBy []Order `json:"order_by,omitempty"`
 
+	// NoDefaultOrder disables the default order of the model when OrderBy is empty.
+	// NoDefaultOrder 在 OrderBy 为空时禁用模型的默认排序。
+	NoDefaultOrder bool `json:"no_default_order,omitempty"`
+
 	// GroupBy specifies the grouping columns.
 	// GroupBy 指定分组列。
 	GroupBy []string `json:"group_by,omitempty"`
 
+	// Rollup adds subtotal rows for each GroupBy prefix and a grand total row.
+	// Rollup 为 GroupBy 的每个前缀添加小计行，并添加总计行。
+	Rollup bool `json:"rollup,omitempty"`
+
 	// Having specifies conditions for grouped results.
 	// Having 指定分组结果的条件。
 	Having []HavingCondition `json:"having,omitempty"`
@@ -135,6 +189,50 @@ type Query struct {
 	// Offset 跳过前 N 条结果。
 	Offset int `json:"offset,omitempty"`
 
+	// BatchSize makes an update or delete run in chunks of at most this many rows, each
+	// its own statement committed before the next, so large writes do not hold locks
+	// for long. It is ignored inside transactions.
+	// BatchSize 使 update 或 delete 按每批最多这么多行分块执行，每块是一条在下一块之前提交的独立语句，
+	// 因此大批量写入不会长时间持有锁。在事务中会被忽略。
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// Lock locks the selected rows ("update" or "share"); only meaningful inside a transaction.
+	// Lock 锁定选中的行（"update" 或 "share"）；仅在事务中有意义。
+	Lock string `json:"lock,omitempty"`
+
+	// Primary forces a read to run on the primary instead of a replica, e.g. to read your own writes.
+	// Primary 强制读操作在主库而不是副本上执行，例如为了读到自己刚写入的数据。
+	Primary bool `json:"primary,omitempty"`
+
+	// Returning lists columns to return from the rows written by a create, create_batch, update or delete ("*" for all).
+	// Returning 列出 create、create_batch、update 或 delete 所写入行要返回的列（"*" 表示全部）。
+	Returning []string `json:"returning,omitempty"`
+
+	// ReturnIDs makes an update or delete report the primary keys of the rows it writes
+	// in Result.IDs, through RETURNING where supported. MySQL selects the keys first,
+	// which costs an extra round trip, and without BatchSize fails with TOO_MANY_ROWS
+	// beyond 10000 rows.
+	// ReturnIDs 使 update 或 delete 在 Result.IDs 中报告其写入行的主键，支持时通过 RETURNING 获取。
+	// MySQL 会先查询这些主键，因此多一次往返；未设置 BatchSize 时超过 10000 行会以 TOO_MANY_ROWS 失败。
+	ReturnIDs bool `json:"return_ids,omitempty"`
+
+	// OnConflict sets what a create does when the row conflicts with an existing
+	// primary or unique key. OnConflictIgnore skips the insert and reports Affected 0.
+	// OnConflict 设置 create 的行与已有主键或唯一键冲突时的处理方式。OnConflictIgnore 跳过插入并报告 Affected 为 0。
+	OnConflict string `json:"on_conflict,omitempty"`
+
+	// Live makes a describe read the table's columns, indexes and foreign keys from the
+	// database instead of the registered model.
+	// Live 使 describe 从数据库而不是已注册的模型读取表的列、索引和外键。
+	Live bool `json:"live,omitempty"`
+
+	// Estimate makes a count of a whole table return the database's row estimate instead
+	// of running COUNT(*). Dialects without one, and tables without statistics yet, are
+	// counted exactly. Result.Meta.Estimated reports which was returned.
+	// Estimate 使对整张表的 count 返回数据库的行数估计，而不执行 COUNT(*)。没有估计值的方言以及尚无统计信息的表
+	// 仍精确计数。Result.Meta.Estimated 表示返回的是哪一种。
+	Estimate bool `json:"estimate,omitempty"`
+
 	// With specifies relations to preload.
 	// With 指定要预加载的关联。
 	With []any `json:"with,omitempty"`
@@ -166,6 +264,15 @@ type Query struct {
 	// QueryToExplain is the query to explain (for ActionExplain).
 	// QueryToExplain 是要解释的查询（用于 ActionExplain）。
 	QueryToExplain *Query `json:"query,omitempty"`
+
+	// Plan runs EXPLAIN against the database for an explain action to estimate rows,
+	// cost and indexes used.
+	// Plan 在 explain 操作中对数据库执行 EXPLAIN，以估算行数、成本和使用的索引。
+	Plan bool `json:"plan,omitempty"`
+
+	// AutoLimited is set when Limit was injected by QueryOptimizer.Optimize.
+	// AutoLimited 在 Limit 由 QueryOptimizer.Optimize 注入时被设置。
+	AutoLimited bool `json:"-"`
 }
 
 // Condition represents a WHERE condition in JQL.
@@ -175,10 +282,20 @@ type Condition struct {
 	// Field 是列名。
 	Field string `json:"field,omitempty"`
 
+	// Fn extracts a date part (year, month, day, hour, minute, second) from Field before comparing.
+	// Fn 在比较前从 Field 中提取日期部分（year、month、day、hour、minute、second）。
+	Fn string `json:"fn,omitempty"`
+
 	// Op is the comparison operator.
 	// Op 是比较运算符。
 	Op Operator `json:"op"`
 
+	// Collate compares Field under this collation, e.g. "de-DE-x-icu" on PostgreSQL,
+	// "utf8mb4_german2_ci" on MySQL or "NOCASE" on SQLite.
+	// Collate 按此排序规则比较 Field，例如 PostgreSQL 的 "de-DE-x-icu"、MySQL 的 "utf8mb4_german2_ci"
+	// 或 SQLite 的 "NOCASE"。
+	Collate string `json:"collate,omitempty"`
+
 	// Value is the comparison value.
 	// Value 是比较值。
 	Value any `json:"value,omitempty"`
@@ -214,6 +331,19 @@ type Order struct {
 	// Desc indicates descending order.
 	// Desc 表示降序。
 	Desc bool `json:"desc,omitempty"`
+
+	// Nulls places NULLs "first" or "last"; empty leaves the database default.
+	// Nulls 将 NULL 放在最前（"first"）或最后（"last"）；为空时使用数据库默认行为。
+	Nulls string `json:"nulls,omitempty"`
+
+	// Collate sorts Field under this collation, e.g. "de_DE" for German alphabetical order.
+	// Collate 按此排序规则对 Field 排序，例如 "de_DE" 表示德语字母顺序。
+	Collate string `json:"collate,omitempty"`
+
+	// Priority sorts by the position of Field's value in this list, rows matching
+	// none of the values last, e.g. ["urgent", "high"]; Desc reverses it.
+	// Priority 按 Field 的值在此列表中的位置排序，不匹配任何值的行排在最后，例如 ["urgent", "high"]；Desc 会反转顺序。
+	Priority []any `json:"priority,omitempty"`
 }
 
 // HavingCondition represents a HAVING clause condition.
@@ -227,6 +357,10 @@ type HavingCondition struct {
 	// Field 是要聚合的列（对于 count 可选）。
 	Field string `json:"field,omitempty"`
 
+	// Args are the arguments of a function registered with RegisterSQLFunc.
+	// Args 是通过 RegisterSQLFunc 注册的函数的参数。
+	Args []any `json:"args,omitempty"`
+
 	// Op is the comparison operator.
 	// Op 是比较运算符。
 	Op Operator `json:"op"`
@@ -269,11 +403,30 @@ type AggregateField struct {
 }
 
 // ParseQuery parses a JQL JSON string into a Query struct.
-// Returns an error if the JSON is invalid or cannot be parsed.
+// Returns an error if the JSON is invalid or contains a key JQL does not define,
+// so typos like "wheer" fail instead of being silently ignored.
 //
 // ParseQuery 将 JQL JSON 字符串解析为 Query 结构体。
-// 如果 JSON 无效或无法解析，则返回错误。
+// 如果 JSON 无效或包含 JQL 未定义的键则返回错误，因此像 "wheer" 这样的拼写错误会失败而不是被静默忽略。
 func ParseQuery(jql string) (*Query, error) {
+	var query Query
+	dec := json.NewDecoder(bytes.NewReader([]byte(jql)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&query); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return nil, &UnknownFieldError{Field: strings.Trim(field, `"`)}
+		}
+		return nil, fmt.Errorf("failed to parse JQL: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("failed to parse JQL: unexpected data after query")
+	}
+	return &query, nil
+}
+
+// ParseQueryLenient parses a JQL JSON string, ignoring unknown keys.
+// ParseQueryLenient 解析 JQL JSON 字符串，忽略未知的键。
+func ParseQueryLenient(jql string) (*Query, error) {
 	var query Query
 	if err := json.Unmarshal([]byte(jql), &query); err != nil {
 		return nil, fmt.Errorf("failed to parse JQL: %w", err)
@@ -281,6 +434,79 @@ func ParseQuery(jql string) (*Query, error) {
 	return &query, nil
 }
 
+// UnknownFieldError is returned by ParseQuery for a key JQL does not define.
+// UnknownFieldError 在遇到 JQL 未定义的键时由 ParseQuery 返回。
+type UnknownFieldError struct {
+	// Field is the offending key.
+	// Field 是出错的键。
+	Field string
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *UnknownFieldError) Error() string {
+	msg := fmt.Sprintf("failed to parse JQL: unknown field %q", e.Field)
+	if s := e.Suggestion(); s != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", s)
+	}
+	return msg
+}
+
+// Suggestion returns the closest known JQL key, or "" if none is close.
+// Suggestion 返回最接近的已知 JQL 键，如果没有接近的则返回 ""。
+func (e *UnknownFieldError) Suggestion() string {
+	best, bestDist := "", 3
+	for _, name := range jqlFieldNames() {
+		if d := editDistance(strings.ToLower(e.Field), name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// jqlFieldNames returns the JSON keys of the JQL types.
+// jqlFieldNames 返回 JQL 类型的 JSON 键。
+func jqlFieldNames() []string {
+	var names []string
+	for _, v := range []any{Query{}, Condition{}, Order{}, HavingCondition{}, JoinClause{}} {
+		t := reflect.TypeOf(v)
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// editDistance returns the edit distance between a and b, counting a swap of two
+// adjacent characters, the most common typo, as one edit.
+// editDistance 返回 a 与 b 之间的编辑距离，相邻两个字符互换（最常见的拼写错误）计为一次编辑。
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
 // String returns the JSON representation of the Query.
 // String 返回 Query 的 JSON 表示。
 func (q *Query) String() string {
@@ -288,6 +514,25 @@ func (q *Query) String() string {
 	return string(data)
 }
 
+// IsWrite reports whether the query modifies data.
+// Transactions are writes if any of their operations is a write.
+//
+// IsWrite 报告查询是否修改数据。
+// 如果事务中的任一操作是写操作，则该事务为写操作。
+func (q *Query) IsWrite() bool {
+	switch q.Action {
+	case ActionCreate, ActionCreateBatch, ActionUpdate, ActionDelete:
+		return true
+	case ActionTransaction:
+		for i := range q.Operations {
+			if q.Operations[i].IsWrite() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Validate checks if the Query is valid.
 // Returns an error describing the validation failure, or nil if valid.
 //
@@ -299,7 +544,7 @@ func (q *Query) Validate() error {
 	}
 
 	switch q.Action {
-	case ActionFind, ActionCount, ActionDelete, ActionUpdate, ActionAggregate:
+	case ActionFind, ActionCount, ActionDelete, ActionUpdate, ActionAggregate, ActionExists:
 		if q.Table == "" {
 			return fmt.Errorf("table is required for action %q", q.Action)
 		}
@@ -331,5 +576,371 @@ func (q *Query) Validate() error {
 		return fmt.Errorf("unknown action: %q", q.Action)
 	}
 
+	if q.Lock != "" {
+		if q.Lock != LockUpdate && q.Lock != LockShare {
+			return fmt.Errorf("invalid lock mode %q: must be %q or %q", q.Lock, LockUpdate, LockShare)
+		}
+		if q.Action != ActionFind {
+			return fmt.Errorf("lock is only supported for action %q", ActionFind)
+		}
+	}
+
+	for i, o := range q.OrderBy {
+		if o.Nulls != "" && o.Nulls != NullsFirst && o.Nulls != NullsLast {
+			return fmt.Errorf("invalid order_by[%d] nulls %q: must be %q or %q", i, o.Nulls, NullsFirst, NullsLast)
+		}
+		if o.Collate != "" {
+			if !isCollation(o.Collate) {
+				return fmt.Errorf("invalid order_by[%d] collate %q: %s", i, o.Collate, collationRule)
+			}
+			if len(o.Priority) > 0 {
+				return fmt.Errorf("order_by[%d] cannot combine collate with priority", i)
+			}
+		}
+	}
+
+	for i, j := range q.Join {
+		switch strings.ToLower(j.Type) {
+		case "", "inner", "left", "right", "full":
+		default:
+			return fmt.Errorf("invalid join[%d] type %q: must be inner, left, right or full", i, j.Type)
+		}
+	}
+
+	if q.Rollup && len(q.GroupBy) == 0 {
+		return fmt.Errorf("rollup requires group_by")
+	}
+
+	if err := validateGroupBy(q); err != nil {
+		return err
+	}
+
+	if q.BatchSize < 0 {
+		return fmt.Errorf("batch_size must not be negative")
+	}
+	if q.BatchSize > 0 && q.Action != ActionUpdate && q.Action != ActionDelete {
+		return fmt.Errorf("batch_size is only supported for actions %q and %q", ActionUpdate, ActionDelete)
+	}
+
+	if q.ReturnIDs && q.Action != ActionUpdate && q.Action != ActionDelete {
+		return fmt.Errorf("return_ids is only supported for actions %q and %q", ActionUpdate, ActionDelete)
+	}
+
+	if q.Plan && q.Action != ActionExplain {
+		return fmt.Errorf("plan is only supported for action %q", ActionExplain)
+	}
+
+	if len(q.Returning) > 0 && q.Action != ActionCreate && q.Action != ActionCreateBatch && q.Action != ActionUpdate && q.Action != ActionDelete {
+		return fmt.Errorf("returning is only supported for actions %q, %q, %q and %q", ActionCreate, ActionCreateBatch, ActionUpdate, ActionDelete)
+	}
+
+	if q.OnConflict != "" {
+		if q.OnConflict != OnConflictIgnore {
+			return fmt.Errorf("invalid on_conflict %q: only %q is supported", q.OnConflict, OnConflictIgnore)
+		}
+		if q.Action != ActionCreate {
+			return fmt.Errorf("on_conflict is only supported for action %q", ActionCreate)
+		}
+	}
+
+	if q.Live && q.Action != ActionDescribe {
+		return fmt.Errorf("live is only supported for action %q", ActionDescribe)
+	}
+
+	if q.Estimate {
+		if q.Action != ActionCount {
+			return fmt.Errorf("estimate is only supported for action %q", ActionCount)
+		}
+		if len(q.Where) > 0 {
+			return fmt.Errorf("estimate counts the whole table and cannot be combined with where")
+		}
+	}
+
+	for i, sel := range q.Select {
+		if m, ok := sel.(map[string]any); ok {
+			if err := validateSelectFn(i, m); err != nil {
+				return err
+			}
+		}
+	}
+	if err := validateConditions("where", q.Where); err != nil {
+		return err
+	}
+	for i, h := range q.Having {
+		if err := validateHaving(i, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateConditions checks every condition, including nested groups and subqueries.
+// path locates the conditions in error messages, e.g. "where[1].and".
+//
+// validateConditions 检查每个条件，包括嵌套分组和子查询。
+// path 用于在错误消息中定位条件，例如 "where[1].and"。
+func validateConditions(path string, conds []Condition) error {
+	for i, c := range conds {
+		if err := validateCondition(fmt.Sprintf("%s[%d]", path, i), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCondition checks that a condition's operator is known and its value fits the operator.
+// validateCondition 检查条件的运算符是否已知，以及值是否与运算符匹配。
+func validateCondition(path string, c Condition) error {
+	if len(c.And) > 0 || len(c.OrGroup) > 0 {
+		if err := validateConditions(path+".and", c.And); err != nil {
+			return err
+		}
+		return validateConditions(path+".or_group", c.OrGroup)
+	}
+
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid condition %s on field %q (op %q): %s", path, c.Field, c.Op, reason)
+	}
+
+	if c.Field == "" {
+		return invalid("field is required")
+	}
+	if c.Op == "" {
+		return invalid("op is required")
+	}
+	if !isKnownOperator(c.Op) {
+		return invalid("unknown operator")
+	}
+	if c.Fn != "" && !isDatePart(c.Fn) {
+		return invalid(fmt.Sprintf("unknown fn %q: must be year, month, day, hour, minute or second", c.Fn))
+	}
+	if c.Collate != "" {
+		if !isCollation(c.Collate) {
+			return invalid(fmt.Sprintf("invalid collate %q: %s", c.Collate, collationRule))
+		}
+		if c.Fn != "" {
+			return invalid("collate applies to text and cannot be combined with fn")
+		}
+		if c.Op == OpIEqual {
+			// SQLite would keep the column's collation and stay case-sensitive
+			// SQLite 会保留列上的排序规则，从而仍然区分大小写
+			return invalid("iequal already ignores case and cannot be combined with collate")
+		}
+	}
+	if c.Op == OpIEqual && c.Fn != "" {
+		return invalid("iequal compares text and cannot be combined with fn")
+	}
+
+	if c.Subquery != nil {
+		return validateConditions(path+".subquery.where", c.Subquery.Where)
+	}
+	if c.Ref != "" {
+		return nil
+	}
+
+	switch c.Op {
+	case OpNull, OpNotNull:
+		// No value needed
+		// 不需要值
+	case OpNullSafeEqual:
+		// A null value matches NULL
+		// 值为 null 时匹配 NULL
+	case OpIn, OpNotIn:
+		values, ok := sliceValues(c.Value)
+		if !ok {
+			return invalid(fmt.Sprintf("value must be an array, got %T", c.Value))
+		}
+		if len(values) == 0 {
+			return invalid("value must not be an empty array")
+		}
+	case OpArrayContains:
+		if _, ok := sliceValues(c.Value); !ok {
+			return invalid(fmt.Sprintf("value must be an array, got %T", c.Value))
+		}
+	case OpBetween:
+		values, ok := sliceValues(c.Value)
+		if !ok || len(values) != 2 {
+			return invalid("value must be an array of exactly two elements")
+		}
+		if values[0] == nil && values[1] == nil {
+			return invalid("at least one bound must not be null")
+		}
+	case OpExists:
+		return invalid("a subquery is required")
+	case OpIEqual:
+		if _, ok := sliceValues(c.Value); ok {
+			return invalid(fmt.Sprintf("value must be a single string, got %T", c.Value))
+		}
+		if c.Value == nil {
+			return invalid(`value is required (use op "null" to match NULL)`)
+		}
+	default:
+		if c.Value == nil {
+			return invalid(`value is required (use op "null" to match NULL)`)
+		}
+	}
+	return nil
+}
+
+// validateSelectFn checks the fn of select item i, which is written into the SQL:
+// it must be an aggregate function or a name a SQL function can be registered under.
+// validateSelectFn 检查第 i 个 select 条目的 fn，它会被写入 SQL：必须是聚合函数，或可用于注册 SQL 函数的名称。
+func validateSelectFn(i int, sel map[string]any) error {
+	fn, _ := sel["fn"].(string)
+	if fn == "" {
+		return fmt.Errorf("invalid select[%d]: fn is required", i)
+	}
+	if !isAggregateFn(fn) && !sqlFuncName.MatchString(fn) {
+		return fmt.Errorf("invalid select[%d]: unknown function %q", i, fn)
+	}
+	return nil
+}
+
+// validateHaving checks a HAVING condition.
+// validateHaving 检查 HAVING 条件。
+func validateHaving(i int, h HavingCondition) error {
+	// Functions called with args are resolved against registered SQL functions when built
+	// 带 args 调用的函数在构建时按已注册的 SQL 函数解析
+	if !isAggregateFn(h.Fn) && (len(h.Args) == 0 || !sqlFuncName.MatchString(h.Fn)) {
+		return fmt.Errorf("invalid having[%d]: unknown aggregate function %q", i, h.Fn)
+	}
+	switch h.Op {
+	case OpEqual, OpNotEqual, OpGreater, OpGreaterOrEq, OpLess, OpLessOrEq:
+	default:
+		return fmt.Errorf("invalid having[%d] on %s(%s): unsupported operator %q", i, h.Fn, h.Field, h.Op)
+	}
+	if h.Value == nil {
+		return fmt.Errorf("invalid having[%d] on %s(%s): value is required", i, h.Fn, h.Field)
+	}
 	return nil
 }
+
+// validateGroupBy checks that a grouped or aggregate query selects only columns
+// listed in GroupBy besides its aggregates, as strict SQL modes require.
+//
+// validateGroupBy 检查分组或聚合查询除聚合外只选择 GroupBy 中列出的列，这是严格 SQL 模式的要求。
+func validateGroupBy(q *Query) error {
+	aggregate := len(q.GroupBy) > 0
+	for _, sel := range q.Select {
+		if m, ok := sel.(map[string]any); ok {
+			if fn, _ := m["fn"].(string); isAggregateFn(fn) {
+				aggregate = true
+			}
+		}
+	}
+	if !aggregate {
+		return nil
+	}
+
+	for _, sel := range q.Select {
+		col, ok := sel.(string)
+		if !ok {
+			continue
+		}
+		if !slices.ContainsFunc(q.GroupBy, func(g string) bool { return sameColumn(col, g) }) {
+			return &GroupByError{Column: col}
+		}
+	}
+	return nil
+}
+
+// sameColumn reports whether a and b name the same column, treating an
+// unqualified name as matching the same column qualified by any table.
+// sameColumn 判断 a 和 b 是否为同一列，未限定表名的列名与任意表限定的同名列匹配。
+func sameColumn(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if strings.Contains(a, ".") == strings.Contains(b, ".") {
+		return false
+	}
+	return a[strings.LastIndex(a, ".")+1:] == b[strings.LastIndex(b, ".")+1:]
+}
+
+// GroupByError is returned by Validate when a grouped or aggregate query selects
+// a plain column that is not in GroupBy.
+// GroupByError 在分组或聚合查询选择了不在 GroupBy 中的普通列时由 Validate 返回。
+type GroupByError struct {
+	// Column is the offending select column.
+	// Column 是出错的 select 列。
+	Column string
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *GroupByError) Error() string {
+	return fmt.Sprintf("invalid select column %q: it must appear in group_by or be used in an aggregate function", e.Column)
+}
+
+// isAggregateFn reports whether fn is a built-in aggregate function, ignoring case.
+// isAggregateFn 判断 fn 是否为内置聚合函数（忽略大小写）。
+func isAggregateFn(fn string) bool {
+	switch strings.ToLower(fn) {
+	case "count", "sum", "avg", "min", "max":
+		return true
+	}
+	return false
+}
+
+// isDatePart reports whether fn names a supported date part, ignoring case.
+// isDatePart 判断 fn 是否为支持的日期部分（忽略大小写）。
+func isDatePart(fn string) bool {
+	switch strings.ToLower(fn) {
+	case DateYear, DateMonth, DateDay, DateHour, DateMinute, DateSecond:
+		return true
+	}
+	return false
+}
+
+// collationPattern matches the collation names a query may use. Collations are
+// written into the SQL, so names are limited to letters, digits and "_", "-", ".",
+// "@", which covers the PostgreSQL (ICU and libc), MySQL and SQLite names.
+//
+// collationPattern 匹配查询可使用的排序规则名称。排序规则会被写入 SQL，因此名称仅限字母、数字以及
+// "_"、"-"、"."、"@"，可覆盖 PostgreSQL（ICU 和 libc）、MySQL 和 SQLite 的名称。
+var collationPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.@-]{0,127}$`)
+
+// collationRule describes collationPattern in error messages.
+// collationRule 在错误消息中描述 collationPattern。
+const collationRule = `use letters, digits, "_", "-", "." and "@"`
+
+// isCollation reports whether name is an acceptable collation name.
+// isCollation 判断 name 是否为可接受的排序规则名称。
+func isCollation(name string) bool {
+	return collationPattern.MatchString(name)
+}
+
+// isKnownOperator reports whether op is a supported JQL operator.
+// isKnownOperator 判断 op 是否为支持的 JQL 运算符。
+func isKnownOperator(op Operator) bool {
+	switch op {
+	case OpEqual, OpNotEqual, OpGreater, OpGreaterOrEq, OpLess, OpLessOrEq,
+		OpIn, OpNotIn, OpLike, OpILike, OpNotLike, OpBetween, OpNull, OpNotNull, OpExists,
+		OpNullSafeEqual, OpArrayContains, OpIEqual:
+		return true
+	}
+	return false
+}
+
+// sliceValues converts any slice or array value to []any.
+// sliceValues 将任意切片或数组值转换为 []any。
+func sliceValues(v any) ([]any, bool) {
+	if values, ok := v.([]any); ok {
+		return values, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		// []byte is a single value, not a list
+		// []byte 是单个值而不是列表
+		return nil, false
+	}
+	values := make([]any, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
@@ -2,6 +2,8 @@ package goorm
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -44,6 +46,21 @@ func TestParseQuery(t *testing.T) {
 			jql:     `{"table": "users"`,
 			wantErr: true,
 		},
+		{
+			name:    "unknown top-level field",
+			jql:     `{"table": "users", "action": "find", "limt": 10}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown condition field",
+			jql:     `{"table": "users", "action": "find", "where": [{"feild": "age", "op": ">", "value": 18}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			jql:     `{"table": "users", "action": "find"} {}`,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -60,6 +77,34 @@ func TestParseQuery(t *testing.T) {
 	}
 }
 
+// TestParseQueryUnknownField tests the error and suggestion for unknown keys.
+// TestParseQueryUnknownField 测试未知键的错误和建议。
+func TestParseQueryUnknownField(t *testing.T) {
+	_, err := ParseQuery(`{"table": "users", "action": "find", "wheer": []}`)
+	var unknown *UnknownFieldError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownFieldError, got %v", err)
+	}
+	if unknown.Field != "wheer" || unknown.Suggestion() != "where" {
+		t.Errorf("unexpected field/suggestion: %q/%q", unknown.Field, unknown.Suggestion())
+	}
+
+	if (&UnknownFieldError{Field: "xyzzy"}).Suggestion() != "" {
+		t.Error("expected no suggestion for an unrelated key")
+	}
+
+	query, err := ParseQueryLenient(`{"table": "users", "action": "find", "wheer": []}`)
+	if err != nil || query.Table != "users" {
+		t.Errorf("lenient parse should ignore unknown keys, got %v, %v", query, err)
+	}
+
+	_, err = ParseQuery(`{"table": "users", "action": "find", "limt": 5}`)
+	result := parseErrorResult(err)
+	if result.Error.Code != "PARSE_ERROR" || result.Error.Details["field"] != "limt" || !strings.Contains(result.Error.Suggestion, `"limit"`) {
+		t.Errorf("unexpected result: %+v", result.Error)
+	}
+}
+
 // TestQueryValidate tests Query validation.
 // TestQueryValidate 测试 Query 验证。
 func TestQueryValidate(t *testing.T) {
@@ -121,6 +166,91 @@ func TestQueryValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "find with lock",
+			query: Query{
+				Table:  "users",
+				Action: ActionFind,
+				Lock:   LockUpdate,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid lock mode",
+			query: Query{
+				Table:  "users",
+				Action: ActionFind,
+				Lock:   "exclusive",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid join type",
+			query: Query{
+				Table:  "users",
+				Action: ActionFind,
+				Join:   []JoinClause{{Table: "orders", Type: "left; DROP TABLE users", On: map[string]string{"users.id": "orders.user_id"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "on_conflict ignore on create",
+			query: Query{
+				Table:      "users",
+				Action:     ActionCreate,
+				Data:       map[string]any{"email": "a@example.com"},
+				OnConflict: OnConflictIgnore,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown on_conflict mode",
+			query: Query{
+				Table:      "users",
+				Action:     ActionCreate,
+				Data:       map[string]any{"email": "a@example.com"},
+				OnConflict: "update",
+			},
+			wantErr: true,
+		},
+		{
+			name: "on_conflict on update",
+			query: Query{
+				Table:      "users",
+				Action:     ActionUpdate,
+				Data:       map[string]any{"email": "a@example.com"},
+				OnConflict: OnConflictIgnore,
+			},
+			wantErr: true,
+		},
+		{
+			name: "lock on count",
+			query: Query{
+				Table:  "users",
+				Action: ActionCount,
+				Lock:   LockShare,
+			},
+			wantErr: true,
+		},
+		{
+			name: "aggregate with grouped column",
+			query: Query{
+				Table:   "orders",
+				Action:  ActionAggregate,
+				Select:  []any{"orders.status", map[string]any{"fn": "count", "as": "n"}},
+				GroupBy: []string{"status"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "aggregate with ungrouped column",
+			query: Query{
+				Table:  "orders",
+				Action: ActionAggregate,
+				Select: []any{"status", map[string]any{"fn": "sum", "field": "total", "as": "total"}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,6 +263,76 @@ func TestQueryValidate(t *testing.T) {
 	}
 }
 
+// TestQueryValidateGroupBy tests that ungrouped select columns fail with INVALID_GROUP_BY.
+// TestQueryValidateGroupBy 测试未分组的 select 列以 INVALID_GROUP_BY 失败。
+func TestQueryValidateGroupBy(t *testing.T) {
+	query := &Query{
+		Table:   "sales",
+		Action:  ActionAggregate,
+		Select:  []any{"region", "product", map[string]any{"fn": "sum", "field": "amount", "as": "total"}},
+		GroupBy: []string{"region"},
+	}
+
+	var groupBy *GroupByError
+	if err := query.Validate(); !errors.As(err, &groupBy) || groupBy.Column != "product" {
+		t.Fatalf("expected a GroupByError for product, got %v", err)
+	}
+
+	result := validationResult(query.Validate())
+	if result.Error.Code != "INVALID_GROUP_BY" || result.Error.Details["column"] != "product" {
+		t.Errorf("unexpected error: %+v", result.Error)
+	}
+}
+
+// TestQueryValidateConditions tests operator and value checks on conditions.
+// TestQueryValidateConditions 测试条件上的运算符和值检查。
+func TestQueryValidateConditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		where   []Condition
+		having  []HavingCondition
+		wantErr string
+	}{
+		{name: "valid in", where: []Condition{{Field: "id", Op: OpIn, Value: []int{1, 2}}}},
+		{name: "valid between", where: []Condition{{Field: "age", Op: OpBetween, Value: []any{18, 30}}}},
+		{name: "valid null", where: []Condition{{Field: "deleted_at", Op: OpNull}}},
+		{name: "valid ref", where: []Condition{{Field: "a", Op: OpEqual, Ref: "b"}}},
+		{name: "in with scalar", where: []Condition{{Field: "id", Op: OpIn, Value: 1}}, wantErr: `field "id" (op "in"): value must be an array`},
+		{name: "in with empty array", where: []Condition{{Field: "id", Op: OpIn, Value: []any{}}}, wantErr: "empty array"},
+		{name: "between with one value", where: []Condition{{Field: "age", Op: OpBetween, Value: []any{1}}}, wantErr: "exactly two elements"},
+		{name: "valid open between", where: []Condition{{Field: "age", Op: OpBetween, Value: []any{nil, 30}}}},
+		{name: "between without bounds", where: []Condition{{Field: "age", Op: OpBetween, Value: []any{nil, nil}}}, wantErr: "at least one bound"},
+		{name: "unknown operator", where: []Condition{{Field: "age", Op: "~", Value: 1}}, wantErr: "unknown operator"},
+		{name: "missing op", where: []Condition{{Field: "age", Value: 1}}, wantErr: "op is required"},
+		{name: "missing value", where: []Condition{{Field: "age", Op: OpEqual}}, wantErr: "value is required"},
+		{name: "exists without subquery", where: []Condition{{Field: "id", Op: OpExists}}, wantErr: "subquery is required"},
+		{
+			name:    "nested group",
+			where:   []Condition{{Field: "a", Op: OpEqual, Value: 1}, {OrGroup: []Condition{{Field: "b", Op: OpIn, Value: "x"}}}},
+			wantErr: "where[1].or_group[0]",
+		},
+		{name: "valid having", having: []HavingCondition{{Fn: "count", Op: OpGreater, Value: 1}}},
+		{name: "having unknown fn", having: []HavingCondition{{Fn: "median", Op: OpGreater, Value: 1}}, wantErr: "unknown aggregate function"},
+		{name: "having like", having: []HavingCondition{{Fn: "sum", Field: "amount", Op: OpLike, Value: 1}}, wantErr: "unsupported operator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Query{Table: "users", Action: ActionFind, Where: tt.where, Having: tt.having}
+			err := q.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 // TestQueryString tests Query JSON serialization.
 // TestQueryString 测试 Query JSON 序列化。
 func TestQueryString(t *testing.T) {
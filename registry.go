@@ -3,6 +3,7 @@ package goorm
 import (
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
 )
@@ -45,9 +46,10 @@ type ModelMeta struct {
 	// Fields 包含字段元数据
 	Fields []*FieldMeta
 
-	// PrimaryKey is the primary key field
-	// PrimaryKey 是主键字段
-	PrimaryKey *FieldMeta
+	// PrimaryKeys are the primary key fields, in declaration order;
+	// more than one means a composite key
+	// PrimaryKeys 是按声明顺序排列的主键字段；多于一个表示复合主键
+	PrimaryKeys []*FieldMeta
 
 	// Indexes contains index definitions
 	// Indexes 包含索引定义
@@ -56,6 +58,10 @@ type ModelMeta struct {
 	// Relations contains relation definitions
 	// Relations 包含关联定义
 	Relations []RelationSchema
+
+	// DefaultOrder is the sort order of find queries without an order_by
+	// DefaultOrder 是没有 order_by 的查找查询所使用的排序
+	DefaultOrder []Order
 }
 
 // FieldMeta contains metadata about a model field.
@@ -73,8 +79,9 @@ type FieldMeta struct {
 	// Type 是字段的 reflect.Type
 	Type reflect.Type
 
-	// SQLType is the SQL type for this field
-	// SQLType 是此字段的 SQL 类型
+	// SQLType is the SQL type for this field, from the `type:` option.
+	// Options such as `type_mysql:` override it on one dialect (see SQLTypeFor).
+	// SQLType 是此字段的 SQL 类型，来自 `type:` 选项。`type_mysql:` 等选项会在对应方言上覆盖它（见 SQLTypeFor）。
 	SQLType string
 
 	// GoType is the Go type string representation
@@ -105,6 +112,18 @@ type FieldMeta struct {
 	// Default 是默认值
 	Default string
 
+	// Check is the boolean expression of a CHECK constraint on the column
+	// Check 是列上 CHECK 约束的布尔表达式
+	Check string
+
+	// Enum lists the values allowed in the column
+	// Enum 列出该列允许的取值
+	Enum []string
+
+	// UUID indicates the field is filled with a generated UUID on create
+	// UUID 表示创建时用生成的 UUID 填充该字段
+	UUID bool
+
 	// Description is the field description
 	// Description 是字段描述
 	Description string
@@ -124,6 +143,10 @@ type FieldMeta struct {
 	// Tags contains all parsed struct tags
 	// Tags 包含所有解析的结构体标签
 	Tags map[string]string
+
+	// Validations are the rules parsed from the `validate` tag
+	// Validations 是从 `validate` 标签解析的规则
+	Validations []ValidationRule
 }
 
 // NewRegistry creates a new model registry.
@@ -134,9 +157,32 @@ func NewRegistry() *Registry {
 	}
 }
 
-// Register registers a model with the registry.
-// Register 向注册表注册一个模型。
-func (r *Registry) Register(model any, naming NamingConfig) error {
+// RegisterOption changes how Register treats a model.
+// RegisterOption 改变 Register 处理模型的方式。
+type RegisterOption int
+
+const (
+	// ReplaceModel lets a model take over a table already registered by another model.
+	// ReplaceModel 允许模型接管已由其他模型注册的表。
+	ReplaceModel RegisterOption = iota + 1
+)
+
+// Register registers a model with the registry. Registering a second model for
+// a table that is already registered fails with ErrDuplicateTable unless
+// ReplaceModel is passed; registering the same model again replaces it.
+//
+// Register 向注册表注册一个模型。为已注册的表注册另一个模型会以 ErrDuplicateTable 失败，
+// 除非传入 ReplaceModel；再次注册同一个模型会替换它。
+func (r *Registry) Register(model any, naming NamingConfig, opts ...RegisterOption) error {
+	_, err := r.register(model, naming, slices.Contains(opts, ReplaceModel))
+	return err
+}
+
+// register registers a model and returns its metadata. replace allows another
+// model to take over a registered table.
+//
+// register 注册模型并返回其元数据。replace 允许另一个模型接管已注册的表。
+func (r *Registry) register(model any, naming NamingConfig, replace bool) (*ModelMeta, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -146,7 +192,7 @@ func (r *Registry) Register(model any, naming NamingConfig) error {
 	}
 
 	if t.Kind() != reflect.Struct {
-		return fmt.Errorf("model must be a struct, got %s", t.Kind())
+		return nil, fmt.Errorf("model must be a struct, got %s", t.Kind())
 	}
 
 	// Determine table name
@@ -177,6 +223,11 @@ func (r *Registry) Register(model any, naming NamingConfig) error {
 		tableName = naming.TablePrefix + tableName
 	}
 
+	if existing, ok := r.models[tableName]; ok && existing.Type != t && !replace {
+		return nil, fmt.Errorf("%w: table %q is registered by model %s, cannot register model %s (pass goorm.ReplaceModel to replace it)",
+			ErrDuplicateTable, tableName, existing.ModelName, t.Name())
+	}
+
 	// Get description
 	// 获取描述
 	description := ""
@@ -200,11 +251,96 @@ func (r *Registry) Register(model any, naming NamingConfig) error {
 	// Parse fields
 	// 解析字段
 	if err := r.parseFields(t, meta, naming); err != nil {
-		return err
+		return nil, err
+	}
+	defaultPrimaryKey(meta, naming)
+
+	relations, err := parseRelations(t)
+	if err != nil {
+		return nil, fmt.Errorf("model %s: %w", t.Name(), err)
+	}
+	meta.Relations = relations
+
+	if meta.DefaultOrder, err = parseDefaultOrder(model, t, meta); err != nil {
+		return nil, fmt.Errorf("model %s: %w", t.Name(), err)
 	}
 
 	r.models[tableName] = meta
-	return nil
+	return meta, nil
+}
+
+// parseDefaultOrder returns the default order of a model, from DefaultOrderer or
+// an `order:` option on the embedded Model, e.g. `goorm:"order:created_at desc,id"`.
+//
+// parseDefaultOrder 从 DefaultOrderer 或嵌入的 Model 上的 `order:` 选项返回模型的默认排序，
+// 例如 `goorm:"order:created_at desc,id"`。
+func parseDefaultOrder(model any, t reflect.Type, meta *ModelMeta) ([]Order, error) {
+	var orders []Order
+	if do, ok := model.(DefaultOrderer); ok {
+		orders = do.DefaultOrder()
+	} else if field, ok := t.FieldByName("Model"); ok {
+		for _, part := range strings.Split(field.Tag.Get("goorm"), ";") {
+			kv := strings.SplitN(strings.TrimSpace(part), ":", 2)
+			if len(kv) != 2 || !strings.EqualFold(kv[0], "order") {
+				continue
+			}
+			for _, item := range strings.Split(kv[1], ",") {
+				words := strings.Fields(item)
+				if len(words) == 0 || len(words) > 2 {
+					return nil, fmt.Errorf("invalid default order %q", item)
+				}
+				order := Order{Field: words[0]}
+				if len(words) == 2 {
+					switch strings.ToLower(words[1]) {
+					case "asc":
+					case "desc":
+						order.Desc = true
+					default:
+						return nil, fmt.Errorf("invalid sort direction %q in default order", words[1])
+					}
+				}
+				orders = append(orders, order)
+			}
+		}
+	}
+
+	for _, order := range orders {
+		if !slices.ContainsFunc(meta.Fields, func(f *FieldMeta) bool { return f.ColumnName == order.Field }) {
+			return nil, fmt.Errorf("default order column %q does not exist", order.Field)
+		}
+	}
+	return orders, nil
+}
+
+// defaultPrimaryKey makes the naming primary key column ("id" by default) the
+// primary key of a model that declares none, e.g. an ID field without tags.
+// Integer keys are auto-incremented. goorm.Model's ID is tagged explicitly, so
+// models embedding it always get an auto-increment "id" key.
+//
+// defaultPrimaryKey 对未声明主键的模型（例如没有标签的 ID 字段），将命名配置中的主键列（默认 "id"）设为主键。
+// 整数主键会自动递增。goorm.Model 的 ID 已显式标注，因此嵌入它的模型总会得到自动递增的 "id" 主键。
+func defaultPrimaryKey(meta *ModelMeta, naming NamingConfig) {
+	if len(meta.PrimaryKeys) > 0 {
+		return
+	}
+
+	column := naming.PrimaryKey
+	if column == "" {
+		column = "id"
+	}
+	for _, field := range meta.Fields {
+		if field.ColumnName != column {
+			continue
+		}
+		field.PrimaryKey = true
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.AutoIncrement = !field.UUID
+		}
+		meta.PrimaryKeys = append(meta.PrimaryKeys, field)
+		return
+	}
 }
 
 // parseFields parses struct fields into field metadata.
@@ -230,26 +366,30 @@ func (r *Registry) parseFields(t reflect.Type, meta *ModelMeta, naming NamingCon
 			continue
 		}
 
-		// Skip fields marked with "-"
-		// 跳过标记为 "-" 的字段
-		if field.Tag.Get("goorm") == "-" || field.Tag.Get("json") == "-" {
+		// Skip fields marked with "-" and relation fields, which are not columns
+		// 跳过标记为 "-" 的字段和关联字段，它们不是列
+		if field.Tag.Get("goorm") == "-" || field.Tag.Get("json") == "-" || field.Tag.Get("rel") != "" {
 			continue
 		}
 
-		fieldMeta := r.parseField(field, naming)
+		fieldMeta, err := r.parseField(field, naming)
+		if err != nil {
+			return fmt.Errorf("model %s: field %s: %w", t.Name(), field.Name, err)
+		}
 		meta.Fields = append(meta.Fields, fieldMeta)
 
 		if fieldMeta.PrimaryKey {
-			meta.PrimaryKey = fieldMeta
+			meta.PrimaryKeys = append(meta.PrimaryKeys, fieldMeta)
 		}
 	}
 
 	return nil
 }
 
-// parseField parses a single struct field.
-// parseField 解析单个结构体字段。
-func (r *Registry) parseField(field reflect.StructField, naming NamingConfig) *FieldMeta {
+// parseField parses a single struct field. It fails on an invalid validate tag,
+// returning the field parsed without its rules.
+// parseField 解析单个结构体字段。validate 标签无效时返回错误，以及不含其规则的已解析字段。
+func (r *Registry) parseField(field reflect.StructField, naming NamingConfig) (*FieldMeta, error) {
 	fm := &FieldMeta{
 		Name:   field.Name,
 		Type:   field.Type,
@@ -298,6 +438,16 @@ func (r *Registry) parseField(field reflect.StructField, naming NamingConfig) *F
 				fm.Index = true
 			case "default":
 				fm.Default = value
+			case "check":
+				fm.Check = value
+			case "enum":
+				for _, v := range strings.Split(value, ",") {
+					if v = strings.TrimSpace(v); v != "" {
+						fm.Enum = append(fm.Enum, v)
+					}
+				}
+			case "uuid":
+				fm.UUID = true
 			case "column":
 				fm.ColumnName = value
 			case "type":
@@ -311,6 +461,8 @@ func (r *Registry) parseField(field reflect.StructField, naming NamingConfig) *F
 	fm.Description = field.Tag.Get("desc")
 	fm.Sensitive = field.Tag.Get("sensitive") == "true"
 	fm.Mask = field.Tag.Get("mask")
+	validations, validationErr := parseValidationRules(field.Tag.Get("validate"))
+	fm.Validations = validations
 
 	if field.Tag.Get("unique") == "true" {
 		fm.Unique = true
@@ -322,7 +474,20 @@ func (r *Registry) parseField(field reflect.StructField, naming NamingConfig) *F
 		field.Type.Kind() == reflect.Slice ||
 		field.Type.Kind() == reflect.Map
 
-	return fm
+	return fm, validationErr
+}
+
+// SQLTypeFor returns the SQL type declared for dialect with a `type_<dialect>:`
+// option (e.g. `type_sqlite:text`), falling back to SQLType. It returns "" when
+// neither is set, leaving the type to the dialect's GoTypeToSQL.
+//
+// SQLTypeFor 返回通过 `type_<dialect>:` 选项（例如 `type_sqlite:text`）为 dialect 声明的 SQL 类型，
+// 未声明时回退到 SQLType。两者都未设置时返回 ""，由方言的 GoTypeToSQL 决定类型。
+func (fm *FieldMeta) SQLTypeFor(dialect string) string {
+	if sqlType := fm.Tags["type_"+dialect]; sqlType != "" {
+		return sqlType
+	}
+	return fm.SQLType
 }
 
 // Get returns the model metadata for a table name.
@@ -347,9 +512,12 @@ func (r *Registry) ListTables() []TableInfo {
 			columns[i] = f.ColumnName
 		}
 
-		pk := "id"
-		if meta.PrimaryKey != nil {
-			pk = meta.PrimaryKey.ColumnName
+		pk := []string{"id"}
+		if len(meta.PrimaryKeys) > 0 {
+			pk = make([]string, len(meta.PrimaryKeys))
+			for i, f := range meta.PrimaryKeys {
+				pk[i] = f.ColumnName
+			}
 		}
 
 		tables = append(tables, TableInfo{
@@ -3,6 +3,7 @@ package goorm
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // RelationType represents the type of relationship between models.
@@ -436,7 +437,7 @@ func (l *RelationLoader) loadManyToMany(data []map[string]any, rel *RelationSche
 
 // parseRelations parses relation definitions from struct tags.
 // parseRelations 从结构体标签解析关联定义。
-func parseRelations(t reflect.Type) []RelationSchema {
+func parseRelations(t reflect.Type) ([]RelationSchema, error) {
 	relations := make([]RelationSchema, 0)
 
 	for i := 0; i < t.NumField(); i++ {
@@ -453,9 +454,11 @@ func parseRelations(t reflect.Type) []RelationSchema {
 			Name:         field.Name,
 			Type:         relTag,
 			Model:        field.Tag.Get("model"),
-			ForeignKey:   field.Tag.Get("fk"),
 			ReferenceKey: field.Tag.Get("ref"),
 		}
+		if err := parseForeignKeyTag(field.Tag.Get("fk"), &rel); err != nil {
+			return nil, fmt.Errorf("relation %s: %w", field.Name, err)
+		}
 
 		// Default reference key
 		// 默认引用键
@@ -497,5 +500,51 @@ func parseRelations(t reflect.Type) []RelationSchema {
 		relations = append(relations, rel)
 	}
 
-	return relations
+	return relations, nil
+}
+
+// foreignKeyActions maps fk tag actions to their SQL.
+// foreignKeyActions 将 fk 标签中的动作映射为 SQL。
+var foreignKeyActions = map[string]string{
+	"cascade":     "CASCADE",
+	"restrict":    "RESTRICT",
+	"set_null":    "SET NULL",
+	"set_default": "SET DEFAULT",
+	"no_action":   "NO ACTION",
+}
+
+// parseForeignKeyTag parses an fk tag like `user_id;on_delete:cascade;on_update:restrict`
+// into the foreign key column and referential actions of rel.
+//
+// parseForeignKeyTag 将形如 `user_id;on_delete:cascade;on_update:restrict` 的 fk 标签
+// 解析为 rel 的外键列和引用动作。
+func parseForeignKeyTag(tag string, rel *RelationSchema) error {
+	parts := strings.Split(tag, ";")
+	rel.ForeignKey = strings.TrimSpace(parts[0])
+
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, ":", 2)
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		if key == "" {
+			continue
+		}
+		if len(kv) != 2 {
+			return fmt.Errorf("fk option %q has no action", key)
+		}
+
+		value := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(kv[1]), " ", "_"))
+		action, ok := foreignKeyActions[value]
+		if !ok {
+			return fmt.Errorf("unknown fk action %q", kv[1])
+		}
+		switch key {
+		case "on_delete":
+			rel.OnDelete = action
+		case "on_update":
+			rel.OnUpdate = action
+		default:
+			return fmt.Errorf("unknown fk option %q", key)
+		}
+	}
+	return nil
 }
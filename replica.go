@@ -0,0 +1,46 @@
+package goorm
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+// replicaPool spreads reads round-robin over read replicas.
+// replicaPool 以轮询方式将读操作分发到只读副本。
+type replicaPool struct {
+	dbs  []*sql.DB
+	next atomic.Uint64
+}
+
+// pick returns the next replica.
+// pick 返回下一个副本。
+func (p *replicaPool) pick() *sql.DB {
+	n := p.next.Add(1) - 1
+	return p.dbs[n%uint64(len(p.dbs))]
+}
+
+// close closes every replica and returns the first error.
+// close 关闭所有副本并返回第一个错误。
+func (p *replicaPool) close() error {
+	var first error
+	for _, db := range p.dbs {
+		if err := db.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// reader returns the connection pool a read query runs on: a replica, or the
+// primary when there are no replicas, query.Primary or Options.Primary in ctx is
+// set, or rows are locked.
+//
+// reader 返回读查询使用的连接池：副本；当没有副本、设置了 query.Primary 或 ctx 中的
+// Options.Primary，或需要锁定行时返回主库。
+func (db *DB) reader(ctx context.Context, query *Query) *sql.DB {
+	if db.replicas == nil || len(db.replicas.dbs) == 0 || query.Primary || query.Lock != "" || OptionsFromContext(ctx).Primary {
+		return db.sqlDB
+	}
+	return db.replicas.pick()
+}
@@ -2,6 +2,7 @@ package goorm
 
 import (
 	"encoding/json"
+	"errors"
 )
 
 // Result represents the response from a JQL execution.
@@ -26,14 +27,34 @@ type Result struct {
 	// Affected 是受影响的行数（用于 update/delete 操作）。
 	Affected int64 `json:"affected,omitempty"`
 
+	// SoftDeleted reports that a delete was turned into an update by soft delete,
+	// so the affected rows were marked deleted rather than removed.
+	// SoftDeleted 表示删除被软删除转换为更新，受影响的行只是被标记为已删除而非被移除。
+	SoftDeleted bool `json:"soft_deleted,omitempty"`
+
 	// ID is the last inserted ID (for create operations).
 	// ID 是最后插入的 ID（用于 create 操作）。
 	ID uint64 `json:"id,omitempty"`
 
-	// IDs contains all inserted IDs (for batch create operations).
-	// IDs 包含所有插入的 ID（用于批量 create 操作）。
+	// IDs contains all inserted IDs (for batch create operations), or the IDs of the
+	// rows written by an update or delete with ReturnIDs.
+	// IDs 包含所有插入的 ID（用于批量 create 操作），或设置了 ReturnIDs 的 update 或 delete 所写入行的 ID。
 	IDs []uint64 `json:"ids,omitempty"`
 
+	// InsertedKey is the primary key of the created record in its native type,
+	// e.g. a UUID string. ID is only set when the key is numeric.
+	// InsertedKey 是所创建记录的原生类型主键，例如 UUID 字符串。仅当主键为数字时才设置 ID。
+	InsertedKey any `json:"inserted_key,omitempty"`
+
+	// InsertedKeys contains the primary keys of all records created by a batch.
+	// InsertedKeys 包含批量创建的所有记录的主键。
+	InsertedKeys []any `json:"inserted_keys,omitempty"`
+
+	// AffectedKeys contains the primary keys of the rows written by an update or delete
+	// with ReturnIDs, in their native type. IDs is only set for numeric keys.
+	// AffectedKeys 包含设置了 ReturnIDs 的 update 或 delete 所写入行的原生类型主键。仅数字主键会设置 IDs。
+	AffectedKeys []any `json:"affected_keys,omitempty"`
+
 	// Meta contains additional metadata about the query.
 	// Meta 包含查询的附加元数据。
 	Meta *ResultMeta `json:"meta,omitempty"`
@@ -74,6 +95,10 @@ type Result struct {
 	// Explain 包含查询解释（用于 explain 操作）。
 	Explain *ExplainResult `json:"explain,omitempty"`
 
+	// Import contains the counts and row errors of ImportCSV.
+	// Import 包含 ImportCSV 的计数和行错误。
+	Import *ImportResult `json:"import,omitempty"`
+
 	// NLQuery contains the original natural language query (for NL operations).
 	// NLQuery 包含原始自然语言查询（用于 NL 操作）。
 	NLQuery string `json:"nl_query,omitempty"`
@@ -105,6 +130,26 @@ type ResultMeta struct {
 	// RowsReturned is the number of rows returned.
 	// RowsReturned 是返回的行数。
 	RowsReturned int64 `json:"rows_returned,omitempty"`
+
+	// AutoLimit is the limit injected by the optimizer, if any.
+	// AutoLimit 是优化器注入的限制（如有）。
+	AutoLimit int `json:"auto_limit,omitempty"`
+
+	// Truncated reports that the result hit the injected limit and more rows may exist.
+	// Truncated 表示结果达到了注入的限制，可能还有更多行。
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Estimated reports that Result.Count is the database's row estimate, not an exact count.
+	// Estimated 表示 Result.Count 是数据库的行数估计，而非精确计数。
+	Estimated bool `json:"estimated,omitempty"`
+
+	// Batches is the number of chunks a write with BatchSize ran in.
+	// Batches 是设置了 BatchSize 的写入所执行的分块数。
+	Batches int `json:"batches,omitempty"`
+
+	// Warnings contains non-fatal notices about the query.
+	// Warnings 包含关于查询的非致命提示。
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // ResultError contains error information.
@@ -154,9 +199,9 @@ type TableInfo struct {
 	// Columns 列出列名。
 	Columns []string `json:"columns"`
 
-	// PrimaryKey is the primary key column name.
-	// PrimaryKey 是主键列名。
-	PrimaryKey string `json:"primary_key"`
+	// PrimaryKey lists the primary key column names; more than one means a composite key.
+	// PrimaryKey 列出主键列名；多于一个表示复合主键。
+	PrimaryKey []string `json:"primary_key"`
 }
 
 // TableSchema contains detailed schema information for a table.
@@ -274,6 +319,14 @@ type RelationSchema struct {
 	// ReferenceKey 是引用的列（通常是主键）。
 	ReferenceKey string `json:"ref,omitempty"`
 
+	// OnDelete is the ON DELETE action of the foreign key, e.g. "CASCADE".
+	// OnDelete 是外键的 ON DELETE 动作，例如 "CASCADE"。
+	OnDelete string `json:"on_delete,omitempty"`
+
+	// OnUpdate is the ON UPDATE action of the foreign key.
+	// OnUpdate 是外键的 ON UPDATE 动作。
+	OnUpdate string `json:"on_update,omitempty"`
+
 	// JoinTable is the join table name (for many_to_many).
 	// JoinTable 是连接表名（用于 many_to_many）。
 	JoinTable string `json:"join_table,omitempty"`
@@ -338,14 +391,23 @@ func (r *Result) IsPendingConfirm() bool {
 	return r.Status == "pending_confirm"
 }
 
-// Err returns an error if the operation failed, nil otherwise.
-// Err 如果操作失败返回错误，否则返回 nil。
+// Err returns a *QueryError if the operation failed, nil otherwise. The error
+// wraps the sentinel for its code, such as ErrDuplicateKey, so callers can branch
+// with errors.Is and reach the code and details with errors.As.
+//
+// Err 如果操作失败返回 *QueryError，否则返回 nil。该错误包装其代码对应的哨兵错误（例如 ErrDuplicateKey），
+// 因此调用方可以用 errors.Is 判断类别，并用 errors.As 获取代码和详情。
+//
+// Example / 示例:
+//
+//	if err := db.ExecuteQuery(ctx, query).Err(); errors.Is(err, goorm.ErrDuplicateKey) { ... }
 func (r *Result) Err() error {
 	if r.Error != nil {
 		return &QueryError{
 			Code:       r.Error.Code,
 			Message:    r.Error.Message,
 			Suggestion: r.Error.Suggestion,
+			Details:    r.Error.Details,
 		}
 	}
 	return nil
@@ -360,12 +422,100 @@ func (r *Result) First() map[string]any {
 	return nil
 }
 
+// ErrNotFound is returned by FindOne when no record matches.
+// ErrNotFound 在没有匹配记录时由 FindOne 返回。
+var ErrNotFound = errors.New("goorm: record not found")
+
+// ErrReturningNotSupported is returned when a query asks for RETURNING on a dialect without it.
+// ErrReturningNotSupported 在不支持 RETURNING 的方言上请求 RETURNING 时返回。
+var ErrReturningNotSupported = errors.New("goorm: RETURNING is not supported by this dialect")
+
+// ErrRollupNotSupported is returned when a query asks for ROLLUP on a dialect without it.
+// ErrRollupNotSupported 在不支持 ROLLUP 的方言上请求 ROLLUP 时返回。
+var ErrRollupNotSupported = errors.New("goorm: ROLLUP is not supported by this dialect")
+
+// ErrTooManyParams is returned when a statement would bind more parameters than the dialect allows.
+// ErrTooManyParams 在语句绑定的参数超过方言允许的数量时返回。
+var ErrTooManyParams = errors.New("goorm: statement binds more parameters than the database allows")
+
+// ErrDuplicateKey is wrapped by errors for writes that violate a unique constraint.
+// ErrDuplicateKey 由违反唯一约束的写入错误包装。
+var ErrDuplicateKey = errors.New("goorm: duplicate key")
+
+// ErrForeignKeyViolation is wrapped by errors for writes that violate a foreign key.
+// ErrForeignKeyViolation 由违反外键约束的写入错误包装。
+var ErrForeignKeyViolation = errors.New("goorm: foreign key violation")
+
+// ErrInvalidColumn is wrapped by errors for queries that reference an unknown column.
+// ErrInvalidColumn 由引用未知列的查询错误包装。
+var ErrInvalidColumn = errors.New("goorm: unknown column")
+
+// ErrTableNotFound is wrapped by errors for queries on an unregistered or missing table.
+// ErrTableNotFound 由查询未注册或不存在的表的错误包装。
+var ErrTableNotFound = errors.New("goorm: table not found")
+
+// ErrSyntax is wrapped by errors for statements the database rejects as malformed.
+// ErrSyntax 由数据库认为格式错误的语句的错误包装。
+var ErrSyntax = errors.New("goorm: syntax error")
+
+// ErrTimeout is wrapped by errors for queries that exceed their timeout.
+// ErrTimeout 由超过超时时间的查询错误包装。
+var ErrTimeout = errors.New("goorm: query timed out")
+
+// ErrConnection is wrapped by errors for queries that cannot reach the database.
+// ErrConnection 由无法连接数据库的查询错误包装。
+var ErrConnection = errors.New("goorm: database connection failed")
+
+// ErrValidation is wrapped by errors for queries or data that fail validation.
+// ErrValidation 由未通过验证的查询或数据的错误包装。
+var ErrValidation = errors.New("goorm: validation failed")
+
+// ErrConfirmRequired is wrapped by errors for dangerous operations awaiting confirmation.
+// ErrConfirmRequired 由等待确认的危险操作的错误包装。
+var ErrConfirmRequired = errors.New("goorm: confirmation required")
+
+// ErrReadOnly is wrapped by errors for writes rejected in read-only mode.
+// ErrReadOnly 由只读模式下被拒绝的写入错误包装。
+var ErrReadOnly = errors.New("goorm: database is read-only")
+
+// ErrShuttingDown is wrapped by errors for queries rejected during Shutdown.
+// ErrShuttingDown 由 Shutdown 期间被拒绝的查询错误包装。
+var ErrShuttingDown = errors.New("goorm: database is shutting down")
+
+// codeErrors maps result error codes to the sentinel errors QueryError wraps.
+// codeErrors 将结果错误代码映射到 QueryError 包装的哨兵错误。
+var codeErrors = map[string]error{
+	"DUPLICATE_KEY":           ErrDuplicateKey,
+	"FK_VIOLATION":            ErrForeignKeyViolation,
+	"INVALID_COLUMN":          ErrInvalidColumn,
+	"INVALID_FIELD":           ErrInvalidColumn,
+	"TABLE_NOT_FOUND":         ErrTableNotFound,
+	"SYNTAX_ERROR":            ErrSyntax,
+	"TIMEOUT":                 ErrTimeout,
+	"CONNECTION_ERROR":        ErrConnection,
+	"VALIDATION_ERROR":        ErrValidation,
+	"MISSING_REQUIRED_FIELD":  ErrValidation,
+	"TYPE_MISMATCH":           ErrValidation,
+	"INVALID_IDENTIFIER":      ErrValidation,
+	"INVALID_GROUP_BY":        ErrValidation,
+	"PARSE_ERROR":             ErrValidation,
+	"MISSING_TENANT":          ErrValidation,
+	"UNSCOPED_JOIN":           ErrValidation,
+	"COMPOSITE_PRIMARY_KEY":   ErrValidation,
+	"CONFIRM_REQUIRED":        ErrConfirmRequired,
+	"READ_ONLY_MODE":          ErrReadOnly,
+	"SHUTTING_DOWN":           ErrShuttingDown,
+	"RETURNING_NOT_SUPPORTED": ErrReturningNotSupported,
+	"ROLLUP_NOT_SUPPORTED":    ErrRollupNotSupported,
+}
+
 // QueryError represents an error from query execution.
 // QueryError 表示查询执行的错误。
 type QueryError struct {
 	Code       string
 	Message    string
 	Suggestion string
+	Details    map[string]any
 }
 
 // Error implements the error interface.
@@ -376,3 +526,13 @@ func (e *QueryError) Error() string {
 	}
 	return e.Message
 }
+
+// Unwrap returns the sentinel error for the error's code, or nil for codes without one.
+// Unwrap 返回错误代码对应的哨兵错误；没有对应哨兵的代码返回 nil。
+func (e *QueryError) Unwrap() error {
+	return codeErrors[e.Code]
+}
+
+// ErrDuplicateTable is returned when two models are registered for the same table.
+// ErrDuplicateTable 在两个模型注册到同一张表时返回。
+var ErrDuplicateTable = errors.New("goorm: table is already registered by another model")
@@ -0,0 +1,228 @@
+package goorm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxReturnIDs is the most rows an update or delete with ReturnIDs and no BatchSize
+// may write on dialects without RETURNING, whose keys are selected in memory first.
+// maxReturnIDs 是在不支持 RETURNING 的方言上，设置了 ReturnIDs 而未设置 BatchSize 的更新或删除最多可写入的行数，
+// 这些行的主键需要先查询到内存中。
+const maxReturnIDs = 10000
+
+// keyWriter runs the statements of a write with ReturnIDs: the Executor outside a
+// transaction and the Transaction inside one.
+// keyWriter 执行设置了 ReturnIDs 的写入的各条语句：事务外为 Executor，事务内为 Transaction。
+type keyWriter interface {
+	// writeQuery runs the update or delete query.
+	// writeQuery 执行更新或删除查询。
+	writeQuery(ctx context.Context, query *Query) *Result
+
+	// findKeys runs sel, a keySelect query.
+	// findKeys 执行 keySelect 查询 sel。
+	findKeys(ctx context.Context, sel *Query) *Result
+}
+
+// writeReturningIDs runs an update or delete with ReturnIDs through w. Dialects with
+// RETURNING return the keys of the rows written. On others the keys of the rows
+// matching query are selected first, at most maxReturnIDs of them, and the write is
+// limited to those rows, so a row that starts matching in between is neither written
+// nor reported. Inside a transaction the selected rows are locked until it ends, so
+// the write changes exactly those.
+//
+// writeReturningIDs 通过 w 执行设置了 ReturnIDs 的更新或删除。支持 RETURNING 的方言直接返回写入行的主键；
+// 其他方言先查询匹配 query 的行的主键（最多 maxReturnIDs 个），并将写入限定为这些行，因此在此期间才开始匹配的行
+// 既不会被写入也不会被报告。在事务中，选中的行会被锁定直到事务结束，因此写入恰好修改这些行。
+func (db *DB) writeReturningIDs(ctx context.Context, query *Query, w keyWriter) *Result {
+	pk, err := db.primaryKeyColumn(query.Table)
+	if err != nil {
+		return validationResult(err)
+	}
+	write := *query
+	write.ReturnIDs = false
+
+	if db.dialect.SupportsReturning() {
+		write.Returning = withKeyColumn(query.Returning, pk)
+		r := w.writeQuery(ctx, &write)
+		if r.Success {
+			returnedKeys(r, pk, len(query.Returning) > 0)
+		}
+		return r
+	}
+	if len(query.Returning) > 0 {
+		return returningNotSupported(db.dialect)
+	}
+
+	sel := keySelect(query, pk, nil)
+	sel.Limit = maxReturnIDs + 1
+	found := w.findKeys(ctx, sel)
+	if !found.Success {
+		return found
+	}
+	if len(found.Data) > maxReturnIDs {
+		if auditOnlyIDs(ctx) {
+			// The keys were wanted only for the audit entry, which goes without them
+			// 主键仅用于审计条目，审计条目不带主键即可
+			return w.writeQuery(ctx, &write)
+		}
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:       "TOO_MANY_ROWS",
+				Message:    fmt.Sprintf("return_ids can report at most %d rows on %s without batch_size", maxReturnIDs, db.dialect.Name()),
+				Suggestion: "Set batch_size to write and report the rows in chunks, or narrow the where conditions",
+				Details:    map[string]any{"limit": maxReturnIDs},
+			},
+		}
+	}
+
+	keys := rowKeys(found.Data, pk)
+	r := &Result{Success: true}
+	if len(keys) > 0 {
+		write.Where = append(slices.Clip(query.Where), Condition{Field: pk, Op: OpIn, Value: keys})
+		if r = w.writeQuery(ctx, &write); !r.Success {
+			return r
+		}
+	}
+	setAffectedKeys(r, keys)
+	return r
+}
+
+// executeWriteReturningIDs runs an update or delete with ReturnIDs outside a transaction.
+// executeWriteReturningIDs 在事务外执行设置了 ReturnIDs 的更新或删除。
+func (e *Executor) executeWriteReturningIDs(ctx context.Context, query *Query) *Result {
+	return e.db.writeReturningIDs(ctx, query, e)
+}
+
+// writeQuery implements keyWriter.
+// writeQuery 实现 keyWriter。
+func (e *Executor) writeQuery(ctx context.Context, query *Query) *Result {
+	return e.executeWriteQuery(ctx, query)
+}
+
+// findKeys implements keyWriter.
+// findKeys 实现 keyWriter。
+func (e *Executor) findKeys(ctx context.Context, sel *Query) *Result {
+	startTime := time.Now()
+	buildResult, err := e.db.newBuilder(ctx, sel).Build()
+	if err != nil {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "BUILD_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+	rows, err := e.db.queryContext(ctx, e.db.sqlDB, buildResult.SQL, buildResult.Params...)
+	e.db.logQuery(buildResult, startTime, err)
+	if err != nil {
+		return e.handleSQLError(err, buildResult)
+	}
+	defer rows.Close()
+
+	data, errResult := scanRows(rows, nil)
+	if errResult != nil {
+		return errResult
+	}
+	return &Result{Success: true, Data: data}
+}
+
+// writeReturningIDs runs an update or delete with ReturnIDs in the transaction.
+// writeReturningIDs 在事务中执行设置了 ReturnIDs 的更新或删除。
+func (t *Transaction) writeReturningIDs(ctx context.Context, query *Query) *Result {
+	return t.db.writeReturningIDs(ctx, query, t)
+}
+
+// writeQuery implements keyWriter.
+// writeQuery 实现 keyWriter。
+func (t *Transaction) writeQuery(ctx context.Context, query *Query) *Result {
+	return t.dispatchOperation(ctx, query)
+}
+
+// findKeys implements keyWriter, locking the rows found until the transaction ends.
+// findKeys 实现 keyWriter，并锁定找到的行直到事务结束。
+func (t *Transaction) findKeys(ctx context.Context, sel *Query) *Result {
+	sel.Lock = LockUpdate
+	build, err := t.db.newBuilder(ctx, sel).Build()
+	if err != nil {
+		return &Result{
+			Success: false,
+			Error: &ResultError{
+				Code:    "BUILD_ERROR",
+				Message: err.Error(),
+			},
+		}
+	}
+	return t.executeFind(ctx, build, nil)
+}
+
+// keySelect returns the query selecting, in key order, the primary keys of rows
+// matching query: at most query.BatchSize of them when it is set, and only those
+// after last when it is not nil.
+//
+// keySelect 返回按主键顺序查询匹配 query 的行主键的查询：设置了 query.BatchSize 时最多返回这么多个，
+// last 不为 nil 时只返回 last 之后的主键。
+func keySelect(query *Query, pk string, last any) *Query {
+	sel := &Query{
+		Table:   query.Table,
+		Action:  ActionFind,
+		Select:  []any{pk},
+		Where:   slices.Clip(query.Where),
+		OrderBy: []Order{{Field: pk}},
+		Limit:   query.BatchSize,
+	}
+	if last != nil {
+		sel.Where = append(sel.Where, Condition{Field: pk, Op: OpGreater, Value: last})
+	}
+	return sel
+}
+
+// withKeyColumn returns columns plus the primary key, unless they include it or "*".
+// withKeyColumn 返回 columns 加上主键；columns 已包含主键或 "*" 时原样返回。
+func withKeyColumn(columns []string, pk string) []string {
+	if slices.Contains(columns, "*") || slices.Contains(columns, pk) {
+		return columns
+	}
+	return append([]string{pk}, columns...)
+}
+
+// rowKeys returns the pk column of every row.
+// rowKeys 返回每行的 pk 列。
+func rowKeys(rows []map[string]any, pk string) []any {
+	keys := make([]any, len(rows))
+	for i, row := range rows {
+		keys[i] = row[pk]
+	}
+	return keys
+}
+
+// returnedKeys reports the keys of the rows a write returned. The rows themselves
+// are dropped unless the query asked for them with Returning.
+// returnedKeys 报告写入返回的行的主键。除非查询通过 Returning 请求了这些行，否则丢弃行本身。
+func returnedKeys(r *Result, pk string, keepRows bool) {
+	keys := rowKeys(r.Data, pk)
+	if !keepRows {
+		r.Data, r.Count = nil, 0
+	}
+	setAffectedKeys(r, keys)
+}
+
+// setAffectedKeys sets r.AffectedKeys to keys and r.IDs to the numeric ones.
+// setAffectedKeys 将 r.AffectedKeys 设为 keys，并将其中的数字主键设为 r.IDs。
+func setAffectedKeys(r *Result, keys []any) {
+	if len(keys) == 0 {
+		return
+	}
+	r.AffectedKeys = make([]any, len(keys))
+	for i, key := range keys {
+		var id uint64
+		r.AffectedKeys[i], id = insertedKey(key)
+		if id != 0 {
+			r.IDs = append(r.IDs, id)
+		}
+	}
+}
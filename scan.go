@@ -0,0 +1,138 @@
+package goorm
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// scannerType is the reflect type of sql.Scanner.
+// scannerType 是 sql.Scanner 的反射类型。
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// nullSafeDests returns one scan destination per column that accepts NULL.
+// Columns whose Go type is known from types scan through the matching sql.NullXxx,
+// so the driver converts the value (e.g. MySQL's []byte "42" to an int); other
+// columns scan into any.
+//
+// nullSafeDests 为每一列返回可以接受 NULL 的扫描目标。
+// 能从 types 得知 Go 类型的列通过对应的 sql.NullXxx 扫描，由驱动完成值转换
+// （例如将 MySQL 的 []byte "42" 转换为整数）；其他列扫描到 any。
+func nullSafeDests(columns []string, types map[string]reflect.Type) []any {
+	dests := make([]any, len(columns))
+	for i, col := range columns {
+		dests[i] = nullScanDest(types[col])
+	}
+	return dests
+}
+
+// nullScanDest returns a NULL-accepting scan destination for a value of type t.
+// nullScanDest 返回类型为 t 的值可接受 NULL 的扫描目标。
+func nullScanDest(t reflect.Type) any {
+	if t == nil {
+		return new(any)
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Custom scanners handle the raw value themselves
+	// 自定义扫描器自行处理原始值
+	if reflect.PointerTo(t).Implements(scannerType) {
+		return new(any)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return new(sql.NullString)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return new(sql.NullInt64)
+	case reflect.Uint, reflect.Uint64:
+		return new(sql.Null[uint64])
+	case reflect.Float32, reflect.Float64:
+		return new(sql.NullFloat64)
+	case reflect.Bool:
+		return new(sql.NullBool)
+	}
+	// Times and the rest keep the driver's value; assignValue converts them
+	// 时间及其他类型保留驱动返回的值，由 assignValue 转换
+	return new(any)
+}
+
+// nullSafeValues returns the scanned values of dests, with NULL as nil. A typed
+// destination whose zero entry is true reads NULL as its zero value instead.
+// zero may be nil.
+//
+// nullSafeValues 返回 dests 中扫描到的值，NULL 表示为 nil。对于 zero 中对应项为 true 的
+// 有类型目标，NULL 读取为其零值。zero 可以为 nil。
+func nullSafeValues(dests []any, zero []bool) []any {
+	values := make([]any, len(dests))
+	for i, dest := range dests {
+		keep := zero != nil && zero[i]
+		switch d := dest.(type) {
+		case *sql.NullString:
+			if d.Valid || keep {
+				values[i] = d.String
+			}
+		case *sql.NullInt64:
+			if d.Valid || keep {
+				values[i] = d.Int64
+			}
+		case *sql.Null[uint64]:
+			if d.Valid || keep {
+				values[i] = d.V
+			}
+		case *sql.NullFloat64:
+			if d.Valid || keep {
+				values[i] = d.Float64
+			}
+		case *sql.NullBool:
+			if d.Valid || keep {
+				values[i] = d.Bool
+			}
+		case *any:
+			values[i] = *d
+		}
+	}
+	return values
+}
+
+// scanFields returns the fields of the registered model of query's table by column
+// name, or nil if the table is not registered or the query joins other tables,
+// whose columns may share those names.
+//
+// scanFields 按列名返回 query 所在表已注册模型的字段；表未注册或查询连接了其他表（其列可能同名）时返回 nil。
+func (db *DB) scanFields(query *Query) map[string]*FieldMeta {
+	if db.registry == nil || len(query.Join) > 0 {
+		return nil
+	}
+	meta, ok := db.registry.Get(query.Table)
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]*FieldMeta, len(meta.Fields))
+	for _, field := range meta.Fields {
+		fields[field.ColumnName] = field
+	}
+	return fields
+}
+
+// fieldScanTypes returns the Go types of the columns that are fields, and for each
+// column whether it reads NULL as the zero value: those whose field is not
+// Nullable do, as the field of a scanned struct would.
+//
+// fieldScanTypes 返回属于 fields 的列的 Go 类型，以及每一列是否将 NULL 读取为零值：
+// 字段不可为 NULL 的列会这样做，与扫描到结构体字段时一致。
+func fieldScanTypes(columns []string, fields map[string]*FieldMeta) (map[string]reflect.Type, []bool) {
+	if fields == nil {
+		return nil, nil
+	}
+	types := make(map[string]reflect.Type, len(columns))
+	zero := make([]bool, len(columns))
+	for i, col := range columns {
+		if field, ok := fields[col]; ok {
+			types[col] = field.Type
+			zero[i] = !field.Nullable
+		}
+	}
+	return types, zero
+}
@@ -0,0 +1,46 @@
+package goorm
+
+import (
+	"context"
+	"regexp"
+)
+
+// schemaKey is the context key for the schema queries are scoped to.
+// schemaKey 是查询所限定的 schema 的上下文键。
+type schemaKey struct{}
+
+// schemaPattern matches the schema names WithSchema accepts: an unqualified identifier.
+// schemaPattern 匹配 WithSchema 接受的 schema 名称：一个不带限定的标识符。
+var schemaPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// WithSchema returns a copy of ctx that scopes queries and migrations to schema,
+// e.g. one Postgres schema per tenant. Tables are then qualified as
+// "schema"."table"; an empty schema leaves them unqualified. The schema is written
+// into the SQL, so a name other than letters, digits and underscores returns an
+// *IdentifierError.
+//
+// WithSchema 返回将查询和迁移限定到 schema 的 ctx 副本，例如每个租户一个 Postgres schema。
+// 表名会被限定为 "schema"."table"；空 schema 则不限定。schema 会被写入 SQL，因此名称含有字母、
+// 数字和下划线以外的字符时返回 *IdentifierError。
+//
+// Example / 示例:
+//
+//	ctx, err := goorm.WithSchema(r.Context(), "tenant_42")
+//	if err != nil { ... }
+//	db.ExecuteContext(ctx, jql)
+func WithSchema(ctx context.Context, schema string) (context.Context, error) {
+	if schema != "" && !schemaPattern.MatchString(schema) {
+		return ctx, &IdentifierError{Identifier: schema, Path: "schema"}
+	}
+	return context.WithValue(ctx, schemaKey{}, schema), nil
+}
+
+// SchemaFromContext returns the schema stored by WithSchema, or "".
+// SchemaFromContext 返回 WithSchema 存储的 schema，没有则返回 ""。
+func SchemaFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	schema, _ := ctx.Value(schemaKey{}).(string)
+	return schema
+}
@@ -0,0 +1,219 @@
+package goorm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaDiff compares the registered models with the live database schema.
+// SchemaDiff 比较已注册的模型与数据库的实际结构。
+type SchemaDiff struct {
+	// MissingTables lists model tables that do not exist in the database.
+	// MissingTables 列出数据库中不存在的模型表。
+	MissingTables []string `json:"missing_tables,omitempty"`
+
+	// ExtraTables lists database tables that belong to no model.
+	// ExtraTables 列出不属于任何模型的数据库表。
+	ExtraTables []string `json:"extra_tables,omitempty"`
+
+	// Tables lists the differences of tables present in both, sorted by name.
+	// Tables 按名称排序列出两边都存在的表的差异。
+	Tables []TableDiff `json:"tables,omitempty"`
+
+	// CheckedAt is when the comparison was made.
+	// CheckedAt 是比较的时间。
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// TableDiff lists how a table in the database differs from its model.
+// TableDiff 列出数据库中的表与其模型的差异。
+type TableDiff struct {
+	// Table is the table name.
+	// Table 是表名。
+	Table string `json:"table"`
+
+	// MissingColumns lists model columns the table lacks.
+	// MissingColumns 列出表中缺少的模型列。
+	MissingColumns []string `json:"missing_columns,omitempty"`
+
+	// ExtraColumns lists table columns that are not in the model.
+	// ExtraColumns 列出不在模型中的表列。
+	ExtraColumns []string `json:"extra_columns,omitempty"`
+
+	// TypeMismatches lists columns whose type differs from the model.
+	// TypeMismatches 列出类型与模型不同的列。
+	TypeMismatches []ColumnTypeDiff `json:"type_mismatches,omitempty"`
+
+	// MissingIndexes lists the indexes declared by index and unique tags that the table lacks.
+	// MissingIndexes 列出由 index 和 unique 标签声明但表中缺少的索引。
+	MissingIndexes []IndexSchema `json:"missing_indexes,omitempty"`
+}
+
+// ColumnTypeDiff describes a column whose database type differs from the model.
+// ColumnTypeDiff 描述数据库类型与模型不同的列。
+type ColumnTypeDiff struct {
+	Column    string `json:"column"`
+	DBType    string `json:"db_type"`
+	ModelType string `json:"model_type"`
+}
+
+// HasDrift reports whether the database differs from the models.
+// HasDrift 判断数据库是否与模型存在差异。
+func (d *SchemaDiff) HasDrift() bool {
+	return len(d.MissingTables) > 0 || len(d.ExtraTables) > 0 || len(d.Tables) > 0
+}
+
+// String formats the differences one per line.
+// String 将差异格式化为每行一条。
+func (d *SchemaDiff) String() string {
+	if !d.HasDrift() {
+		return "schema matches models"
+	}
+	var lines []string
+	for _, table := range d.MissingTables {
+		lines = append(lines, "missing table "+table)
+	}
+	for _, table := range d.ExtraTables {
+		lines = append(lines, "extra table "+table)
+	}
+	for _, t := range d.Tables {
+		for _, col := range t.MissingColumns {
+			lines = append(lines, fmt.Sprintf("%s: missing column %s", t.Table, col))
+		}
+		for _, col := range t.ExtraColumns {
+			lines = append(lines, fmt.Sprintf("%s: extra column %s", t.Table, col))
+		}
+		for _, c := range t.TypeMismatches {
+			lines = append(lines, fmt.Sprintf("%s: column %s is %s, model expects %s", t.Table, c.Column, c.DBType, c.ModelType))
+		}
+		for _, idx := range t.MissingIndexes {
+			kind := "index"
+			if idx.Unique {
+				kind = "unique index"
+			}
+			lines = append(lines, fmt.Sprintf("%s: missing %s %s (%s)", t.Table, kind, idx.Name, strings.Join(idx.Columns, ", ")))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SchemaDiff compares the registered models with the database, in the schema set
+// on ctx by WithSchema. Unlike Plan, it reports extra tables and columns whether
+// or not aggressive migration is enabled, and changes nothing.
+//
+// SchemaDiff 比较已注册的模型与数据库（在 ctx 中由 WithSchema 设置的 schema 内）。与 Plan 不同，
+// 无论是否启用激进迁移，它都会报告多余的表和列，且不做任何修改。
+func (db *DB) SchemaDiff(ctx context.Context) (*SchemaDiff, error) {
+	return NewMigrator(db).scoped(ctx).diff(ctx)
+}
+
+// diff builds a SchemaDiff from an aggressive migration plan.
+// diff 基于激进迁移计划构建 SchemaDiff。
+func (m *Migrator) diff(ctx context.Context) (*SchemaDiff, error) {
+	plan, err := m.plan(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &SchemaDiff{CheckedAt: plan.CreatedAt}
+	tables := make(map[string]*TableDiff)
+	tableDiff := func(name string) *TableDiff {
+		if tables[name] == nil {
+			tables[name] = &TableDiff{Table: name}
+		}
+		return tables[name]
+	}
+
+	for _, change := range plan.Changes {
+		switch change.Action {
+		case MigrationActionCreateTable:
+			diff.MissingTables = append(diff.MissingTables, change.Table)
+		case MigrationActionDropTable:
+			diff.ExtraTables = append(diff.ExtraTables, change.Table)
+		case MigrationActionAddColumn:
+			t := tableDiff(change.Table)
+			t.MissingColumns = append(t.MissingColumns, change.Column)
+		case MigrationActionDropColumn:
+			t := tableDiff(change.Table)
+			t.ExtraColumns = append(t.ExtraColumns, change.Column)
+		case MigrationActionModifyColumn:
+			t := tableDiff(change.Table)
+			t.TypeMismatches = append(t.TypeMismatches, ColumnTypeDiff{
+				Column:    change.Column,
+				DBType:    change.OldType,
+				ModelType: change.NewType,
+			})
+		}
+	}
+
+	for _, table := range m.db.registry.ListTables() {
+		if slices.Contains(diff.MissingTables, table.Name) {
+			continue
+		}
+		missing, err := m.missingIndexes(ctx, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) > 0 {
+			tableDiff(table.Name).MissingIndexes = missing
+		}
+	}
+
+	sort.Strings(diff.MissingTables)
+	sort.Strings(diff.ExtraTables)
+	for _, t := range tables {
+		sort.Strings(t.MissingColumns)
+		sort.Strings(t.ExtraColumns)
+		sort.Slice(t.TypeMismatches, func(i, j int) bool { return t.TypeMismatches[i].Column < t.TypeMismatches[j].Column })
+		diff.Tables = append(diff.Tables, *t)
+	}
+	sort.Slice(diff.Tables, func(i, j int) bool { return diff.Tables[i].Table < diff.Tables[j].Table })
+	return diff, nil
+}
+
+// missingIndexes returns the indexes the index and unique tags of table's model
+// call for that the database lacks. An index tag is satisfied by any index leading
+// with the column, a unique tag only by a unique index on the column alone.
+//
+// missingIndexes 返回表模型的 index 和 unique 标签所要求、但数据库中缺少的索引。index 标签由任何以该列
+// 开头的索引满足，unique 标签只由仅包含该列的唯一索引满足。
+func (m *Migrator) missingIndexes(ctx context.Context, table string) ([]IndexSchema, error) {
+	meta, ok := m.db.registry.Get(table)
+	if !ok {
+		return nil, nil
+	}
+
+	var indexes []dbIndex
+	var missing []IndexSchema
+	loaded := false
+	for _, field := range meta.Fields {
+		if field.PrimaryKey || (!field.Index && !field.Unique) {
+			continue
+		}
+		if !loaded {
+			var err error
+			if indexes, err = m.getDBIndexes(ctx, table); err != nil {
+				return nil, err
+			}
+			loaded = true
+		}
+		found := slices.ContainsFunc(indexes, func(idx dbIndex) bool {
+			if field.Unique {
+				return idx.Unique && len(idx.Columns) == 1 && idx.Columns[0] == field.ColumnName
+			}
+			return len(idx.Columns) > 0 && idx.Columns[0] == field.ColumnName
+		})
+		if !found {
+			missing = append(missing, IndexSchema{
+				Name:    fmt.Sprintf("idx_%s_%s", table, field.ColumnName),
+				Columns: []string{field.ColumnName},
+				Unique:  field.Unique,
+			})
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,143 @@
+package goorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// serverTimeout applies Config.ServerTimeouts to query: it returns query with the
+// dialect's timeout hint and the statement, if any, that limits the transaction
+// the query runs in to the time left before the deadline of ctx. Limits are only
+// ever set for a transaction, never for a session, so no pooled connection keeps
+// one. Without a deadline the setup statement removes an earlier limit of the
+// transaction inTx reports, and is "" outside one.
+//
+// serverTimeout 对 query 应用 Config.ServerTimeouts：返回带有方言超时提示的 query，以及将查询所在事务
+// 限制在 ctx 截止时间前剩余时间内的语句（如有）。限制只针对事务设置，从不针对会话，因此连接池中的连接不会保留限制。
+// 没有截止时间时，inTx 表示的事务中该语句会移除之前设置的限制，事务之外则为 ""。
+func (db *DB) serverTimeout(ctx context.Context, query string, inTx bool) (string, string) {
+	if !db.config.ServerTimeouts {
+		return query, ""
+	}
+	var timeout time.Duration
+	deadline, ok := ctx.Deadline()
+	if ok {
+		// Round up so a nearly expired deadline does not become "no limit"
+		// 向上取整，避免即将到期的截止时间变成“不限制”
+		timeout = max(time.Until(deadline).Round(time.Millisecond), time.Millisecond)
+	}
+	query = db.dialect.TimeoutHint(query, timeout)
+	if !ok && !inTx {
+		return query, ""
+	}
+	return query, db.dialect.StatementTimeout(timeout, true)
+}
+
+// serverTimeout applies Config.ServerTimeouts to a statement of the transaction,
+// running the dialect's SET LOCAL-style statement before it.
+//
+// serverTimeout 对事务中的语句应用 Config.ServerTimeouts，在其之前执行方言的 SET LOCAL 类语句。
+func (t *Transaction) serverTimeout(ctx context.Context, query string) (string, error) {
+	query, setup := t.db.serverTimeout(ctx, query, true)
+	if setup != "" {
+		if _, err := t.tx.ExecContext(ctx, setup); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// timedTx begins a transaction on pool and runs setup, the SET LOCAL-style
+// statement of serverTimeout, in it. The transaction is rolled back when setup fails.
+//
+// timedTx 在 pool 上开始事务并在其中执行 setup（serverTimeout 的 SET LOCAL 类语句）。setup 失败时回滚事务。
+func timedTx(ctx context.Context, pool *sql.DB, setup string) (*sql.Tx, error) {
+	tx, err := pool.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, setup); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// rowScanner is the single row of a query: a *sql.Row, or a row standing in for
+// one that failed before the query could run.
+// rowScanner 是查询的单行结果：*sql.Row，或代替在查询执行前就失败的行。
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// errRow is a row whose Scan returns err.
+// errRow 是 Scan 返回 err 的行。
+type errRow struct {
+	err error
+}
+
+// Scan returns the error the row stands for.
+// Scan 返回该行所代表的错误。
+func (r errRow) Scan(dest ...any) error {
+	return r.err
+}
+
+// queryRows is the result of a query: *sql.Rows, or timedRows when the query ran
+// in the transaction of timedTx.
+// queryRows 是查询的结果：*sql.Rows，或查询在 timedTx 的事务中执行时的 timedRows。
+type queryRows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close() error
+}
+
+// timedRows are rows read in the transaction of timedTx, which Close ends. The
+// transaction must outlive the rows, as ending it cancels them.
+// timedRows 是在 timedTx 的事务中读取的行，Close 会结束该事务。事务必须比行存活更久，因为结束事务会取消这些行。
+type timedRows struct {
+	*sql.Rows
+	tx *sql.Tx
+}
+
+// Close closes the rows, then commits the transaction, or rolls it back when
+// reading the rows failed.
+// Close 关闭行，然后提交事务；读取行失败时回滚事务。
+func (r *timedRows) Close() error {
+	if err := r.Rows.Close(); err != nil {
+		r.tx.Rollback()
+		return err
+	}
+	if r.Rows.Err() != nil {
+		r.tx.Rollback()
+		return nil
+	}
+	if err := r.tx.Commit(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+// timedRow is a row read in the transaction of timedTx, which Scan ends.
+// timedRow 是在 timedTx 的事务中读取的行，Scan 会结束该事务。
+type timedRow struct {
+	row *sql.Row
+	tx  *sql.Tx
+}
+
+// Scan scans the row, then commits the transaction, or rolls it back on error.
+// Scan 扫描该行，然后提交事务；出错时回滚事务。
+func (r timedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.tx.Rollback()
+		return err
+	}
+	if cerr := r.tx.Commit(); cerr != nil {
+		return cerr
+	}
+	return err
+}
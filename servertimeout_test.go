@@ -0,0 +1,121 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServerTimeouts tests that statements carry the query timeout to the server.
+// TestServerTimeouts 测试语句会把查询超时传递给服务器。
+func TestServerTimeouts(t *testing.T) {
+	handler := func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	}
+	find := &Query{Table: "users", Action: ActionFind, Timeout: "2s"}
+	setTimeout := regexp.MustCompile(`^SET (LOCAL )?statement_timeout = (\d+)$`)
+
+	pg, backend := newFakeDB(t, &PostgresDialect{}, handler)
+	pg.config.ServerTimeouts = true
+	if result := pg.ExecuteQuery(context.Background(), find); !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+	queries := backend.Queries()
+	if len(queries) != 2 || queries[1] != `SELECT * FROM "users"` {
+		t.Fatalf("expected SET then SELECT, got %v", queries)
+	}
+	m := setTimeout.FindStringSubmatch(queries[0])
+	if m == nil || m[1] == "" {
+		t.Fatalf("expected a transaction-local timeout, got %q", queries[0])
+	}
+	if ms, _ := strconv.Atoi(m[2]); ms < 1900 || ms > 2000 {
+		t.Errorf("expected about 2000ms, got %dms", ms)
+	}
+
+	// Without a deadline nothing is set, as no limit outlives its transaction
+	// 没有截止时间时不设置任何内容，因为限制不会在其事务之后保留
+	pg.config.DefaultTimeout, pg.config.QueryTimeout = 0, 0
+	pg.ExecuteQuery(context.Background(), &Query{Table: "users", Action: ActionFind})
+	if q := backend.Queries()[2]; q != `SELECT * FROM "users"` {
+		t.Errorf("expected no setup without a deadline, got %q", q)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	pg.ExecuteQuery(ctx, &Query{Action: ActionTransaction, Operations: []Query{
+		{Table: "users", Action: ActionUpdate, Data: map[string]any{"name": "a"}, Where: []Condition{{Field: "id", Op: OpEqual, Value: 1}}},
+	}})
+	if m := setTimeout.FindStringSubmatch(backend.Queries()[3]); m == nil || m[1] == "" {
+		t.Errorf("expected SET LOCAL inside the transaction, got %v", backend.Queries()[3:])
+	}
+
+	mysql, backend := newFakeDB(t, &MySQLDialect{}, handler)
+	mysql.config.ServerTimeouts = true
+	mysql.ExecuteQuery(context.Background(), find)
+	if q := backend.Queries(); len(q) != 1 || !strings.HasPrefix(q[0], "SELECT /*+ MAX_EXECUTION_TIME(") {
+		t.Errorf("expected a MAX_EXECUTION_TIME hint, got %v", q)
+	}
+
+	// The transaction of the timeout ends only once every row is read
+	// 超时所在的事务在读取所有行之后才结束
+	many := make([][]driver.Value, 1000)
+	for i := range many {
+		many[i] = []driver.Value{int64(i)}
+	}
+	pg, _ = newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, many, nil
+	})
+	pg.config.ServerTimeouts = true
+	for i := 0; i < 200; i++ {
+		result := pg.ExecuteQuery(context.Background(), find)
+		if !result.Success {
+			t.Fatalf("find %d failed: %+v", i, result.Error)
+		}
+		if len(result.Data) != len(many) {
+			t.Fatalf("find %d returned %d rows, want %d", i, len(result.Data), len(many))
+		}
+	}
+}
+
+// TestServerTimeoutSetupError tests that a failing timeout setup is reported
+// instead of running the statement without it.
+// TestServerTimeoutSetupError 测试超时设置失败时会报告错误，而不是在没有限制的情况下执行语句。
+func TestServerTimeoutSetupError(t *testing.T) {
+	handler := func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if strings.HasPrefix(query, "SET ") {
+			return nil, nil, errors.New("permission denied to set statement_timeout")
+		}
+		return []string{"count"}, [][]driver.Value{{int64(1)}}, nil
+	}
+	db, backend := newFakeDB(t, &PostgresDialect{}, handler)
+	db.config.ServerTimeouts = true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionCount})
+	if result.Success || !strings.Contains(result.Error.Message, "permission denied") {
+		t.Errorf("expected the setup error from count, got %+v", result.Error)
+	}
+
+	tx, err := db.BeginContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	var n int64
+	if err := tx.queryRowContext(ctx, `SELECT COUNT(*) FROM "users"`).Scan(&n); err == nil ||
+		!strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected the setup error in the transaction, got %v", err)
+	}
+
+	for _, q := range backend.Queries() {
+		if !strings.HasPrefix(q, "SET ") {
+			t.Errorf("no statement should run after a failed setup, got %q", q)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package goorm
+
+import (
+	"context"
+	"sync"
+)
+
+// inflight tracks the work Shutdown waits for: queries running through
+// ExecuteQuery, open streams and open transactions. A nil *inflight tracks nothing.
+//
+// inflight 跟踪 Shutdown 需要等待的工作：通过 ExecuteQuery 运行的查询、未关闭的流和未结束的事务。
+// nil 的 *inflight 不做跟踪。
+type inflight struct {
+	mu      sync.Mutex
+	closing bool
+	wg      sync.WaitGroup
+}
+
+// enter registers a query, or returns false once the database is shutting down.
+// enter 登记一个查询；数据库开始关闭后返回 false。
+func (f *inflight) enter() bool {
+	if f == nil {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closing {
+		return false
+	}
+	f.wg.Add(1)
+	return true
+}
+
+// leave marks a query registered by enter as finished.
+// leave 将由 enter 登记的查询标记为已完成。
+func (f *inflight) leave() {
+	if f != nil {
+		f.wg.Done()
+	}
+}
+
+// drain stops new queries from entering and waits for the running ones to
+// finish or for ctx to be done.
+//
+// drain 阻止新查询进入，并等待正在运行的查询完成或 ctx 结束。
+func (f *inflight) drain(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	f.closing = true
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown closes the database gracefully. New queries, streams, transactions and
+// imports are rejected with SHUTTING_DOWN while the running ones finish: queries
+// through ExecuteQuery (including Exists), streams until their iterator is closed
+// (so ExportJSONL until it returns) and transactions until they are committed or
+// rolled back (so ImportCSV until it returns). Then the connections are closed. If
+// ctx is done first, the connections are closed anyway, aborting the work still
+// running, and ctx's error is returned. Close is the variant that does not wait.
+//
+// Shutdown 优雅地关闭数据库。在运行中的工作完成期间，新的查询、流、事务和导入会以 SHUTTING_DOWN 被拒绝：
+// 通过 ExecuteQuery 执行的查询（包括 Exists）、直到迭代器关闭的流（因此 ExportJSONL 直到其返回），
+// 以及直到提交或回滚的事务（因此 ImportCSV 直到其返回）。之后关闭连接。如果 ctx 先结束，仍会关闭连接并中止
+// 仍在运行的工作，同时返回 ctx 的错误。Close 是不等待的版本。
+//
+// Example / 示例:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := db.Shutdown(ctx); err != nil {
+//	    log.Printf("shutdown: %v", err)
+//	}
+func (db *DB) Shutdown(ctx context.Context) error {
+	err := db.inflight.drain(ctx)
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// shuttingDownResult returns the error result for a query received during Shutdown.
+// shuttingDownResult 返回在 Shutdown 期间收到的查询的错误结果。
+func shuttingDownResult() *Result {
+	return &Result{
+		Success: false,
+		Error: &ResultError{
+			Code:       "SHUTTING_DOWN",
+			Message:    "database is shutting down",
+			Suggestion: "Retry against another instance",
+		},
+	}
+}
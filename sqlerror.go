@@ -0,0 +1,203 @@
+package goorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+)
+
+// sqlStateError is implemented by the errors of PostgreSQL drivers such as lib/pq
+// and pgx, which carry the server's SQLSTATE code.
+//
+// sqlStateError 由 lib/pq 和 pgx 等 PostgreSQL 驱动的错误实现，携带服务端的 SQLSTATE 代码。
+type sqlStateError interface {
+	SQLState() string
+}
+
+// sqliteCodeError is implemented by the errors of modernc.org/sqlite, whose Code
+// is the extended result code.
+//
+// sqliteCodeError 由 modernc.org/sqlite 的错误实现，其 Code 为扩展结果码。
+type sqliteCodeError interface {
+	Code() int
+}
+
+// sqlStateCodes maps PostgreSQL SQLSTATE codes to GoORM error codes.
+// sqlStateCodes 将 PostgreSQL 的 SQLSTATE 代码映射为 GoORM 错误代码。
+var sqlStateCodes = map[string]string{
+	"23505": "DUPLICATE_KEY",
+	"23503": "FK_VIOLATION",
+	"42703": "INVALID_COLUMN",
+	"42P01": "TABLE_NOT_FOUND",
+	"42601": "SYNTAX_ERROR",
+	"57014": "TIMEOUT",
+}
+
+// mysqlErrorCodes maps MySQL server error numbers to GoORM error codes.
+// mysqlErrorCodes 将 MySQL 服务端错误号映射为 GoORM 错误代码。
+var mysqlErrorCodes = map[uint64]string{
+	1062: "DUPLICATE_KEY",
+	1586: "DUPLICATE_KEY",
+	1216: "FK_VIOLATION",
+	1217: "FK_VIOLATION",
+	1451: "FK_VIOLATION",
+	1452: "FK_VIOLATION",
+	1054: "INVALID_COLUMN",
+	1146: "TABLE_NOT_FOUND",
+	1064: "SYNTAX_ERROR",
+	1205: "TIMEOUT",
+	3024: "TIMEOUT",
+	1040: "CONNECTION_ERROR",
+	1045: "CONNECTION_ERROR",
+}
+
+// sqliteErrorCodes maps SQLite extended result codes to GoORM error codes.
+// sqliteErrorCodes 将 SQLite 扩展结果码映射为 GoORM 错误代码。
+var sqliteErrorCodes = map[int64]string{
+	2067: "DUPLICATE_KEY", // SQLITE_CONSTRAINT_UNIQUE
+	1555: "DUPLICATE_KEY", // SQLITE_CONSTRAINT_PRIMARYKEY
+	787:  "FK_VIOLATION",  // SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+// sqliteError is SQLite's generic SQLITE_ERROR result code, which SQLite uses for
+// unknown columns, unknown tables and syntax errors alike.
+//
+// sqliteError 是 SQLite 的通用结果码 SQLITE_ERROR，SQLite 对未知列、未知表和语法错误都使用该代码。
+const sqliteError = 1
+
+// classifySQLError returns the GoORM error code for a database error. The code the
+// driver reports is used when its error type is recognized; the message is matched
+// only when it is not, or when SQLite reports its generic SQLITE_ERROR.
+//
+// classifySQLError 返回数据库错误对应的 GoORM 错误代码。能识别驱动的错误类型时使用其报告的代码；
+// 只有无法识别，或 SQLite 报告通用的 SQLITE_ERROR 时，才匹配错误消息。
+func classifySQLError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "TIMEOUT"
+	}
+	if code, ok := driverErrorCode(err); ok {
+		return code
+	}
+	return messageErrorCode(err.Error())
+}
+
+// driverErrorCode reads the code carried by a PostgreSQL, MySQL or SQLite driver
+// error and maps it. The drivers are not dependencies of GoORM, so their errors are
+// recognized by the SQLState and Code methods, or by the exported fields of
+// *mysql.MySQLError (Number), *pq.Error (Code) and sqlite3.Error (ExtendedCode).
+// ok is false when err comes from none of them.
+//
+// driverErrorCode 读取 PostgreSQL、MySQL 或 SQLite 驱动错误携带的代码并进行映射。这些驱动不是 GoORM 的依赖，
+// 因此通过 SQLState 和 Code 方法，或 *mysql.MySQLError（Number）、*pq.Error（Code）和
+// sqlite3.Error（ExtendedCode）的导出字段识别其错误。err 不来自这些驱动时 ok 为 false。
+func driverErrorCode(err error) (code string, ok bool) {
+	var state sqlStateError
+	if errors.As(err, &state) {
+		return sqlErrorCode(sqlStateCodes[state.SQLState()]), true
+	}
+	var sqlite sqliteCodeError
+	if errors.As(err, &sqlite) {
+		return sqliteErrorCode(int64(sqlite.Code()))
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		v := reflect.ValueOf(e)
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		if f := v.FieldByName("Number"); f.IsValid() && f.CanUint() {
+			return sqlErrorCode(mysqlErrorCodes[f.Uint()]), true
+		}
+		if f := v.FieldByName("ExtendedCode"); f.IsValid() && f.CanInt() {
+			return sqliteErrorCode(f.Int())
+		}
+		if f := v.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.String && len(f.String()) == 5 {
+			return sqlErrorCode(sqlStateCodes[f.String()]), true
+		}
+	}
+	return "", false
+}
+
+// sqliteErrorCode maps a SQLite extended result code. ok is false for SQLITE_ERROR,
+// whose cause only the message tells.
+//
+// sqliteErrorCode 映射 SQLite 扩展结果码。对于只能从消息得知原因的 SQLITE_ERROR，ok 为 false。
+func sqliteErrorCode(extended int64) (code string, ok bool) {
+	if extended&0xff == sqliteError {
+		return "", false
+	}
+	return sqlErrorCode(sqliteErrorCodes[extended]), true
+}
+
+// sqlErrorCode returns code, or SQL_ERROR for an unmapped driver code.
+// sqlErrorCode 返回 code；未映射的驱动代码返回 SQL_ERROR。
+func sqlErrorCode(code string) string {
+	if code == "" {
+		return "SQL_ERROR"
+	}
+	return code
+}
+
+// foreignKeyPatterns match foreign key violations in driver messages: the code pgx
+// appends, MySQL errors 1451 and 1452, SQLite's constraint failure, and PostgreSQL's
+// own message.
+//
+// foreignKeyPatterns 匹配驱动消息中的外键违规：pgx 附加的代码、MySQL 错误 1451 和 1452、
+// SQLite 的约束失败以及 PostgreSQL 自身的消息。
+var foreignKeyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\(SQLSTATE 23503\)`),
+	regexp.MustCompile(`\bError 145[12]\b`),
+	regexp.MustCompile(`FOREIGN KEY constraint failed`),
+	regexp.MustCompile(`violates foreign key constraint`),
+}
+
+// messageErrorCode classifies a database error by its message, for errors whose
+// driver type is not recognized.
+//
+// messageErrorCode 根据消息对数据库错误分类，用于无法识别驱动类型的错误。
+func messageErrorCode(errStr string) string {
+	switch {
+	case containsAny(errStr, []string{"duplicate key", "Duplicate entry", "unique constraint", "UNIQUE constraint failed"}):
+		return "DUPLICATE_KEY"
+	case matchesAny(foreignKeyPatterns, errStr):
+		return "FK_VIOLATION"
+	case containsAny(errStr, []string{"column", "does not exist", "Unknown column"}):
+		return "INVALID_COLUMN"
+	case containsAny(errStr, []string{"table", "does not exist", "doesn't exist"}):
+		return "TABLE_NOT_FOUND"
+	case containsAny(errStr, []string{"syntax error", "near"}):
+		return "SYNTAX_ERROR"
+	case containsAny(errStr, []string{"timeout", "deadline exceeded"}):
+		return "TIMEOUT"
+	case containsAny(errStr, []string{"connection refused", "no connection"}):
+		return "CONNECTION_ERROR"
+	}
+	return "SQL_ERROR"
+}
+
+// matchesAny reports whether any of patterns matches s.
+// matchesAny 报告 patterns 中是否有任一模式匹配 s。
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlErrorSuggestions are the suggestions handleSQLError gives for each error code.
+// sqlErrorSuggestions 是 handleSQLError 为各错误代码给出的建议。
+var sqlErrorSuggestions = map[string]string{
+	"DUPLICATE_KEY":    "使用 UPDATE 而不是 INSERT，或检查唯一约束 / Use UPDATE instead of INSERT, or check unique constraint",
+	"FK_VIOLATION":     "确保引用的记录存在 / Ensure the referenced record exists",
+	"INVALID_COLUMN":   "检查列名拼写 / Check column name spelling",
+	"TABLE_NOT_FOUND":  "运行 db.AutoSync() 创建表 / Run db.AutoSync() to create table",
+	"SYNTAX_ERROR":     "检查 JQL 语法 / Check JQL syntax",
+	"TIMEOUT":          "增加超时时间或优化查询 / Increase timeout or optimize query",
+	"CONNECTION_ERROR": "检查数据库连接 / Check database connection",
+}
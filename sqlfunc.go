@@ -0,0 +1,193 @@
+package goorm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sqlFuncPlaceholder matches an argument placeholder such as {0}, optionally
+// wrapped in single quotes ('{0}').
+// sqlFuncPlaceholder 匹配参数占位符（例如 {0}），可以用单引号包裹（'{0}'）。
+var sqlFuncPlaceholder = regexp.MustCompile(`'\{(\d+)\}'|\{(\d+)\}`)
+
+// sqlFuncName matches the names a SQL function can be registered and called under.
+// sqlFuncName 匹配 SQL 函数可注册和调用的名称。
+var sqlFuncName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqlFuncPart is a literal piece of a function template or an argument placeholder.
+// sqlFuncPart 是函数模板中的字面片段或参数占位符。
+type sqlFuncPart struct {
+	literal string
+	arg     int
+	bind    bool
+}
+
+// sqlFunc is a registered SQL function template.
+// sqlFunc 是已注册的 SQL 函数模板。
+type sqlFunc struct {
+	parts []sqlFuncPart
+	arity int
+}
+
+// sqlFuncRegistry holds the SQL functions registered with RegisterSQLFunc.
+// sqlFuncRegistry 保存通过 RegisterSQLFunc 注册的 SQL 函数。
+type sqlFuncRegistry struct {
+	mu    sync.RWMutex
+	funcs map[string]*sqlFunc
+}
+
+// newSQLFuncRegistry creates an empty function registry.
+// newSQLFuncRegistry 创建空的函数注册表。
+func newSQLFuncRegistry() *sqlFuncRegistry {
+	return &sqlFuncRegistry{funcs: make(map[string]*sqlFunc)}
+}
+
+// get returns the function registered under name, ignoring case.
+// get 返回以 name 注册的函数（忽略大小写）。
+func (r *sqlFuncRegistry) get(name string) (*sqlFunc, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.funcs[strings.ToLower(name)]
+	return fn, ok
+}
+
+// RegisterSQLFunc registers a SQL function template that select and having
+// entries can call by name with "args". In the template, {N} is replaced by
+// argument N: string arguments are quoted as identifiers and other values are
+// bound as parameters. '{N}' always binds argument N as a parameter.
+//
+// RegisterSQLFunc 注册 SQL 函数模板，select 和 having 条目可以通过名称并使用 "args" 调用。
+// 模板中的 {N} 会被替换为第 N 个参数：字符串参数作为标识符加引号，其他值作为参数绑定。
+// '{N}' 总是将第 N 个参数作为参数绑定。
+//
+// Example / 示例:
+//
+//	db.RegisterSQLFunc("date_trunc", "date_trunc('{0}', {1})")
+//	db.RegisterSQLFunc("coalesce", "COALESCE({0}, {1})")
+//
+//	{"action": "aggregate", "table": "orders",
+//	 "select": [{"fn": "date_trunc", "args": ["day", "created_at"], "as": "day"},
+//	            {"fn": "count", "as": "orders"}],
+//	 "group_by": ["day"]}
+func (db *DB) RegisterSQLFunc(name, template string) error {
+	if name == "" {
+		return fmt.Errorf("SQL function name is required")
+	}
+	if !sqlFuncName.MatchString(name) {
+		return fmt.Errorf("invalid SQL function name %q: use letters, digits and underscores", name)
+	}
+	fn, err := parseSQLFunc(template)
+	if err != nil {
+		return fmt.Errorf("SQL function %s: %w", name, err)
+	}
+
+	db.mu.Lock()
+	if db.sqlFuncs == nil {
+		db.sqlFuncs = newSQLFuncRegistry()
+	}
+	funcs := db.sqlFuncs
+	db.mu.Unlock()
+
+	funcs.mu.Lock()
+	funcs.funcs[strings.ToLower(name)] = fn
+	funcs.mu.Unlock()
+
+	// Templates built with the previous definition are stale
+	// 使用旧定义构建的模板已过期
+	db.templates.reset()
+	return nil
+}
+
+// newBuilder returns a SQL builder for query that can render the registered SQL
+// functions and qualifies tables with the schema of ctx.
+// newBuilder 返回 query 的 SQL 构建器，它可以生成已注册的 SQL 函数，并以 ctx 中的 schema 限定表名。
+func (db *DB) newBuilder(ctx context.Context, query *Query) *SQLBuilder {
+	db.mu.RLock()
+	funcs := db.sqlFuncs
+	maxInValues := db.config.MaxInValues
+	db.mu.RUnlock()
+	return NewSQLBuilder(db.dialect, query).withFuncs(funcs).withSchema(SchemaFromContext(ctx)).withMaxInValues(maxInValues).withLocation(db.location()).withTemplates(db.templates)
+}
+
+// parseSQLFunc splits a template into literal text and argument placeholders.
+// parseSQLFunc 将模板拆分为字面文本和参数占位符。
+func parseSQLFunc(template string) (*sqlFunc, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("template is empty")
+	}
+
+	fn := &sqlFunc{}
+	last := 0
+	for _, m := range sqlFuncPlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		if m[0] > last {
+			fn.parts = append(fn.parts, sqlFuncPart{literal: template[last:m[0]], arg: -1})
+		}
+
+		// Group 1 is the quoted form '{N}', group 2 the bare {N}
+		// 分组 1 是带引号的 '{N}'，分组 2 是不带引号的 {N}
+		part := sqlFuncPart{bind: m[2] >= 0}
+		var digits string
+		if part.bind {
+			digits = template[m[2]:m[3]]
+		} else {
+			digits = template[m[4]:m[5]]
+		}
+		part.arg, _ = strconv.Atoi(digits)
+		if part.arg+1 > fn.arity {
+			fn.arity = part.arg + 1
+		}
+
+		fn.parts = append(fn.parts, part)
+		last = m[1]
+	}
+	if last < len(template) {
+		fn.parts = append(fn.parts, sqlFuncPart{literal: template[last:], arg: -1})
+	}
+	return fn, nil
+}
+
+// quotesArg reports whether a string argument i is written as a quoted identifier.
+// quotesArg 判断字符串参数 i 是否作为带引号的标识符写入。
+func (fn *sqlFunc) quotesArg(i int) bool {
+	for _, part := range fn.parts {
+		if part.arg == i && !part.bind {
+			return true
+		}
+	}
+	return false
+}
+
+// render writes the function call for args, binding values through b.
+// render 为 args 生成函数调用，并通过 b 绑定值。
+func (fn *sqlFunc) render(b *SQLBuilder, name string, args []any) (string, error) {
+	if len(args) < fn.arity {
+		return "", fmt.Errorf("SQL function %s expects %d arguments, got %d", name, fn.arity, len(args))
+	}
+
+	var sb strings.Builder
+	for _, part := range fn.parts {
+		if part.arg < 0 {
+			sb.WriteString(part.literal)
+			continue
+		}
+
+		arg := args[part.arg]
+		s, isString := arg.(string)
+		switch {
+		case part.bind || !isString:
+			sb.WriteString(b.addParam(arg))
+		case s == "*":
+			sb.WriteString(s)
+		default:
+			sb.WriteString(b.quoteColumn(s))
+		}
+	}
+	return sb.String(), nil
+}
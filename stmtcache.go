@@ -0,0 +1,308 @@
+package goorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// DefaultStatementCacheSize is the number of prepared statements kept when
+// Config.PrepareStatements is on and Config.StatementCacheSize is not set.
+//
+// DefaultStatementCacheSize 是启用 Config.PrepareStatements 且未设置
+// Config.StatementCacheSize 时保留的预处理语句数量。
+const DefaultStatementCacheSize = 256
+
+// stmtKey identifies a prepared statement by its pool and SQL text.
+// stmtKey 以连接池和 SQL 文本标识预处理语句。
+type stmtKey struct {
+	pool *sql.DB
+	sql  string
+}
+
+// stmtEntry is a cached statement. refs counts calls using it, so an evicted
+// statement is closed only once the last call returns.
+//
+// stmtEntry 是缓存的语句。refs 记录正在使用它的调用数，因此被淘汰的语句只在最后一个调用返回后关闭。
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtCache is an LRU of prepared statements keyed by the generated SQL, not
+// its parameters. A nil *stmtCache disables caching.
+//
+// stmtCache 是按生成的 SQL（而非参数）索引的预处理语句 LRU。nil *stmtCache 表示禁用缓存。
+type stmtCache struct {
+	mu     sync.Mutex
+	size   int
+	lru    *list.List
+	items  map[stmtKey]*list.Element
+	closed bool
+}
+
+// newStmtCache creates a statement cache holding up to size statements.
+// newStmtCache 创建最多保存 size 条语句的语句缓存。
+func newStmtCache(size int) *stmtCache {
+	if size <= 0 {
+		size = DefaultStatementCacheSize
+	}
+	return &stmtCache{
+		size:  size,
+		lru:   list.New(),
+		items: make(map[stmtKey]*list.Element),
+	}
+}
+
+// acquire returns the prepared statement for query on pool, preparing it on a
+// miss. It returns nil when caching is off or preparing fails, in which case the
+// caller runs the SQL directly. Every non-nil entry must be released.
+//
+// acquire 返回 pool 上 query 的预处理语句，未命中时进行预处理。缓存关闭或预处理失败时返回 nil，
+// 此时调用方直接执行 SQL。每个非 nil 条目都必须调用 release。
+func (c *stmtCache) acquire(ctx context.Context, pool *sql.DB, query string) *stmtEntry {
+	if c == nil {
+		return nil
+	}
+	key := stmtKey{pool: pool, sql: query}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	if elem, ok := c.items[key]; ok {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		return entry
+	}
+	c.mu.Unlock()
+
+	// Prepare without holding the lock so other statements are not blocked
+	// 不持有锁进行预处理，以免阻塞其他语句
+	stmt, err := pool.PrepareContext(ctx, query)
+	if err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		stmt.Close()
+		return nil
+	}
+	if elem, ok := c.items[key]; ok {
+		// Prepared concurrently by another call
+		// 已被另一个调用并发预处理
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*stmtEntry)
+		entry.refs++
+		c.mu.Unlock()
+		stmt.Close()
+		return entry
+	}
+
+	entry := &stmtEntry{key: key, stmt: stmt, refs: 1}
+	c.items[key] = c.lru.PushFront(entry)
+	var stale []*sql.Stmt
+	for c.lru.Len() > c.size {
+		if s := c.evict(c.lru.Back()); s != nil {
+			stale = append(stale, s)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range stale {
+		s.Close()
+	}
+	return entry
+}
+
+// release marks a call using entry as done, closing the statement if it was evicted meanwhile.
+// release 标记使用 entry 的调用已结束，若语句已在此期间被淘汰则将其关闭。
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	entry.refs--
+	closeNow := entry.evicted && entry.refs == 0
+	c.mu.Unlock()
+
+	if closeNow {
+		entry.stmt.Close()
+	}
+}
+
+// evict removes elem from the cache and returns its statement if no call is using it.
+// The caller holds c.mu and closes the returned statement after unlocking.
+//
+// evict 从缓存中移除 elem，若没有调用正在使用则返回其语句。调用方持有 c.mu，并在解锁后关闭返回的语句。
+func (c *stmtCache) evict(elem *list.Element) *sql.Stmt {
+	entry := c.lru.Remove(elem).(*stmtEntry)
+	delete(c.items, entry.key)
+	entry.evicted = true
+	if entry.refs > 0 {
+		return nil
+	}
+	return entry.stmt
+}
+
+// close closes every cached statement; statements still in use close when released.
+// close 关闭所有缓存的语句；仍在使用的语句在释放时关闭。
+func (c *stmtCache) close() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.closed = true
+	var stale []*sql.Stmt
+	for c.lru.Len() > 0 {
+		if s := c.evict(c.lru.Back()); s != nil {
+			stale = append(stale, s)
+		}
+	}
+	c.mu.Unlock()
+
+	var first error
+	for _, s := range stale {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// len returns the number of cached statements.
+// len 返回缓存的语句数量。
+func (c *stmtCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// queryContext runs a query on pool through the statement cache.
+// queryContext 通过语句缓存在 pool 上执行查询。
+func (db *DB) queryContext(ctx context.Context, pool *sql.DB, query string, args ...any) (queryRows, error) {
+	query, setup := db.serverTimeout(ctx, query, false)
+	traceStatement(ctx, query)
+	if setup != "" {
+		tx, err := timedTx(ctx, pool, setup)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		// Ending the transaction cancels the rows, so it ends when the caller closes them
+		// 结束事务会取消这些行，因此在调用方关闭它们时才结束事务
+		return &timedRows{Rows: rows, tx: tx}, nil
+	}
+	var rows *sql.Rows
+	var err error
+	if entry := db.stmts.acquire(ctx, pool, query); entry != nil {
+		defer db.stmts.release(entry)
+		rows, err = entry.stmt.QueryContext(ctx, args...)
+	} else {
+		rows, err = pool.QueryContext(ctx, query, args...)
+	}
+	if err != nil {
+		// A nil *sql.Rows would make a non-nil queryRows
+		// nil 的 *sql.Rows 会成为非 nil 的 queryRows
+		return nil, err
+	}
+	return rows, nil
+}
+
+// queryRowContext runs a single-row query on pool through the statement cache.
+// queryRowContext 通过语句缓存在 pool 上执行单行查询。
+func (db *DB) queryRowContext(ctx context.Context, pool *sql.DB, query string, args ...any) rowScanner {
+	query, setup := db.serverTimeout(ctx, query, false)
+	traceStatement(ctx, query)
+	if setup != "" {
+		tx, err := timedTx(ctx, pool, setup)
+		if err != nil {
+			return errRow{err}
+		}
+		return timedRow{row: tx.QueryRowContext(ctx, query, args...), tx: tx}
+	}
+	if entry := db.stmts.acquire(ctx, pool, query); entry != nil {
+		defer db.stmts.release(entry)
+		return entry.stmt.QueryRowContext(ctx, args...)
+	}
+	return pool.QueryRowContext(ctx, query, args...)
+}
+
+// execContext executes a statement on pool through the statement cache.
+// execContext 通过语句缓存在 pool 上执行语句。
+func (db *DB) execContext(ctx context.Context, pool *sql.DB, query string, args ...any) (sql.Result, error) {
+	query, setup := db.serverTimeout(ctx, query, false)
+	traceStatement(ctx, query)
+	if setup != "" {
+		tx, err := timedTx(ctx, pool, setup)
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		return result, tx.Commit()
+	}
+	if entry := db.stmts.acquire(ctx, pool, query); entry != nil {
+		defer db.stmts.release(entry)
+		return entry.stmt.ExecContext(ctx, args...)
+	}
+	return pool.ExecContext(ctx, query, args...)
+}
+
+// queryContext runs a query in the transaction, reusing the cached statement of the primary.
+// queryContext 在事务中执行查询，复用主库的缓存语句。
+func (t *Transaction) queryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query, err := t.serverTimeout(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	traceStatement(ctx, query)
+	if entry := t.db.stmts.acquire(ctx, t.db.sqlDB, query); entry != nil {
+		defer t.db.stmts.release(entry)
+		return t.tx.StmtContext(ctx, entry.stmt).QueryContext(ctx, args...)
+	}
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// queryRowContext runs a single-row query in the transaction, reusing the cached statement of the primary.
+// queryRowContext 在事务中执行单行查询，复用主库的缓存语句。
+func (t *Transaction) queryRowContext(ctx context.Context, query string, args ...any) rowScanner {
+	query, err := t.serverTimeout(ctx, query)
+	if err != nil {
+		return errRow{err}
+	}
+	traceStatement(ctx, query)
+	if entry := t.db.stmts.acquire(ctx, t.db.sqlDB, query); entry != nil {
+		defer t.db.stmts.release(entry)
+		return t.tx.StmtContext(ctx, entry.stmt).QueryRowContext(ctx, args...)
+	}
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+// execContext executes a statement in the transaction, reusing the cached statement of the primary.
+// execContext 在事务中执行语句，复用主库的缓存语句。
+func (t *Transaction) execContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query, err := t.serverTimeout(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	traceStatement(ctx, query)
+	if entry := t.db.stmts.acquire(ctx, t.db.sqlDB, query); entry != nil {
+		defer t.db.stmts.release(entry)
+		return t.tx.StmtContext(ctx, entry.stmt).ExecContext(ctx, args...)
+	}
+	return t.tx.ExecContext(ctx, query, args...)
+}
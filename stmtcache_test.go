@@ -0,0 +1,104 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+// TestStatementCache tests that identical SQL is prepared once and evicted statements are closed.
+// TestStatementCache 测试相同的 SQL 只预处理一次，且被淘汰的语句会被关闭。
+func TestStatementCache(t *testing.T) {
+	db, backend := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+	db.stmts = newStmtCache(2)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		result := db.ExecuteQuery(ctx, &Query{
+			Table:  "events",
+			Action: ActionCreate,
+			Data:   map[string]any{"name": fmt.Sprintf("event %d", i), "kind": "click", "source": "web"},
+		})
+		if !result.Success {
+			t.Fatalf("create failed: %+v", result.Error)
+		}
+	}
+	if prepared, _ := backend.Statements(); prepared != 1 {
+		t.Errorf("identical inserts should be prepared once, got %d", prepared)
+	}
+	if n := len(backend.Queries()); n != 5 {
+		t.Errorf("expected 5 executions, got %d", n)
+	}
+
+	for _, table := range []string{"users", "orders"} {
+		if result := db.ExecuteQuery(ctx, &Query{Table: table, Action: ActionFind}); !result.Success {
+			t.Fatalf("find failed: %+v", result.Error)
+		}
+	}
+	if n := db.stmts.len(); n != 2 {
+		t.Errorf("cache should hold 2 statements, got %d", n)
+	}
+	if _, closed := backend.Statements(); closed != 1 {
+		t.Errorf("the least recently used statement should be closed, got %d closed", closed)
+	}
+
+	tx := db.ExecuteQuery(ctx, &Query{
+		Action: ActionTransaction,
+		Operations: []Query{
+			{Table: "users", Action: ActionFind},
+			{Table: "users", Action: ActionUpdate, Where: []Condition{{Field: "id", Op: OpEqual, Value: 1}}, Data: map[string]any{"name": "x"}},
+		},
+	})
+	if !tx.Success {
+		t.Fatalf("transaction failed: %+v", tx.Error)
+	}
+
+	if err := db.stmts.close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.stmts.len(); n != 0 {
+		t.Errorf("close should empty the cache, got %d", n)
+	}
+	prepared, _ := backend.Statements()
+	if result := db.ExecuteQuery(ctx, &Query{Table: "users", Action: ActionFind}); !result.Success {
+		t.Fatalf("find after close failed: %+v", result.Error)
+	}
+	if after, _ := backend.Statements(); after != prepared {
+		t.Error("a closed cache should run SQL without preparing")
+	}
+}
+
+// BenchmarkStatementCache measures the client-side cost of identical-shape inserts
+// with and without the statement cache. The fake driver has no parse cost, so this
+// isolates the overhead of the cache; the server-side saving needs a real database
+// (see BenchmarkCreatePrepared in the benchmark package).
+//
+// BenchmarkStatementCache 测量在启用和不启用语句缓存时相同结构插入的客户端开销。
+// 假驱动没有解析成本，因此这里只衡量缓存本身的开销；服务端的节省需要真实数据库
+// （见 benchmark 包中的 BenchmarkCreatePrepared）。
+func BenchmarkStatementCache(b *testing.B) {
+	for _, prepare := range []bool{false, true} {
+		b.Run(fmt.Sprintf("prepare=%v", prepare), func(b *testing.B) {
+			db, _ := newFakeDB(b, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+				return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+			})
+			if prepare {
+				db.stmts = newStmtCache(0)
+			}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				db.ExecuteQuery(ctx, &Query{
+					Table:  "events",
+					Action: ActionCreate,
+					Data:   map[string]any{"name": "event"},
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package goorm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// RowIterator iterates over a query's rows without buffering them.
+// Always call Close when done.
+//
+// RowIterator 逐行遍历查询结果而不进行缓冲。
+// 使用完毕后务必调用 Close。
+//
+// Example / 示例:
+//
+//	it, err := db.Stream(ctx, &goorm.Query{Table: "events"})
+//	if err != nil {
+//	    return err
+//	}
+//	defer it.Close()
+//
+//	for it.Next() {
+//	    var e Event
+//	    if err := it.Scan(&e); err != nil {
+//	        return err
+//	    }
+//	}
+//	return it.Err()
+type RowIterator struct {
+	rows    queryRows
+	columns []string
+	values  []any
+	naming  NamingConfig
+	err     error
+
+	// release ends the stream's inflight registration on Close.
+	// release 在 Close 时结束流的 inflight 登记。
+	release func()
+}
+
+// Stream executes a find query and returns an iterator over its rows.
+// Rows are read from the connection as the caller advances, so memory stays
+// bounded regardless of the result size. before_find hooks run; after_find hooks do not.
+//
+// Stream 执行查找查询并返回行迭代器。
+// 行随调用方推进从连接中读取，因此无论结果多大内存都保持有界。会运行 before_find 钩子，但不运行 after_find 钩子。
+func (db *DB) Stream(ctx context.Context, query *Query) (it *RowIterator, err error) {
+	// The stream stays in flight until it is closed, so Shutdown waits for it
+	// 流在关闭前一直处于运行中，因此 Shutdown 会等待它
+	if !db.inflight.enter() {
+		return nil, shuttingDownResult().Err()
+	}
+	defer func() {
+		if it == nil || it.rows == nil {
+			db.inflight.leave()
+		}
+	}()
+
+	q := *query
+	q.Action = ActionFind
+	if err := db.validate(&q); err != nil {
+		return nil, err
+	}
+
+	if r := db.runHooks(ctx, HookBeforeFind, &q, nil); r != nil {
+		if err := r.Err(); err != nil {
+			return nil, err
+		}
+		// Skipped by a hook: iterate over nothing
+		// 被钩子跳过：不遍历任何行
+		return &RowIterator{}, nil
+	}
+
+	buildResult, err := db.newBuilder(ctx, db.checkOrderPriority(db.withDefaultOrder(&q))).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	rows, err := db.queryContext(ctx, db.reader(ctx, &q), buildResult.SQL, buildResult.Params...)
+	db.logQuery(buildResult, startTime, err)
+	if err != nil {
+		return nil, NewExecutor(db).handleSQLError(err, buildResult).Err()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	return &RowIterator{
+		rows:    rows,
+		columns: columns,
+		values:  make([]any, len(columns)),
+		naming:  db.naming(),
+		release: db.inflight.leave,
+	}, nil
+}
+
+// ExportJSONL streams the rows of a find query to w as JSON Lines, one object per
+// line, and returns the number of rows written. Rows are written as they are read,
+// so memory stays bounded. JSON columns are embedded as JSON, and sensitive fields
+// are masked when Config.Security.MaskSensitive is set.
+//
+// ExportJSONL 以 JSON Lines 格式（每行一个对象）将查找查询的行流式写入 w，并返回写入的行数。
+// 行在读取时即被写出，因此内存保持有界。JSON 列以 JSON 嵌入，设置 Config.Security.MaskSensitive 时敏感字段会被脱敏。
+func (db *DB) ExportJSONL(ctx context.Context, query *Query, w io.Writer) (int64, error) {
+	it, err := db.Stream(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+
+	masks := db.sensitiveColumns(query.Table)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	var n int64
+	for it.Next() {
+		rows := []map[string]any{it.Map()}
+		db.decodeJSONColumns(query.Table, rows)
+		db.decodeArrayColumns(query.Table, rows)
+		db.parseTimeColumns(query.Table, rows)
+		maskRow(rows[0], masks)
+		if err := enc.Encode(rows[0]); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, it.Err()
+}
+
+// Next advances to the next row, returning false at the end or on error.
+// Next 前进到下一行，到达末尾或出错时返回 false。
+func (it *RowIterator) Next() bool {
+	if it.rows == nil || it.err != nil {
+		return false
+	}
+	if !it.rows.Next() {
+		return false
+	}
+
+	ptrs := make([]any, len(it.values))
+	for i := range it.values {
+		ptrs[i] = &it.values[i]
+	}
+	if err := it.rows.Scan(ptrs...); err != nil {
+		it.err = err
+		return false
+	}
+	return true
+}
+
+// Columns returns the column names of the result.
+// Columns 返回结果的列名。
+func (it *RowIterator) Columns() []string {
+	return it.columns
+}
+
+// Map returns the current row as a map of column name to value.
+// Map 以列名到值的映射返回当前行。
+func (it *RowIterator) Map() map[string]any {
+	row := make(map[string]any, len(it.columns))
+	for i, col := range it.columns {
+		val := it.values[i]
+		// Handle []byte conversion to string
+		// 处理 []byte 到 string 的转换
+		if b, ok := val.([]byte); ok {
+			val = string(b)
+		}
+		row[col] = val
+	}
+	return row
+}
+
+// Scan copies the current row into dest, which must be a *map[string]any
+// or a pointer to a struct. Struct fields are matched by column name; NULL
+// leaves pointer fields nil and sets other fields to their zero value.
+//
+// Scan 将当前行复制到 dest，dest 必须是 *map[string]any 或结构体指针。结构体字段按列名匹配；
+// NULL 会使指针字段保持为 nil，其他字段设为零值。
+func (it *RowIterator) Scan(dest any) error {
+	if m, ok := dest.(*map[string]any); ok {
+		*m = it.Map()
+		return nil
+	}
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("scan destination must be a non-nil pointer to a struct or map, got %T", dest)
+	}
+
+	// Rescan the row through NULL-safe destinations typed after the struct fields
+	// 通过按结构体字段类型确定的 NULL 安全目标重新扫描当前行
+	fields := columnFields(v.Elem().Type(), it.naming)
+	types := make(map[string]reflect.Type, len(fields))
+	for col, index := range fields {
+		types[col] = v.Elem().Type().FieldByIndex(index).Type
+	}
+	dests := nullSafeDests(it.columns, types)
+	if err := it.rows.Scan(dests...); err != nil {
+		return err
+	}
+
+	for i, val := range nullSafeValues(dests, nil) {
+		col := it.columns[i]
+		index, ok := fields[col]
+		if !ok {
+			continue
+		}
+		if err := assignValue(v.Elem().FieldByIndex(index), val); err != nil {
+			return fmt.Errorf("column %q: %w", col, err)
+		}
+	}
+	return nil
+}
+
+// Err returns the error, if any, encountered during iteration.
+// Err 返回迭代过程中遇到的错误（如果有）。
+func (it *RowIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if it.rows == nil {
+		return nil
+	}
+	return it.rows.Err()
+}
+
+// Close releases the underlying rows and connection.
+// Close 释放底层的行和连接。
+func (it *RowIterator) Close() error {
+	if it.rows == nil {
+		return nil
+	}
+	if it.release != nil {
+		defer it.release()
+		it.release = nil
+	}
+	return it.rows.Close()
+}
+
+// columnFields maps column names to struct field indexes, following embedded structs.
+// columnFields 将列名映射到结构体字段索引，包括嵌入的结构体。
+func columnFields(t reflect.Type, naming NamingConfig) map[string][]int {
+	fields := make(map[string][]int)
+	var registry Registry
+
+	var walk func(t reflect.Type, prefix []int)
+	walk = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			index := append(append([]int(nil), prefix...), i)
+
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				walk(field.Type, index)
+				continue
+			}
+			if !field.IsExported() || field.Tag.Get("goorm") == "-" || field.Tag.Get("json") == "-" {
+				continue
+			}
+
+			// Only the column name is needed here
+			// 此处只需要列名
+			fm, _ := registry.parseField(field, naming)
+			fields[fm.ColumnName] = index
+		}
+	}
+	walk(t, nil)
+
+	return fields
+}
+
+// assignValue stores a database value into a struct field.
+// assignValue 将数据库值存入结构体字段。
+func assignValue(field reflect.Value, val any) error {
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(val)
+	}
+
+	if val == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := assignValue(ptr.Elem(), val); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	rv := reflect.ValueOf(val)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+	case field.Kind() == reflect.String:
+		if b, ok := val.([]byte); ok {
+			field.SetString(string(b))
+		} else {
+			field.SetString(fmt.Sprint(val))
+		}
+	case field.Kind() == reflect.Bool && rv.CanInt():
+		field.SetBool(rv.Int() != 0)
+	case rv.Type().ConvertibleTo(field.Type()) && rv.Kind() != reflect.String:
+		field.Set(rv.Convert(field.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to %s", val, field.Type())
+	}
+	return nil
+}
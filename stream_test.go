@@ -0,0 +1,249 @@
+package goorm
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type streamedUser struct {
+	Model
+	Name   string  `json:"name"`
+	Age    int     `json:"age"`
+	Active bool    `json:"active"`
+	Nick   *string `json:"nick"`
+}
+
+// TestStream tests iterating over rows without buffering.
+// TestStream 测试不缓冲地遍历行。
+func TestStream(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id", "name", "age", "active", "nick", "created_at"}, [][]driver.Value{
+			{int64(1), []byte("Alice"), int64(30), int64(1), "ally", created},
+			{int64(2), "Bob", int64(25), int64(0), nil, created},
+		}, nil
+	})
+
+	it, err := db.Stream(context.Background(), &Query{Table: "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var users []streamedUser
+	for it.Next() {
+		var u streamedUser
+		if err := it.Scan(&u); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+
+		if u.ID == 1 {
+			if row := it.Map(); row["name"] != "Alice" {
+				t.Errorf("Map should convert []byte to string, got %#v", row["name"])
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	alice := users[0]
+	if alice.ID != 1 || alice.Name != "Alice" || alice.Age != 30 || !alice.Active {
+		t.Errorf("unexpected first user: %+v", alice)
+	}
+	if alice.Nick == nil || *alice.Nick != "ally" || !alice.CreatedAt.Equal(created) {
+		t.Errorf("unexpected pointer/time fields: %+v", alice)
+	}
+	if users[1].Nick != nil || users[1].Active {
+		t.Errorf("unexpected second user: %+v", users[1])
+	}
+}
+
+// TestStreamErrors tests stream validation and scan destination errors.
+// TestStreamErrors 测试流式查询的验证和扫描目标错误。
+func TestStreamErrors(t *testing.T) {
+	db, _ := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id"}, [][]driver.Value{{int64(1)}}, nil
+	})
+
+	if _, err := db.Stream(context.Background(), &Query{}); err == nil {
+		t.Error("expected error for missing table")
+	}
+
+	it, err := db.Stream(context.Background(), &Query{Table: "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	if !it.Next() {
+		t.Fatal("expected a row")
+	}
+	var notPointer streamedUser
+	if err := it.Scan(notPointer); err == nil {
+		t.Error("expected error for non-pointer destination")
+	}
+}
+
+// TestStreamScanNulls tests NULL-safe scanning into typed struct fields.
+// TestStreamScanNulls 测试向有类型的结构体字段进行 NULL 安全扫描。
+func TestStreamScanNulls(t *testing.T) {
+	db, _ := newFakeDB(t, &MySQLDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id", "name", "age", "active", "nick"}, [][]driver.Value{
+			{[]byte("7"), nil, nil, nil, nil},
+			{[]byte("8"), []byte("Eve"), []byte("41"), []byte("1"), []byte("evie")},
+		}, nil
+	})
+
+	it, err := db.Stream(context.Background(), &Query{Table: "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var users []streamedUser
+	for it.Next() {
+		var u streamedUser
+		if err := it.Scan(&u); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(users))
+	}
+	if u := users[0]; u.ID != 7 || u.Name != "" || u.Age != 0 || u.Active || u.Nick != nil {
+		t.Errorf("NULL columns should leave zero values and nil pointers: %+v", u)
+	}
+	if u := users[1]; u.ID != 8 || u.Name != "Eve" || u.Age != 41 || !u.Active || u.Nick == nil || *u.Nick != "evie" {
+		t.Errorf("text columns should convert to field types: %+v", u)
+	}
+}
+
+type exportedContact struct {
+	ID     uint64 `json:"id" goorm:"primaryKey;autoIncrement"`
+	Name   string `json:"name"`
+	Phone  string `json:"phone" sensitive:"true" mask:"partial"`
+	IDCard string `json:"id_card" sensitive:"true" mask:"hide"`
+}
+
+// TestExportJSONL tests streaming rows out as JSON Lines.
+// TestExportJSONL 测试以 JSON Lines 格式流式导出行。
+func TestExportJSONL(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id", "name", "phone", "id_card"}, [][]driver.Value{
+			{int64(1), "Alice <a>", "13812348888", "110101199001011234"},
+			{int64(2), []byte("Bob"), nil, "x"},
+		}, nil
+	})
+	if err := db.Register(&exportedContact{}); err != nil {
+		t.Fatal(err)
+	}
+	db.config.Security.MaskSensitive = true
+
+	var buf bytes.Buffer
+	query := &Query{
+		Table:   "exported_contacts",
+		Select:  []any{"id", "name", "phone", "id_card"},
+		Where:   []Condition{{Field: "id", Op: OpGreater, Value: 0}},
+		OrderBy: []Order{{Field: "id", Desc: true}},
+	}
+	n, err := db.ExportJSONL(context.Background(), query, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 rows written, got %d", n)
+	}
+	sql := backend.Queries()[0]
+	if !strings.Contains(sql, "WHERE") || !strings.Contains(sql, "ORDER BY") {
+		t.Errorf("expected where and order to be applied, got %s", sql)
+	}
+
+	want := `{"id":1,"name":"Alice <a>","phone":"138****8888"}` + "\n" + `{"id":2,"name":"Bob","phone":null}` + "\n"
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+
+	db.config.Security.MaskSensitive = false
+	buf.Reset()
+	if _, err := db.ExportJSONL(context.Background(), query, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"phone":"13812348888"`) || !strings.Contains(buf.String(), `"id_card":"x"`) {
+		t.Errorf("expected unmasked rows, got %s", buf.String())
+	}
+}
+
+// TestMaskPartial tests partial masking of sensitive values.
+// TestMaskPartial 测试敏感值的部分脱敏。
+func TestMaskPartial(t *testing.T) {
+	tests := map[string]string{
+		"13812348888": "138****8888",
+		"1234567":     "*******",
+		"张三丰的手机号码":    "张三丰*手机号码",
+	}
+	for in, want := range tests {
+		if got := maskPartial(in); got != want {
+			t.Errorf("maskPartial(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestFindScanNulls tests that find results of a registered model read NULL as nil
+// only for nullable fields and convert text values to the field types.
+// TestFindScanNulls 测试已注册模型的查询结果只对可为 NULL 的字段将 NULL 读取为 nil，并将文本值转换为字段类型。
+func TestFindScanNulls(t *testing.T) {
+	db, _ := newFakeDB(t, &MySQLDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return []string{"id", "name", "age", "active", "nick", "total"}, [][]driver.Value{
+			{[]byte("7"), nil, nil, nil, nil, nil},
+			{[]byte("8"), []byte("Eve"), []byte("41"), []byte("1"), []byte("evie"), []byte("3")},
+		}, nil
+	})
+	if err := db.Register(&streamedUser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := db.ExecuteQuery(context.Background(), &Query{Table: "streamed_users", Action: ActionFind})
+	if !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+	first, second := result.Data[0], result.Data[1]
+	if first["name"] != "" || first["age"] != int64(0) || first["active"] != false {
+		t.Errorf("NULL in fields that are not nullable should read as zero values: %v", first)
+	}
+	if first["nick"] != nil || first["total"] != nil {
+		t.Errorf("NULL in nullable fields and other columns should read as nil: %v", first)
+	}
+	if second["id"] != uint64(8) || second["age"] != int64(41) || second["active"] != true || second["nick"] != "evie" {
+		t.Errorf("text values should convert to the field types: %v", second)
+	}
+	if second["total"] != "3" {
+		t.Errorf("columns that are not fields should keep the driver's value: %v", second)
+	}
+
+	// Finds in a transaction scan the same way
+	// 事务中的查询以相同方式扫描
+	tx := db.ExecuteQuery(context.Background(), &Query{Action: ActionTransaction, Operations: []Query{
+		{Table: "streamed_users", Action: ActionFind},
+	}})
+	if !tx.Success {
+		t.Fatalf("transaction failed: %+v", tx.Error)
+	}
+	if got := tx.Results[0].Data; !reflect.DeepEqual(got, result.Data) {
+		t.Errorf("transaction find scanned\n%v\nwant\n%v", got, result.Data)
+	}
+}
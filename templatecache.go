@@ -0,0 +1,409 @@
+package goorm
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultSQLTemplateCacheSize is the number of query shapes kept when
+// Config.CacheSQLTemplates is on and Config.SQLTemplateCacheSize is not set.
+//
+// DefaultSQLTemplateCacheSize 是启用 Config.CacheSQLTemplates 且未设置
+// Config.SQLTemplateCacheSize 时保留的查询结构数量。
+const DefaultSQLTemplateCacheSize = 256
+
+// paramSlot stands for the value numbered n in a query's shape while its template
+// is built. Its String form is a marker no SQL contains, so a value written into the
+// SQL rather than bound shows in the template.
+//
+// paramSlot 在构建查询模板时代表查询结构中编号为 n 的值。其 String 形式是任何 SQL 都不包含的标记，
+// 因此被写入 SQL 而非绑定的值会在模板中显现。
+type paramSlot int
+
+// paramSlotMarker starts the String form of every paramSlot.
+// paramSlotMarker 是每个 paramSlot 的 String 形式的开头。
+const paramSlotMarker = "\x00goorm-param-"
+
+// String returns the slot's marker.
+// String 返回占位的标记。
+func (s paramSlot) String() string {
+	return fmt.Sprintf("%s%d\x00", paramSlotMarker, int(s))
+}
+
+// sqlTemplate is the SQL of a query shape and the params it binds: a paramSlot
+// for each value of the query, and anything else as is. An empty sql marks a shape
+// whose SQL depends on its values, which is built every time.
+//
+// sqlTemplate 是查询结构的 SQL 及其绑定的参数：查询中的每个值为一个 paramSlot，其他参数保持原样。
+// sql 为空表示该结构的 SQL 依赖于其值，每次都需要重新构建。
+type sqlTemplate struct {
+	sql    string
+	params []any
+}
+
+// templateEntry is a cached template and its key.
+// templateEntry 是缓存的模板及其键。
+type templateEntry struct {
+	key  string
+	tmpl *sqlTemplate
+}
+
+// templateCache is an LRU of SQL templates keyed by query shape: the query with
+// its bound values left out, plus the builder settings that change the SQL. A nil
+// *templateCache disables caching.
+//
+// templateCache 是按查询结构索引的 SQL 模板 LRU。查询结构即去掉绑定值的查询，加上会改变 SQL 的构建器设置。
+// nil *templateCache 表示禁用缓存。
+type templateCache struct {
+	mu    sync.Mutex
+	size  int
+	gen   int
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+// newTemplateCache creates a template cache holding up to size shapes.
+// newTemplateCache 创建最多保存 size 个结构的模板缓存。
+func newTemplateCache(size int) *templateCache {
+	if size <= 0 {
+		size = DefaultSQLTemplateCacheSize
+	}
+	return &templateCache{
+		size:  size,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// build returns the statement of b's query, building the template of its shape on
+// a miss and binding the query's values into it.
+// build 返回 b 的查询对应的语句：未命中时构建其结构的模板，然后将查询的值绑定到模板中。
+func (c *templateCache) build(b *SQLBuilder) (*BuildResult, error) {
+	s := &shaper{key: make([]byte, 0, 256)}
+	s.query(b.query)
+	s.str(b.dialect.Name())
+	s.str(b.schema)
+	s.str(b.primaryKey)
+	s.int(b.maxIn)
+
+	tmpl, gen := c.get(s.key)
+	if tmpl == nil {
+		shape := (&shaper{copy: true}).query(b.query)
+		tmpl = b.template(shape)
+		c.put(string(s.key), tmpl, gen)
+	}
+	if tmpl.sql == "" {
+		return b.build()
+	}
+
+	params := make([]any, len(tmpl.params))
+	for i, p := range tmpl.params {
+		if slot, ok := p.(paramSlot); ok {
+			p = b.paramValue(s.values[slot])
+		}
+		params[i] = p
+	}
+	return &BuildResult{SQL: tmpl.sql, Params: params, query: b.query}, nil
+}
+
+// template builds the template of shape with b's settings. The template has no SQL
+// when shape fails to build or a slot is written into the SQL or a bound value.
+// template 使用 b 的设置构建 shape 的模板。shape 构建失败，或占位被写入 SQL 或绑定值中时，模板没有 SQL。
+func (b *SQLBuilder) template(shape *Query) *sqlTemplate {
+	sb := *b
+	sb.query = shape
+	sb.params = make([]any, 0)
+	sb.paramN = 0
+	sb.templates = nil
+
+	result, err := sb.build()
+	if err != nil || strings.Contains(result.SQL, paramSlotMarker) {
+		return &sqlTemplate{}
+	}
+	for _, p := range result.Params {
+		if _, ok := p.(paramSlot); !ok && p != nil && strings.Contains(fmt.Sprint(p), paramSlotMarker) {
+			return &sqlTemplate{}
+		}
+	}
+	return &sqlTemplate{sql: result.SQL, params: result.Params}
+}
+
+// get returns the template cached under key, or nil, and the cache generation.
+// get 返回 key 下缓存的模板（或 nil）以及缓存的代数。
+func (c *templateCache) get(key []byte) (*sqlTemplate, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[string(key)]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*templateEntry).tmpl, c.gen
+	}
+	return nil, c.gen
+}
+
+// put caches tmpl under key unless the cache was reset since generation gen.
+// put 将 tmpl 缓存在 key 下，除非缓存在 gen 代之后已被重置。
+func (c *templateCache) put(key string, tmpl *sqlTemplate, gen int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if elem, ok := c.items[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.lru.PushFront(&templateEntry{key: key, tmpl: tmpl})
+	for c.lru.Len() > c.size {
+		entry := c.lru.Remove(c.lru.Back()).(*templateEntry)
+		delete(c.items, entry.key)
+	}
+}
+
+// reset drops every cached template.
+// reset 丢弃所有缓存的模板。
+func (c *templateCache) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.lru.Init()
+	clear(c.items)
+}
+
+// len returns the number of cached templates.
+// len 返回缓存的模板数量。
+func (c *templateCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// shaper walks a query, writing its shape to key and collecting the values it binds.
+// Everything the builder reads from a query goes into key except those values, of
+// which only the count of IN, NOT IN and BETWEEN lists and whether a value is nil
+// go in, as they change the SQL. Values are visited in a fixed order, so queries of
+// the same shape number theirs alike. With copy set, the walk also returns a copy of
+// the query with each value replaced by its paramSlot.
+//
+// shaper 遍历查询，将其结构写入 key 并收集其绑定的值。构建器从查询中读取的所有内容都会写入 key，
+// 但这些值除外：只写入 IN、NOT IN 和 BETWEEN 列表的数量以及值是否为 nil，因为它们会改变 SQL。
+// 值按固定顺序访问，因此相同结构的查询以相同方式编号。设置 copy 时，遍历还会返回查询的副本，
+// 其中每个值都被替换为其 paramSlot。
+type shaper struct {
+	key    []byte
+	values []any
+	copy   bool
+}
+
+// query walks q, returning its copy with copy set and nil otherwise.
+// query 遍历 q；设置了 copy 时返回其副本，否则返回 nil。
+func (s *shaper) query(q *Query) *Query {
+	shape := *q
+	s.str(q.Table)
+	s.str(string(q.Action))
+	shape.Where = s.conditions(q.Where)
+	shape.Data = s.data(q.Data)
+	shape.DataBatch = nil
+	if s.copy && q.DataBatch != nil {
+		shape.DataBatch = make([]map[string]any, len(q.DataBatch))
+	}
+	s.int(len(q.DataBatch))
+	for i, record := range q.DataBatch {
+		record = s.data(record)
+		if shape.DataBatch != nil {
+			shape.DataBatch[i] = record
+		}
+	}
+	s.verbatim(q.Select)
+	shape.OrderBy = nil
+	if s.copy && q.OrderBy != nil {
+		shape.OrderBy = make([]Order, len(q.OrderBy))
+	}
+	s.int(len(q.OrderBy))
+	for i, o := range q.OrderBy {
+		s.str(o.Field)
+		s.flag(o.Desc)
+		s.str(o.Nulls)
+		s.str(o.Collate)
+		o.Priority = s.list(o.Priority)
+		if shape.OrderBy != nil {
+			shape.OrderBy[i] = o
+		}
+	}
+	s.strs(q.GroupBy)
+	s.flag(q.Rollup)
+	shape.Having = nil
+	if s.copy && q.Having != nil {
+		shape.Having = make([]HavingCondition, len(q.Having))
+	}
+	s.int(len(q.Having))
+	for i, h := range q.Having {
+		s.str(h.Fn)
+		s.str(h.Field)
+		s.verbatim(h.Args)
+		s.str(string(h.Op))
+		h.Value = s.value(h.Value)
+		if shape.Having != nil {
+			shape.Having[i] = h
+		}
+	}
+	s.int(q.Limit)
+	s.int(q.Offset)
+	s.str(q.Lock)
+	s.strs(q.Returning)
+	s.str(q.OnConflict)
+	s.verbatim(q.Join)
+
+	if !s.copy {
+		return nil
+	}
+	shaped := shape
+	return &shaped
+}
+
+// conditions walks conditions, returning their copies with copy set.
+// conditions 遍历 conditions；设置了 copy 时返回其副本。
+func (s *shaper) conditions(conditions []Condition) []Condition {
+	s.int(len(conditions))
+	var shaped []Condition
+	if s.copy && conditions != nil {
+		shaped = make([]Condition, len(conditions))
+	}
+	for i, cond := range conditions {
+		s.str(cond.Field)
+		s.str(cond.Fn)
+		s.str(string(cond.Op))
+		s.str(cond.Collate)
+		s.flag(cond.Or)
+		s.str(cond.Ref)
+		switch cond.Op {
+		case OpIn, OpNotIn, OpBetween:
+			if list, ok := sliceValues(cond.Value); ok {
+				cond.Value = s.list(list)
+			} else {
+				s.verbatim(cond.Value)
+			}
+		case OpArrayContains:
+			// Bound as a single array literal, so the values stay in the shape
+			// 作为单个数组字面量绑定，因此值保留在结构中
+			s.verbatim(cond.Value)
+		default:
+			cond.Value = s.value(cond.Value)
+		}
+		s.flag(cond.Subquery != nil)
+		if cond.Subquery != nil {
+			cond.Subquery = s.query(cond.Subquery)
+		}
+		cond.And = s.conditions(cond.And)
+		cond.OrGroup = s.conditions(cond.OrGroup)
+		if shaped != nil {
+			shaped[i] = cond
+		}
+	}
+	return shaped
+}
+
+// data walks data in column order, returning its copy with copy set. Operator maps
+// such as {"$incr": 1} keep their keys.
+// data 按列顺序遍历 data；设置了 copy 时返回其副本。{"$incr": 1} 等运算符 map 保留其键。
+func (s *shaper) data(data map[string]any) map[string]any {
+	s.int(len(data))
+	var shaped map[string]any
+	if s.copy && data != nil {
+		shaped = make(map[string]any, len(data))
+	}
+	for _, col := range sortedColumns(data) {
+		s.str(col)
+		var v any
+		if m, ok := data[col].(map[string]any); ok {
+			s.key = append(s.key, '{')
+			v = s.data(m)
+		} else {
+			v = s.value(data[col])
+		}
+		if shaped != nil {
+			shaped[col] = v
+		}
+	}
+	return shaped
+}
+
+// list walks list, returning it with each value replaced by a slot with copy set.
+// list 遍历 list；设置了 copy 时返回将每个值替换为占位的 list。
+func (s *shaper) list(list []any) []any {
+	s.key = append(s.key, '[')
+	s.int(len(list))
+	var slotted []any
+	if s.copy && list != nil {
+		slotted = make([]any, len(list))
+	}
+	for i, v := range list {
+		v = s.value(v)
+		if slotted != nil {
+			slotted[i] = v
+		}
+	}
+	return slotted
+}
+
+// value collects v and returns its slot; nil is returned as is.
+// value 收集 v 并返回其占位；nil 原样返回。
+func (s *shaper) value(v any) any {
+	if v == nil {
+		s.key = append(s.key, 'n')
+		return nil
+	}
+	s.key = append(s.key, '?')
+	s.values = append(s.values, v)
+	return paramSlot(len(s.values) - 1)
+}
+
+// verbatim writes v itself, for parts of the query that go into the SQL as they are.
+// verbatim 写入 v 本身，用于原样进入 SQL 的查询部分。
+func (s *shaper) verbatim(v any) {
+	s.key = append(s.key, '=')
+	if rv := reflect.ValueOf(v); !rv.IsValid() || rv.Kind() == reflect.Slice && rv.Len() == 0 {
+		s.int(0)
+		return
+	}
+	s.str(fmt.Sprintf("%#v", v))
+}
+
+// strs writes every string of v.
+// strs 写入 v 中的每个字符串。
+func (s *shaper) strs(v []string) {
+	s.int(len(v))
+	for _, str := range v {
+		s.str(str)
+	}
+}
+
+// str writes v with its length, so no two sequences of strings write the same key.
+// str 写入 v 及其长度，使任何两个不同的字符串序列都不会写出相同的键。
+func (s *shaper) str(v string) {
+	s.int(len(v))
+	s.key = append(s.key, v...)
+}
+
+// int writes v.
+// int 写入 v。
+func (s *shaper) int(v int) {
+	s.key = strconv.AppendInt(s.key, int64(v), 10)
+	s.key = append(s.key, ':')
+}
+
+// flag writes v.
+// flag 写入 v。
+func (s *shaper) flag(v bool) {
+	if v {
+		s.key = append(s.key, 't')
+	} else {
+		s.key = append(s.key, 'f')
+	}
+}
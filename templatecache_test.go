@@ -0,0 +1,186 @@
+package goorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSQLTemplateCache tests that queries built through the template cache get the
+// same SQL and params as queries built directly.
+// TestSQLTemplateCache 测试通过模板缓存构建的查询与直接构建的查询得到相同的 SQL 和参数。
+func TestSQLTemplateCache(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	find := func(ids []any, name string, upper any) *Query {
+		return &Query{
+			Table:  "users",
+			Action: ActionFind,
+			Where: []Condition{
+				{Field: "id", Op: OpIn, Value: ids},
+				{Field: "name", Op: OpIEqual, Value: name},
+				{Field: "age", Op: OpBetween, Value: []any{18, upper}},
+				{OrGroup: []Condition{
+					{Field: "status", Op: OpEqual, Value: name},
+					{Field: "team_id", Op: OpIn, Subquery: &Query{
+						Table:  "teams",
+						Select: []any{"id"},
+						Where:  []Condition{{Field: "created_at", Op: OpGreater, Value: at}},
+					}},
+				}},
+			},
+			OrderBy: []Order{{Field: "status", Priority: []any{"active", name}}},
+			Limit:   10,
+		}
+	}
+
+	queries := []*Query{
+		find([]any{1, 2, 3}, "alice", 65),
+		find([]any{4, 5, 6}, "bob", 30),
+		find([]any{7, 8}, "carol", 30),
+		find([]any{1, 2, 3}, "dave", nil),
+		{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "alice", "created_at": at}},
+		{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "bob", "created_at": at.Add(time.Hour)}},
+		{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "alice", "meta": map[string]any{"a": 1}}},
+		{Table: "users", Action: ActionCreate, Data: map[string]any{"name": "bob", "meta": map[string]any{"a": 2}}},
+		{Table: "users", Action: ActionUpdate, Data: map[string]any{"visits": map[string]any{"$incr": 1}, "name": "x"},
+			Where: []Condition{{Field: "id", Op: OpEqual, Value: 1}}},
+		{Table: "users", Action: ActionUpdate, Data: map[string]any{"visits": map[string]any{"$incr": 5}, "name": "y"},
+			Where: []Condition{{Field: "id", Op: OpEqual, Value: 2}}},
+		{Table: "users", Action: ActionCreateBatch, DataBatch: []map[string]any{{"name": "a", "age": 1}, {"name": "b"}}},
+		{Table: "users", Action: ActionCreateBatch, DataBatch: []map[string]any{{"name": "c", "age": 2}, {"name": "d"}}},
+		{Table: "users", Action: ActionAggregate, Select: []any{"team_id", map[string]any{"fn": "count", "as": "n"}},
+			GroupBy: []string{"team_id"}, Having: []HavingCondition{{Fn: "count", Op: OpGreater, Value: 5}}},
+		{Table: "users", Action: ActionAggregate, Select: []any{"team_id", map[string]any{"fn": "count", "as": "n"}},
+			GroupBy: []string{"team_id"}, Having: []HavingCondition{{Fn: "count", Op: OpGreater, Value: 9}}},
+		{Table: "posts", Action: ActionFind, Where: []Condition{{Field: "tags", Op: OpArrayContains, Value: []any{"go"}}}},
+		{Table: "posts", Action: ActionFind, Where: []Condition{{Field: "tags", Op: OpArrayContains, Value: []any{"sql"}}}},
+	}
+
+	db, _ := newFakeDB(t, &PostgresDialect{}, nil)
+	db.config.Location = time.FixedZone("UTC+8", 8*60*60)
+	db.templates = newTemplateCache(0)
+	ctx := context.Background()
+
+	for i, q := range queries {
+		want, wantErr := db.newBuilder(ctx, q).withTemplates(nil).Build()
+		for pass := 0; pass < 2; pass++ {
+			got, err := db.newBuilder(ctx, q).Build()
+			if (err != nil) != (wantErr != nil) {
+				t.Fatalf("query %d: error %v, want %v", i, err, wantErr)
+			}
+			if err != nil {
+				continue
+			}
+			if got.SQL != want.SQL {
+				t.Errorf("query %d pass %d:\n got %s\nwant %s", i, pass, got.SQL, want.SQL)
+			}
+			if !reflect.DeepEqual(got.Params, want.Params) {
+				t.Errorf("query %d pass %d: params %v, want %v", i, pass, got.Params, want.Params)
+			}
+		}
+	}
+
+	// find: 3 shapes (IN length and the null bound change the SQL); create: 2; update,
+	// batch, aggregate: 1 each; array_contains: 2, as its values are part of the shape
+	// find：3 个结构（IN 长度和 null 边界会改变 SQL）；create：2；update、batch、aggregate 各 1；
+	// array_contains：2，因为其值属于结构的一部分
+	if n := db.templates.len(); n != 10 {
+		t.Errorf("expected 10 cached shapes, got %d", n)
+	}
+}
+
+// TestSQLTemplateCacheReset tests that registering a SQL function drops templates
+// built with the previous definition.
+// TestSQLTemplateCacheReset 测试注册 SQL 函数会丢弃使用旧定义构建的模板。
+func TestSQLTemplateCacheReset(t *testing.T) {
+	db, _ := newFakeDB(t, &PostgresDialect{}, nil)
+	db.templates = newTemplateCache(0)
+	ctx := context.Background()
+
+	q := &Query{
+		Table:   "orders",
+		Action:  ActionAggregate,
+		Select:  []any{map[string]any{"fn": "bucket", "args": []any{"created_at"}, "as": "day"}},
+		GroupBy: []string{"day"},
+	}
+	if err := db.RegisterSQLFunc("bucket", "date_trunc('day', {0})"); err != nil {
+		t.Fatal(err)
+	}
+	first, err := db.newBuilder(ctx, q).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.RegisterSQLFunc("bucket", "date_trunc('week', {0})"); err != nil {
+		t.Fatal(err)
+	}
+	second, err := db.newBuilder(ctx, q).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.SQL == second.SQL {
+		t.Errorf("redefined function should change the SQL, got %s", second.SQL)
+	}
+}
+
+// BenchmarkSQLTemplateCache measures building a find query with and without the template cache.
+// BenchmarkSQLTemplateCache 测量使用和不使用模板缓存构建查询的开销。
+func BenchmarkSQLTemplateCache(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		b.Run(fmt.Sprintf("cached=%v", cached), func(b *testing.B) {
+			db, _ := newFakeDB(b, &PostgresDialect{}, nil)
+			if cached {
+				db.templates = newTemplateCache(0)
+			}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := db.newBuilder(ctx, &Query{
+					Table:  "users",
+					Action: ActionFind,
+					Where: []Condition{
+						{Field: "status", Op: OpEqual, Value: "active"},
+						{Field: "age", Op: OpBetween, Value: []any{18, i}},
+						{Field: "team_id", Op: OpIn, Value: []any{1, 2, 3}},
+					},
+					OrderBy: []Order{{Field: "created_at", Desc: true}},
+					Limit:   20,
+				}).Build()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestSQLTemplateShapeFields tests that the shaper knows every query field. A field
+// added to these structs must be written to the shape key if the builder reads it,
+// then listed here.
+// TestSQLTemplateShapeFields 测试 shaper 了解查询的每个字段。向这些结构添加的字段若被构建器读取，
+// 必须写入结构键，然后列在此处。
+func TestSQLTemplateShapeFields(t *testing.T) {
+	known := map[reflect.Type][]string{
+		reflect.TypeOf(Query{}): {"Table", "Action", "Where", "Data", "DataBatch", "Select", "OrderBy",
+			"NoDefaultOrder", "GroupBy", "Rollup", "Having", "Limit", "Offset", "BatchSize", "Lock", "Primary",
+			"Returning", "ReturnIDs", "OnConflict", "Live", "Estimate", "With", "Join", "Has", "Operations", "As", "Timeout",
+			"Debug", "QueryToExplain", "Plan", "AutoLimited"},
+		reflect.TypeOf(Condition{}):       {"Field", "Fn", "Op", "Collate", "Value", "Or", "Ref", "Subquery", "And", "OrGroup"},
+		reflect.TypeOf(Order{}):           {"Field", "Desc", "Nulls", "Collate", "Priority"},
+		reflect.TypeOf(HavingCondition{}): {"Fn", "Field", "Args", "Op", "Value"},
+	}
+	for typ, fields := range known {
+		var got []string
+		for i := 0; i < typ.NumField(); i++ {
+			got = append(got, typ.Field(i).Name)
+		}
+		if !reflect.DeepEqual(got, fields) {
+			t.Errorf("%s fields changed, update shaper and this test:\n got %v\nwant %v", typ.Name(), got, fields)
+		}
+	}
+}
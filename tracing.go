@@ -0,0 +1,124 @@
+package goorm
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Tracer starts spans for EnableTracing. It mirrors the subset of the
+// OpenTelemetry trace API GoORM needs, so goorm does not depend on the OTel SDK;
+// see docs/hooks.md for an adapter around a trace.Tracer.
+//
+// Tracer 为 EnableTracing 创建 span。它对应 GoORM 所需的 OpenTelemetry trace API 子集，
+// 因此 goorm 不依赖 OTel SDK；包装 trace.Tracer 的适配器见 docs/hooks.md。
+type Tracer interface {
+	// Start starts a span named name as a child of the span in ctx.
+	// Start 以 ctx 中的 span 为父级创建名为 name 的 span。
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+// Span 是由 Tracer 创建的 span。
+type Span interface {
+	// SetAttribute sets an attribute; value is a string, int64 or bool.
+	// SetAttribute 设置属性；value 为 string、int64 或 bool。
+	SetAttribute(key string, value any)
+
+	// RecordError records err and marks the span as failed.
+	// RecordError 记录 err 并将 span 标记为失败。
+	RecordError(err error)
+
+	// End ends the span.
+	// End 结束 span。
+	End()
+}
+
+// EnableTracing adds middleware that starts a span per ExecuteQuery call, named
+// "<action> <table>" and nested under the span in the caller's context. Spans
+// carry the OTel database attributes db.system, db.operation, db.sql.table and
+// db.statement (every statement run, separated by ";\n"), plus
+// goorm.rows_returned and goorm.rows_affected; failed queries record an error.
+//
+// EnableTracing 添加中间件，为每次 ExecuteQuery 调用创建名为 "<action> <table>" 的 span，
+// 并嵌套在调用方上下文中的 span 之下。span 带有 OTel 数据库属性 db.system、db.operation、
+// db.sql.table 和 db.statement（执行的所有语句，以 ";\n" 分隔），以及 goorm.rows_returned 和
+// goorm.rows_affected；失败的查询会记录错误。
+func (db *DB) EnableTracing(tracer Tracer) {
+	system := dbSystem(db.dialect.Name())
+	db.Use(func(next QueryHandler) QueryHandler {
+		return func(ctx context.Context, query *Query) *Result {
+			name := string(query.Action)
+			if query.Table != "" {
+				name += " " + query.Table
+			}
+			ctx, span := tracer.Start(ctx, name)
+			defer span.End()
+
+			traced := &tracedStatements{}
+			result := next(context.WithValue(ctx, traceKey{}, traced), query)
+
+			span.SetAttribute("db.system", system)
+			span.SetAttribute("db.operation", string(query.Action))
+			if query.Table != "" {
+				span.SetAttribute("db.sql.table", query.Table)
+			}
+			if statement := traced.String(); statement != "" {
+				span.SetAttribute("db.statement", statement)
+			}
+			if result == nil {
+				return result
+			}
+			if len(result.Data) > 0 {
+				span.SetAttribute("goorm.rows_returned", int64(len(result.Data)))
+			}
+			if result.Affected > 0 {
+				span.SetAttribute("goorm.rows_affected", result.Affected)
+			}
+			if err := result.Err(); err != nil {
+				span.RecordError(err)
+			}
+			return result
+		}
+	})
+}
+
+// dbSystem maps a dialect name to the OTel db.system value.
+// dbSystem 将方言名映射为 OTel 的 db.system 值。
+func dbSystem(dialect string) string {
+	if dialect == "postgres" {
+		return "postgresql"
+	}
+	return dialect
+}
+
+// traceKey is the context key for the statements of the traced query.
+// traceKey 是被追踪查询的语句的上下文键。
+type traceKey struct{}
+
+// tracedStatements collects the SQL run for one traced query.
+// tracedStatements 收集一次被追踪查询所执行的 SQL。
+type tracedStatements struct {
+	mu  sync.Mutex
+	sql []string
+}
+
+// String returns the collected statements separated by ";\n".
+// String 返回以 ";\n" 分隔的已收集语句。
+func (t *tracedStatements) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.sql, ";\n")
+}
+
+// traceStatement records query on the traced query of ctx, if any.
+// traceStatement 将 query 记录到 ctx 中被追踪的查询（如有）。
+func traceStatement(ctx context.Context, query string) {
+	t, ok := ctx.Value(traceKey{}).(*tracedStatements)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	t.sql = append(t.sql, query)
+	t.mu.Unlock()
+}
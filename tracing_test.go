@@ -0,0 +1,77 @@
+package goorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// fakeSpan records what EnableTracing sets on a span.
+// fakeSpan 记录 EnableTracing 在 span 上设置的内容。
+type fakeSpan struct {
+	name   string
+	parent *fakeSpan
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *fakeSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *fakeSpan) RecordError(err error)              { s.err = err }
+func (s *fakeSpan) End()                               { s.ended = true }
+
+// fakeTracer starts fakeSpans, nesting them under the span in the context.
+// fakeTracer 创建 fakeSpan，并将其嵌套在上下文中的 span 之下。
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+type fakeSpanKey struct{}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(fakeSpanKey{}).(*fakeSpan)
+	span := &fakeSpan{name: name, parent: parent, attrs: make(map[string]any)}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, fakeSpanKey{}, span), span
+}
+
+// TestEnableTracing tests that each query gets a span with the OTel database attributes.
+// TestEnableTracing 测试每个查询都会得到带有 OTel 数据库属性的 span。
+func TestEnableTracing(t *testing.T) {
+	db, _ := newFakeDB(t, &PostgresDialect{}, func(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		if len(args) > 0 && args[0].Value == int64(0) {
+			return nil, nil, errors.New("boom")
+		}
+		return []string{"id", "name"}, [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}}, nil
+	})
+	tracer := &fakeTracer{}
+	db.EnableTracing(tracer)
+
+	request, _ := tracer.Start(context.Background(), "GET /users")
+	if result := db.ExecuteQuery(request, &Query{Table: "users", Action: ActionFind}); !result.Success {
+		t.Fatalf("find failed: %+v", result.Error)
+	}
+
+	span := tracer.spans[1]
+	if span.name != "find users" || span.parent != tracer.spans[0] || !span.ended {
+		t.Errorf("unexpected span %q (parent %v, ended %v)", span.name, span.parent, span.ended)
+	}
+	want := map[string]any{
+		"db.system":           "postgresql",
+		"db.operation":        "find",
+		"db.sql.table":        "users",
+		"db.statement":        `SELECT * FROM "users"`,
+		"goorm.rows_returned": int64(2),
+	}
+	for key, value := range want {
+		if span.attrs[key] != value {
+			t.Errorf("%s = %v, want %v", key, span.attrs[key], value)
+		}
+	}
+
+	db.ExecuteQuery(context.Background(), &Query{Table: "users", Action: ActionFind, Where: []Condition{{Field: "id", Op: OpEqual, Value: 0}}})
+	if failed := tracer.spans[2]; failed.err == nil || failed.parent != nil {
+		t.Errorf("expected a root span with an error, got %+v", failed)
+	}
+}
@@ -14,6 +14,11 @@ type Transaction struct {
 	db      *DB
 	tx      *sql.Tx
 	results map[string]*Result
+
+	// release ends the inflight registration of a transaction started by
+	// BeginContext once it is committed or rolled back.
+	// release 在 BeginContext 开始的事务提交或回滚后结束其 inflight 登记。
+	release func()
 }
 
 // executeTransactionInternal executes a JQL transaction.
@@ -98,6 +103,8 @@ func (db *DB) executeTransactionInternal(ctx context.Context, query *Query) *Res
 			stepResult.Meta = &ResultMeta{SQL: op.As}
 		}
 		results = append(results, stepResult)
+
+		ReportProgress(ctx, i+1, len(query.Operations), fmt.Sprintf("%s %s", op.Action, op.Table))
 	}
 
 	// Commit transaction
@@ -122,7 +129,90 @@ func (db *DB) executeTransactionInternal(ctx context.Context, query *Query) *Res
 // executeOperation executes a single operation within a transaction.
 // executeOperation 在事务中执行单个操作。
 func (t *Transaction) executeOperation(ctx context.Context, query *Query) *Result {
-	builder := NewSQLBuilder(t.db.dialect, query)
+	auditIDs := t.db.auditIDs(query)
+	if auditIDs {
+		op := *query
+		op.ReturnIDs = true
+		query = &op
+		ctx = withAuditIDs(ctx)
+	}
+	if err := t.db.validate(query); err != nil {
+		return validationResult(err)
+	}
+	if t.db.config.ReadOnly && query.IsWrite() {
+		return readOnlyResult(query.Action)
+	}
+
+	before, after, hasHooks := operationHooks(query.Action)
+	if hasHooks {
+		if r := t.db.runHooks(ctx, before, query, nil); r != nil {
+			return r
+		}
+	}
+	if query.Action == ActionCreate {
+		if r := t.db.applyModelDefaults(query.Table, query.Data); r != nil {
+			return r
+		}
+	}
+	if r := t.db.encodeJSONColumns(query.Table, query.Data); r != nil {
+		return r
+	}
+	t.db.encodeArrayColumns(query.Table, query.Data)
+
+	result := t.dispatchOperation(ctx, query)
+	if query.Action == ActionFind && result.Success {
+		t.db.decodeJSONColumns(query.Table, result.Data)
+		t.db.decodeArrayColumns(query.Table, result.Data)
+		t.db.parseTimeColumns(query.Table, result.Data)
+	}
+	if hasHooks && result.Success {
+		result.SoftDeleted = softDeleted(before, query)
+		if r := t.db.runHooks(ctx, after, query, result); r != nil {
+			return r
+		}
+	}
+	if auditIDs {
+		result.IDs, result.AffectedKeys = nil, nil
+	}
+
+	return result
+}
+
+// operationHooks returns the before and after hook types for a transaction operation.
+// operationHooks 返回事务操作的 before 和 after 钩子类型。
+func operationHooks(action Action) (before, after HookType, ok bool) {
+	switch action {
+	case ActionCreate:
+		return HookBeforeCreate, HookAfterCreate, true
+	case ActionUpdate:
+		return HookBeforeUpdate, HookAfterUpdate, true
+	case ActionDelete:
+		return HookBeforeDelete, HookAfterDelete, true
+	case ActionFind:
+		return HookBeforeFind, HookAfterFind, true
+	default:
+		return "", "", false
+	}
+}
+
+// dispatchOperation builds and runs a single operation within a transaction.
+// dispatchOperation 在事务中构建并执行单个操作。
+func (t *Transaction) dispatchOperation(ctx context.Context, query *Query) *Result {
+	t.db.warnLock(query, true)
+
+	if query.ReturnIDs && (query.Action == ActionUpdate || query.Action == ActionDelete) {
+		return t.writeReturningIDs(ctx, query)
+	}
+	if len(query.Returning) > 0 && !t.db.dialect.SupportsReturning() {
+		return returningNotSupported(t.db.dialect)
+	}
+
+	if query.Action == ActionFind {
+		query = t.db.checkOrderPriority(t.db.withDefaultOrder(query))
+	}
+
+	pk := t.db.insertKeyColumn(query.Table)
+	builder := t.db.newBuilder(ctx, query).WithPrimaryKey(pk)
 	buildResult, err := builder.Build()
 	if err != nil {
 		return &Result{
@@ -136,11 +226,32 @@ func (t *Transaction) executeOperation(ctx context.Context, query *Query) *Resul
 
 	switch query.Action {
 	case ActionCreate:
-		return t.executeCreate(ctx, buildResult)
+		if len(query.Returning) > 0 {
+			r := t.executeFind(ctx, buildResult, t.db.scanFields(query))
+			if r.Success {
+				if len(r.Data) > 0 {
+					r.InsertedKey, r.ID = insertedKey(r.Data[0][pk])
+					r.Count, r.Affected = 0, 1
+				} else {
+					// An ignored conflict returns no row
+					// 被忽略的冲突不返回行
+					r.InsertedKey, r.ID = insertedKey(query.Data[pk])
+				}
+			}
+			return r
+		}
+		return t.executeCreate(ctx, buildResult, query.Data[pk])
 	case ActionUpdate, ActionDelete:
+		if len(query.Returning) > 0 {
+			r := t.executeFind(ctx, buildResult, t.db.scanFields(query))
+			if r.Success {
+				r.Affected = r.Count
+			}
+			return r
+		}
 		return t.executeWrite(ctx, buildResult)
 	case ActionFind:
-		return t.executeFind(ctx, buildResult)
+		return t.executeFind(ctx, buildResult, t.db.scanFields(query))
 	default:
 		return &Result{
 			Success: false,
@@ -153,13 +264,25 @@ func (t *Transaction) executeOperation(ctx context.Context, query *Query) *Resul
 }
 
 // executeCreate executes a create operation in transaction.
+// providedKey is the primary key value from the data, if any.
+//
 // executeCreate 在事务中执行创建操作。
-func (t *Transaction) executeCreate(ctx context.Context, build *BuildResult) *Result {
-	var lastID uint64
+// providedKey 是数据中提供的主键值（如果有）。
+func (t *Transaction) executeCreate(ctx context.Context, build *BuildResult, providedKey any) *Result {
+	var key any
+	affected := int64(1)
+	startTime := time.Now()
 
 	if t.db.dialect.SupportsReturning() {
-		err := t.tx.QueryRowContext(ctx, build.SQL, build.Params...).Scan(&lastID)
-		if err != nil && err != sql.ErrNoRows {
+		err := t.queryRowContext(ctx, build.SQL, build.Params...).Scan(&key)
+		if err == sql.ErrNoRows {
+			// An ignored conflict returns no row
+			// 被忽略的冲突不返回行
+			err = nil
+			key, affected = providedKey, 0
+		}
+		t.db.logQuery(build, startTime, err)
+		if err != nil {
 			return &Result{
 				Success: false,
 				Error: &ResultError{
@@ -169,7 +292,8 @@ func (t *Transaction) executeCreate(ctx context.Context, build *BuildResult) *Re
 			}
 		}
 	} else {
-		result, err := t.tx.ExecContext(ctx, build.SQL, build.Params...)
+		result, err := t.execContext(ctx, build.SQL, build.Params...)
+		t.db.logQuery(build, startTime, err)
 		if err != nil {
 			return &Result{
 				Success: false,
@@ -179,21 +303,31 @@ func (t *Transaction) executeCreate(ctx context.Context, build *BuildResult) *Re
 				},
 			}
 		}
-		id, _ := result.LastInsertId()
-		lastID = uint64(id)
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			affected = 0
+		}
+		if providedKey != nil {
+			key = providedKey
+		} else if affected > 0 {
+			key, _ = result.LastInsertId()
+		}
 	}
 
+	key, lastID := insertedKey(key)
 	return &Result{
-		Success:  true,
-		ID:       lastID,
-		Affected: 1,
+		Success:     true,
+		ID:          lastID,
+		InsertedKey: key,
+		Affected:    affected,
 	}
 }
 
 // executeWrite executes an update/delete operation in transaction.
 // executeWrite 在事务中执行更新/删除操作。
 func (t *Transaction) executeWrite(ctx context.Context, build *BuildResult) *Result {
-	result, err := t.tx.ExecContext(ctx, build.SQL, build.Params...)
+	startTime := time.Now()
+	result, err := t.execContext(ctx, build.SQL, build.Params...)
+	t.db.logQuery(build, startTime, err)
 	if err != nil {
 		return &Result{
 			Success: false,
@@ -211,10 +345,13 @@ func (t *Transaction) executeWrite(ctx context.Context, build *BuildResult) *Res
 	}
 }
 
-// executeFind executes a find operation in transaction.
-// executeFind 在事务中执行查询操作。
-func (t *Transaction) executeFind(ctx context.Context, build *BuildResult) *Result {
-	rows, err := t.tx.QueryContext(ctx, build.SQL, build.Params...)
+// executeFind executes a find operation in transaction, scanning the rows through
+// fields as scanRows does.
+// executeFind 在事务中执行查询操作，并像 scanRows 一样通过 fields 扫描各行。
+func (t *Transaction) executeFind(ctx context.Context, build *BuildResult, fields map[string]*FieldMeta) *Result {
+	startTime := time.Now()
+	rows, err := t.queryContext(ctx, build.SQL, build.Params...)
+	t.db.logQuery(build, startTime, err)
 	if err != nil {
 		return &Result{
 			Success: false,
@@ -226,40 +363,10 @@ func (t *Transaction) executeFind(ctx context.Context, build *BuildResult) *Resu
 	}
 	defer rows.Close()
 
-	columns, err := rows.Columns()
-	if err != nil {
-		return &Result{
-			Success: false,
-			Error: &ResultError{
-				Code:    "COLUMN_ERROR",
-				Message: err.Error(),
-			},
-		}
-	}
-
-	data := make([]map[string]any, 0)
-	for rows.Next() {
-		values := make([]any, len(columns))
-		valuePtrs := make([]any, len(columns))
-		for i := range values {
-			valuePtrs[i] = &values[i]
-		}
-
-		if err := rows.Scan(valuePtrs...); err != nil {
-			continue
-		}
-
-		row := make(map[string]any, len(columns))
-		for i, col := range columns {
-			val := values[i]
-			if b, ok := val.([]byte); ok {
-				val = string(b)
-			}
-			row[col] = val
-		}
-		data = append(data, row)
+	data, errResult := scanRows(rows, fields)
+	if errResult != nil {
+		return errResult
 	}
-
 	return &Result{
 		Success: true,
 		Data:    data,
@@ -328,6 +435,9 @@ func (t *Transaction) resolveValue(v any) any {
 	// 从结果获取字段值
 	switch field {
 	case "id":
+		if result.InsertedKey != nil {
+			return result.InsertedKey
+		}
 		return result.ID
 	case "affected":
 		return result.Affected
@@ -348,7 +458,7 @@ func (t *Transaction) resolveValue(v any) any {
 // generateTransactionID generates a unique transaction ID.
 // generateTransactionID 生成唯一的事务 ID。
 func generateTransactionID() string {
-	return fmt.Sprintf("tx_%d", time.Now().UnixNano())
+	return randomID("tx_")
 }
 
 // Begin starts a manual transaction.
@@ -357,11 +467,17 @@ func (db *DB) Begin() (*Transaction, error) {
 	return db.BeginContext(db.ctx)
 }
 
-// BeginContext starts a manual transaction with context.
-// BeginContext 使用上下文开始一个手动事务。
+// BeginContext starts a manual transaction with context. Shutdown waits for the
+// transaction until it is committed or rolled back.
+//
+// BeginContext 使用上下文开始一个手动事务。Shutdown 会等待该事务直到其提交或回滚。
 func (db *DB) BeginContext(ctx context.Context) (*Transaction, error) {
+	if !db.inflight.enter() {
+		return nil, shuttingDownResult().Err()
+	}
 	tx, err := db.sqlDB.BeginTx(ctx, nil)
 	if err != nil {
+		db.inflight.leave()
 		return nil, err
 	}
 
@@ -369,21 +485,33 @@ func (db *DB) BeginContext(ctx context.Context) (*Transaction, error) {
 		db:      db,
 		tx:      tx,
 		results: make(map[string]*Result),
+		release: db.inflight.leave,
 	}, nil
 }
 
 // Commit commits the transaction.
 // Commit 提交事务。
 func (t *Transaction) Commit() error {
+	defer t.end()
 	return t.tx.Commit()
 }
 
 // Rollback rolls back the transaction.
 // Rollback 回滚事务。
 func (t *Transaction) Rollback() error {
+	defer t.end()
 	return t.tx.Rollback()
 }
 
+// end releases the transaction's inflight registration, once.
+// end 释放事务的 inflight 登记，仅一次。
+func (t *Transaction) end() {
+	if t.release != nil {
+		t.release()
+		t.release = nil
+	}
+}
+
 // Execute executes a JQL query within the transaction.
 // Execute 在事务中执行 JQL 查询。
 func (t *Transaction) Execute(jql string) *Result {
@@ -395,13 +523,7 @@ func (t *Transaction) Execute(jql string) *Result {
 func (t *Transaction) ExecuteContext(ctx context.Context, jql string) *Result {
 	query, err := ParseQuery(jql)
 	if err != nil {
-		return &Result{
-			Success: false,
-			Error: &ResultError{
-				Code:    "PARSE_ERROR",
-				Message: err.Error(),
-			},
-		}
+		return parseErrorResult(err)
 	}
 
 	return t.executeOperation(ctx, query)
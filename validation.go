@@ -0,0 +1,205 @@
+package goorm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// emailPattern is a permissive email address check.
+// emailPattern 是宽松的邮箱地址检查。
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// ValidationRule is a single rule parsed from a `validate` struct tag.
+// ValidationRule 是从 `validate` 结构体标签解析的单条规则。
+type ValidationRule struct {
+	// Name is the rule name: required, min, max, email or regex.
+	// Name 是规则名：required、min、max、email 或 regex。
+	Name string
+
+	// Param is the rule parameter, e.g. "255" for max=255.
+	// Param 是规则参数，例如 max=255 中的 "255"。
+	Param string
+
+	// re is the compiled pattern for regex rules.
+	// re 是 regex 规则的已编译模式。
+	re *regexp.Regexp
+}
+
+// parseValidationRules parses a tag like `required,email,max=255`.
+// regex consumes the rest of the tag so patterns may contain commas.
+// It fails if a regex pattern does not compile.
+//
+// parseValidationRules 解析形如 `required,email,max=255` 的标签。
+// regex 会占用标签的剩余部分，因此模式中可以包含逗号。regex 模式无法编译时返回错误。
+func parseValidationRules(tag string) ([]ValidationRule, error) {
+	var rules []ValidationRule
+	for tag != "" {
+		part := tag
+		if strings.HasPrefix(strings.TrimSpace(tag), "regex=") {
+			tag = ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			part, tag = tag[:i], tag[i+1:]
+		} else {
+			tag = ""
+		}
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		rule := ValidationRule{Name: part}
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			rule.Name = strings.ToLower(strings.TrimSpace(kv[0]))
+			rule.Param = kv[1]
+		} else {
+			rule.Name = strings.ToLower(part)
+		}
+		if rule.Name == "regex" {
+			re, err := regexp.Compile(rule.Param)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validate regex %q: %w", rule.Param, err)
+			}
+			rule.re = re
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// FieldError describes a single failed validation rule.
+// FieldError 描述单个未通过的验证规则。
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field that failed validation.
+// ValidationError 列出所有未通过验证的字段。
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error implements the error interface.
+// Error 实现 error 接口。
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// ModelValidationHook returns a hook enforcing the `validate` tag rules of meta
+// on create and update data. Missing fields only fail "required" on create.
+//
+// ModelValidationHook 返回在创建和更新数据上执行 meta 中 `validate` 标签规则的钩子。
+// 缺失的字段仅在创建时触发 "required" 失败。
+func ModelValidationHook(meta *ModelMeta) HookFunc {
+	return func(ctx *HookContext) error {
+		var errs []FieldError
+
+		for _, field := range meta.Fields {
+			if len(field.Validations) == 0 {
+				continue
+			}
+
+			value, present := ctx.Data[field.ColumnName]
+			for _, rule := range field.Validations {
+				if !present {
+					if rule.Name == "required" && ctx.Action == ActionCreate {
+						errs = append(errs, FieldError{Field: field.ColumnName, Rule: rule.Name, Message: "is required"})
+					}
+					continue
+				}
+				if msg := checkRule(rule, value); msg != "" {
+					errs = append(errs, FieldError{Field: field.ColumnName, Rule: rule.Name, Message: msg})
+				}
+			}
+		}
+
+		if len(errs) > 0 {
+			return &ValidationError{Errors: errs}
+		}
+		return nil
+	}
+}
+
+// checkRule returns a failure message, or "" if value satisfies rule.
+// checkRule 返回失败消息，如果 value 满足规则则返回 ""。
+func checkRule(rule ValidationRule, value any) string {
+	switch rule.Name {
+	case "required":
+		if isZeroValue(value) {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(rule.Param, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid %s parameter %q", rule.Name, rule.Param)
+		}
+		size, ok := ruleSize(value)
+		if !ok {
+			return ""
+		}
+		if rule.Name == "min" && size < limit {
+			return fmt.Sprintf("must be at least %s", rule.Param)
+		}
+		if rule.Name == "max" && size > limit {
+			return fmt.Sprintf("must be at most %s", rule.Param)
+		}
+	case "email":
+		s, ok := value.(string)
+		if !ok || !emailPattern.MatchString(s) {
+			return "must be a valid email address"
+		}
+	case "regex":
+		if rule.re == nil {
+			return fmt.Sprintf("invalid pattern %q", rule.Param)
+		}
+		if !rule.re.MatchString(fmt.Sprint(value)) {
+			return fmt.Sprintf("must match %s", rule.Param)
+		}
+	}
+	return ""
+}
+
+// ruleSize returns the length of strings and collections, or the numeric value.
+// ruleSize 返回字符串和集合的长度，或数值本身。
+func ruleSize(value any) (float64, bool) {
+	if s, ok := value.(string); ok {
+		return float64(utf8.RuneCountInString(s)), true
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// isZeroValue reports whether value is nil or its type's zero value.
+// isZeroValue 判断 value 是否为 nil 或其类型的零值。
+func isZeroValue(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
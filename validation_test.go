@@ -0,0 +1,100 @@
+package goorm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type validatedUser struct {
+	Model
+	Name  string `json:"name" validate:"required,min=2,max=10"`
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"regex=^[A-Z]{2,3}$"`
+	Age   int    `json:"age" validate:"min=0,max=150"`
+}
+
+// TestParseValidationRules tests parsing of the validate tag.
+// TestParseValidationRules 测试 validate 标签的解析。
+func TestParseValidationRules(t *testing.T) {
+	rules, err := parseValidationRules("required, max=255,regex=^a{1,2}$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d: %+v", len(rules), rules)
+	}
+	if rules[1].Name != "max" || rules[1].Param != "255" {
+		t.Errorf("unexpected max rule: %+v", rules[1])
+	}
+	if rules[2].Name != "regex" || rules[2].Param != "^a{1,2}$" || rules[2].re == nil {
+		t.Errorf("regex should keep commas in its pattern: %+v", rules[2])
+	}
+
+	if _, err := parseValidationRules("required,regex=^[a-"); err == nil {
+		t.Error("expected an error for a regex that does not compile")
+	}
+	type badPattern struct {
+		ID   uint64 `json:"id" goorm:"primaryKey"`
+		Code string `json:"code" validate:"regex=(unclosed"`
+	}
+	if err := NewRegistry().Register(&badPattern{}, DefaultConfig().Naming); err == nil || !strings.Contains(err.Error(), "Code") {
+		t.Errorf("expected registration to fail naming the field, got %v", err)
+	}
+}
+
+// TestModelValidation tests that validate tags are enforced on create and update.
+// TestModelValidation 测试 validate 标签在创建和更新时生效。
+func TestModelValidation(t *testing.T) {
+	db, backend := newFakeDB(t, &SQLiteDialect{}, nil)
+	if err := db.Register(&validatedUser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	result := db.ExecuteQuery(ctx, &Query{
+		Table:  "validated_users",
+		Action: ActionCreate,
+		Data:   map[string]any{"name": "A", "email": "not-an-email", "code": "abc", "age": 200},
+	})
+	if result.Success || result.Error.Code != "VALIDATION_ERROR" {
+		t.Fatalf("expected VALIDATION_ERROR, got %+v", result)
+	}
+
+	fields := result.Error.Details["fields"].([]FieldError)
+	failed := map[string]string{}
+	for _, fe := range fields {
+		failed[fe.Field] = fe.Rule
+	}
+	expected := map[string]string{"name": "min", "email": "email", "code": "regex", "age": "max"}
+	for field, rule := range expected {
+		if failed[field] != rule {
+			t.Errorf("expected %s to fail %s, got %q", field, rule, failed[field])
+		}
+	}
+
+	// Missing required fields only fail on create
+	// 缺失的必填字段仅在创建时失败
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "validated_users",
+		Action: ActionUpdate,
+		Where:  []Condition{{Field: "id", Op: "=", Value: 1}},
+		Data:   map[string]any{"age": 30},
+	})
+	if !result.Success {
+		t.Errorf("partial update should pass validation, got %v", result.Error)
+	}
+
+	result = db.ExecuteQuery(ctx, &Query{
+		Table:  "validated_users",
+		Action: ActionCreate,
+		Data:   map[string]any{"name": "Alice", "email": "alice@example.com", "code": "AB", "age": 30},
+	})
+	if !result.Success {
+		t.Errorf("valid create should succeed, got %v", result.Error)
+	}
+
+	if n := len(backend.Queries()); n != 2 {
+		t.Errorf("expected 2 statements, got %d", n)
+	}
+}